@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/pkg/logger"
+	"knock-fm/internal/pkg/tracing"
+	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/repository/postgres"
 	"knock-fm/internal/repository/redis"
 	"knock-fm/internal/service/api"
 	"knock-fm/internal/service/platforms"
+	"knock-fm/internal/service/worker"
 	"os"
 	"os/signal"
 	"syscall"
@@ -32,6 +35,16 @@ func main() {
 	log := logger.New(cfg.LogLevel)
 	log.Info("Starting API service...")
 
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "knock-fm-api", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Restrict the ports accepted in previewed URLs
+	urldetector.SetAllowedPorts(cfg.URLAllowedPorts)
+
 	// Connect to PostgreSQL
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -68,8 +81,12 @@ func main() {
 
 	// Create repositories
 	knokRepo := postgres.NewKnokRepository(db, log)
+	knokRepo.SetTimelineWindow(cfg.TimelineWindow)
 	serverRepo := postgres.NewServerRepository(db, log)
-	queueRepo := redis.NewQueueRepository(redisClient, log)
+	queueRepo := redis.NewQueueRepository(redisClient, redis.QueueOptions{
+		KeyPrefix:       cfg.RedisKeyPrefix,
+		MaxPayloadBytes: cfg.MaxJobPayloadBytes,
+	}, log)
 	platformRepo := postgres.NewPlatformRepository(db, log)
 
 	// Create and load platform loader
@@ -83,8 +100,20 @@ func main() {
 		"platform_count", platformLoader.Count(),
 	)
 
+	// Create URL detector and metadata extractor for link previews
+	urlDetector := urldetector.New(platformLoader, nil, log)
+	urlDetector.SetPlatformCacheSize(cfg.PlatformCacheSize)
+	urlDetector.SetLogRejectedURLs(cfg.LogRejectedURLs)
+	urlDetector.SetStripEmojiBeforeURLs(cfg.StripEmojiBeforeURLs)
+	extractor := worker.NewJobProcessor(log, nil, nil)
+	extractor.SetRodDomains(cfg.RodDomains)
+	extractor.SetAllowedImageHosts(cfg.AllowedImageHosts)
+	extractor.SetOEmbedProvidersSource(ctx, cfg.OEmbedProvidersSource)
+	extractor.SetPublicHostsOnly(true)
+
 	// Create API service
-	apiService, err := api.New(cfg, log, knokRepo, serverRepo, queueRepo, platformRepo, platformLoader)
+	schemaStatus := postgres.NewSchemaStatus(db)
+	apiService, err := api.New(cfg, log, knokRepo, serverRepo, queueRepo, platformRepo, platformLoader, schemaStatus, urlDetector, extractor, extractor)
 	if err != nil {
 		log.Error("Failed to create API service", "error", err)
 		os.Exit(1)
@@ -122,5 +151,10 @@ func main() {
 		log.Error("Error stopping API service", "error", err)
 	}
 
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Error shutting down tracing", "error", err)
+	}
+
 	log.Info("API service shutdown complete")
 }
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/pkg/logger"
+	"knock-fm/internal/pkg/tracing"
+	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/repository/postgres"
 	"knock-fm/internal/repository/redis"
 	"knock-fm/internal/service/bot"
@@ -32,6 +34,16 @@ func main() {
 	log := logger.New(cfg.LogLevel)
 	log.Info("Starting Discord bot service...")
 
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "knock-fm-bot", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Only accept detected URLs on the configured ports
+	urldetector.SetAllowedPorts(cfg.URLAllowedPorts)
+
 	// Connect to PostgreSQL
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -67,7 +79,10 @@ func main() {
 	}
 
 	// Create repositories
-	queueRepo := redis.NewQueueRepository(redisClient, log)
+	queueRepo := redis.NewQueueRepository(redisClient, redis.QueueOptions{
+		KeyPrefix:       cfg.RedisKeyPrefix,
+		MaxPayloadBytes: cfg.MaxJobPayloadBytes,
+	}, log)
 	knokRepo := postgres.NewKnokRepository(db, log)
 	serverRepo := postgres.NewServerRepository(db, log)
 	platformRepo := postgres.NewPlatformRepository(db, log)
@@ -114,7 +129,7 @@ func main() {
 	}
 
 	// Graceful shutdown with timeout
-	_, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Stop bot service
@@ -122,5 +137,10 @@ func main() {
 		log.Error("Error stopping bot service", "error", err)
 	}
 
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Error shutting down tracing", "error", err)
+	}
+
 	log.Info("Bot service shutdown complete")
 }
@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"knock-fm/internal/domain"
 	"knock-fm/internal/pkg/logger"
+	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/repository/postgres"
+	"knock-fm/internal/service/platforms"
 	"os"
 
 	_ "github.com/lib/pq"
@@ -18,6 +21,8 @@ func main() {
 		clearKnoks = flag.Bool("clear-knoks", false, "Clear only knoks table (keeps servers)")
 		migrate    = flag.Bool("migrate", false, "Run database migrations")
 		status     = flag.Bool("status", false, "Show migration status")
+		backfill   = flag.Bool("backfill-item-ids", false, "Set platform item IDs on existing knoks from platform extraction patterns")
+		redact     = flag.Bool("redact-message-content", false, "Apply the MESSAGE_CONTENT_RETENTION policy to existing knoks' message content")
 		dbURL      = flag.String("db", "", "Database URL (defaults to DATABASE_URL env var)")
 	)
 	flag.Parse()
@@ -96,6 +101,38 @@ func main() {
 		}
 		log.Info("Migration status", "current_version", version)
 
+	case *backfill:
+		platformLoader := platforms.NewLoader(postgres.NewPlatformRepository(db, log), log)
+		if err := platformLoader.Load(ctx); err != nil {
+			log.Error("Failed to load platforms", "error", err)
+			os.Exit(1)
+		}
+		detector := urldetector.New(platformLoader, nil, log)
+
+		knokRepo := postgres.NewKnokRepository(db, log)
+		updated, err := knokRepo.BackfillPlatformItemIDs(ctx, detector.ExtractItemID)
+		if err != nil {
+			log.Error("Failed to backfill platform item IDs", "error", err, "updated", updated)
+			os.Exit(1)
+		}
+		log.Info("Platform item ID backfill completed", "updated", updated)
+
+	case *redact:
+		policy := os.Getenv("MESSAGE_CONTENT_RETENTION")
+		statuses, redactFn, err := messageContentRedaction(policy)
+		if err != nil {
+			log.Error("Invalid message content retention policy", "error", err)
+			os.Exit(1)
+		}
+
+		knokRepo := postgres.NewKnokRepository(db, log)
+		updated, err := knokRepo.RedactMessageContent(ctx, statuses, redactFn)
+		if err != nil {
+			log.Error("Failed to redact message content", "error", err, "updated", updated)
+			os.Exit(1)
+		}
+		log.Info("Message content redaction completed", "policy", policy, "updated", updated)
+
 	default:
 		fmt.Println("Database utility for Knok FM")
 		fmt.Println("")
@@ -104,6 +141,8 @@ func main() {
 		fmt.Println("  -reset       Reset database (WARNING: destroys all data)")
 		fmt.Println("  -migrate     Run database migrations")
 		fmt.Println("  -status      Show migration status")
+		fmt.Println("  -backfill-item-ids Set platform item IDs on existing knoks")
+		fmt.Println("  -redact-message-content Apply MESSAGE_CONTENT_RETENTION to existing knoks")
 		fmt.Println("  -db       Database URL (optional)")
 		fmt.Println("")
 		fmt.Println("Examples:")
@@ -111,10 +150,30 @@ func main() {
 		fmt.Println("  go run cmd/dbutil/main.go -clear-knoks")
 		fmt.Println("  go run cmd/dbutil/main.go -reset")
 		fmt.Println("  go run cmd/dbutil/main.go -migrate")
+		fmt.Println("  go run cmd/dbutil/main.go -backfill-item-ids")
+		fmt.Println("  MESSAGE_CONTENT_RETENTION=urls go run cmd/dbutil/main.go -redact-message-content")
 		os.Exit(0)
 	}
 }
 
+// messageContentRedaction returns the extraction statuses of the knoks a retention policy
+// applies to (nil for all) and how their stored message content is rewritten
+func messageContentRedaction(policy string) ([]string, func(content string) *string, error) {
+	switch policy {
+	case domain.MessageContentRetentionNone, domain.MessageContentRetentionURLs:
+		return nil, func(content string) *string {
+			return domain.RetainedMessageContent(policy, content)
+		}, nil
+	case domain.MessageContentRetentionRedact:
+		statuses := []string{domain.ExtractionStatusComplete, domain.ExtractionStatusLowQuality}
+		return statuses, func(string) *string { return nil }, nil
+	case "", domain.MessageContentRetentionFull:
+		return nil, nil, fmt.Errorf("MESSAGE_CONTENT_RETENTION must be urls, none or redact_after_extraction to redact, got %q", policy)
+	default:
+		return nil, nil, fmt.Errorf("unknown MESSAGE_CONTENT_RETENTION %q", policy)
+	}
+}
+
 func confirmClearKnoks() error {
 	fmt.Print("This will delete all knoks but keep servers. Type 'yes' to confirm: ")
 	var response string
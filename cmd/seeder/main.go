@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/domain"
 	"knock-fm/internal/pkg/logger"
+	"knock-fm/internal/pkg/retry"
 	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/repository/postgres"
 	"knock-fm/internal/repository/redis"
@@ -16,7 +18,11 @@ import (
 	"os"
 	"os/signal"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -27,13 +33,16 @@ import (
 
 func main() {
 	var (
-		channelID = flag.String("channel", "", "Discord channel ID to seed from (required)")
-		guildID   = flag.String("guild", "", "Discord guild/server ID (required)")
-		limit     = flag.Int("limit", 0, "Maximum number of messages to fetch (0 = no limit)")
-		batchSize = flag.Int("batch", 100, "Number of messages to fetch per Discord API call (max 100)")
-		beforeID  = flag.String("before", "", "Fetch messages before this message ID (for pagination)")
-		afterID   = flag.String("after", "", "Fetch messages after this message ID (for pagination)")
-		dryRun    = flag.Bool("dry-run", false, "Print what would be done without actually creating knoks")
+		channelID   = flag.String("channel", "", "Discord channel ID to seed from (required)")
+		guildID     = flag.String("guild", "", "Discord guild/server ID (required)")
+		limit       = flag.Int("limit", 0, "Maximum number of messages to fetch (0 = no limit)")
+		batchSize   = flag.Int("batch", 100, "Number of messages to fetch per Discord API call (max 100)")
+		workers     = flag.Int("workers", 4, "Number of URLs to process concurrently (max 32)")
+		beforeID    = flag.String("before", "", "Fetch messages before this message ID (for pagination)")
+		afterID     = flag.String("after", "", "Fetch messages after this message ID (for pagination)")
+		since       = flag.String("since", "", "Fetch messages posted at or after this date (RFC3339 or YYYY-MM-DD)")
+		dryRun      = flag.Bool("dry-run", false, "Print what would be done without actually creating knoks")
+		enqueueOnly = flag.Bool("enqueue-only", false, "Only queue extraction for existing pending or failed knoks; create nothing")
 	)
 	flag.Parse()
 
@@ -49,12 +58,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Discord accepts only one of before/after, and -since sets the after cursor
+	if *since != "" && *afterID != "" {
+		fmt.Fprintln(os.Stderr, "Error: -since and -after cannot be used together")
+		os.Exit(1)
+	}
+	if *beforeID != "" && (*afterID != "" || *since != "") {
+		fmt.Fprintln(os.Stderr, "Error: -before cannot be combined with -after or -since")
+		os.Exit(1)
+	}
+	if *since != "" {
+		sinceTime, err := parseSinceDate(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -since value: %v\n", err)
+			os.Exit(1)
+		}
+		// after is exclusive, so start one below the first possible ID at sinceTime
+		*afterID = strconv.FormatUint(snowflakeFromTime(sinceTime)-1, 10)
+	}
+
 	// Validate batch size
 	if *batchSize < 1 || *batchSize > 100 {
 		fmt.Fprintln(os.Stderr, "Error: -batch must be between 1 and 100")
 		os.Exit(1)
 	}
 
+	// Validate worker count
+	if *workers < 1 || *workers > 32 {
+		fmt.Fprintln(os.Stderr, "Error: -workers must be between 1 and 32")
+		os.Exit(1)
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -72,9 +106,15 @@ func main() {
 		"guild_id", *guildID,
 		"limit", *limit,
 		"batch_size", *batchSize,
+		"workers", *workers,
+		"since", *since,
 		"dry_run", *dryRun,
+		"enqueue_only", *enqueueOnly,
 	)
 
+	// Only accept detected URLs on the configured ports
+	urldetector.SetAllowedPorts(cfg.URLAllowedPorts)
+
 	// Connect to Discord
 	discord, err := discordgo.New("Bot " + cfg.DiscordToken)
 	if err != nil {
@@ -82,8 +122,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Test Discord connection
-	if _, err := discord.User("@me"); err != nil {
+	// Test Discord connection, retrying in case Discord is briefly unreachable.
+	// A rejected token won't fix itself, so 4xx responses aren't retried.
+	backoff := retry.Backoff{Attempts: cfg.DiscordConnectAttempts}
+	err = retry.Do(context.Background(), backoff, log, "authenticate with Discord", func() error {
+		_, err := discord.User("@me")
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil &&
+			restErr.Response.StatusCode >= 400 && restErr.Response.StatusCode < 500 {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
 		log.Error("Failed to authenticate with Discord", "error", err)
 		os.Exit(1)
 	}
@@ -123,7 +174,10 @@ func main() {
 	knokRepo := postgres.NewKnokRepository(db, log)
 	serverRepo := postgres.NewServerRepository(db, log)
 	platformRepo := postgres.NewPlatformRepository(db, log)
-	queueRepo := redis.NewQueueRepository(redisClient, log)
+	queueRepo := redis.NewQueueRepository(redisClient, redis.QueueOptions{
+		KeyPrefix:       cfg.RedisKeyPrefix,
+		MaxPayloadBytes: cfg.MaxJobPayloadBytes,
+	}, log)
 
 	// Create and load platform loader
 	platformLoader := platforms.NewLoader(platformRepo, log)
@@ -136,22 +190,29 @@ func main() {
 
 	// Create URL detector
 	urlDet := urldetector.New(platformLoader, nil, log)
+	urlDet.SetStripEmojiBeforeURLs(cfg.StripEmojiBeforeURLs)
 
 	// Create seeder
 	seeder := &Seeder{
-		discord:      discord,
-		knokRepo:     knokRepo,
-		serverRepo:   serverRepo,
-		queueRepo:    queueRepo,
-		urlDetector:  urlDet,
-		logger:       log,
-		channelID:    *channelID,
-		guildID:      *guildID,
-		limit:        *limit,
-		batchSize:    *batchSize,
-		beforeID:     *beforeID,
-		afterID:      *afterID,
-		dryRun:       *dryRun,
+		discord:     discord,
+		fetcher:     discord,
+		knokRepo:    knokRepo,
+		serverRepo:  serverRepo,
+		queueRepo:   queueRepo,
+		urlDetector: urlDet,
+		logger:      log,
+		channelID:   *channelID,
+		guildID:     *guildID,
+		limit:       *limit,
+		batchSize:   *batchSize,
+		workers:     *workers,
+		beforeID:    *beforeID,
+		afterID:     *afterID,
+		dryRun:      *dryRun,
+		enqueueOnly: *enqueueOnly,
+
+		contentRetention: cfg.MessageContentRetention,
+		retryBudget:      cfg.ExtractionRetryBudget,
 	}
 
 	// Setup graceful shutdown
@@ -176,9 +237,15 @@ func main() {
 	log.Info("Seeder completed successfully")
 }
 
+// messageFetcher fetches a page of channel messages; satisfied by *discordgo.Session
+type messageFetcher interface {
+	ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error)
+}
+
 // Seeder handles fetching Discord messages and creating knoks
 type Seeder struct {
 	discord     *discordgo.Session
+	fetcher     messageFetcher
 	knokRepo    domain.KnokRepository
 	serverRepo  domain.ServerRepository
 	queueRepo   domain.QueueRepository
@@ -189,39 +256,61 @@ type Seeder struct {
 	guildID   string
 	limit     int
 	batchSize int
+	workers   int
 	beforeID  string
 	afterID   string
 	dryRun    bool
+
+	// enqueueOnly re-queues extraction for existing pending or failed knoks instead of
+	// creating knoks. requeued holds the IDs of knoks queued so far in this run.
+	enqueueOnly bool
+	requeued    sync.Map
+
+	// contentRetention is the message content retention policy applied to new knoks
+	contentRetention string
+
+	// retryBudget keeps enqueue-only runs from re-queuing knoks that are cooling down
+	retryBudget domain.ExtractionRetryBudget
 }
 
 // Run executes the seeding process
 func (s *Seeder) Run(ctx context.Context) error {
-	// Ensure server exists in database
-	if err := s.ensureServer(ctx); err != nil {
-		return fmt.Errorf("failed to ensure server exists: %w", err)
+	// Ensure server exists in database (enqueue-only runs only touch existing knoks)
+	if !s.enqueueOnly {
+		if err := s.ensureServer(ctx); err != nil {
+			return fmt.Errorf("failed to ensure server exists: %w", err)
+		}
 	}
 
-	// Fetch messages from Discord
-	messages, err := s.fetchMessages(ctx)
+	// Fetch messages from Discord and process each batch before fetching the next,
+	// so memory stays bounded regardless of channel size
+	stats := &SeedingStats{}
+	fetched, err := s.fetchMessages(ctx, func(messages []*discordgo.Message) {
+		s.processMessages(ctx, messages, stats)
+	})
 	if err != nil {
+		if ctx.Err() != nil {
+			s.logger.Warn("Seeding interrupted",
+				"fetched", fetched,
+				"messages_processed", stats.MessagesProcessed.Load(),
+				"knoks_created", stats.KnoksCreated.Load(),
+			)
+		}
 		return fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
 	s.logger.Info("Fetched messages from Discord",
-		"total_messages", len(messages),
+		"total_messages", fetched,
 	)
 
-	// Process messages and create knoks
-	stats := s.processMessages(ctx, messages)
-
 	// Print summary
 	s.logger.Info("Seeding completed",
-		"messages_processed", stats.MessagesProcessed,
-		"urls_detected", stats.URLsDetected,
-		"knoks_created", stats.KnoksCreated,
-		"knoks_skipped", stats.KnoksSkipped,
-		"jobs_queued", stats.JobsQueued,
-		"errors", stats.Errors,
+		"messages_processed", stats.MessagesProcessed.Load(),
+		"urls_detected", stats.URLsDetected.Load(),
+		"knoks_created", stats.KnoksCreated.Load(),
+		"knoks_skipped", stats.KnoksSkipped.Load(),
+		"jobs_queued", stats.JobsQueued.Load(),
+		"errors", stats.Errors.Load(),
 	)
 
 	return nil
@@ -280,11 +369,16 @@ func (s *Seeder) ensureServer(ctx context.Context) error {
 	return nil
 }
 
-// fetchMessages fetches messages from Discord with pagination
-func (s *Seeder) fetchMessages(ctx context.Context) ([]*discordgo.Message, error) {
-	var allMessages []*discordgo.Message
+// fetchMessages fetches messages from Discord with pagination, passing each batch to
+// process before fetching the next. Returns the number of messages fetched.
+// Messages are fetched newest first, or oldest first when an after cursor is set.
+// After each batch the ID to resume from (with -before or -after) is logged, so an
+// interrupted run can be continued where it stopped.
+func (s *Seeder) fetchMessages(ctx context.Context, process func([]*discordgo.Message)) (int, error) {
+	fetched := 0
 	beforeID := s.beforeID
 	afterID := s.afterID
+	forward := afterID != ""
 
 	s.logger.Info("Starting message fetch from Discord",
 		"channel_id", s.channelID,
@@ -295,14 +389,17 @@ func (s *Seeder) fetchMessages(ctx context.Context) ([]*discordgo.Message, error
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
-			return allMessages, ctx.Err()
+			return fetched, ctx.Err()
 		default:
 		}
 
 		// Fetch batch of messages
-		messages, err := s.discord.ChannelMessages(s.channelID, s.batchSize, beforeID, afterID, "")
+		messages, err := s.channelMessages(ctx, beforeID, afterID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch messages: %w", err)
+			if ctx.Err() != nil {
+				return fetched, ctx.Err()
+			}
+			return fetched, fmt.Errorf("failed to fetch messages: %w", err)
 		}
 
 		// No more messages
@@ -311,48 +408,109 @@ func (s *Seeder) fetchMessages(ctx context.Context) ([]*discordgo.Message, error
 			break
 		}
 
+		// Discord returns each batch newest first; walk forward pages oldest first
+		if forward {
+			sortOldestFirst(messages)
+		}
+
+		// Trim the batch to the limit
+		reachedLimit := false
+		if s.limit > 0 && fetched+len(messages) >= s.limit {
+			messages = messages[:s.limit-fetched]
+			reachedLimit = true
+		}
+		fetched += len(messages)
+
 		s.logger.Info("Fetched message batch",
 			"batch_size", len(messages),
-			"total_so_far", len(allMessages)+len(messages),
+			"total_so_far", fetched,
 		)
 
-		allMessages = append(allMessages, messages...)
+		process(messages)
+
+		// A batch cut short by cancellation isn't done, so don't advance the resume point past it
+		if ctx.Err() != nil {
+			return fetched, ctx.Err()
+		}
+
+		// Update pagination cursor from the last message processed
+		if forward {
+			afterID = messages[len(messages)-1].ID
+			s.logger.Info("Processed message batch", "resume_after", afterID)
+		} else {
+			beforeID = messages[len(messages)-1].ID
+			s.logger.Info("Processed message batch", "resume_before", beforeID)
+		}
 
 		// Check limit
-		if s.limit > 0 && len(allMessages) >= s.limit {
+		if reachedLimit {
 			s.logger.Info("Reached message limit",
 				"limit", s.limit,
-				"fetched", len(allMessages),
+				"fetched", fetched,
 			)
-			allMessages = allMessages[:s.limit]
 			break
 		}
 
-		// Update pagination cursor
-		// Discord returns messages in reverse chronological order (newest first)
-		beforeID = messages[len(messages)-1].ID
-
 		// Rate limiting: Discord allows 50 requests per second, but be conservative
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return fetched, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
 	}
 
-	return allMessages, nil
+	return fetched, nil
 }
 
-// processMessages processes Discord messages and creates knoks
-func (s *Seeder) processMessages(ctx context.Context, messages []*discordgo.Message) *SeedingStats {
-	stats := &SeedingStats{}
+// channelMessages fetches one page of messages, returning as soon as ctx is cancelled.
+// discordgo honors the context for the HTTP request but not while it waits on a rate
+// limit bucket, so the call runs in a goroutine that is abandoned on cancellation.
+func (s *Seeder) channelMessages(ctx context.Context, beforeID, afterID string) ([]*discordgo.Message, error) {
+	type result struct {
+		messages []*discordgo.Message
+		err      error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		messages, err := s.fetcher.ChannelMessages(s.channelID, s.batchSize, beforeID, afterID, "", discordgo.WithContext(ctx))
+		resultCh <- result{messages: messages, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultCh:
+		return res.messages, res.err
+	}
+}
+
+// processMessages processes Discord messages and creates knoks, adding to stats.
+// URLs are processed by up to s.workers goroutines; it returns once all have finished.
+func (s *Seeder) processMessages(ctx context.Context, messages []*discordgo.Message, stats *SeedingStats) {
+	workers := s.workers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Items (see dedupKey) already handed to a worker in this batch. Concurrent workers would
+	// all miss the existing-knok check for a URL shared by several messages.
+	dispatched := make(map[string]bool)
 
 	for _, message := range messages {
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
 			s.logger.Warn("Context cancelled, stopping message processing")
-			return stats
+			return
 		default:
 		}
 
-		stats.MessagesProcessed++
+		stats.MessagesProcessed.Add(1)
 
 		// Skip bot messages
 		if message.Author.Bot {
@@ -368,22 +526,42 @@ func (s *Seeder) processMessages(ctx context.Context, messages []*discordgo.Mess
 			continue
 		}
 
-		stats.URLsDetected += len(urls)
+		stats.URLsDetected.Add(int64(len(urls)))
 
 		// Process each URL
 		for _, urlInfo := range urls {
-			if err := s.processURL(ctx, message, urlInfo, stats); err != nil {
-				s.logger.Error("Failed to process URL",
-					"error", err,
-					"url", urlInfo.URL,
-					"message_id", message.ID,
-				)
-				stats.Errors++
+			key := dedupKey(urlInfo)
+			if dispatched[key] {
+				s.logger.Debug("URL already processed in this batch, skipping", "url", urlInfo.URL)
+				stats.KnoksSkipped.Add(1)
+				continue
 			}
+			dispatched[key] = true
+
+			// Wait for a free worker
+			select {
+			case <-ctx.Done():
+				s.logger.Warn("Context cancelled, stopping message processing")
+				return
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if err := s.processURL(ctx, message, urlInfo, stats); err != nil {
+					s.logger.Error("Failed to process URL",
+						"error", err,
+						"url", urlInfo.URL,
+						"message_id", message.ID,
+					)
+					stats.Errors.Add(1)
+				}
+			}()
 		}
 	}
-
-	return stats
 }
 
 // processURL creates a knok and queues a job for a single URL
@@ -394,19 +572,23 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 			"url", urlInfo.URL,
 			"platform", urlInfo.Platform,
 		)
-		stats.KnoksSkipped++
+		stats.KnoksSkipped.Add(1)
 		return nil
 	}
 
-	// Check if knok already exists by canonical URL
-	existingKnok, err := s.knokRepo.GetByCanonicalURL(ctx, s.guildID, urlInfo.CanonicalURL)
+	if s.enqueueOnly {
+		return s.requeueURL(ctx, urlInfo, stats)
+	}
+
+	// Check if knok already exists by canonical URL or platform item ID
+	existingKnok, err := s.findExistingKnok(ctx, urlInfo)
 	if err == nil && existingKnok != nil {
 		s.logger.Debug("Knok already exists, skipping",
 			"knok_id", existingKnok.ID,
 			"url", urlInfo.URL,
 			"extraction_status", existingKnok.ExtractionStatus,
 		)
-		stats.KnoksSkipped++
+		stats.KnoksSkipped.Add(1)
 		return nil
 	}
 
@@ -420,8 +602,8 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 			"platform", urlInfo.Platform,
 			"message_id", message.ID,
 		)
-		stats.KnoksCreated++
-		stats.JobsQueued++
+		stats.KnoksCreated.Add(1)
+		stats.JobsQueued.Add(1)
 		return nil
 	}
 
@@ -435,11 +617,15 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 		Platform:         urlInfo.Platform,
 		DiscordMessageID: message.ID,
 		DiscordChannelID: message.ChannelID,
-		MessageContent:   &message.Content,
+		MessageContent:   domain.RetainedMessageContent(s.contentRetention, message.Content),
+		DiscordUserID:    &message.Author.ID,
 		ExtractionStatus: domain.ExtractionStatusPending,
 		PostedAt:         message.Timestamp,
 		CreatedAt:        now,
 	}
+	if urlInfo.PlatformItemID != "" {
+		knok.PlatformItemID = &urlInfo.PlatformItemID
+	}
 
 	if err := s.knokRepo.Create(ctx, knok); err != nil {
 		return fmt.Errorf("failed to create knok: %w", err)
@@ -450,7 +636,7 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 		"url", urlInfo.URL,
 		"platform", urlInfo.Platform,
 	)
-	stats.KnoksCreated++
+	stats.KnoksCreated.Add(1)
 
 	// Queue metadata extraction job
 	jobPayload := map[string]interface{}{
@@ -461,7 +647,9 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 		"discord_channel_id": message.ChannelID,
 		"discord_guild_id":   s.guildID,
 		"discord_user_id":    message.Author.ID,
-		"message_content":    message.Content,
+	}
+	if content := domain.QueuedMessageContent(s.contentRetention, message.Content); content != nil {
+		jobPayload["message_content"] = *content
 	}
 
 	if err := s.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadata, jobPayload); err != nil {
@@ -472,19 +660,153 @@ func (s *Seeder) processURL(ctx context.Context, message *discordgo.Message, url
 		"knok_id", knokID,
 		"url", urlInfo.URL,
 	)
-	stats.JobsQueued++
+	stats.JobsQueued.Add(1)
 
 	return nil
 }
 
-// SeedingStats tracks statistics for the seeding process
+// discordEpoch is the first millisecond of 2015 (Unix ms), the zero point of Discord snowflakes
+const discordEpoch = 1420070400000
+
+// snowflakeFromTime returns the smallest Discord snowflake with timestamp t. Snowflakes
+// store milliseconds since discordEpoch in their top 42 bits, so every message posted
+// at or after t has an ID at least this large.
+func snowflakeFromTime(t time.Time) uint64 {
+	ms := t.UnixMilli() - discordEpoch
+	if ms < 0 {
+		ms = 0
+	}
+	return uint64(ms) << 22
+}
+
+// parseSinceDate parses a -since value as RFC3339 or a plain YYYY-MM-DD date (UTC)
+func parseSinceDate(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expected RFC3339 or YYYY-MM-DD, got %q", value)
+		}
+	}
+	if t.UnixMilli() <= discordEpoch {
+		return time.Time{}, fmt.Errorf("date must be after 2015-01-01 (the Discord epoch)")
+	}
+	return t, nil
+}
+
+// sortOldestFirst sorts messages by snowflake ID, oldest first
+func sortOldestFirst(messages []*discordgo.Message) {
+	sort.Slice(messages, func(i, j int) bool {
+		a, _ := strconv.ParseUint(messages[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(messages[j].ID, 10, 64)
+		return a < b
+	})
+}
+
+// dedupKey identifies the item a URL points to, preferring the platform item ID over the
+// canonical URL so different URL variants of one item share a key
+func dedupKey(urlInfo urldetector.URLInfo) string {
+	if urlInfo.PlatformItemID != "" {
+		return urlInfo.Platform + ":" + urlInfo.PlatformItemID
+	}
+	return urlInfo.CanonicalURL
+}
+
+// findExistingKnok looks up a knok by canonical URL, falling back to the platform item ID
+// so other URL variants of the same item (youtu.be vs youtube.com/watch) are found too
+func (s *Seeder) findExistingKnok(ctx context.Context, urlInfo urldetector.URLInfo) (*domain.Knok, error) {
+	knok, err := s.knokRepo.GetByCanonicalURL(ctx, s.guildID, urlInfo.CanonicalURL)
+	if urlInfo.PlatformItemID == "" || !errors.Is(err, domain.ErrKnokNotFound) {
+		return knok, err
+	}
+	return s.knokRepo.GetByPlatformItemID(ctx, s.guildID, urlInfo.Platform, urlInfo.PlatformItemID)
+}
+
+// requeueURL queues metadata extraction for the existing knok with a URL if it is
+// pending or failed. Nothing is created, and complete or processing knoks are left alone.
+func (s *Seeder) requeueURL(ctx context.Context, urlInfo urldetector.URLInfo, stats *SeedingStats) error {
+	knok, err := s.findExistingKnok(ctx, urlInfo)
+	if err != nil {
+		if errors.Is(err, domain.ErrKnokNotFound) {
+			s.logger.Debug("No existing knok, skipping", "url", urlInfo.URL)
+			stats.KnoksSkipped.Add(1)
+			return nil
+		}
+		return fmt.Errorf("failed to look up knok: %w", err)
+	}
+
+	if !needsExtraction(knok) {
+		s.logger.Debug("Knok doesn't need extraction, skipping",
+			"knok_id", knok.ID,
+			"extraction_status", knok.ExtractionStatus,
+		)
+		stats.KnoksSkipped.Add(1)
+		return nil
+	}
+
+	if until, cooling := s.retryBudget.CooldownUntil(knok, time.Now()); cooling {
+		s.logger.Info("Knok extraction keeps failing, skipping until cooldown ends",
+			"knok_id", knok.ID,
+			"cooldown_until", until,
+		)
+		stats.KnoksSkipped.Add(1)
+		return nil
+	}
+
+	// The same knok can be linked from many messages; queue it once per run
+	if _, alreadyQueued := s.requeued.LoadOrStore(knok.ID, true); alreadyQueued {
+		stats.KnoksSkipped.Add(1)
+		return nil
+	}
+
+	if s.dryRun {
+		s.logger.Info("[DRY RUN] Would queue extraction for existing knok",
+			"knok_id", knok.ID,
+			"url", knok.URL,
+			"extraction_status", knok.ExtractionStatus,
+		)
+		stats.JobsQueued.Add(1)
+		return nil
+	}
+
+	jobPayload := map[string]interface{}{
+		"knok_id":            knok.ID.String(),
+		"url":                knok.URL,
+		"platform":           knok.Platform,
+		"discord_message_id": knok.DiscordMessageID,
+		"discord_channel_id": knok.DiscordChannelID,
+		"discord_guild_id":   s.guildID,
+	}
+
+	if err := s.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadata, jobPayload); err != nil {
+		return fmt.Errorf("failed to queue metadata extraction job: %w", err)
+	}
+
+	s.logger.Info("Queued extraction for existing knok",
+		"knok_id", knok.ID,
+		"url", knok.URL,
+		"extraction_status", knok.ExtractionStatus,
+	)
+	stats.JobsQueued.Add(1)
+
+	return nil
+}
+
+// needsExtraction reports whether an enqueue-only run should queue extraction for a knok
+func needsExtraction(knok *domain.Knok) bool {
+	return knok.ExtractionStatus == domain.ExtractionStatusPending ||
+		knok.ExtractionStatus == domain.ExtractionStatusFailed
+}
+
+// SeedingStats tracks statistics for the seeding process. Counters are atomic since
+// URLs are processed by concurrent workers.
 type SeedingStats struct {
-	MessagesProcessed int
-	URLsDetected      int
-	KnoksCreated      int
-	KnoksSkipped      int
-	JobsQueued        int
-	Errors            int
+	MessagesProcessed atomic.Int64
+	URLsDetected      atomic.Int64
+	KnoksCreated      atomic.Int64
+	KnoksSkipped      atomic.Int64
+	JobsQueued        atomic.Int64
+	Errors            atomic.Int64
 }
 
 // cleanMarkdownLinks removes markdown link formatting from Discord messages
@@ -499,23 +821,14 @@ func cleanMarkdownLinks(content string) string {
 	// Remove angle brackets around URLs (Discord sometimes adds these)
 	cleaned = regexp.MustCompile(`<([^>]+)>`).ReplaceAllString(cleaned, "$1")
 
-	// Remove common emojis before URLs (simpler approach - just remove known emoji patterns)
-	// Match any non-ASCII characters followed by http to catch emojis before URLs
-	cleaned = regexp.MustCompile(`[^\x00-\x7F]+\s*(https?://)`).ReplaceAllString(cleaned, "$1")
+	// Emoji before URLs are stripped by DetectURLs, the same way as in the bot
 
 	// Clean common Unicode characters that break URLs
 	// Em-dash (—) and en-dash (–) before URLs
 	cleaned = regexp.MustCompile(`[—–]\s*`).ReplaceAllString(cleaned, "")
 
-	// Zero-width spaces and other invisible characters (using actual Unicode characters)
-	// U+200B: Zero Width Space
-	// U+200C: Zero Width Non-Joiner
-	// U+200D: Zero Width Joiner
-	// U+FEFF: Zero Width No-Break Space (BOM)
-	cleaned = strings.ReplaceAll(cleaned, "\u200B", "")
-	cleaned = strings.ReplaceAll(cleaned, "\u200C", "")
-	cleaned = strings.ReplaceAll(cleaned, "\u200D", "")
-	cleaned = strings.ReplaceAll(cleaned, "\uFEFF", "")
+	// Zero-width spaces and other invisible characters, stripped the same way DetectURLs does
+	cleaned = urldetector.StripInvisibleChars(cleaned)
 
 	// Expand YouTube shortened URLs
 	cleaned = strings.ReplaceAll(cleaned, "youtu.be/", "youtube.com/watch?v=")
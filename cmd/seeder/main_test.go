@@ -0,0 +1,583 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// createTestLogger creates a logger for testing
+func createTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError, // Only show errors during tests
+	}))
+}
+
+// blockingFetcher returns one page of messages, then blocks every later call until
+// release is closed, ignoring the request context like a rate-limited discordgo call
+type blockingFetcher struct {
+	firstPage []*discordgo.Message
+	calls     int
+	blocked   chan struct{}
+	release   chan struct{}
+}
+
+func (f *blockingFetcher) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	f.calls++
+	if f.calls == 1 {
+		return f.firstPage, nil
+	}
+	close(f.blocked)
+	<-f.release
+	return nil, nil
+}
+
+func TestFetchMessagesCancellation(t *testing.T) {
+	fetcher := &blockingFetcher{
+		firstPage: []*discordgo.Message{{ID: "2"}, {ID: "1"}},
+		blocked:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	t.Cleanup(func() { close(fetcher.release) })
+
+	seeder := &Seeder{
+		fetcher:   fetcher,
+		logger:    createTestLogger(),
+		channelID: "channel-1",
+		batchSize: 2,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel once the second page request is stuck
+	go func() {
+		<-fetcher.blocked
+		cancel()
+	}()
+
+	type result struct {
+		fetched   int
+		processed int
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		processed := 0
+		fetched, err := seeder.fetchMessages(ctx, func(messages []*discordgo.Message) {
+			processed += len(messages)
+		})
+		done <- result{fetched: fetched, processed: processed, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, context.Canceled) {
+			t.Errorf("fetchMessages() error = %v, want context.Canceled", res.err)
+		}
+		if res.fetched != 2 || res.processed != 2 {
+			t.Errorf("fetchMessages() fetched %d and processed %d messages, want the 2 fetched before cancellation",
+				res.fetched, res.processed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fetchMessages() did not return promptly after cancellation")
+	}
+}
+
+// pagedFetcher serves messages (held newest first) in pages like Discord: each page is
+// newest first, and holds the messages just before beforeID or just after afterID
+type pagedFetcher struct {
+	messages []*discordgo.Message
+	calls    int
+}
+
+func (f *pagedFetcher) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	f.calls++
+
+	if afterID != "" {
+		after, _ := strconv.ParseUint(afterID, 10, 64)
+		var newer []*discordgo.Message
+		for _, message := range f.messages {
+			if id, _ := strconv.ParseUint(message.ID, 10, 64); id > after {
+				newer = append(newer, message)
+			}
+		}
+		if len(newer) > limit {
+			newer = newer[len(newer)-limit:]
+		}
+		return newer, nil
+	}
+
+	start := 0
+	if beforeID != "" {
+		for i, message := range f.messages {
+			if message.ID == beforeID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(f.messages) {
+		end = len(f.messages)
+	}
+	return f.messages[start:end], nil
+}
+
+func TestFetchMessagesProcessesEachBatch(t *testing.T) {
+	var messages []*discordgo.Message
+	for id := 5; id >= 1; id-- {
+		messages = append(messages, &discordgo.Message{ID: strconv.Itoa(id)})
+	}
+
+	tests := []struct {
+		name        string
+		limit       int
+		afterID     string
+		wantBatches [][]string
+		wantFetched int
+	}{
+		{
+			name:        "All messages in batches",
+			wantBatches: [][]string{{"5", "4"}, {"3", "2"}, {"1"}},
+			wantFetched: 5,
+		},
+		{
+			name:        "Limit trims the last batch",
+			limit:       3,
+			wantBatches: [][]string{{"5", "4"}, {"3"}},
+			wantFetched: 3,
+		},
+		{
+			name:        "After cursor walks forward oldest first",
+			afterID:     "1",
+			wantBatches: [][]string{{"2", "3"}, {"4", "5"}},
+			wantFetched: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &pagedFetcher{messages: messages}
+			seeder := &Seeder{
+				fetcher:   fetcher,
+				logger:    createTestLogger(),
+				channelID: "channel-1",
+				batchSize: 2,
+				limit:     tt.limit,
+				afterID:   tt.afterID,
+			}
+
+			var batches [][]string
+			fetched, err := seeder.fetchMessages(context.Background(), func(batch []*discordgo.Message) {
+				// Each batch is processed before the next one is fetched
+				if fetcher.calls != len(batches)+1 {
+					t.Errorf("batch %d processed after %d fetches, want %d", len(batches), fetcher.calls, len(batches)+1)
+				}
+				var ids []string
+				for _, message := range batch {
+					ids = append(ids, message.ID)
+				}
+				batches = append(batches, ids)
+			})
+			if err != nil {
+				t.Fatalf("fetchMessages() error = %v", err)
+			}
+
+			if fetched != tt.wantFetched {
+				t.Errorf("fetchMessages() fetched %d, want %d", fetched, tt.wantFetched)
+			}
+			if !reflect.DeepEqual(batches, tt.wantBatches) {
+				t.Errorf("batches = %v, want %v", batches, tt.wantBatches)
+			}
+		})
+	}
+}
+
+func TestSnowflakeFromTime(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want uint64
+	}{
+		{
+			// Example snowflake from the Discord API docs: 175928847299117063 was
+			// created at 1462015105796 ms; the low 22 bits are worker, process and increment
+			name: "Discord docs example",
+			time: time.UnixMilli(1462015105796),
+			want: 175928847299117063 &^ (1<<22 - 1),
+		},
+		{
+			name: "Discord epoch",
+			time: time.UnixMilli(discordEpoch),
+			want: 0,
+		},
+		{
+			name: "Before the epoch clamps to zero",
+			time: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := snowflakeFromTime(tt.time)
+			if got != tt.want {
+				t.Errorf("snowflakeFromTime(%v) = %d, want %d", tt.time, got, tt.want)
+			}
+
+			// Round trip through discordgo's decoder
+			decoded, err := discordgo.SnowflakeTimestamp(strconv.FormatUint(got, 10))
+			if err != nil {
+				t.Fatalf("SnowflakeTimestamp() error = %v", err)
+			}
+			if got != 0 && !decoded.Equal(tt.time) {
+				t.Errorf("snowflake decodes to %v, want %v", decoded, tt.time)
+			}
+		})
+	}
+}
+
+func TestParseSinceDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2025-01-01", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-01-01T12:30:00Z", want: time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{value: "2025-01-01T12:30:00+02:00", want: time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "Jan 1 2025", wantErr: true},
+		{value: "2014-06-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSinceDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSinceDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseSinceDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakePlatformLoader serves the default platform config
+type fakePlatformLoader struct{}
+
+func (l *fakePlatformLoader) GetAllByPriority() ([]*domain.Platform, error) {
+	config := domain.GetDefaultPlatformConfig()
+	platforms := make([]*domain.Platform, 0, len(config.Platforms))
+	for _, platform := range config.Platforms {
+		p := platform
+		p.Enabled = true
+		platforms = append(platforms, &p)
+	}
+	return platforms, nil
+}
+
+func (l *fakePlatformLoader) IsLoaded() bool { return true }
+
+// fakeKnokRepo stores knoks by canonical URL; other methods are unimplemented.
+// Canonical URLs containing "already-seeded" exist before the run.
+type fakeKnokRepo struct {
+	domain.KnokRepository
+	mu    sync.Mutex
+	knoks map[string]*domain.Knok
+}
+
+func (r *fakeKnokRepo) GetByCanonicalURL(ctx context.Context, serverID, canonicalURL string) (*domain.Knok, error) {
+	// Give other workers a chance to interleave with the lookup
+	time.Sleep(time.Millisecond)
+
+	if strings.Contains(canonicalURL, "already-seeded") {
+		return &domain.Knok{CanonicalURL: canonicalURL}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if knok, ok := r.knoks[canonicalURL]; ok {
+		return knok, nil
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetByPlatformItemID(ctx context.Context, serverID, platform, itemID string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.Platform == platform && knok.PlatformItemID != nil && *knok.PlatformItemID == itemID {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) Create(ctx context.Context, knok *domain.Knok) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.knoks[knok.CanonicalURL]; ok {
+		return fmt.Errorf("duplicate knok for %s", knok.CanonicalURL)
+	}
+	r.knoks[knok.CanonicalURL] = knok
+	return nil
+}
+
+// fakeQueueRepo counts and records enqueued jobs; other methods are unimplemented
+type fakeQueueRepo struct {
+	domain.QueueRepository
+	enqueued atomic.Int64
+
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+}
+
+func (q *fakeQueueRepo) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	q.enqueued.Add(1)
+	if p, ok := payload.(map[string]interface{}); ok {
+		q.mu.Lock()
+		q.payloads = append(q.payloads, p)
+		q.mu.Unlock()
+	}
+	return nil
+}
+
+func TestProcessMessagesConcurrent(t *testing.T) {
+	author := &discordgo.User{ID: "user-1"}
+	bot := &discordgo.User{ID: "bot-1", Bot: true}
+
+	var messages []*discordgo.Message
+	addMessage := func(content string, user *discordgo.User) {
+		messages = append(messages, &discordgo.Message{
+			ID:        strconv.Itoa(len(messages) + 1),
+			ChannelID: "channel-1",
+			Content:   content,
+			Author:    user,
+		})
+	}
+
+	// 40 distinct new tracks, two per message
+	for i := 0; i < 20; i++ {
+		addMessage(fmt.Sprintf("https://soundcloud.com/artist/track-%d https://artist.bandcamp.com/track/song-%d", i, i), author)
+	}
+	// The same track shared in two messages creates one knok
+	addMessage("https://soundcloud.com/artist/shared-track", author)
+	addMessage("again https://soundcloud.com/artist/shared-track", author)
+	// Existing knoks and root URLs are skipped
+	addMessage("https://soundcloud.com/artist/already-seeded", author)
+	addMessage("https://soundcloud.com", author)
+	// Bot messages and messages without links are ignored
+	addMessage("https://soundcloud.com/artist/from-a-bot", bot)
+	addMessage("no links here", author)
+
+	knokRepo := &fakeKnokRepo{knoks: make(map[string]*domain.Knok)}
+	queueRepo := &fakeQueueRepo{}
+	logger := createTestLogger()
+	seeder := &Seeder{
+		knokRepo:    knokRepo,
+		queueRepo:   queueRepo,
+		urlDetector: urldetector.New(&fakePlatformLoader{}, nil, logger),
+		logger:      logger,
+		guildID:     "guild-1",
+		workers:     8,
+	}
+
+	stats := &SeedingStats{}
+	seeder.processMessages(context.Background(), messages, stats)
+
+	want := map[string]int64{
+		"MessagesProcessed": int64(len(messages)),
+		"URLsDetected":      44,
+		"KnoksCreated":      41,
+		"KnoksSkipped":      3,
+		"JobsQueued":        41,
+		"Errors":            0,
+	}
+	got := map[string]int64{
+		"MessagesProcessed": stats.MessagesProcessed.Load(),
+		"URLsDetected":      stats.URLsDetected.Load(),
+		"KnoksCreated":      stats.KnoksCreated.Load(),
+		"KnoksSkipped":      stats.KnoksSkipped.Load(),
+		"JobsQueued":        stats.JobsQueued.Load(),
+		"Errors":            stats.Errors.Load(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stats = %v, want %v", got, want)
+	}
+
+	if len(knokRepo.knoks) != 41 {
+		t.Errorf("created %d knoks, want 41", len(knokRepo.knoks))
+	}
+	if queueRepo.enqueued.Load() != 41 {
+		t.Errorf("enqueued %d jobs, want 41", queueRepo.enqueued.Load())
+	}
+}
+
+func TestProcessURLMessageContentRetention(t *testing.T) {
+	const content = "secret plans for tonight https://soundcloud.com/artist/track"
+
+	tests := []struct {
+		policy      string
+		wantPayload string // empty = no message_content in the job payload
+	}{
+		{policy: domain.MessageContentRetentionFull, wantPayload: content},
+		{policy: domain.MessageContentRetentionURLs, wantPayload: "https://soundcloud.com/artist/track"},
+		{policy: domain.MessageContentRetentionNone},
+		{policy: domain.MessageContentRetentionRedact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			queueRepo := &fakeQueueRepo{}
+			seeder := &Seeder{
+				knokRepo:         &fakeKnokRepo{knoks: make(map[string]*domain.Knok)},
+				queueRepo:        queueRepo,
+				logger:           createTestLogger(),
+				guildID:          "guild-1",
+				contentRetention: tt.policy,
+			}
+
+			url := "https://soundcloud.com/artist/track"
+			message := &discordgo.Message{ID: "1", ChannelID: "channel-1", Content: content, Author: &discordgo.User{ID: "user-1"}}
+			urlInfo := urldetector.URLInfo{URL: url, CanonicalURL: url, Platform: "soundcloud"}
+			if err := seeder.processURL(context.Background(), message, urlInfo, &SeedingStats{}); err != nil {
+				t.Fatalf("processURL() error = %v", err)
+			}
+
+			if len(queueRepo.payloads) != 1 {
+				t.Fatalf("jobs = %d, want 1", len(queueRepo.payloads))
+			}
+			got, ok := queueRepo.payloads[0]["message_content"]
+			if tt.wantPayload == "" {
+				if ok {
+					t.Errorf("job payload message_content = %q, want none", got)
+				}
+			} else if got != tt.wantPayload {
+				t.Errorf("job payload message_content = %q, want %q", got, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestEnqueueOnly(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string // empty = no existing knok
+		wantQueued bool
+	}{
+		{name: "No existing knok"},
+		{name: "Pending knok", status: domain.ExtractionStatusPending, wantQueued: true},
+		{name: "Failed knok", status: domain.ExtractionStatusFailed, wantQueued: true},
+		{name: "Processing knok", status: domain.ExtractionStatusProcessing},
+		{name: "Complete knok", status: domain.ExtractionStatusComplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "https://soundcloud.com/artist/track"
+			knokRepo := &fakeKnokRepo{knoks: make(map[string]*domain.Knok)}
+			if tt.status != "" {
+				knokRepo.knoks[url] = &domain.Knok{
+					ID:               uuid.New(),
+					URL:              url,
+					CanonicalURL:     url,
+					ExtractionStatus: tt.status,
+				}
+			}
+			queueRepo := &fakeQueueRepo{}
+			seeder := &Seeder{
+				knokRepo:    knokRepo,
+				queueRepo:   queueRepo,
+				logger:      createTestLogger(),
+				guildID:     "guild-1",
+				enqueueOnly: true,
+			}
+
+			message := &discordgo.Message{ID: "1", ChannelID: "channel-1", Author: &discordgo.User{ID: "user-1"}}
+			urlInfo := urldetector.URLInfo{URL: url, CanonicalURL: url, Platform: "soundcloud"}
+			stats := &SeedingStats{}
+
+			// The second pass is the same URL shared in another message
+			for i := 0; i < 2; i++ {
+				if err := seeder.processURL(context.Background(), message, urlInfo, stats); err != nil {
+					t.Fatalf("processURL() error = %v", err)
+				}
+			}
+
+			wantQueued := int64(0)
+			if tt.wantQueued {
+				wantQueued = 1
+			}
+			if got := queueRepo.enqueued.Load(); got != wantQueued {
+				t.Errorf("enqueued %d jobs, want %d", got, wantQueued)
+			}
+			if got := stats.JobsQueued.Load(); got != wantQueued {
+				t.Errorf("JobsQueued = %d, want %d", got, wantQueued)
+			}
+			if got := stats.KnoksSkipped.Load(); got != 2-wantQueued {
+				t.Errorf("KnoksSkipped = %d, want %d", got, 2-wantQueued)
+			}
+			if stats.KnoksCreated.Load() != 0 {
+				t.Errorf("KnoksCreated = %d, want 0 in enqueue-only mode", stats.KnoksCreated.Load())
+			}
+
+			wantKnoks := 0
+			if tt.status != "" {
+				wantKnoks = 1
+			}
+			if len(knokRepo.knoks) != wantKnoks {
+				t.Errorf("repository has %d knoks, want %d", len(knokRepo.knoks), wantKnoks)
+			}
+		})
+	}
+}
+
+func TestCleanMarkdownLinksAgreesWithDetector(t *testing.T) {
+	detector := urldetector.New(&fakePlatformLoader{}, nil, createTestLogger())
+
+	messages := []string{
+		"https://soundcloud.com/\u200bartist/track",
+		"listen\u200bhttps://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8",
+		"https://www.mix\u200dcloud.com/\u200cshow/\u2060episode/\ufeff",
+		"https://artist.band\u00adcamp.com/album/record",
+		"[new single](<https://soundcloud.com/artist/single>)",
+		"[listen](https://soundcloud.com/artist/track) and <https://www.nts.live/shows/show>",
+		"🔥 https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8?si=abc",
+		"🔥soundcloud.com/artist/track",
+		"https://youtu.be/dQw4w9WgXcQ",
+	}
+
+	canonical := func(urls []urldetector.URLInfo) []string {
+		result := make([]string, len(urls))
+		for i, info := range urls {
+			result[i] = info.CanonicalURL
+		}
+		return result
+	}
+
+	for _, message := range messages {
+		direct := canonical(detector.DetectURLs(message))
+		seeded := canonical(detector.DetectURLs(cleanMarkdownLinks(message)))
+		if len(direct) == 0 {
+			t.Errorf("DetectURLs(%q) found no links", message)
+		}
+		if !reflect.DeepEqual(seeded, direct) {
+			t.Errorf("seeder detected %q in %q, bot detected %q", seeded, message, direct)
+		}
+	}
+}
@@ -3,14 +3,18 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/pkg/logger"
+	"knock-fm/internal/pkg/tracing"
+	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/repository/postgres"
 	"knock-fm/internal/repository/redis"
 	"knock-fm/internal/service/worker"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,17 +22,56 @@ import (
 )
 
 func main() {
+	// Debugging modes that run once in the foreground instead of the queue loop. They're
+	// defined before config.Load, which parses the command line
+	runJobID := flag.String("run-job", "", "Process the queued job with this ID once, with debug logging, and exit")
+	extractURL := flag.String("extract-url", "", "Run metadata extraction for this URL once, with debug logging, print the result and exit")
+	validateOEmbed := flag.Bool("validate-oembed", false, "Report how much of the oEmbed providers file (OEMBED_PROVIDERS_PATH or the embedded one) loads and exit, failing if required providers are missing")
+
 	// Load configuration
 	cfg := config.Load()
 
+	if *validateOEmbed {
+		os.Exit(runOEmbedValidation(cfg.OEmbedProvidersSource))
+	}
+
 	// Validate worker-specific configuration
 	if err := cfg.ValidateForWorker(); err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Setup logging
-	log := logger.New(cfg.LogLevel)
+	// Setup logging, verbose when debugging a single run
+	logLevel := cfg.LogLevel
+	if *runJobID != "" || *extractURL != "" {
+		logLevel = "debug"
+	}
+	log := logger.New(logLevel)
+
+	// Only accept detected URLs on the configured ports
+	urldetector.SetAllowedPorts(cfg.URLAllowedPorts)
+
+	// Extracting a bare URL needs neither the database nor the queue
+	if *extractURL != "" {
+		processor := worker.NewJobProcessor(log, nil, nil)
+		processor.SetRodDomains(cfg.RodDomains)
+		processor.SetAllowedImageHosts(cfg.AllowedImageHosts)
+		processor.SetMarkRestricted(cfg.MarkRestrictedContent)
+		processor.SetOEmbedProvidersSource(context.Background(), cfg.OEmbedProvidersSource)
+		if err := worker.ExtractURL(context.Background(), processor, *extractURL, os.Stdout); err != nil {
+			log.Error("Extraction failed", "url", *extractURL, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "knock-fm-worker", cfg.OTLPEndpoint)
+	if err != nil {
+		log.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	log.Info("Starting worker service...")
 
 	// Connect to PostgreSQL
@@ -66,7 +109,10 @@ func main() {
 	}
 
 	// Create repositories
-	queueRepo := redis.NewQueueRepository(redisClient, log)
+	queueRepo := redis.NewQueueRepository(redisClient, redis.QueueOptions{
+		KeyPrefix:       cfg.RedisKeyPrefix,
+		MaxPayloadBytes: cfg.MaxJobPayloadBytes,
+	}, log)
 	knokRepo := postgres.NewKnokRepository(db, log)
 	serverRepo := postgres.NewServerRepository(db, log)
 
@@ -77,6 +123,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *runJobID != "" {
+		job, err := queueRepo.GetJob(context.Background(), *runJobID)
+		if err != nil {
+			log.Error("Failed to load job", "job_id", *runJobID, "error", err)
+			os.Exit(1)
+		}
+		if err := workerService.RunJob(context.Background(), job); err != nil {
+			log.Error("Job failed", "job_id", job.ID, "job_type", job.Type, "error", err)
+			os.Exit(1)
+		}
+		log.Info("Job succeeded", "job_id", job.ID, "job_type", job.Type)
+		return
+	}
+
 	// Create a channel to track shutdown completion
 	done := make(chan struct{})
 
@@ -101,7 +161,7 @@ func main() {
 	}
 
 	// Graceful shutdown with timeout
-	_, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Stop worker service
@@ -109,5 +169,35 @@ func main() {
 		log.Error("Error stopping worker service", "error", err)
 	}
 
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Error shutting down tracing", "error", err)
+	}
+
 	log.Info("Worker service shutdown complete")
 }
+
+// runOEmbedValidation prints the oEmbed providers file report and returns the exit code
+func runOEmbedValidation(source string) int {
+	report, err := worker.ValidateOEmbedProviders(context.Background(), source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid oEmbed providers file: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Providers: %d loaded, %d skipped\n", report.ProvidersLoaded, report.ProvidersSkipped)
+	fmt.Printf("Schemes:   %d loaded, %d skipped\n", report.SchemesLoaded, report.SchemesSkipped)
+	for _, skip := range report.Skipped {
+		if skip.Scheme != "" {
+			fmt.Printf("  skipped scheme %q of %s: %s\n", skip.Scheme, skip.Provider, skip.Reason)
+		} else {
+			fmt.Printf("  skipped provider %s: %s\n", skip.Provider, skip.Reason)
+		}
+	}
+
+	if len(report.MissingRequired) > 0 {
+		fmt.Fprintf(os.Stderr, "Missing required providers: %s\n", strings.Join(report.MissingRequired, ", "))
+		return 1
+	}
+	return 0
+}
@@ -2,9 +2,14 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"knock-fm/internal/domain"
 	"log"
+	"net"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,22 +20,133 @@ type Config struct {
 	LogLevel     string
 	StaticDir    string
 
+	// AdminAddr is the internal listen address (host:port) for admin routes. When set they
+	// are only served there, not on Port. Default: empty, admin routes share Port
+	AdminAddr string
+
+	// RedisKeyPrefix namespaces every Redis key so several environments can share one
+	// Redis instance (optional, empty = bare keys)
+	RedisKeyPrefix string
+
+	// MaxJobPayloadBytes caps the size of a queued job payload; oversized message content
+	// is truncated to fit. 0 = no limit. Default: 65536
+	MaxJobPayloadBytes int
+
 	// Discord restrictions (optional)
 	DiscordAllowedGuilds   []string // Empty = allow all guilds
 	DiscordAllowedChannels []string // Empty = allow all channels (or use per-server settings)
 
+	// Discord gateway sharding (optional)
+	// Each bot process connects as shard DiscordShardID of DiscordShardCount and only
+	// receives events for its subset of guilds. Default: single shard (0 of 1)
+	DiscordShardID    int
+	DiscordShardCount int
+
+	// DiscordConnectAttempts is how many times the bot and seeder try to reach Discord at
+	// startup, backing off between tries, before giving up. Default: 5
+	DiscordConnectAttempts int
+
+	// DiscordMessageContentIntent requests the privileged Message Content Intent, without
+	// which Discord sends guild messages with empty content. Default: true
+	DiscordMessageContentIntent bool
+
+	// DiscordEmptyContentWarnAfter is how many guild messages in a row with no content the
+	// bot sees before warning that the Message Content Intent seems to be missing.
+	// 0 = never warn. Default: 20
+	DiscordEmptyContentWarnAfter int
+
 	// DefaultUnknownPlatformMode controls how the bot handles URLs from unrecognized platforms
 	// Values: "permissive" (accept all URLs) or "strict" (reject unknown platforms)
 	// Default: "permissive"
 	// Can be overridden per-server via server.settings JSONB field
 	DefaultUnknownPlatformMode string
+
+	// BatchMetadataExtraction queues one extraction job per message instead of one per URL,
+	// so the worker can share a browser and HTTP client across links from the same message
+	// Default: false
+	BatchMetadataExtraction bool
+
+	// MarkLowQualityMetadata gives extractions whose only metadata is a title equal to the
+	// URL the "low_quality" status instead of "complete", so they can be retried
+	// Default: false
+	MarkLowQualityMetadata bool
+
+	// MarkRestrictedContent gives knoks whose page is private, age-restricted or behind a
+	// login (401/403 or an age-gate/private notice) the "restricted" status instead of
+	// storing the login page's title
+	// Default: false
+	MarkRestrictedContent bool
+
+	// MessageContentRetention controls how much of the Discord message a knok stores:
+	// "full", "urls" (only the words containing links), "none", or
+	// "redact_after_extraction" (cleared once metadata extraction finishes)
+	// Default: "full"
+	MessageContentRetention string
+
+	// URLAllowedPorts lists the explicit ports accepted in detected URLs
+	// URLs with any other port are ignored. Empty = 80 and 443
+	URLAllowedPorts []string
+
+	// PreviewRateLimit is the number of link preview requests allowed per client per minute
+	// Default: 5
+	PreviewRateLimit int
+
+	// TrustedProxies lists the reverse proxies (IPs or CIDR ranges) whose X-Forwarded-For
+	// header identifies the client for rate limiting. Requests from anywhere else are keyed
+	// by their own address. Default: empty (X-Forwarded-For is ignored)
+	TrustedProxies []*net.IPNet
+
+	// RodDomains lists JS-only sites whose metadata is extracted with the headless browser
+	// first, skipping the oEmbed and HTTP tiers. Subdomains match too
+	RodDomains []string
+
+	// AllowedImageHosts lists the hosts extracted images are kept from, e.g. platform CDNs;
+	// images elsewhere are dropped. Subdomains match too. Default: empty (keep every image)
+	AllowedImageHosts []string
+
+	// LogRejectedURLs logs, at debug level, each candidate URL the detector drops because
+	// it can't be normalized, with the reason. Default: false
+	LogRejectedURLs bool
+
+	// StripEmojiBeforeURLs removes emoji written directly before a link, e.g.
+	// "🔥soundcloud.com/...", before URL detection. Default: true
+	StripEmojiBeforeURLs bool
+
+	// PlatformCacheSize is how many hosts' detected platforms the URL detector caches,
+	// 0 disables the cache. Default: 1024
+	PlatformCacheSize int
+
+	// ExtractionRetryBudget stops knoks that keep failing extraction from being re-queued:
+	// after MaxFailures failures within Window they're left alone for Cooldown.
+	// Default: 3 failures within 24h, 24h cooldown
+	ExtractionRetryBudget domain.ExtractionRetryBudget
+
+	// OEmbedProvidersSource is a file path or http(s) URL of an oembed.com style providers
+	// file loaded at startup instead of the copy built into the binary. Default: none
+	OEmbedProvidersSource string
+
+	// OTLPEndpoint is the OTLP/HTTP collector URL traces are exported to, e.g.
+	// http://localhost:4318. Default: none (tracing disabled)
+	OTLPEndpoint string
+
+	// KnokWaitMaxTimeout caps how long GET /api/v1/knoks/{id}/wait holds a request open
+	// waiting for extraction to finish. Default: 30s
+	KnokWaitMaxTimeout time.Duration
+
+	// TimelineWindow limits GET /api/v1/knoks to knoks posted this recently unless from or
+	// to is given. Set in days by TIMELINE_WINDOW_DAYS. Default: 0 (every knok)
+	TimelineWindow time.Duration
+
+	// JobTimeouts overrides the worker's processing timeout per job type, e.g.
+	// "extract_metadata=2m,notify_complete=15s". Unlisted job types use the worker defaults
+	JobTimeouts map[string]time.Duration
 }
 
 func Load() *Config {
 	config := &Config{
 		Port:      getEnvWithDefault("PORT", "8080"),
 		LogLevel:  getEnvWithDefault("LOG_LEVEL", "info"),
-		StaticDir: getEnvWithDefault("STATIC_DIR", "./web/build"),
+		StaticDir: getEnvWithDefault("STATIC_DIR", "./web/dist"),
 
 		// Default to permissive mode (accept unknown platforms)
 		DefaultUnknownPlatformMode: getEnvWithDefault("UNKNOWN_PLATFORM_MODE", "permissive"),
@@ -38,15 +154,183 @@ func Load() *Config {
 		// Discord restrictions (optional)
 		DiscordAllowedGuilds:   parseCommaSeparated(getEnvWithDefault("DISCORD_ALLOWED_GUILDS", "")),
 		DiscordAllowedChannels: parseCommaSeparated(getEnvWithDefault("DISCORD_ALLOWED_CHANNELS", "")),
+
+		// Optional domains that skip straight to Rod extraction
+		RodDomains: parseCommaSeparated(getEnvWithDefault("ROD_DOMAINS", "")),
+
+		// Optional image hosts extracted images must come from
+		AllowedImageHosts: parseCommaSeparated(getEnvWithDefault("ALLOWED_IMAGE_HOSTS", "")),
+
+		// Optional oEmbed providers file overriding the embedded one
+		OEmbedProvidersSource: getEnvWithDefault("OEMBED_PROVIDERS_PATH", ""),
+
+		// Optional OpenTelemetry trace exporter
+		OTLPEndpoint: getEnvWithDefault("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+	}
+
+	// Optional separate listener for admin routes
+	config.AdminAddr = getEnvWithDefault("ADMIN_ADDR", "")
+	if config.AdminAddr != "" {
+		if _, _, err := net.SplitHostPort(config.AdminAddr); err != nil {
+			log.Fatalf("Invalid ADMIN_ADDR value: must be host:port, e.g. 127.0.0.1:9090")
+		}
 	}
 
 	// Required environment variables (for database/redis services)
 	config.DatabaseURL = mustGetEnv("DATABASE_URL")
 	config.RedisURL = mustGetEnv("REDIS_URL")
+	config.RedisKeyPrefix = getEnvWithDefault("REDIS_KEY_PREFIX", "")
 
 	// Optional Discord token (only required for bot service)
 	config.DiscordToken = getEnvWithDefault("DISCORD_TOKEN", "")
 
+	// Optional gateway sharding
+	shardID, shardCount, err := parseShardConfig(
+		getEnvWithDefault("DISCORD_SHARD_ID", ""),
+		getEnvWithDefault("DISCORD_SHARD_COUNT", ""),
+	)
+	if err != nil {
+		log.Fatalf("Invalid Discord shard configuration: %v", err)
+	}
+	config.DiscordShardID = shardID
+	config.DiscordShardCount = shardCount
+
+	// Optional Discord startup connection attempts
+	connectAttempts, err := strconv.Atoi(getEnvWithDefault("DISCORD_CONNECT_ATTEMPTS", "5"))
+	if err != nil || connectAttempts < 1 {
+		log.Fatalf("Invalid DISCORD_CONNECT_ATTEMPTS value: must be a positive integer")
+	}
+	config.DiscordConnectAttempts = connectAttempts
+
+	// Optional Message Content Intent handling
+	contentIntent, err := strconv.ParseBool(getEnvWithDefault("DISCORD_MESSAGE_CONTENT_INTENT", "true"))
+	if err != nil {
+		log.Fatalf("Invalid DISCORD_MESSAGE_CONTENT_INTENT value: %v", err)
+	}
+	config.DiscordMessageContentIntent = contentIntent
+
+	emptyContentWarnAfter, err := strconv.Atoi(getEnvWithDefault("DISCORD_EMPTY_CONTENT_WARN_AFTER", "20"))
+	if err != nil || emptyContentWarnAfter < 0 {
+		log.Fatalf("Invalid DISCORD_EMPTY_CONTENT_WARN_AFTER value: must be a non-negative integer")
+	}
+	config.DiscordEmptyContentWarnAfter = emptyContentWarnAfter
+
+	// Optional batched metadata extraction
+	batchExtraction, err := strconv.ParseBool(getEnvWithDefault("BATCH_METADATA_EXTRACTION", "false"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_METADATA_EXTRACTION value: %v", err)
+	}
+	config.BatchMetadataExtraction = batchExtraction
+
+	// Optional low quality metadata status
+	markLowQuality, err := strconv.ParseBool(getEnvWithDefault("MARK_LOW_QUALITY_METADATA", "false"))
+	if err != nil {
+		log.Fatalf("Invalid MARK_LOW_QUALITY_METADATA value: %v", err)
+	}
+	config.MarkLowQualityMetadata = markLowQuality
+
+	// Optional restricted content status
+	markRestricted, err := strconv.ParseBool(getEnvWithDefault("MARK_RESTRICTED_CONTENT", "false"))
+	if err != nil {
+		log.Fatalf("Invalid MARK_RESTRICTED_CONTENT value: %v", err)
+	}
+	config.MarkRestrictedContent = markRestricted
+
+	// Optional diagnostics for URLs that fail detection
+	logRejectedURLs, err := strconv.ParseBool(getEnvWithDefault("LOG_REJECTED_URLS", "false"))
+	if err != nil {
+		log.Fatalf("Invalid LOG_REJECTED_URLS value: %v", err)
+	}
+	config.LogRejectedURLs = logRejectedURLs
+
+	// Optional emoji stripping before URL detection
+	stripEmojiBeforeURLs, err := strconv.ParseBool(getEnvWithDefault("STRIP_EMOJI_BEFORE_URLS", "true"))
+	if err != nil {
+		log.Fatalf("Invalid STRIP_EMOJI_BEFORE_URLS value: %v", err)
+	}
+	config.StripEmojiBeforeURLs = stripEmojiBeforeURLs
+
+	// Optional message content retention policy
+	config.MessageContentRetention = getEnvWithDefault("MESSAGE_CONTENT_RETENTION", domain.MessageContentRetentionFull)
+	if !domain.IsValidMessageContentRetention(config.MessageContentRetention) {
+		log.Fatalf("Invalid MESSAGE_CONTENT_RETENTION value: expected full, urls, none or redact_after_extraction")
+	}
+
+	// Optional URL port allow list
+	allowedPorts, err := parsePortList(getEnvWithDefault("URL_ALLOWED_PORTS", ""))
+	if err != nil {
+		log.Fatalf("Invalid URL_ALLOWED_PORTS value: %v", err)
+	}
+	config.URLAllowedPorts = allowedPorts
+
+	// Optional preview endpoint rate limit
+	previewRateLimit, err := strconv.Atoi(getEnvWithDefault("PREVIEW_RATE_LIMIT", "5"))
+	if err != nil || previewRateLimit < 1 {
+		log.Fatalf("Invalid PREVIEW_RATE_LIMIT value: must be a positive integer")
+	}
+	config.PreviewRateLimit = previewRateLimit
+
+	// Optional reverse proxies trusted to report the client address
+	trustedProxies, err := parseTrustedProxies(getEnvWithDefault("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES value: %v", err)
+	}
+	config.TrustedProxies = trustedProxies
+
+	// Optional job payload size limit
+	maxJobPayloadBytes, err := strconv.Atoi(getEnvWithDefault("MAX_JOB_PAYLOAD_BYTES", "65536"))
+	if err != nil || maxJobPayloadBytes < 0 {
+		log.Fatalf("Invalid MAX_JOB_PAYLOAD_BYTES value: must be a non-negative integer")
+	}
+	config.MaxJobPayloadBytes = maxJobPayloadBytes
+
+	// Optional platform detection cache size
+	platformCacheSize, err := strconv.Atoi(getEnvWithDefault("PLATFORM_CACHE_SIZE", "1024"))
+	if err != nil || platformCacheSize < 0 {
+		log.Fatalf("Invalid PLATFORM_CACHE_SIZE value: must be a non-negative integer")
+	}
+	config.PlatformCacheSize = platformCacheSize
+
+	// Optional extraction retry budget
+	maxFailures, err := strconv.Atoi(getEnvWithDefault("EXTRACTION_MAX_FAILURES", "3"))
+	if err != nil || maxFailures < 0 {
+		log.Fatalf("Invalid EXTRACTION_MAX_FAILURES value: must be a non-negative integer")
+	}
+	failureWindow, err := time.ParseDuration(getEnvWithDefault("EXTRACTION_FAILURE_WINDOW", "24h"))
+	if err != nil || failureWindow <= 0 {
+		log.Fatalf("Invalid EXTRACTION_FAILURE_WINDOW value: must be a positive duration")
+	}
+	failureCooldown, err := time.ParseDuration(getEnvWithDefault("EXTRACTION_FAILURE_COOLDOWN", "24h"))
+	if err != nil || failureCooldown <= 0 {
+		log.Fatalf("Invalid EXTRACTION_FAILURE_COOLDOWN value: must be a positive duration")
+	}
+	config.ExtractionRetryBudget = domain.ExtractionRetryBudget{
+		MaxFailures: maxFailures,
+		Window:      failureWindow,
+		Cooldown:    failureCooldown,
+	}
+
+	// Optional cap on long-polling for extraction results
+	knokWaitMaxTimeout, err := time.ParseDuration(getEnvWithDefault("KNOK_WAIT_MAX_TIMEOUT", "30s"))
+	if err != nil || knokWaitMaxTimeout <= 0 {
+		log.Fatalf("Invalid KNOK_WAIT_MAX_TIMEOUT value: must be a positive duration")
+	}
+	config.KnokWaitMaxTimeout = knokWaitMaxTimeout
+
+	// Optional default window for the global timeline
+	timelineWindowDays, err := strconv.Atoi(getEnvWithDefault("TIMELINE_WINDOW_DAYS", "0"))
+	if err != nil || timelineWindowDays < 0 {
+		log.Fatalf("Invalid TIMELINE_WINDOW_DAYS value: must be a non-negative integer")
+	}
+	config.TimelineWindow = time.Duration(timelineWindowDays) * 24 * time.Hour
+
+	// Optional per-job-type worker timeouts
+	jobTimeouts, err := parseJobTimeouts(getEnvWithDefault("JOB_TIMEOUTS", ""))
+	if err != nil {
+		log.Fatalf("Invalid JOB_TIMEOUTS value: %v", err)
+	}
+	config.JobTimeouts = jobTimeouts
+
 	// Command line flags override environment
 	flag.StringVar(&config.Port, "port", config.Port, "Server port")
 	flag.StringVar(&config.LogLevel, "log-level", config.LogLevel, "Log level")
@@ -85,6 +369,115 @@ func parseCommaSeparated(value string) []string {
 	return result
 }
 
+// parsePortList parses a comma-separated list of TCP ports, rejecting values outside 1-65535
+func parsePortList(value string) ([]string, error) {
+	ports := parseCommaSeparated(value)
+	for _, port := range ports {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+	}
+	return ports, nil
+}
+
+// parseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges. A bare
+// address is a range holding just that address
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range parseCommaSeparated(value) {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 128
+			if v4 := ip.To4(); v4 != nil {
+				ip, bits = v4, 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// parseJobTimeouts parses a comma-separated list of job_type=duration pairs.
+// Job types must be known and durations positive, so a typo can't silently fall back to the default.
+func parseJobTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range parseCommaSeparated(value) {
+		jobType, durationStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected job_type=duration, got %q", entry)
+		}
+		jobType = strings.TrimSpace(jobType)
+
+		if !domain.IsValidJobType(jobType) {
+			return nil, fmt.Errorf("unknown job type %q", jobType)
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(durationStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for %s: %w", jobType, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("timeout for %s must be positive, got %s", jobType, duration)
+		}
+		timeouts[jobType] = duration
+	}
+	return timeouts, nil
+}
+
+// parseShardConfig parses the shard ID and count environment values.
+// Both empty means a single shard (0 of 1). A count without an ID is rejected so two
+// processes can't silently connect as the same shard.
+func parseShardConfig(shardIDStr, shardCountStr string) (int, int, error) {
+	shardIDStr = strings.TrimSpace(shardIDStr)
+	shardCountStr = strings.TrimSpace(shardCountStr)
+
+	if shardIDStr == "" && shardCountStr == "" {
+		return 0, 1, nil
+	}
+	if shardIDStr == "" || shardCountStr == "" {
+		return 0, 0, fmt.Errorf("DISCORD_SHARD_ID and DISCORD_SHARD_COUNT must be set together")
+	}
+
+	shardID, err := strconv.Atoi(shardIDStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("DISCORD_SHARD_ID must be an integer: %w", err)
+	}
+	shardCount, err := strconv.Atoi(shardCountStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("DISCORD_SHARD_COUNT must be an integer: %w", err)
+	}
+
+	if shardCount < 1 {
+		return 0, 0, fmt.Errorf("DISCORD_SHARD_COUNT must be at least 1, got %d", shardCount)
+	}
+	if shardID < 0 || shardID >= shardCount {
+		return 0, 0, fmt.Errorf("DISCORD_SHARD_ID must be between 0 and %d, got %d", shardCount-1, shardID)
+	}
+
+	return shardID, shardCount, nil
+}
+
+// ShardForGuild returns the shard that Discord routes a guild's events to:
+// (guild_id >> 22) % shard_count. Returns -1 if the guild ID is not a valid snowflake.
+func ShardForGuild(guildID string, shardCount int) int {
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil || shardCount < 1 {
+		return -1
+	}
+	return int((id >> 22) % uint64(shardCount))
+}
+
 // ValidateForBot ensures all required fields for bot service are present
 func (c *Config) ValidateForBot() error {
 	if c.DiscordToken == "" {
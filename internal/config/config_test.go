@@ -0,0 +1,161 @@
+package config
+
+import (
+	"knock-fm/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseShardConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		shardID   string
+		count     string
+		wantID    int
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "Unset defaults to single shard", wantID: 0, wantCount: 1},
+		{name: "Valid first shard", shardID: "0", count: "4", wantID: 0, wantCount: 4},
+		{name: "Valid last shard", shardID: "3", count: "4", wantID: 3, wantCount: 4},
+		{name: "Whitespace is trimmed", shardID: " 1 ", count: " 2 ", wantID: 1, wantCount: 2},
+		{name: "ID without count", shardID: "1", wantErr: true},
+		{name: "Count without ID", count: "2", wantErr: true},
+		{name: "ID out of range", shardID: "4", count: "4", wantErr: true},
+		{name: "Negative ID", shardID: "-1", count: "4", wantErr: true},
+		{name: "Zero count", shardID: "0", count: "0", wantErr: true},
+		{name: "Non-numeric ID", shardID: "one", count: "2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, gotCount, err := parseShardConfig(tt.shardID, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseShardConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotID != tt.wantID || gotCount != tt.wantCount {
+				t.Errorf("parseShardConfig() = (%d, %d), want (%d, %d)", gotID, gotCount, tt.wantID, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestShardForGuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		guildID    string
+		shardCount int
+		want       int
+	}{
+		{name: "Single shard", guildID: "41771983423143937", shardCount: 1, want: 0},
+		{name: "Multiple shards", guildID: "41771983423143937", shardCount: 4, want: 2},
+		{name: "Invalid snowflake", guildID: "not-a-guild", shardCount: 2, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShardForGuild(tt.guildID, tt.shardCount); got != tt.want {
+				t.Errorf("ShardForGuild() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePortList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "Unset", value: "", want: 0},
+		{name: "Single port", value: "443", want: 1},
+		{name: "Several ports", value: "80, 443, 8443", want: 3},
+		{name: "Non-numeric", value: "443,https", wantErr: true},
+		{name: "Out of range", value: "70000", wantErr: true},
+		{name: "Zero", value: "0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePortList(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePortList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("parsePortList() returned %d ports, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Unset", value: ""},
+		{name: "Single address", value: "10.0.0.2", want: []string{"10.0.0.2/32"}},
+		{name: "IPv6 address", value: "::1", want: []string{"::1/128"}},
+		{name: "Ranges and addresses", value: "172.16.0.0/12, 127.0.0.1", want: []string{"172.16.0.0/12", "127.0.0.1/32"}},
+		{name: "Invalid address", value: "caddy", wantErr: true},
+		{name: "Invalid range", value: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTrustedProxies(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ranges []string
+			for _, proxy := range got {
+				ranges = append(ranges, proxy.String())
+			}
+			if !reflect.DeepEqual(ranges, tt.want) {
+				t.Errorf("parseTrustedProxies() = %v, want %v", ranges, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJobTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{name: "Unset", value: "", want: map[string]time.Duration{}},
+		{
+			name:  "Several job types",
+			value: "extract_metadata=2m, notify_complete = 15s",
+			want: map[string]time.Duration{
+				domain.JobTypeExtractMetadata: 2 * time.Minute,
+				domain.JobTypeNotifyComplete:  15 * time.Second,
+			},
+		},
+		{name: "Unknown job type", value: "extract_metdata=2m", wantErr: true},
+		{name: "Missing duration", value: "extract_metadata", wantErr: true},
+		{name: "Invalid duration", value: "extract_metadata=90", wantErr: true},
+		{name: "Zero duration", value: "extract_metadata=0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJobTimeouts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJobTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJobTimeouts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "errors"
+
+// Sentinel errors returned by repositories when a record doesn't exist, so callers
+// don't depend on the storage package's errors (e.g. sql.ErrNoRows)
+var (
+	ErrKnokNotFound     = errors.New("knok not found")
+	ErrServerNotFound   = errors.New("server not found")
+	ErrPlatformNotFound = errors.New("platform not found")
+)
+
+// ErrPlatformExists is returned when creating a platform whose ID is already taken
+var ErrPlatformExists = errors.New("platform already exists")
+
+// ErrPayloadTooLarge is returned when a job payload exceeds the queue's size limit
+var ErrPayloadTooLarge = errors.New("job payload too large")
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,11 +16,23 @@ type Knok struct {
 	Platform     string `json:"platform" db:"platform"`
 	Title    *string   `json:"title" db:"title"`
 
+	// PlatformItemID is the platform's own ID for the item (e.g. a YouTube video ID),
+	// extracted with the platform's extraction patterns. Nil if no pattern matched.
+	PlatformItemID *string `json:"platform_item_id" db:"platform_item_id"`
+
 	// Discord-specific fields
 	DiscordMessageID string  `json:"discord_message_id" db:"discord_message_id"`
 	DiscordChannelID string  `json:"discord_channel_id" db:"discord_channel_id"`
 	MessageContent   *string `json:"message_content" db:"message_content"`
 
+	// DiscordUserID is the author of the message the knok was posted in. Nil for knoks
+	// stored before authors were recorded.
+	DiscordUserID *string `json:"discord_user_id" db:"discord_user_id"`
+
+	// ThumbnailURL is the extracted image, also kept in Metadata["image"]. Nil until
+	// extraction finds one.
+	ThumbnailURL *string `json:"thumbnail_url" db:"thumbnail_url"`
+
 	// Metadata and processing
 	Metadata         map[string]interface{} `json:"metadata" db:"metadata"`
 	ExtractionStatus string                 `json:"extraction_status" db:"extraction_status"`
@@ -38,9 +51,264 @@ const (
 	ExtractionStatusProcessing = "processing"
 	ExtractionStatusComplete   = "complete"
 	ExtractionStatusFailed     = "failed"
+
+	// ExtractionStatusLowQuality marks an extraction that finished with nothing better than
+	// a title equal to the URL, so it can be found and retried instead of shown as complete
+	ExtractionStatusLowQuality = "low_quality"
+
+	// ExtractionStatusRestricted marks a knok whose page is private, age-restricted or
+	// behind a login, so there is nothing to extract until it's opened up
+	ExtractionStatusRestricted = "restricted"
+)
+
+// IsValidExtractionStatus reports whether status is a knok extraction status
+func IsValidExtractionStatus(status string) bool {
+	switch status {
+	case ExtractionStatusPending, ExtractionStatusProcessing, ExtractionStatusComplete,
+		ExtractionStatusFailed, ExtractionStatusLowQuality, ExtractionStatusRestricted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Message content retention policies, controlling how much of the Discord message a knok
+// keeps in message_content
+const (
+	// MessageContentRetentionFull keeps the whole message
+	MessageContentRetentionFull = "full"
+
+	// MessageContentRetentionURLs keeps only the words of the message that contain a URL
+	MessageContentRetentionURLs = "urls"
+
+	// MessageContentRetentionNone never stores the message
+	MessageContentRetentionNone = "none"
+
+	// MessageContentRetentionRedact keeps the whole message until metadata extraction
+	// finishes, then clears it; it's only needed to re-detect links until then
+	MessageContentRetentionRedact = "redact_after_extraction"
 )
 
+// IsValidMessageContentRetention checks if the message content retention policy is known
+func IsValidMessageContentRetention(policy string) bool {
+	switch policy {
+	case MessageContentRetentionFull, MessageContentRetentionURLs,
+		MessageContentRetentionNone, MessageContentRetentionRedact:
+		return true
+	}
+	return false
+}
+
+// RetainedMessageContent returns the part of a message's content a new knok stores under
+// the retention policy, or nil if nothing should be stored
+func RetainedMessageContent(policy, content string) *string {
+	switch policy {
+	case MessageContentRetentionNone:
+		return nil
+	case MessageContentRetentionURLs:
+		var urls []string
+		for _, word := range strings.Fields(content) {
+			lower := strings.ToLower(word)
+			if strings.Contains(lower, "http://") || strings.Contains(lower, "https://") {
+				urls = append(urls, word)
+			}
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		retained := strings.Join(urls, " ")
+		return &retained
+	default:
+		return &content
+	}
+}
+
+// QueuedMessageContent returns the part of a message's content an extraction job payload
+// carries under the retention policy, or nil if none. Queued jobs outlive the extraction in
+// Redis, so content that is cleared once extraction finishes isn't queued at all
+func QueuedMessageContent(policy, content string) *string {
+	if policy == MessageContentRetentionRedact {
+		return nil
+	}
+	return RetainedMessageContent(policy, content)
+}
+
 // IsValidPlatform checks if the platform is supported
 func (k *Knok) IsValidPlatform() bool {
 	return IsValidPlatform(k.Platform)
 }
+
+// ExtractionMethodTiers maps each extraction_method recorded in knok metadata to the
+// tier of the fallback chain that produced it. Lower tiers are cheaper and more reliable.
+var ExtractionMethodTiers = map[string]int{
+	"oembed":         0,
+	"http_static":    1,
+	"rod_browser":    2,
+	"title_fallback": 3,
+	"error_fallback": 3,
+}
+
+// ActivityBucketSizes maps each bucket size accepted by the activity histogram to its
+// shortest length, used to bound how many buckets a request can span
+var ActivityBucketSizes = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 28 * 24 * time.Hour,
+}
+
+// ActivityBucket counts the knoks posted in one histogram bucket, starting at Start (UTC)
+type ActivityBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// Columns knok timelines can be sorted by
+const (
+	KnokSortPostedAt  = "posted_at"
+	KnokSortCreatedAt = "created_at"
+	KnokSortTitle     = "title"
+)
+
+// KnokSort orders a knok timeline; cursor pagination follows the sort column
+type KnokSort struct {
+	Column    string
+	Ascending bool
+}
+
+// DefaultKnokSort lists the most recently posted knoks first
+var DefaultKnokSort = KnokSort{Column: KnokSortPostedAt}
+
+// IsValidKnokSortColumn reports whether knok timelines can be sorted by column
+func IsValidKnokSortColumn(column string) bool {
+	switch column {
+	case KnokSortPostedAt, KnokSortCreatedAt, KnokSortTitle:
+		return true
+	default:
+		return false
+	}
+}
+
+// KnokCursor marks where the previous page of a sorted knok timeline ended. Time sorts
+// page on the last knok's timestamp, title sorts on the last knok itself so its ID can
+// break ties between equal titles
+type KnokCursor struct {
+	Time   time.Time
+	KnokID uuid.UUID
+}
+
+// KnokCounts counts a server's knoks in total, per platform and per extraction status
+type KnokCounts struct {
+	Total      int            `json:"total"`
+	ByPlatform map[string]int `json:"by_platform"`
+	ByStatus   map[string]int `json:"by_status"`
+}
+
+// ServerStats summarizes a server's completed knoks for the /stats command
+type ServerStats struct {
+	Total          int              `json:"total"`
+	Platforms      []*PlatformCount `json:"platforms"` // most knoks first
+	LatestPostedAt *time.Time       `json:"latest_posted_at,omitempty"`
+}
+
+// PlatformCount is how many knoks were shared from one platform
+type PlatformCount struct {
+	Platform string `json:"platform"`
+	Count    int    `json:"count"`
+}
+
+// PosterCount is how many knoks one Discord user shared
+type PosterCount struct {
+	UserID string `json:"user_id"`
+	Count  int    `json:"count"`
+}
+
+// ExtractionReport summarizes extraction outcomes per platform
+type ExtractionReport struct {
+	Platforms   []*PlatformExtractionStats `json:"platforms"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+}
+
+// PlatformExtractionStats holds extraction outcomes for one platform. Knoks that are
+// still pending or processing are not counted.
+type PlatformExtractionStats struct {
+	Platform string `json:"platform"`
+	Total    int    `json:"total"`
+
+	// Succeeded counts complete knoks extracted by oEmbed, static HTML or Rod.
+	// Fallback counts complete knoks that only got a title derived from the URL.
+	Succeeded int `json:"succeeded"`
+	Fallback  int `json:"fallback"`
+	Failed    int `json:"failed"`
+
+	// SuccessRate is Succeeded / Total
+	SuccessRate float64 `json:"success_rate"`
+
+	// AverageTier is the mean extraction tier of complete knoks, nil if there are none
+	AverageTier *float64 `json:"average_tier"`
+
+	Methods        map[string]int `json:"methods"`
+	FailureReasons map[string]int `json:"failure_reasons,omitempty"`
+}
+
+// ExtractionRetryBudget stops knoks whose extraction keeps failing from being re-extracted
+// over and over: once a knok has failed MaxFailures times within Window of its first
+// failure, it isn't re-extracted until Cooldown has passed since its last failure, however
+// often it's reposted. MaxFailures 0 disables the budget
+type ExtractionRetryBudget struct {
+	MaxFailures int
+	Window      time.Duration
+	Cooldown    time.Duration
+}
+
+// RecordFailure counts a failed extraction in the knok's metadata: extraction_attempts is
+// the number of failures since failure_window_start, and failed_at is the last failure
+// (both Unix seconds). A failure after the window has passed starts a new window; without
+// a window every failure is counted
+func (b ExtractionRetryBudget) RecordFailure(knok *Knok, now time.Time) {
+	if knok.Metadata == nil {
+		knok.Metadata = make(map[string]interface{})
+	}
+
+	attempts, _ := metadataInt(knok.Metadata, "extraction_attempts")
+	windowStart, ok := metadataInt(knok.Metadata, "failure_window_start")
+	if !ok || (b.Window > 0 && now.Sub(time.Unix(windowStart, 0)) > b.Window) {
+		attempts = 0
+		windowStart = now.Unix()
+	}
+
+	knok.Metadata["extraction_attempts"] = attempts + 1
+	knok.Metadata["failure_window_start"] = windowStart
+	knok.Metadata["failed_at"] = now.Unix()
+}
+
+// CooldownUntil reports whether the knok has used up its retry budget, and if so when it
+// may be extracted again
+func (b ExtractionRetryBudget) CooldownUntil(knok *Knok, now time.Time) (time.Time, bool) {
+	if b.MaxFailures <= 0 || knok.Metadata == nil {
+		return time.Time{}, false
+	}
+
+	attempts, _ := metadataInt(knok.Metadata, "extraction_attempts")
+	failedAt, ok := metadataInt(knok.Metadata, "failed_at")
+	if !ok || attempts < int64(b.MaxFailures) {
+		return time.Time{}, false
+	}
+
+	until := time.Unix(failedAt, 0).Add(b.Cooldown)
+	return until, now.Before(until)
+}
+
+// metadataInt reads an integer from knok metadata, which holds float64 after a round trip
+// through JSONB
+func metadataInt(metadata map[string]interface{}, key string) (int64, bool) {
+	switch v := metadata[key].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
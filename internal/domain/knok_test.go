@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetainedMessageContent(t *testing.T) {
+	content := "omg listen to this https://soundcloud.com/artist/track and <https://youtu.be/abc> tonight"
+
+	tests := []struct {
+		name    string
+		policy  string
+		content string
+		want    *string
+	}{
+		{name: "Full", policy: MessageContentRetentionFull, content: content, want: &content},
+		{name: "Redact after extraction keeps it until then", policy: MessageContentRetentionRedact, content: content, want: &content},
+		{name: "None", policy: MessageContentRetentionNone, content: content, want: nil},
+		{
+			name:    "URLs only",
+			policy:  MessageContentRetentionURLs,
+			content: content,
+			want:    stringPtr("https://soundcloud.com/artist/track <https://youtu.be/abc>"),
+		},
+		{name: "URLs only without a URL", policy: MessageContentRetentionURLs, content: "no links here", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RetainedMessageContent(tt.policy, tt.content)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("RetainedMessageContent() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestQueuedMessageContent(t *testing.T) {
+	content := "omg listen to this https://soundcloud.com/artist/track tonight"
+
+	tests := []struct {
+		name   string
+		policy string
+		want   *string
+	}{
+		{name: "Full", policy: MessageContentRetentionFull, want: &content},
+		{name: "Unset policy keeps everything", policy: "", want: &content},
+		{name: "URLs only", policy: MessageContentRetentionURLs, want: stringPtr("https://soundcloud.com/artist/track")},
+		{name: "None", policy: MessageContentRetentionNone, want: nil},
+		{name: "Redact after extraction", policy: MessageContentRetentionRedact, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QueuedMessageContent(tt.policy, content)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("QueuedMessageContent() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestExtractionRetryBudget(t *testing.T) {
+	budget := ExtractionRetryBudget{MaxFailures: 2, Window: time.Hour, Cooldown: 3 * time.Hour}
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name         string
+		failAt       time.Duration
+		checkAt      time.Duration
+		wantAttempts int
+		wantCooling  bool
+	}{
+		{name: "First failure", failAt: 0, checkAt: time.Minute, wantAttempts: 1, wantCooling: false},
+		{name: "Second failure in the window", failAt: 30 * time.Minute, checkAt: time.Hour, wantAttempts: 2, wantCooling: true},
+		{name: "Cooldown passed", failAt: -1, checkAt: 4 * time.Hour, wantAttempts: 2, wantCooling: false},
+		{name: "Failure after the window starts over", failAt: 5 * time.Hour, checkAt: 5 * time.Hour, wantAttempts: 1, wantCooling: false},
+	}
+
+	knok := &Knok{}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.failAt >= 0 {
+				budget.RecordFailure(knok, start.Add(step.failAt))
+			}
+			if got, _ := metadataInt(knok.Metadata, "extraction_attempts"); got != int64(step.wantAttempts) {
+				t.Errorf("extraction_attempts = %d, want %d", got, step.wantAttempts)
+			}
+			if _, cooling := budget.CooldownUntil(knok, start.Add(step.checkAt)); cooling != step.wantCooling {
+				t.Errorf("CooldownUntil() cooling = %v, want %v", cooling, step.wantCooling)
+			}
+		})
+	}
+
+	t.Run("Disabled budget", func(t *testing.T) {
+		if _, cooling := (ExtractionRetryBudget{}).CooldownUntil(knok, start); cooling {
+			t.Error("CooldownUntil() with no budget reported cooling")
+		}
+	})
+}
+
+func stringPtr(s string) *string { return &s }
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // GetPlatformConstraintSQL generates the SQL constraint for platform validation
 func GetPlatformConstraintSQL() string {
@@ -13,3 +16,10 @@ func GetPlatformConstraintSQL() string {
 
 	return "CHECK (platform IN (" + strings.Join(quotedPlatforms, ", ") + "))"
 }
+
+// AppliedMigration is a schema migration recorded as applied to the database
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
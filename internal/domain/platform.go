@@ -52,6 +52,10 @@ func GetDefaultPlatformConfig() PlatformConfig {
 					"m.youtube.com",     // Mobile
 					"music.youtube.com", // YouTube Music
 				},
+				ExtractionPatterns: []string{
+					`youtu\.be/([\w-]{11})`,
+					`youtube\.com/(?:watch\?(?:.*&)?v=|shorts/|embed/|live/|v/)([\w-]{11})`,
+				},
 			},
 			PlatformSoundCloud: {
 				ID:   PlatformSoundCloud,
@@ -82,6 +86,9 @@ func GetDefaultPlatformConfig() PlatformConfig {
 					"spotify.link",     // Short link
 					"spoti.fi",         // Short link
 				},
+				ExtractionPatterns: []string{
+					`spotify\.com/(?:intl-[\w-]+/)?((?:track|album|playlist|artist|episode|show)/[A-Za-z0-9]+)`,
+				},
 			},
 			PlatformAppleMusic: {
 				ID:   PlatformAppleMusic,
@@ -118,6 +125,9 @@ func GetDefaultPlatformConfig() PlatformConfig {
 					"tidal.com",
 					"listen.tidal.com",
 				},
+				ExtractionPatterns: []string{
+					`tidal\.com/(?:browse/)?((?:track|album|playlist|video)/[\w-]+)`,
+				},
 			},
 			PlatformDeezer: {
 				ID:   PlatformDeezer,
@@ -126,6 +136,9 @@ func GetDefaultPlatformConfig() PlatformConfig {
 					"deezer.com",
 					"deezer.page.link", // Short link
 				},
+				ExtractionPatterns: []string{
+					`deezer\.com/(?:[a-z]{2}/)?((?:track|album|playlist)/\d+)`,
+				},
 			},
 		},
 	}
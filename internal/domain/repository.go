@@ -15,12 +15,16 @@ type KnokRepository interface {
 	// GetByDiscordMessage retrieves a knok by Discord message ID
 	GetByDiscordMessage(ctx context.Context, messageID string) (*Knok, error)
 
-	// Search performs full-text search on knoks within a server with cursor pagination
-	Search(ctx context.Context, query string, cursor *time.Time, limit int) ([]*Knok, error)
+	// Search performs full-text search on knoks with cursor pagination, within one server or
+	// across all servers when serverID is nil
+	Search(ctx context.Context, serverID *string, query string, cursor *time.Time, limit int) ([]*Knok, error)
 
 	// Get random knok
 	GetRandom(ctx context.Context) (*Knok, error)
 
+	// GetForDate deterministically selects one completed knok for a calendar date (UTC)
+	GetForDate(ctx context.Context, date time.Time) (*Knok, error)
+
 	// Create inserts a new knok
 	Create(ctx context.Context, knok *Knok) error
 
@@ -30,23 +34,78 @@ type KnokRepository interface {
 	// Delete removes a knok by ID
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// SoftDeleteByUser soft-deletes every knok posted by a Discord user, optionally only
+	// within one server, and returns how many were deleted
+	SoftDeleteByUser(ctx context.Context, userID string, serverID *string) (int, error)
+
 	// GetByURL finds knoks by URL within a server (for duplicate detection)
 	GetByURL(ctx context.Context, serverID, url string) (*Knok, error)
 
 	// GetByCanonicalURL finds knoks by canonical URL within a server (for duplicate detection)
 	GetByCanonicalURL(ctx context.Context, serverID, canonicalURL string) (*Knok, error)
 
-	// GetRecent gets the most recent knoks across all servers with cursor pagination (global timeline)
-	GetRecent(ctx context.Context, cursor *time.Time, limit int) ([]*Knok, error)
+	// GetByPlatformItemID finds knoks by platform item ID within a server (for duplicate detection
+	// across URL variants of the same item)
+	GetByPlatformItemID(ctx context.Context, serverID, platform, itemID string) (*Knok, error)
+
+	// GetRecent gets knoks across all servers in sort order with cursor pagination (global
+	// timeline), optionally only those posted in [from, to]. Only knoks with the given
+	// extraction status are included, complete when status is empty
+	GetRecent(ctx context.Context, sort KnokSort, cursor *KnokCursor, from, to *time.Time, status string, limit int) ([]*Knok, error)
+
+	// GetRecentByPlatform gets knoks on a platform across all servers in sort order with
+	// cursor pagination (global timeline filtered by platform), optionally only those
+	// posted in [from, to], with the extraction status filter of GetRecent
+	GetRecentByPlatform(ctx context.Context, platform string, sort KnokSort, cursor *KnokCursor, from, to *time.Time, status string, limit int) ([]*Knok, error)
 
-	// GetRecentByServer gets the most recent knoks for a server with cursor pagination
-	GetRecentByServer(ctx context.Context, serverID string, cursor *time.Time, limit int) ([]*Knok, error)
+	// GetRecentByServer gets a server's knoks in sort order with cursor pagination,
+	// optionally only those posted in [from, to], with the extraction status filter of GetRecent
+	GetRecentByServer(ctx context.Context, serverID string, sort KnokSort, cursor *KnokCursor, from, to *time.Time, status string, limit int) ([]*Knok, error)
 
-	// GetByPlatform gets knoks filtered by platform within a server
+	// GetByPlatform gets a server's completed knoks on a platform with offset pagination,
+	// along with the total number of matches
 	GetByPlatform(ctx context.Context, serverID, platform string, offset, limit int) ([]*Knok, int, error)
 
+	// GetByPlatformCursor gets a server's knoks on a platform in sort order with cursor
+	// pagination, optionally only those posted in [from, to], with the extraction status
+	// filter of GetRecent; an empty platform gets the whole server timeline
+	GetByPlatformCursor(ctx context.Context, serverID, platform string, sort KnokSort, cursor *KnokCursor, from, to *time.Time, status string, limit int) ([]*Knok, error)
+
+	// GetByStatus gets knoks with an extraction status across all servers with cursor pagination
+	GetByStatus(ctx context.Context, status string, cursor *time.Time, limit int) ([]*Knok, error)
+
+	// GetByExtractionMethod gets knoks extracted with a method across all servers with cursor pagination
+	GetByExtractionMethod(ctx context.Context, method string, cursor *time.Time, limit int) ([]*Knok, error)
+
+	// GetAllByPlatform gets knoks on a platform across all servers with keyset pagination on
+	// (posted_at, id), so knoks posted at the same time aren't skipped between pages
+	GetAllByPlatform(ctx context.Context, platform string, cursor *KnokCursor, limit int) ([]*Knok, error)
+
+	// GetByPlatformAndStatus gets up to limit of the newest knoks on a platform with one of the
+	// given extraction statuses across all servers
+	GetByPlatformAndStatus(ctx context.Context, platform string, statuses []string, limit int) ([]*Knok, error)
+
+	// UpdatePlatform changes the platform a knok is classified as
+	UpdatePlatform(ctx context.Context, id uuid.UUID, platform string) error
+
 	// UpdateExtractionStatus updates the metadata extraction status
 	UpdateExtractionStatus(ctx context.Context, id uuid.UUID, status string) error
+
+	// GetExtractionReport aggregates extraction outcomes by platform and method
+	GetExtractionReport(ctx context.Context) (*ExtractionReport, error)
+
+	// GetCountsByServer counts a server's knoks in total, per platform and per extraction status
+	GetCountsByServer(ctx context.Context, serverID string) (*KnokCounts, error)
+
+	// GetServerStats summarizes a server's completed knoks per platform
+	GetServerStats(ctx context.Context, serverID string) (*ServerStats, error)
+
+	// GetTopPosters counts a server's completed knoks per poster, most knoks first
+	GetTopPosters(ctx context.Context, serverID string, limit int) ([]*PosterCount, error)
+
+	// GetActivityHistogram counts knoks posted in [from, to) per bucket ("hour", "day",
+	// "week" or "month"), for one server or all servers when serverID is nil
+	GetActivityHistogram(ctx context.Context, serverID *string, from, to time.Time, bucket string) ([]*ActivityBucket, error)
 }
 
 // ServerRepository defines the interface for platform data operations
@@ -77,7 +136,7 @@ type ServerRepository interface {
 	// GetByChannelID finds a server that has the specified channel configured
 	GetByChannelID(ctx context.Context, channelID string) (*Server, error)
 
-	// UpdateSettings updates just the settings field for a server
+	// UpdateSettings merges settings into a server's existing settings (see MergeSettings)
 	UpdateSettings(ctx context.Context, id string, settings map[string]interface{}) error
 }
 
@@ -86,17 +145,60 @@ type QueueRepository interface {
 	// Enqueue adds a new job to the queue
 	Enqueue(ctx context.Context, jobType string, payload interface{}) error
 
+	// EnqueuePriority adds a new job that is dequeued ahead of regularly enqueued jobs
+	EnqueuePriority(ctx context.Context, jobType string, payload interface{}) error
+
 	// Dequeue retrieves the next job from the queue
 	Dequeue(ctx context.Context, jobType string) (*QueueJob, error)
 
 	// Complete marks a job as completed
 	Complete(ctx context.Context, jobID string) error
 
-	// Fail marks a job as failed with error details
-	Fail(ctx context.Context, jobID string, errorMsg string) error
+	// Fail marks a job as failed with error details, scheduling a retry while it has retries
+	// left. It reports whether the job failed permanently and moved to the dead letter list
+	Fail(ctx context.Context, jobID string, errorMsg string) (bool, error)
 
 	// GetPendingCount returns the number of pending jobs
 	GetPendingCount(ctx context.Context, jobType string) (int, error)
+
+	// GetProcessingJobIDs returns the IDs of jobs currently in the processing list
+	GetProcessingJobIDs(ctx context.Context, jobType string) ([]string, error)
+
+	// ReclaimProcessing moves every job in the processing list back to pending and
+	// returns the number of jobs reclaimed
+	ReclaimProcessing(ctx context.Context, jobType string) (int, error)
+
+	// RecomputeStats rebuilds a job type's drifted stats counters from the queue's lists
+	// and returns the repaired stats
+	RecomputeStats(ctx context.Context, jobType string) (map[string]int64, error)
+
+	// SetPaused pauses or resumes job processing for every worker
+	SetPaused(ctx context.Context, paused bool) error
+
+	// IsPaused reports whether job processing is paused
+	IsPaused(ctx context.Context) (bool, error)
+
+	// RequestOEmbedReload asks every worker to reload its oEmbed providers
+	RequestOEmbedReload(ctx context.Context) error
+
+	// GetOEmbedReloadRequest returns when an oEmbed providers reload was last requested, as
+	// unix nanoseconds, or 0 if it never was
+	GetOEmbedReloadRequest(ctx context.Context) (int64, error)
+
+	// AcquireKnokLock claims a knok for extraction for up to ttl. It returns a token for
+	// ReleaseKnokLock, or false if another worker already holds the lock
+	AcquireKnokLock(ctx context.Context, knokID uuid.UUID, ttl time.Duration) (string, bool, error)
+
+	// ReleaseKnokLock releases a knok's lock if it's still held with token
+	ReleaseKnokLock(ctx context.Context, knokID uuid.UUID, token string) error
+
+	// ClaimMessageURL marks a message's URL as handled for ttl. It returns false if it
+	// already was, e.g. when the same message is delivered twice
+	ClaimMessageURL(ctx context.Context, messageID, url string, ttl time.Duration) (bool, error)
+
+	// ReleaseMessageURL drops a message URL's claim, so a URL that couldn't be handled is
+	// tried again if the message is delivered again
+	ReleaseMessageURL(ctx context.Context, messageID, url string) error
 }
 
 // QueueJob represents a job in the processing queue
@@ -107,15 +209,30 @@ type QueueJob struct {
 	Status    string                 `json:"status"`
 	CreatedAt string                 `json:"created_at"`
 	UpdatedAt *string                `json:"updated_at"`
+
+	// TraceContext holds the W3C trace context of whoever queued the job, so processing
+	// continues the same trace
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // Job types
 const (
-	JobTypeExtractMetadata = "extract_metadata"
-	JobTypeProcessKnok     = "process_knok"
-	JobTypeNotifyComplete  = "notify_complete"
+	JobTypeExtractMetadata      = "extract_metadata"
+	JobTypeExtractMetadataBatch = "extract_metadata_batch"
+	JobTypeProcessKnok          = "process_knok"
+	JobTypeNotifyComplete       = "notify_complete"
 )
 
+// IsValidJobType reports whether jobType is one of the known job types
+func IsValidJobType(jobType string) bool {
+	switch jobType {
+	case JobTypeExtractMetadata, JobTypeExtractMetadataBatch, JobTypeProcessKnok, JobTypeNotifyComplete:
+		return true
+	default:
+		return false
+	}
+}
+
 // Job statuses
 const (
 	JobStatusPending    = "pending"
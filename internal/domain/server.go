@@ -1,6 +1,15 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Server represents a Discord server configuration
 type Server struct {
@@ -26,7 +35,17 @@ type Server struct {
 //   "banned_users": ["987654321"],
 //   "require_metadata": false,
 //   "notification_channel": "111222333",
-//   "max_knoks_per_user": 100
+//   "max_knoks_per_user": 100,
+//   "allow_bot_authors": false,
+//   "allowed_bot_ids": ["444555666"],
+//   "require_dominant_link": false,
+//   "max_context_words": 5,
+//   "notification_mode": "reaction",  // or "silent", "reply", "thread"
+//   "quiet_hours_start": "22:00",
+//   "quiet_hours_end": "07:00",
+//   "quiet_hours_timezone": "Europe/London",
+//   "embed_style": "compact",  // or "cards"
+//   "ephemeral_commands": ["search", "status"]
 // }
 type ServerSettings struct {
 	// UnknownPlatformMode controls how the server handles URLs from unrecognized platforms
@@ -34,12 +53,283 @@ type ServerSettings struct {
 	// If not set, falls back to global config.DefaultUnknownPlatformMode
 	UnknownPlatformMode *string  `json:"unknown_platform_mode"`
 
+	// AutoExtraction queues metadata extraction for new knoks automatically. When false,
+	// knoks are tracked but stay pending until extraction is requested. Default: true
 	AutoExtraction      bool     `json:"auto_extraction"`
 	AllowedChannels     []string `json:"allowed_channels"`
 	BannedUsers         []string `json:"banned_users"`
+
+	// RequireMetadata hides knoks from the server timeline unless they have a title and
+	// metadata from a real extraction method (not a title or error fallback)
 	RequireMetadata     bool     `json:"require_metadata"`
 	NotificationChannel *string  `json:"notification_channel"`
 	MaxKnoksPerUser     *int     `json:"max_knoks_per_user"`
+
+	// AllowBotAuthors enables tracking of messages posted by bots and webhooks (e.g. bridges)
+	// Bot messages are ignored unless this is true
+	AllowBotAuthors bool `json:"allow_bot_authors"`
+
+	// AllowedBotIDs optionally restricts AllowBotAuthors to specific bot user or webhook IDs
+	// Empty = any bot or webhook is accepted when AllowBotAuthors is true
+	AllowedBotIDs []string `json:"allowed_bot_ids"`
+
+	// RequireDominantLink only tracks links that are the main content of a message,
+	// skipping links pasted mid-conversation
+	RequireDominantLink bool `json:"require_dominant_link"`
+
+	// MaxContextWords is the number of non-URL words allowed alongside a link when
+	// RequireDominantLink is enabled. If not set, the bot default is used
+	MaxContextWords *int `json:"max_context_words"`
+
+	// NotificationMode controls how the bot gives feedback on tracked links
+	// Values: "silent" (no feedback), "reaction" (🎵 reaction when tracked),
+	// "reply" (reply with the title once extracted) or "thread" (post the title in a thread)
+	// If not set or invalid, defaults to "reaction"
+	NotificationMode *string `json:"notification_mode"`
+
+	// QuietHoursStart and QuietHoursEnd ("HH:MM") suppress completion notifications
+	// between those times; the window may wrap past midnight. Knoks are still tracked.
+	// QuietHoursTimezone is an IANA zone name (default: UTC)
+	QuietHoursStart    *string `json:"quiet_hours_start"`
+	QuietHoursEnd      *string `json:"quiet_hours_end"`
+	QuietHoursTimezone *string `json:"quiet_hours_timezone"`
+
+	// EmbedStyle controls how /recent and /search lay out their results
+	// Values: "compact" (one embed listing every knok) or "cards" (one embed per knok
+	// with its thumbnail). If not set or invalid, defaults to "compact"
+	EmbedStyle *string `json:"embed_style"`
+
+	// EphemeralCommands lists slash commands whose responses are only shown to the user
+	// who ran them. Empty = every response is posted to the channel
+	EphemeralCommands []string `json:"ephemeral_commands"`
+}
+
+// SettingsValidationError reports a server setting that doesn't match the ServerSettings schema
+type SettingsValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *SettingsValidationError) Error() string {
+	return fmt.Sprintf("invalid setting %q: %s", e.Key, e.Message)
+}
+
+// knownSettingKeys are the JSON keys of the ServerSettings fields
+var knownSettingKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	settingsType := reflect.TypeOf(ServerSettings{})
+	for i := 0; i < settingsType.NumField(); i++ {
+		if name, _, _ := strings.Cut(settingsType.Field(i).Tag.Get("json"), ","); name != "" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// ValidateSettings checks a settings map against the ServerSettings schema: every key must
+// be a documented setting with the documented type, and enumerated or formatted values
+// must be valid. Returns a *SettingsValidationError naming the offending key.
+func ValidateSettings(settings map[string]interface{}) error {
+	// Check keys in a stable order so the same payload always reports the same key
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !knownSettingKeys[key] {
+			return &SettingsValidationError{Key: key, Message: "unknown setting"}
+		}
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	var parsed ServerSettings
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &SettingsValidationError{
+				Key:     typeErr.Field,
+				Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+			}
+		}
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	if parsed.UnknownPlatformMode != nil {
+		switch *parsed.UnknownPlatformMode {
+		case "permissive", "strict":
+		default:
+			return &SettingsValidationError{Key: "unknown_platform_mode", Message: `must be "permissive" or "strict"`}
+		}
+	}
+
+	if parsed.NotificationMode != nil {
+		switch *parsed.NotificationMode {
+		case NotificationModeSilent, NotificationModeReaction, NotificationModeReply, NotificationModeThread:
+		default:
+			return &SettingsValidationError{Key: "notification_mode", Message: `must be "silent", "reaction", "reply" or "thread"`}
+		}
+	}
+
+	if parsed.EmbedStyle != nil {
+		switch *parsed.EmbedStyle {
+		case EmbedStyleCompact, EmbedStyleCards:
+		default:
+			return &SettingsValidationError{Key: "embed_style", Message: `must be "compact" or "cards"`}
+		}
+	}
+
+	if parsed.MaxContextWords != nil && *parsed.MaxContextWords < 0 {
+		return &SettingsValidationError{Key: "max_context_words", Message: "must not be negative"}
+	}
+	if parsed.MaxKnoksPerUser != nil && *parsed.MaxKnoksPerUser < 0 {
+		return &SettingsValidationError{Key: "max_knoks_per_user", Message: "must not be negative"}
+	}
+
+	quietHours := []struct {
+		key   string
+		value *string
+	}{
+		{"quiet_hours_start", parsed.QuietHoursStart},
+		{"quiet_hours_end", parsed.QuietHoursEnd},
+	}
+	for _, setting := range quietHours {
+		if setting.value == nil {
+			continue
+		}
+		if _, err := time.Parse("15:04", *setting.value); err != nil {
+			return &SettingsValidationError{Key: setting.key, Message: `must be a time of day as "HH:MM"`}
+		}
+	}
+	if (parsed.QuietHoursStart == nil) != (parsed.QuietHoursEnd == nil) {
+		return &SettingsValidationError{Key: "quiet_hours_end", Message: "quiet_hours_start and quiet_hours_end must be set together"}
+	}
+
+	if parsed.QuietHoursTimezone != nil {
+		if _, err := time.LoadLocation(*parsed.QuietHoursTimezone); err != nil {
+			return &SettingsValidationError{Key: "quiet_hours_timezone", Message: "must be an IANA time zone name"}
+		}
+	}
+
+	return nil
+}
+
+// Notification mode constants
+const (
+	NotificationModeSilent   = "silent"
+	NotificationModeReaction = "reaction"
+	NotificationModeReply    = "reply"
+	NotificationModeThread   = "thread"
+)
+
+// Embed style constants
+const (
+	EmbedStyleCompact = "compact"
+	EmbedStyleCards   = "cards"
+)
+
+// MergeSettings returns existing with patch applied: keys in patch replace existing ones and
+// keys set to nil are removed. Neither map is modified
+func MergeSettings(existing, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(existing)+len(patch))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// NotificationMode returns the server's notification mode, defaulting to reaction
+func (s *Server) NotificationMode() string {
+	if s.Settings == nil {
+		return NotificationModeReaction
+	}
+
+	mode, _ := s.Settings["notification_mode"].(string)
+	switch mode {
+	case NotificationModeSilent, NotificationModeReaction, NotificationModeReply, NotificationModeThread:
+		return mode
+	}
+
+	return NotificationModeReaction
+}
+
+// EmbedStyle returns the server's embed style for listing commands, defaulting to compact
+func (s *Server) EmbedStyle() string {
+	if s.Settings == nil {
+		return EmbedStyleCompact
+	}
+
+	style, _ := s.Settings["embed_style"].(string)
+	switch style {
+	case EmbedStyleCompact, EmbedStyleCards:
+		return style
+	}
+
+	return EmbedStyleCompact
+}
+
+// EphemeralCommand reports whether the server wants command's responses shown only to
+// the user who ran it
+func (s *Server) EphemeralCommand(command string) bool {
+	return slices.Contains(s.StringSliceSetting("ephemeral_commands"), command)
+}
+
+// InQuietHours reports whether t falls within the server's quiet hours.
+// Returns false if quiet hours aren't configured or the settings are invalid.
+func (s *Server) InQuietHours(t time.Time) bool {
+	if s.Settings == nil {
+		return false
+	}
+
+	startStr, _ := s.Settings["quiet_hours_start"].(string)
+	endStr, _ := s.Settings["quiet_hours_end"].(string)
+	if startStr == "" || endStr == "" {
+		return false
+	}
+
+	start, err := time.Parse("15:04", startStr)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", endStr)
+	if err != nil {
+		return false
+	}
+
+	location := time.UTC
+	if tz, ok := s.Settings["quiet_hours_timezone"].(string); ok && tz != "" {
+		loaded, err := time.LoadLocation(tz)
+		if err != nil {
+			return false
+		}
+		location = loaded
+	}
+
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	switch {
+	case startMinute == endMinute:
+		return false
+	case startMinute < endMinute:
+		return minute >= startMinute && minute < endMinute
+	default:
+		// Window wraps past midnight (e.g. 22:00-07:00)
+		return minute >= startMinute || minute < endMinute
+	}
 }
 
 // HasConfiguredChannel returns true if a channel is configured for knok tracking
@@ -57,3 +347,64 @@ func (s *Server) IsChannelAllowed(channelID string) bool {
 	// If a specific channel is configured, only allow that one
 	return *s.ConfiguredChannelID == channelID
 }
+
+
+// AutoExtractionEnabled reports whether new knoks get metadata extraction queued
+// automatically. Defaults to true; only an explicit "auto_extraction": false disables it,
+// leaving knoks pending until extraction is requested (e.g. via the admin refresh endpoint).
+func (s *Server) AutoExtractionEnabled() bool {
+	if s.Settings == nil {
+		return true
+	}
+	value, ok := s.Settings["auto_extraction"].(bool)
+	return !ok || value
+}
+
+// BoolSetting returns a boolean from the Settings JSONB field, or false if unset or not a boolean
+func (s *Server) BoolSetting(key string) bool {
+	if s.Settings == nil {
+		return false
+	}
+	value, ok := s.Settings[key].(bool)
+	return ok && value
+}
+
+// IntSetting returns an integer from the Settings JSONB field.
+// JSON numbers decode as float64, so both int and float64 values are accepted.
+func (s *Server) IntSetting(key string) (int, bool) {
+	if s.Settings == nil {
+		return 0, false
+	}
+
+	switch value := s.Settings[key].(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), true
+	}
+
+	return 0, false
+}
+
+// StringSliceSetting returns a list of strings from the Settings JSONB field.
+// Non-string entries are skipped; returns nil if the setting is unset.
+func (s *Server) StringSliceSetting(key string) []string {
+	if s.Settings == nil {
+		return nil
+	}
+
+	switch values := s.Settings[key].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			if str, ok := v.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerInQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		at       time.Time
+		want     bool
+	}{
+		{
+			name:     "Not configured",
+			settings: map[string]interface{}{},
+			at:       time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "Inside same-day window",
+			settings: map[string]interface{}{"quiet_hours_start": "09:00", "quiet_hours_end": "17:00"},
+			at:       time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "End of window is exclusive",
+			settings: map[string]interface{}{"quiet_hours_start": "09:00", "quiet_hours_end": "17:00"},
+			at:       time.Date(2024, 6, 1, 17, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "Inside window wrapping midnight",
+			settings: map[string]interface{}{"quiet_hours_start": "22:00", "quiet_hours_end": "07:00"},
+			at:       time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "Outside window wrapping midnight",
+			settings: map[string]interface{}{"quiet_hours_start": "22:00", "quiet_hours_end": "07:00"},
+			at:       time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name: "Timezone is applied",
+			settings: map[string]interface{}{
+				"quiet_hours_start":    "22:00",
+				"quiet_hours_end":      "07:00",
+				"quiet_hours_timezone": "America/New_York",
+			},
+			// 04:00 UTC is midnight in New York (EDT)
+			at:   time.Date(2024, 6, 1, 4, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "Invalid timezone disables quiet hours",
+			settings: map[string]interface{}{
+				"quiet_hours_start":    "00:00",
+				"quiet_hours_end":      "23:59",
+				"quiet_hours_timezone": "Mars/Olympus_Mons",
+			},
+			at:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name:     "Invalid time disables quiet hours",
+			settings: map[string]interface{}{"quiet_hours_start": "10pm", "quiet_hours_end": "07:00"},
+			at:       time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{ID: "guild-1", Settings: tt.settings}
+			if got := server.InQuietHours(tt.at); got != tt.want {
+				t.Errorf("InQuietHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		wantKey  string
+	}{
+		{
+			name:     "Empty settings",
+			settings: map[string]interface{}{},
+		},
+		{
+			name: "All documented types",
+			settings: map[string]interface{}{
+				"unknown_platform_mode": "strict",
+				"auto_extraction":       true,
+				"allowed_channels":      []interface{}{"123"},
+				"max_knoks_per_user":    float64(100),
+				"notification_mode":     "thread",
+				"quiet_hours_start":     "22:00",
+				"quiet_hours_end":       "07:00",
+				"quiet_hours_timezone":  "Europe/London",
+				"embed_style":           "cards",
+				"ephemeral_commands":    []interface{}{"search", "status"},
+			},
+		},
+		{
+			name:     "Unknown key",
+			settings: map[string]interface{}{"unknown_platform_modee": "strict"},
+			wantKey:  "unknown_platform_modee",
+		},
+		{
+			name:     "Unknown key reported before type errors",
+			settings: map[string]interface{}{"auto_extraction": "yes", "notifcation_mode": "silent"},
+			wantKey:  "notifcation_mode",
+		},
+		{
+			name:     "Wrongly typed bool",
+			settings: map[string]interface{}{"allow_bot_authors": "true"},
+			wantKey:  "allow_bot_authors",
+		},
+		{
+			name:     "Wrongly typed list",
+			settings: map[string]interface{}{"allowed_channels": "123"},
+			wantKey:  "allowed_channels",
+		},
+		{
+			name:     "Fractional integer",
+			settings: map[string]interface{}{"max_context_words": 2.5},
+			wantKey:  "max_context_words",
+		},
+		{
+			name:     "Invalid enum value",
+			settings: map[string]interface{}{"notification_mode": "loud"},
+			wantKey:  "notification_mode",
+		},
+		{
+			name:     "Invalid embed style",
+			settings: map[string]interface{}{"embed_style": "grid"},
+			wantKey:  "embed_style",
+		},
+		{
+			name:     "Quiet hours without end",
+			settings: map[string]interface{}{"quiet_hours_start": "22:00"},
+			wantKey:  "quiet_hours_end",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings(tt.settings)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("ValidateSettings() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *SettingsValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidateSettings() error = %v, want *SettingsValidationError", err)
+			}
+			if validationErr.Key != tt.wantKey {
+				t.Errorf("ValidateSettings() key = %q, want %q", validationErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestMergeSettings(t *testing.T) {
+	existing := map[string]interface{}{
+		"unknown_platform_mode": "permissive",
+		"allowed_channels":      []interface{}{"123"},
+	}
+	patch := map[string]interface{}{
+		"unknown_platform_mode": "strict",
+		"allowed_channels":      nil,
+		"auto_extraction":       false,
+	}
+
+	got := MergeSettings(existing, patch)
+
+	want := map[string]interface{}{"unknown_platform_mode": "strict", "auto_extraction": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSettings() = %v, want %v", got, want)
+	}
+	if existing["unknown_platform_mode"] != "permissive" || len(existing) != 2 {
+		t.Errorf("MergeSettings() modified existing: %v", existing)
+	}
+}
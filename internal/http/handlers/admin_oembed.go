@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+)
+
+// OEmbedReloader reloads the oEmbed providers used for link previews
+type OEmbedReloader interface {
+	// ReloadOEmbedProviders reloads the providers file and returns how many providers loaded
+	ReloadOEmbedProviders(ctx context.Context) (int, error)
+}
+
+// AdminOEmbedHandler handles admin operations on the oEmbed providers list
+type AdminOEmbedHandler struct {
+	reloader  OEmbedReloader
+	queueRepo domain.QueueRepository
+	logger    *slog.Logger
+}
+
+// NewAdminOEmbedHandler creates a new admin oEmbed handler
+func NewAdminOEmbedHandler(reloader OEmbedReloader, queueRepo domain.QueueRepository, logger *slog.Logger) *AdminOEmbedHandler {
+	return &AdminOEmbedHandler{
+		reloader:  reloader,
+		queueRepo: queueRepo,
+		logger:    logger,
+	}
+}
+
+// ReloadOEmbedResponse reports the result of an oEmbed providers reload
+type ReloadOEmbedResponse struct {
+	ProviderCount    int  `json:"provider_count"`
+	WorkersRequested bool `json:"workers_requested"`
+}
+
+// ReloadProviders handles POST /api/v1/admin/oembed/reload. The API's own providers, used for
+// link previews, are reloaded straight away; workers reload theirs on their next poll.
+func (h *AdminOEmbedHandler) ReloadProviders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var response ReloadOEmbedResponse
+	if h.reloader != nil {
+		count, err := h.reloader.ReloadOEmbedProviders(ctx)
+		if err != nil {
+			h.logger.Error("Failed to reload oEmbed providers", "error", err)
+			http.Error(w, "Failed to reload oEmbed providers: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		response.ProviderCount = count
+	}
+
+	if err := h.queueRepo.RequestOEmbedReload(ctx); err != nil {
+		h.logger.Error("Failed to request oEmbed providers reload from workers", "error", err)
+		http.Error(w, "Failed to request oEmbed providers reload from workers", http.StatusInternalServerError)
+		return
+	}
+	response.WorkersRequested = true
+
+	h.logger.Info("oEmbed providers reloaded via admin API", "provider_count", response.ProviderCount)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
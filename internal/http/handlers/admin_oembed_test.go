@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeOEmbedReloader returns a fixed provider count or error
+type fakeOEmbedReloader struct {
+	count int
+	err   error
+}
+
+func (r *fakeOEmbedReloader) ReloadOEmbedProviders(ctx context.Context) (int, error) {
+	return r.count, r.err
+}
+
+// reloadRequestQueue counts oEmbed reload requests; other methods are unimplemented
+type reloadRequestQueue struct {
+	domain.QueueRepository
+	requests int
+	err      error
+}
+
+func (q *reloadRequestQueue) RequestOEmbedReload(ctx context.Context) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.requests++
+	return nil
+}
+
+func TestReloadOEmbedProviders(t *testing.T) {
+	tests := []struct {
+		name         string
+		reloader     OEmbedReloader
+		queue        *reloadRequestQueue
+		wantStatus   int
+		wantCount    int
+		wantRequests int
+	}{
+		{
+			name:         "Reloads and signals workers",
+			reloader:     &fakeOEmbedReloader{count: 42},
+			queue:        &reloadRequestQueue{},
+			wantStatus:   http.StatusOK,
+			wantCount:    42,
+			wantRequests: 1,
+		},
+		{
+			name:       "Bad providers file",
+			reloader:   &fakeOEmbedReloader{err: errors.New("no usable oEmbed providers")},
+			queue:      &reloadRequestQueue{},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Queue error",
+			reloader:   &fakeOEmbedReloader{count: 42},
+			queue:      &reloadRequestQueue{err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminOEmbedHandler(tt.reloader, tt.queue, createTestLogger())
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/oembed/reload", nil)
+			rec := httptest.NewRecorder()
+
+			handler.ReloadProviders(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.queue.requests != tt.wantRequests {
+				t.Errorf("worker reload requests = %d, want %d", tt.queue.requests, tt.wantRequests)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ReloadOEmbedResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.ProviderCount != tt.wantCount || !resp.WorkersRequested {
+				t.Errorf("response = %+v, want %d providers with workers requested", resp, tt.wantCount)
+			}
+		})
+	}
+}
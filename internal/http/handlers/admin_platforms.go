@@ -3,7 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
 	"log/slog"
 	"net/http"
 	"time"
@@ -46,43 +49,97 @@ func NewAdminPlatformHandler(
 
 // CreatePlatformRequest represents the request body for creating a platform
 type CreatePlatformRequest struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	URLPatterns []string `json:"url_patterns"`
-	Priority    int      `json:"priority"`
-	Enabled     bool     `json:"enabled"`
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	URLPatterns        []string `json:"url_patterns"`
+	Priority           int      `json:"priority"`
+	Enabled            bool     `json:"enabled"`
+	ExtractionPatterns []string `json:"extraction_patterns,omitempty"`
 }
 
-// UpdatePlatformRequest represents the request body for updating a platform
+// UpdatePlatformRequest represents the request body for updating a platform.
+// Omitting extraction_patterns keeps the platform's current ones.
 type UpdatePlatformRequest struct {
-	Name        string   `json:"name"`
-	URLPatterns []string `json:"url_patterns"`
-	Priority    int      `json:"priority"`
-	Enabled     bool     `json:"enabled"`
+	Name               string   `json:"name"`
+	URLPatterns        []string `json:"url_patterns"`
+	Priority           int      `json:"priority"`
+	Enabled            bool     `json:"enabled"`
+	ExtractionPatterns []string `json:"extraction_patterns,omitempty"`
 }
 
 // PatchPlatformRequest represents the request body for partial updates
 type PatchPlatformRequest struct {
-	Name        *string   `json:"name,omitempty"`
-	URLPatterns *[]string `json:"url_patterns,omitempty"`
-	Priority    *int      `json:"priority,omitempty"`
-	Enabled     *bool     `json:"enabled,omitempty"`
+	Name               *string   `json:"name,omitempty"`
+	URLPatterns        *[]string `json:"url_patterns,omitempty"`
+	Priority           *int      `json:"priority,omitempty"`
+	Enabled            *bool     `json:"enabled,omitempty"`
+	ExtractionPatterns *[]string `json:"extraction_patterns,omitempty"`
 }
 
 // PlatformResponse represents the response for platform operations
 type PlatformResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	URLPatterns []string  `json:"url_patterns"`
-	Priority    int       `json:"priority"`
-	Enabled     bool      `json:"enabled"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	URLPatterns        []string  `json:"url_patterns"`
+	Priority           int       `json:"priority"`
+	Enabled            bool      `json:"enabled"`
+	ExtractionPatterns []string  `json:"extraction_patterns,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Warnings lists problems that don't block the change, e.g. a priority tie with
+	// another platform matching the same links
+	Warnings []string `json:"warnings,omitempty"`
 }
 
-// CreatePlatform handles POST /api/admin/platforms
+// validatePriority rejects negative priorities. Detection tries platforms in descending
+// priority order, so the default of 0 is the lowest.
+func validatePriority(priority int) error {
+	if priority < 0 {
+		return fmt.Errorf("priority must be non-negative, got %d", priority)
+	}
+	return nil
+}
+
+// withCandidate returns platforms with candidate replacing the platform with the same ID
+func withCandidate(platforms []*domain.Platform, candidate *domain.Platform) []*domain.Platform {
+	merged := make([]*domain.Platform, 0, len(platforms)+1)
+	for _, p := range platforms {
+		if p.ID != candidate.ID {
+			merged = append(merged, p)
+		}
+	}
+	return append(merged, candidate)
+}
+
+// priorityWarnings describes enabled platforms that share platform's priority and match
+// the same links, making detection between them nondeterministic
+func (h *AdminPlatformHandler) priorityWarnings(platform *domain.Platform) []string {
+	platforms, err := h.platformLoader.GetAll()
+	if err != nil {
+		h.logger.Warn("Failed to get platforms to check priority ties", "error", err)
+		return nil
+	}
+
+	var warnings []string
+	for _, tie := range urldetector.FindPriorityTies(withCandidate(platforms, platform)) {
+		if tie.Platforms[0] != platform.ID && tie.Platforms[1] != platform.ID {
+			continue
+		}
+		warning := fmt.Sprintf("%s and %s both have priority %d and match %s, so either may be detected; give one a higher priority",
+			tie.Platforms[0], tie.Platforms[1], tie.Priority, tie.Pattern)
+		h.logger.Warn("Platform priority tie", "platforms", tie.Platforms, "priority", tie.Priority, "pattern", tie.Pattern)
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// CreatePlatform handles POST /api/admin/platforms. An existing ID is a 409 unless
+// ?upsert=true is given, in which case the existing platform is replaced, so seed
+// scripts can be re-run safely.
 func (h *AdminPlatformHandler) CreatePlatform(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	upsert := r.URL.Query().Get("upsert") == "true"
 
 	var req CreatePlatformRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -104,20 +161,39 @@ func (h *AdminPlatformHandler) CreatePlatform(w http.ResponseWriter, r *http.Req
 		http.Error(w, "url_patterns must contain at least one pattern", http.StatusBadRequest)
 		return
 	}
+	if err := validatePriority(req.Priority); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := urldetector.ValidateExtractionPatterns(req.ExtractionPatterns); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Create platform
 	now := time.Now()
 	platform := &domain.Platform{
-		ID:          req.ID,
-		Name:        req.Name,
-		URLPatterns: req.URLPatterns,
-		Priority:    req.Priority,
-		Enabled:     req.Enabled,
-		CreatedAt:   now,
-		UpdatedAt:   &now,
+		ID:                 req.ID,
+		Name:               req.Name,
+		URLPatterns:        req.URLPatterns,
+		Priority:           req.Priority,
+		Enabled:            req.Enabled,
+		ExtractionPatterns: req.ExtractionPatterns,
+		CreatedAt:          now,
+		UpdatedAt:          &now,
+	}
+
+	status := http.StatusCreated
+	err := h.platformRepo.CreatePlatform(ctx, platform)
+	if errors.Is(err, domain.ErrPlatformExists) && upsert {
+		status = http.StatusOK
+		err = h.platformRepo.UpdatePlatform(ctx, platform)
+	}
+	if errors.Is(err, domain.ErrPlatformExists) {
+		http.Error(w, "Platform already exists: "+req.ID, http.StatusConflict)
+		return
 	}
-
-	if err := h.platformRepo.CreatePlatform(ctx, platform); err != nil {
+	if err != nil {
 		h.logger.Error("Failed to create platform",
 			"error", err,
 			"id", req.ID,
@@ -130,6 +206,7 @@ func (h *AdminPlatformHandler) CreatePlatform(w http.ResponseWriter, r *http.Req
 		"id", platform.ID,
 		"name", platform.Name,
 		"patterns", len(platform.URLPatterns),
+		"replaced", status == http.StatusOK,
 	)
 
 	// Refresh platform loader cache
@@ -139,17 +216,19 @@ func (h *AdminPlatformHandler) CreatePlatform(w http.ResponseWriter, r *http.Req
 
 	// Return created platform
 	response := PlatformResponse{
-		ID:          platform.ID,
-		Name:        platform.Name,
-		URLPatterns: platform.URLPatterns,
-		Priority:    platform.Priority,
-		Enabled:     platform.Enabled,
-		CreatedAt:   platform.CreatedAt,
-		UpdatedAt:   *platform.UpdatedAt,
+		ID:                 platform.ID,
+		Name:               platform.Name,
+		URLPatterns:        platform.URLPatterns,
+		Priority:           platform.Priority,
+		Enabled:            platform.Enabled,
+		ExtractionPatterns: platform.ExtractionPatterns,
+		CreatedAt:          platform.CreatedAt,
+		UpdatedAt:          *platform.UpdatedAt,
+		Warnings:           h.priorityWarnings(platform),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -181,6 +260,14 @@ func (h *AdminPlatformHandler) UpdatePlatform(w http.ResponseWriter, r *http.Req
 		http.Error(w, "url_patterns must contain at least one pattern", http.StatusBadRequest)
 		return
 	}
+	if err := validatePriority(req.Priority); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := urldetector.ValidateExtractionPatterns(req.ExtractionPatterns); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get existing platform to preserve created_at
 	existing, err := h.platformLoader.GetAll()
@@ -202,24 +289,26 @@ func (h *AdminPlatformHandler) UpdatePlatform(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	extractionPatterns := req.ExtractionPatterns
+	if extractionPatterns == nil {
+		extractionPatterns = existingPlatform.ExtractionPatterns
+	}
+
 	// Update platform
 	now := time.Now()
 	platform := &domain.Platform{
-		ID:          platformID,
-		Name:        req.Name,
-		URLPatterns: req.URLPatterns,
-		Priority:    req.Priority,
-		Enabled:     req.Enabled,
-		CreatedAt:   existingPlatform.CreatedAt,
-		UpdatedAt:   &now,
+		ID:                 platformID,
+		Name:               req.Name,
+		URLPatterns:        req.URLPatterns,
+		Priority:           req.Priority,
+		Enabled:            req.Enabled,
+		ExtractionPatterns: extractionPatterns,
+		CreatedAt:          existingPlatform.CreatedAt,
+		UpdatedAt:          &now,
 	}
 
 	if err := h.platformRepo.UpdatePlatform(ctx, platform); err != nil {
-		h.logger.Error("Failed to update platform",
-			"error", err,
-			"id", platformID,
-		)
-		http.Error(w, "Failed to update platform: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to update platform", "id", platformID)
 		return
 	}
 
@@ -235,13 +324,15 @@ func (h *AdminPlatformHandler) UpdatePlatform(w http.ResponseWriter, r *http.Req
 
 	// Return updated platform
 	response := PlatformResponse{
-		ID:          platform.ID,
-		Name:        platform.Name,
-		URLPatterns: platform.URLPatterns,
-		Priority:    platform.Priority,
-		Enabled:     platform.Enabled,
-		CreatedAt:   platform.CreatedAt,
-		UpdatedAt:   *platform.UpdatedAt,
+		ID:                 platform.ID,
+		Name:               platform.Name,
+		URLPatterns:        platform.URLPatterns,
+		Priority:           platform.Priority,
+		Enabled:            platform.Enabled,
+		ExtractionPatterns: platform.ExtractionPatterns,
+		CreatedAt:          platform.CreatedAt,
+		UpdatedAt:          *platform.UpdatedAt,
+		Warnings:           h.priorityWarnings(platform),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -299,22 +390,29 @@ func (h *AdminPlatformHandler) PatchPlatform(w http.ResponseWriter, r *http.Requ
 		existingPlatform.URLPatterns = *req.URLPatterns
 	}
 	if req.Priority != nil {
+		if err := validatePriority(*req.Priority); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		existingPlatform.Priority = *req.Priority
 	}
 	if req.Enabled != nil {
 		existingPlatform.Enabled = *req.Enabled
 	}
+	if req.ExtractionPatterns != nil {
+		if err := urldetector.ValidateExtractionPatterns(*req.ExtractionPatterns); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		existingPlatform.ExtractionPatterns = *req.ExtractionPatterns
+	}
 
 	// Update timestamp
 	now := time.Now()
 	existingPlatform.UpdatedAt = &now
 
 	if err := h.platformRepo.UpdatePlatform(ctx, existingPlatform); err != nil {
-		h.logger.Error("Failed to patch platform",
-			"error", err,
-			"id", platformID,
-		)
-		http.Error(w, "Failed to update platform: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to patch platform", "id", platformID)
 		return
 	}
 
@@ -330,13 +428,15 @@ func (h *AdminPlatformHandler) PatchPlatform(w http.ResponseWriter, r *http.Requ
 
 	// Return updated platform
 	response := PlatformResponse{
-		ID:          existingPlatform.ID,
-		Name:        existingPlatform.Name,
-		URLPatterns: existingPlatform.URLPatterns,
-		Priority:    existingPlatform.Priority,
-		Enabled:     existingPlatform.Enabled,
-		CreatedAt:   existingPlatform.CreatedAt,
-		UpdatedAt:   *existingPlatform.UpdatedAt,
+		ID:                 existingPlatform.ID,
+		Name:               existingPlatform.Name,
+		URLPatterns:        existingPlatform.URLPatterns,
+		Priority:           existingPlatform.Priority,
+		Enabled:            existingPlatform.Enabled,
+		ExtractionPatterns: existingPlatform.ExtractionPatterns,
+		CreatedAt:          existingPlatform.CreatedAt,
+		UpdatedAt:          *existingPlatform.UpdatedAt,
+		Warnings:           h.priorityWarnings(existingPlatform),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -381,11 +481,7 @@ func (h *AdminPlatformHandler) DeletePlatform(w http.ResponseWriter, r *http.Req
 	existingPlatform.UpdatedAt = &now
 
 	if err := h.platformRepo.UpdatePlatform(ctx, existingPlatform); err != nil {
-		h.logger.Error("Failed to delete platform",
-			"error", err,
-			"id", platformID,
-		)
-		http.Error(w, "Failed to delete platform: "+err.Error(), http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to delete platform", "id", platformID)
 		return
 	}
 
@@ -446,16 +542,149 @@ func (h *AdminPlatformHandler) ListPlatforms(w http.ResponseWriter, r *http.Requ
 		}
 
 		responses = append(responses, PlatformResponse{
-			ID:          p.ID,
-			Name:        p.Name,
-			URLPatterns: p.URLPatterns,
-			Priority:    p.Priority,
-			Enabled:     p.Enabled,
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   updatedAt,
+			ID:                 p.ID,
+			Name:               p.Name,
+			URLPatterns:        p.URLPatterns,
+			Priority:           p.Priority,
+			Enabled:            p.Enabled,
+			ExtractionPatterns: p.ExtractionPatterns,
+			CreatedAt:          p.CreatedAt,
+			UpdatedAt:          updatedAt,
 		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(responses)
 }
+
+// CheckConflictsRequest represents the request body for checking pattern conflicts.
+// Platform is an optional new or updated platform to check against the enabled ones.
+type CheckConflictsRequest struct {
+	Platform   *CreatePlatformRequest `json:"platform,omitempty"`
+	SampleURLs []string               `json:"sample_urls"`
+}
+
+// CheckConflictsResponse reports patterns and sample URLs that match more than one platform
+type CheckConflictsResponse struct {
+	HasConflicts     bool                          `json:"has_conflicts"`
+	PatternConflicts []urldetector.PatternConflict `json:"pattern_conflicts"`
+	Samples          []urldetector.URLMatch        `json:"samples"`
+}
+
+// CheckConflicts handles POST /api/v1/admin/platforms/check-conflicts
+func (h *AdminPlatformHandler) CheckConflicts(w http.ResponseWriter, r *http.Request) {
+	var req CheckConflictsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("Invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	platforms, err := h.platformLoader.GetAll()
+	if err != nil {
+		h.logger.Error("Failed to get platforms", "error", err)
+		http.Error(w, "Failed to get platforms", http.StatusInternalServerError)
+		return
+	}
+
+	// A candidate platform replaces the enabled platform with the same ID, if any
+	if req.Platform != nil {
+		if req.Platform.ID == "" {
+			http.Error(w, "platform.id is required", http.StatusBadRequest)
+			return
+		}
+
+		candidate := &domain.Platform{
+			ID:          req.Platform.ID,
+			Name:        req.Platform.Name,
+			URLPatterns: req.Platform.URLPatterns,
+			Priority:    req.Platform.Priority,
+			Enabled:     true,
+		}
+
+		platforms = withCandidate(platforms, candidate)
+	}
+
+	response := CheckConflictsResponse{
+		PatternConflicts: urldetector.FindPatternConflicts(platforms),
+		Samples:          urldetector.MatchURLs(platforms, req.SampleURLs),
+	}
+	if response.PatternConflicts == nil {
+		response.PatternConflicts = []urldetector.PatternConflict{}
+	}
+
+	response.HasConflicts = len(response.PatternConflicts) > 0
+	for _, sample := range response.Samples {
+		if sample.Ambiguous {
+			response.HasConflicts = true
+		}
+	}
+
+	h.logger.Info("Checked platform pattern conflicts",
+		"platform_count", len(platforms),
+		"pattern_conflicts", len(response.PatternConflicts),
+		"samples", len(response.Samples),
+		"has_conflicts", response.HasConflicts,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// TestPatternsRequest represents the request body for a pattern dry run
+type TestPatternsRequest struct {
+	Patterns []string `json:"patterns"`
+	URLs     []string `json:"urls"`
+}
+
+// TestPatternsResponse reports which URLs each pattern matches, and which matched none
+type TestPatternsResponse struct {
+	Patterns  []urldetector.PatternMatch `json:"patterns"`
+	Unmatched []string                   `json:"unmatched"`
+}
+
+// TestPatterns handles POST /api/v1/admin/platforms/test - a dry run of URL patterns
+// against sample URLs. Nothing is persisted.
+func (h *AdminPlatformHandler) TestPatterns(w http.ResponseWriter, r *http.Request) {
+	var req TestPatternsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("Invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Patterns) == 0 {
+		http.Error(w, "patterns must contain at least one pattern", http.StatusBadRequest)
+		return
+	}
+	if len(req.URLs) == 0 {
+		http.Error(w, "urls must contain at least one URL", http.StatusBadRequest)
+		return
+	}
+
+	response := TestPatternsResponse{
+		Patterns:  urldetector.MatchPatterns(req.Patterns, req.URLs),
+		Unmatched: []string{},
+	}
+
+	matched := make(map[string]bool)
+	for _, result := range response.Patterns {
+		for _, url := range result.Matches {
+			matched[url] = true
+		}
+	}
+	for _, url := range req.URLs {
+		if !matched[url] {
+			response.Unmatched = append(response.Unmatched, url)
+		}
+	}
+
+	h.logger.Info("Tested platform patterns",
+		"patterns", len(req.Patterns),
+		"urls", len(req.URLs),
+		"unmatched", len(response.Unmatched),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
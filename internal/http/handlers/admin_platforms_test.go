@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakePlatformLoader serves a fixed set of enabled platforms and counts refreshes
+type fakePlatformLoader struct {
+	platforms []*domain.Platform
+	refreshes int
+}
+
+func (l *fakePlatformLoader) Refresh(ctx context.Context) error {
+	l.refreshes++
+	return nil
+}
+
+func (l *fakePlatformLoader) GetAll() ([]*domain.Platform, error) { return l.platforms, nil }
+
+func (l *fakePlatformLoader) Count() int { return len(l.platforms) }
+
+// fakePlatformRepo records the last platforms passed to CreatePlatform and UpdatePlatform,
+// rejecting creates for IDs in existing; other methods are unimplemented
+type fakePlatformRepo struct {
+	PlatformRepository
+	existing map[string]bool
+	created  *domain.Platform
+	updated  *domain.Platform
+}
+
+func (r *fakePlatformRepo) CreatePlatform(ctx context.Context, platform *domain.Platform) error {
+	if r.existing[platform.ID] {
+		return domain.ErrPlatformExists
+	}
+	r.created = platform
+	return nil
+}
+
+func (r *fakePlatformRepo) UpdatePlatform(ctx context.Context, platform *domain.Platform) error {
+	r.updated = platform
+	return nil
+}
+
+func TestPatchPlatformExtractionPatterns(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantPatterns []string
+	}{
+		{
+			name:         "Sets extraction patterns",
+			body:         `{"extraction_patterns": ["example\\.com/item/(\\d+)"]}`,
+			wantStatus:   http.StatusOK,
+			wantPatterns: []string{`example\.com/item/(\d+)`},
+		},
+		{
+			name:         "Omitted extraction patterns are kept",
+			body:         `{"priority": 5}`,
+			wantStatus:   http.StatusOK,
+			wantPatterns: []string{`example\.com/(\w+)`},
+		},
+		{
+			name:       "Pattern without a capture group",
+			body:       `{"extraction_patterns": ["example\\.com/item/\\d+"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid regex",
+			body:       `{"extraction_patterns": ["example\\.com/(\\d+"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &fakePlatformLoader{platforms: []*domain.Platform{
+				{ID: "example", Name: "Example", URLPatterns: []string{"example.com"}, ExtractionPatterns: []string{`example\.com/(\w+)`}},
+			}}
+			repo := &fakePlatformRepo{}
+			handler := NewAdminPlatformHandler(repo, loader, createTestLogger())
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/admin/platforms/example", strings.NewReader(tt.body))
+			req.SetPathValue("id", "example")
+			rec := httptest.NewRecorder()
+
+			handler.PatchPlatform(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.updated != nil {
+					t.Errorf("platform was updated despite invalid request")
+				}
+				return
+			}
+
+			if repo.updated == nil || !reflect.DeepEqual(repo.updated.ExtractionPatterns, tt.wantPatterns) {
+				t.Errorf("stored extraction patterns = %+v, want %v", repo.updated, tt.wantPatterns)
+			}
+
+			var response PlatformResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(response.ExtractionPatterns, tt.wantPatterns) {
+				t.Errorf("response extraction patterns = %v, want %v", response.ExtractionPatterns, tt.wantPatterns)
+			}
+		})
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	loader := &fakePlatformLoader{platforms: []*domain.Platform{
+		{ID: "youtube", URLPatterns: []string{"youtube.com", "youtu.be"}, Priority: 10, Enabled: true},
+		{ID: "soundcloud", URLPatterns: []string{"soundcloud.com"}, Priority: 10, Enabled: true},
+	}}
+	handler := NewAdminPlatformHandler(nil, loader, createTestLogger())
+
+	tests := []struct {
+		name              string
+		body              string
+		wantStatus        int
+		wantConflicts     bool
+		wantPatternCount  int
+		wantAmbiguousURLs []string
+	}{
+		{
+			name:       "Existing platforms only",
+			body:       `{"sample_urls": ["https://youtube.com/watch?v=1", "https://soundcloud.com/a/b"]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "New platform claiming an existing domain",
+			body: `{
+				"platform": {"id": "yt_mirror", "name": "YT Mirror", "url_patterns": ["youtube.com"], "priority": 5},
+				"sample_urls": ["https://youtube.com/watch?v=1", "https://soundcloud.com/a/b"]
+			}`,
+			wantStatus:        http.StatusOK,
+			wantConflicts:     true,
+			wantPatternCount:  2,
+			wantAmbiguousURLs: []string{"https://youtube.com/watch?v=1"},
+		},
+		{
+			name: "Updated platform replaces its current patterns",
+			body: `{
+				"platform": {"id": "soundcloud", "name": "SoundCloud", "url_patterns": ["soundcloud.com", "on.soundcloud.com"], "priority": 10},
+				"sample_urls": ["https://on.soundcloud.com/abc"]
+			}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Candidate without an ID",
+			body:       `{"platform": {"url_patterns": ["youtube.com"]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Malformed body",
+			body:       `{"sample_urls": "https://youtube.com"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/platforms/check-conflicts", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			handler.CheckConflicts(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response CheckConflictsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if response.HasConflicts != tt.wantConflicts {
+				t.Errorf("has_conflicts = %v, want %v", response.HasConflicts, tt.wantConflicts)
+			}
+			if len(response.PatternConflicts) != tt.wantPatternCount {
+				t.Errorf("pattern_conflicts = %+v, want %d", response.PatternConflicts, tt.wantPatternCount)
+			}
+
+			var ambiguous []string
+			for _, sample := range response.Samples {
+				if sample.Ambiguous {
+					ambiguous = append(ambiguous, sample.URL)
+				}
+			}
+			if strings.Join(ambiguous, ",") != strings.Join(tt.wantAmbiguousURLs, ",") {
+				t.Errorf("ambiguous samples = %v, want %v", ambiguous, tt.wantAmbiguousURLs)
+			}
+		})
+	}
+}
+
+func TestTestPatterns(t *testing.T) {
+	handler := NewAdminPlatformHandler(nil, &fakePlatformLoader{}, createTestLogger())
+
+	tests := []struct {
+		name          string
+		body          string
+		wantStatus    int
+		wantMatches   map[string][]string
+		wantUnmatched []string
+	}{
+		{
+			name: "Reports matches per pattern",
+			body: `{
+				"patterns": ["bandcamp.com", "www.dublab.com"],
+				"urls": ["https://artist.bandcamp.com/album/x", "https://dublab.com/archive/show", "https://bandcamp.com/", "https://example.com/"]
+			}`,
+			wantStatus: http.StatusOK,
+			wantMatches: map[string][]string{
+				// Bandcamp patterns only match artist subdomains
+				"bandcamp.com":   {"https://artist.bandcamp.com/album/x"},
+				"www.dublab.com": {"https://dublab.com/archive/show"},
+			},
+			wantUnmatched: []string{"https://bandcamp.com/", "https://example.com/"},
+		},
+		{
+			name:       "No patterns",
+			body:       `{"patterns": [], "urls": ["https://example.com"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No URLs",
+			body:       `{"patterns": ["example.com"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Malformed body",
+			body:       `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/platforms/test", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			handler.TestPatterns(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response TestPatternsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			got := make(map[string][]string)
+			for _, result := range response.Patterns {
+				if result.Regex == "" {
+					t.Errorf("pattern %q has no regex", result.Pattern)
+				}
+				got[result.Pattern] = result.Matches
+			}
+			if !reflect.DeepEqual(got, tt.wantMatches) {
+				t.Errorf("matches = %v, want %v", got, tt.wantMatches)
+			}
+			if !reflect.DeepEqual(response.Unmatched, tt.wantUnmatched) {
+				t.Errorf("unmatched = %v, want %v", response.Unmatched, tt.wantUnmatched)
+			}
+		})
+	}
+}
+
+func TestCreatePlatformDuplicateID(t *testing.T) {
+	body := `{"id": "nts", "name": "NTS Radio", "url_patterns": ["nts.live"], "enabled": true}`
+
+	tests := []struct {
+		name        string
+		query       string
+		existing    bool
+		wantStatus  int
+		wantCreated bool
+		wantUpdated bool
+	}{
+		{name: "New platform", wantStatus: http.StatusCreated, wantCreated: true},
+		{name: "Duplicate ID", existing: true, wantStatus: http.StatusConflict},
+		{name: "Duplicate ID with upsert", query: "?upsert=true", existing: true, wantStatus: http.StatusOK, wantUpdated: true},
+		{name: "New platform with upsert", query: "?upsert=true", wantStatus: http.StatusCreated, wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePlatformRepo{existing: map[string]bool{"nts": tt.existing}}
+			loader := &fakePlatformLoader{}
+			handler := NewAdminPlatformHandler(repo, loader, createTestLogger())
+			rec := httptest.NewRecorder()
+
+			handler.CreatePlatform(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/platforms"+tt.query, strings.NewReader(body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if (repo.created != nil) != tt.wantCreated || (repo.updated != nil) != tt.wantUpdated {
+				t.Errorf("created = %v, updated = %v, want created %v, updated %v", repo.created, repo.updated, tt.wantCreated, tt.wantUpdated)
+			}
+
+			wantRefreshes := 1
+			if tt.wantStatus == http.StatusConflict {
+				wantRefreshes = 0
+			}
+			if loader.refreshes != wantRefreshes {
+				t.Errorf("loader refreshed %d times, want %d", loader.refreshes, wantRefreshes)
+			}
+		})
+	}
+}
+
+func TestPlatformPriorityValidation(t *testing.T) {
+	existing := func() []*domain.Platform {
+		return []*domain.Platform{
+			{ID: "youtube", Name: "YouTube", URLPatterns: []string{"youtube.com"}, Priority: 5, Enabled: true},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		body         string
+		wantStatus   int
+		wantWarnings int
+	}{
+		{
+			name:       "Negative priority on create",
+			method:     http.MethodPost,
+			body:       `{"id": "nts", "name": "NTS", "url_patterns": ["nts.live"], "priority": -1, "enabled": true}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Negative priority on update",
+			method:     http.MethodPut,
+			id:         "youtube",
+			body:       `{"name": "YouTube", "url_patterns": ["youtube.com"], "priority": -5, "enabled": true}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Negative priority on patch",
+			method:     http.MethodPatch,
+			id:         "youtube",
+			body:       `{"priority": -1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Same priority without overlapping patterns",
+			method:     http.MethodPost,
+			body:       `{"id": "nts", "name": "NTS", "url_patterns": ["nts.live"], "priority": 5, "enabled": true}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:         "Same priority with overlapping patterns warns",
+			method:       http.MethodPost,
+			body:         `{"id": "yt_mirror", "name": "Mirror", "url_patterns": ["youtube.com"], "priority": 5, "enabled": true}`,
+			wantStatus:   http.StatusCreated,
+			wantWarnings: 1,
+		},
+		{
+			name:       "Higher priority with overlapping patterns",
+			method:     http.MethodPost,
+			body:       `{"id": "yt_mirror", "name": "Mirror", "url_patterns": ["youtube.com"], "priority": 6, "enabled": true}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:         "Patch into a tie warns",
+			method:       http.MethodPatch,
+			id:           "yt_mirror",
+			body:         `{"priority": 5}`,
+			wantStatus:   http.StatusOK,
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &fakePlatformLoader{platforms: append(existing(),
+				&domain.Platform{ID: "yt_mirror", Name: "Mirror", URLPatterns: []string{"youtube.com"}, Priority: 1, Enabled: true},
+			)}
+			if tt.method == http.MethodPost {
+				loader.platforms = existing()
+			}
+			handler := NewAdminPlatformHandler(&fakePlatformRepo{}, loader, createTestLogger())
+
+			req := httptest.NewRequest(tt.method, "/api/v1/admin/platforms/"+tt.id, strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+
+			switch tt.method {
+			case http.MethodPost:
+				handler.CreatePlatform(rec, req)
+			case http.MethodPut:
+				handler.UpdatePlatform(rec, req)
+			case http.MethodPatch:
+				handler.PatchPlatform(rec, req)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code >= http.StatusBadRequest {
+				return
+			}
+
+			var response PlatformResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %d", response.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// AdminQueueHandler handles admin operations for inspecting and repairing the job queue
+type AdminQueueHandler struct {
+	queueRepo domain.QueueRepository
+	logger    *slog.Logger
+}
+
+// NewAdminQueueHandler creates a new admin queue handler
+func NewAdminQueueHandler(queueRepo domain.QueueRepository, logger *slog.Logger) *AdminQueueHandler {
+	return &AdminQueueHandler{
+		queueRepo: queueRepo,
+		logger:    logger,
+	}
+}
+
+// ProcessingJobsResponse lists the jobs held in a job type's processing list
+type ProcessingJobsResponse struct {
+	JobType string   `json:"job_type"`
+	JobIDs  []string `json:"job_ids"`
+	Count   int      `json:"count"`
+}
+
+// jobTypeFromPath returns the validated job type path value, writing a 400 if it's unknown
+func jobTypeFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	jobType := r.PathValue("jobType")
+	if !domain.IsValidJobType(jobType) {
+		http.Error(w, "Unknown job type: "+jobType, http.StatusBadRequest)
+		return "", false
+	}
+	return jobType, true
+}
+
+// ListProcessing handles GET /api/v1/admin/queue/{jobType}/processing
+func (h *AdminQueueHandler) ListProcessing(w http.ResponseWriter, r *http.Request) {
+	jobType, ok := jobTypeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	jobIDs, err := h.queueRepo.GetProcessingJobIDs(r.Context(), jobType)
+	if err != nil {
+		h.logger.Error("Failed to get processing jobs", "error", err, "job_type", jobType)
+		http.Error(w, "Failed to get processing jobs", http.StatusInternalServerError)
+		return
+	}
+	if jobIDs == nil {
+		jobIDs = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProcessingJobsResponse{
+		JobType: jobType,
+		JobIDs:  jobIDs,
+		Count:   len(jobIDs),
+	})
+}
+
+// ReclaimProcessing handles POST /api/v1/admin/queue/{jobType}/processing/reclaim.
+// Every processing job goes back to pending, including any a live worker still holds.
+func (h *AdminQueueHandler) ReclaimProcessing(w http.ResponseWriter, r *http.Request) {
+	jobType, ok := jobTypeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	reclaimed, err := h.queueRepo.ReclaimProcessing(r.Context(), jobType)
+	if err != nil {
+		h.logger.Error("Failed to reclaim processing jobs", "error", err, "job_type", jobType, "reclaimed", reclaimed)
+		http.Error(w, "Failed to reclaim processing jobs", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Processing jobs reclaimed via admin API",
+		"job_type", jobType,
+		"reclaimed", reclaimed,
+	)
+
+	response := map[string]interface{}{
+		"message":   "Processing jobs reclaimed",
+		"job_type":  jobType,
+		"reclaimed": reclaimed,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RecomputeStats handles POST /api/v1/admin/queue/{jobType}/stats/recompute, rebuilding
+// the job type's drifted stats counters from the queue's lists
+func (h *AdminQueueHandler) RecomputeStats(w http.ResponseWriter, r *http.Request) {
+	jobType, ok := jobTypeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.queueRepo.RecomputeStats(r.Context(), jobType)
+	if err != nil {
+		h.logger.Error("Failed to recompute queue stats", "error", err, "job_type", jobType)
+		http.Error(w, "Failed to recompute queue stats", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Queue stats recomputed via admin API", "job_type", jobType)
+
+	response := map[string]interface{}{
+		"message":   "Queue stats recomputed",
+		"job_type":  jobType,
+		"stats":     stats,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// PauseWorker handles POST /api/v1/admin/worker/pause. Workers stop dequeuing on their
+// next poll; jobs already running finish, and new jobs wait in the queue.
+func (h *AdminQueueHandler) PauseWorker(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// ResumeWorker handles POST /api/v1/admin/worker/resume
+func (h *AdminQueueHandler) ResumeWorker(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+// setPaused stores the pause state and writes the JSON response for pause and resume
+func (h *AdminQueueHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if err := h.queueRepo.SetPaused(r.Context(), paused); err != nil {
+		h.logger.Error("Failed to set worker pause state", "error", err, "paused", paused)
+		http.Error(w, "Failed to set worker pause state", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Job processing resumed"
+	if paused {
+		message = "Job processing paused"
+	}
+	h.logger.Info(message+" via admin API", "paused", paused)
+
+	response := map[string]interface{}{
+		"message":   message,
+		"paused":    paused,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeProcessingQueue holds processing job IDs per job type and the pause flag; other
+// methods are unimplemented
+type fakeProcessingQueue struct {
+	domain.QueueRepository
+	processing map[string][]string
+	pending    map[string][]string
+	paused     bool
+	err        error
+}
+
+func (q *fakeProcessingQueue) SetPaused(ctx context.Context, paused bool) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.paused = paused
+	return nil
+}
+
+func (q *fakeProcessingQueue) GetProcessingJobIDs(ctx context.Context, jobType string) ([]string, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.processing[jobType], nil
+}
+
+func (q *fakeProcessingQueue) ReclaimProcessing(ctx context.Context, jobType string) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	reclaimed := q.processing[jobType]
+	q.pending[jobType] = append(q.pending[jobType], reclaimed...)
+	delete(q.processing, jobType)
+	return len(reclaimed), nil
+}
+
+func (q *fakeProcessingQueue) RecomputeStats(ctx context.Context, jobType string) (map[string]int64, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return map[string]int64{"pending": int64(len(q.pending[jobType])), "processing": int64(len(q.processing[jobType]))}, nil
+}
+
+func TestListProcessing(t *testing.T) {
+	tests := []struct {
+		name       string
+		jobType    string
+		queue      *fakeProcessingQueue
+		wantStatus int
+		wantIDs    []string
+	}{
+		{
+			name:       "Lists processing jobs",
+			jobType:    domain.JobTypeExtractMetadata,
+			queue:      &fakeProcessingQueue{processing: map[string][]string{domain.JobTypeExtractMetadata: {"job-2", "job-1"}}},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"job-2", "job-1"},
+		},
+		{
+			name:       "Empty processing list",
+			jobType:    domain.JobTypeNotifyComplete,
+			queue:      &fakeProcessingQueue{},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{},
+		},
+		{
+			name:       "Unknown job type",
+			jobType:    "not_a_job",
+			queue:      &fakeProcessingQueue{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Queue error",
+			jobType:    domain.JobTypeExtractMetadata,
+			queue:      &fakeProcessingQueue{err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminQueueHandler(tt.queue, createTestLogger())
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/queue/"+tt.jobType+"/processing", nil)
+			req.SetPathValue("jobType", tt.jobType)
+			rec := httptest.NewRecorder()
+
+			handler.ListProcessing(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ProcessingJobsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(resp.JobIDs, tt.wantIDs) {
+				t.Errorf("job_ids = %v, want %v", resp.JobIDs, tt.wantIDs)
+			}
+			if resp.Count != len(tt.wantIDs) || resp.JobType != tt.jobType {
+				t.Errorf("response = %+v, want count %d for %s", resp, len(tt.wantIDs), tt.jobType)
+			}
+		})
+	}
+}
+
+func TestReclaimProcessing(t *testing.T) {
+	tests := []struct {
+		name          string
+		jobType       string
+		queue         *fakeProcessingQueue
+		wantStatus    int
+		wantReclaimed int
+	}{
+		{
+			name:    "Reclaims processing jobs",
+			jobType: domain.JobTypeExtractMetadata,
+			queue: &fakeProcessingQueue{
+				processing: map[string][]string{domain.JobTypeExtractMetadata: {"job-1", "job-2"}},
+				pending:    map[string][]string{},
+			},
+			wantStatus:    http.StatusOK,
+			wantReclaimed: 2,
+		},
+		{
+			name:    "Other job types are left alone",
+			jobType: domain.JobTypeNotifyComplete,
+			queue: &fakeProcessingQueue{
+				processing: map[string][]string{domain.JobTypeExtractMetadata: {"job-1"}},
+				pending:    map[string][]string{},
+			},
+			wantStatus:    http.StatusOK,
+			wantReclaimed: 0,
+		},
+		{
+			name:       "Unknown job type",
+			jobType:    "not_a_job",
+			queue:      &fakeProcessingQueue{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Queue error",
+			jobType:    domain.JobTypeExtractMetadata,
+			queue:      &fakeProcessingQueue{err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminQueueHandler(tt.queue, createTestLogger())
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/queue/"+tt.jobType+"/processing/reclaim", nil)
+			req.SetPathValue("jobType", tt.jobType)
+			rec := httptest.NewRecorder()
+
+			handler.ReclaimProcessing(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Reclaimed int `json:"reclaimed"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Reclaimed != tt.wantReclaimed {
+				t.Errorf("reclaimed = %d, want %d", resp.Reclaimed, tt.wantReclaimed)
+			}
+			if len(tt.queue.processing[tt.jobType]) != 0 || len(tt.queue.pending[tt.jobType]) != tt.wantReclaimed {
+				t.Errorf("queue after reclaim: processing %v, pending %v", tt.queue.processing, tt.queue.pending)
+			}
+		})
+	}
+}
+
+func TestPauseResumeWorker(t *testing.T) {
+	queue := &fakeProcessingQueue{}
+	handler := NewAdminQueueHandler(queue, createTestLogger())
+
+	steps := []struct {
+		name       string
+		handle     http.HandlerFunc
+		path       string
+		wantPaused bool
+	}{
+		{name: "Pause", handle: handler.PauseWorker, path: "/api/v1/admin/worker/pause", wantPaused: true},
+		{name: "Pause again", handle: handler.PauseWorker, path: "/api/v1/admin/worker/pause", wantPaused: true},
+		{name: "Resume", handle: handler.ResumeWorker, path: "/api/v1/admin/worker/resume", wantPaused: false},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			step.handle(rec, httptest.NewRequest(http.MethodPost, step.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			var resp struct {
+				Paused bool `json:"paused"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Paused != step.wantPaused || queue.paused != step.wantPaused {
+				t.Errorf("response paused = %v, stored paused = %v, want %v", resp.Paused, queue.paused, step.wantPaused)
+			}
+		})
+	}
+
+	t.Run("Queue error", func(t *testing.T) {
+		handler := NewAdminQueueHandler(&fakeProcessingQueue{err: errors.New("connection refused")}, createTestLogger())
+		rec := httptest.NewRecorder()
+		handler.PauseWorker(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/worker/pause", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+func TestRecomputeStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		jobType    string
+		queue      *fakeProcessingQueue
+		wantStatus int
+		wantStats  map[string]int64
+	}{
+		{
+			name:    "Recomputes stats",
+			jobType: domain.JobTypeExtractMetadata,
+			queue: &fakeProcessingQueue{
+				processing: map[string][]string{domain.JobTypeExtractMetadata: {"job-1"}},
+				pending:    map[string][]string{domain.JobTypeExtractMetadata: {"job-2", "job-3"}},
+			},
+			wantStatus: http.StatusOK,
+			wantStats:  map[string]int64{"pending": 2, "processing": 1},
+		},
+		{
+			name:       "Unknown job type",
+			jobType:    "not_a_job",
+			queue:      &fakeProcessingQueue{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Queue error",
+			jobType:    domain.JobTypeExtractMetadata,
+			queue:      &fakeProcessingQueue{err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminQueueHandler(tt.queue, createTestLogger())
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/queue/"+tt.jobType+"/stats/recompute", nil)
+			req.SetPathValue("jobType", tt.jobType)
+			rec := httptest.NewRecorder()
+
+			handler.RecomputeStats(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				JobType string           `json:"job_type"`
+				Stats   map[string]int64 `json:"stats"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.JobType != tt.jobType || !reflect.DeepEqual(resp.Stats, tt.wantStats) {
+				t.Errorf("response = %+v, want %s stats %v", resp, tt.jobType, tt.wantStats)
+			}
+		})
+	}
+}
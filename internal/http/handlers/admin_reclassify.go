@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// reclassifyPageSize is how many unknown knoks are loaded at a time while reclassifying
+const reclassifyPageSize = 100
+
+// PlatformDetector detects URLs with the platform patterns it was last built from
+type PlatformDetector interface {
+	URLDetector
+
+	// Refresh rebuilds the patterns from the platform cache
+	Refresh()
+}
+
+// AdminReclassifyHandler re-runs platform detection over knoks stored as "unknown"
+type AdminReclassifyHandler struct {
+	knokRepo domain.KnokRepository
+	detector PlatformDetector
+	logger   *slog.Logger
+}
+
+// NewAdminReclassifyHandler creates a new admin reclassify handler
+func NewAdminReclassifyHandler(knokRepo domain.KnokRepository, detector PlatformDetector, logger *slog.Logger) *AdminReclassifyHandler {
+	return &AdminReclassifyHandler{
+		knokRepo: knokRepo,
+		detector: detector,
+		logger:   logger,
+	}
+}
+
+// ReclassifyKnoks handles POST /api/v1/admin/knoks/reclassify. Every unknown knok's stored
+// message content and URL are run through URL detection again with the current platform
+// patterns, and knoks that now match a known platform are moved to it. The whole scan runs
+// synchronously within the request, so it takes a while when there are many unknown knoks.
+func (h *AdminReclassifyHandler) ReclassifyKnoks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Platforms added since startup only reach the detector once its patterns are rebuilt
+	h.detector.Refresh()
+
+	scanned := 0
+	reclassified := make(map[string]int)
+	var cursor *domain.KnokCursor
+	for {
+		knoks, err := h.knokRepo.GetAllByPlatform(ctx, domain.PlatformUnknown, cursor, reclassifyPageSize)
+		if err != nil {
+			h.logger.Error("Failed to list unknown knoks", "error", err, "scanned", scanned)
+			http.Error(w, "Failed to list unknown knoks", http.StatusInternalServerError)
+			return
+		}
+
+		for _, knok := range knoks {
+			scanned++
+			platform := h.detectPlatform(knok)
+			if platform == domain.PlatformUnknown {
+				continue
+			}
+
+			if err := h.knokRepo.UpdatePlatform(ctx, knok.ID, platform); err != nil {
+				h.logger.Error("Failed to update knok platform", "error", err, "knok_id", knok.ID, "platform", platform)
+				http.Error(w, "Failed to update knok platform", http.StatusInternalServerError)
+				return
+			}
+			reclassified[platform]++
+		}
+
+		if len(knoks) < reclassifyPageSize {
+			break
+		}
+		last := knoks[len(knoks)-1]
+		cursor = &domain.KnokCursor{Time: last.PostedAt, KnokID: last.ID}
+	}
+
+	total := 0
+	for _, count := range reclassified {
+		total += count
+	}
+
+	h.logger.Info("Unknown knoks reclassified via admin API",
+		"scanned", scanned,
+		"reclassified", total,
+	)
+
+	response := map[string]interface{}{
+		"message":      "Unknown knoks reclassified",
+		"scanned":      scanned,
+		"reclassified": total,
+		"platforms":    reclassified,
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// detectPlatform returns the platform detected for a knok's link in its message content,
+// falling back to detecting the knok's URL on its own
+func (h *AdminReclassifyHandler) detectPlatform(knok *domain.Knok) string {
+	if knok.MessageContent != nil {
+		for _, info := range h.detector.DetectURLs(*knok.MessageContent) {
+			if info.URL == knok.URL || info.CanonicalURL == knok.CanonicalURL {
+				return info.Platform
+			}
+		}
+	}
+
+	if urls := h.detector.DetectURLs(knok.URL); len(urls) > 0 {
+		return urls[0].Platform
+	}
+	return domain.PlatformUnknown
+}
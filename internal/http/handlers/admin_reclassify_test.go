@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// detectorPlatformLoader serves platforms to a real URL detector
+type detectorPlatformLoader struct {
+	platforms []*domain.Platform
+}
+
+func (l *detectorPlatformLoader) GetAllByPriority() ([]*domain.Platform, error) {
+	return l.platforms, nil
+}
+
+func (l *detectorPlatformLoader) IsLoaded() bool { return true }
+
+// platformKnokRepo stores knoks in memory for listing and updating by platform; other
+// methods are unimplemented
+type platformKnokRepo struct {
+	domain.KnokRepository
+	knoks []*domain.Knok
+}
+
+func (r *platformKnokRepo) GetAllByPlatform(ctx context.Context, platform string, cursor *domain.KnokCursor, limit int) ([]*domain.Knok, error) {
+	sorted := append([]*domain.Knok(nil), r.knoks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return beforeCursor(sorted[j], domain.KnokCursor{Time: sorted[i].PostedAt, KnokID: sorted[i].ID})
+	})
+
+	var knoks []*domain.Knok
+	for _, knok := range sorted {
+		if knok.Platform == platform && (cursor == nil || beforeCursor(knok, *cursor)) && len(knoks) < limit {
+			knoks = append(knoks, knok)
+		}
+	}
+	return knoks, nil
+}
+
+// beforeCursor reports whether knok comes after cursor in newest-first order, comparing
+// (posted_at, id) the way Postgres compares the row values
+func beforeCursor(knok *domain.Knok, cursor domain.KnokCursor) bool {
+	if !knok.PostedAt.Equal(cursor.Time) {
+		return knok.PostedAt.Before(cursor.Time)
+	}
+	return bytes.Compare(knok.ID[:], cursor.KnokID[:]) < 0
+}
+
+func (r *platformKnokRepo) UpdatePlatform(ctx context.Context, id uuid.UUID, platform string) error {
+	for _, knok := range r.knoks {
+		if knok.ID == id {
+			knok.Platform = platform
+			return nil
+		}
+	}
+	return domain.ErrKnokNotFound
+}
+
+func TestReclassifyKnoks(t *testing.T) {
+	loader := &detectorPlatformLoader{platforms: []*domain.Platform{
+		{ID: "soundcloud", Name: "SoundCloud", URLPatterns: []string{"soundcloud.com"}, Enabled: true},
+	}}
+	detector := urldetector.New(loader, nil, createTestLogger())
+
+	content := "new show up now https://www.nts.live/shows/late-night-mix"
+	now := time.Now()
+	ntsKnok := &domain.Knok{
+		ID:             uuid.New(),
+		URL:            "https://www.nts.live/shows/late-night-mix",
+		CanonicalURL:   "https://nts.live/shows/late-night-mix",
+		Platform:       domain.PlatformUnknown,
+		MessageContent: &content,
+		PostedAt:       now,
+	}
+	otherKnok := &domain.Knok{
+		ID:           uuid.New(),
+		URL:          "https://example.com/some-page",
+		CanonicalURL: "https://example.com/some-page",
+		Platform:     domain.PlatformUnknown,
+		PostedAt:     now.Add(-time.Minute),
+	}
+	repo := &platformKnokRepo{knoks: []*domain.Knok{ntsKnok, otherKnok}}
+	handler := NewAdminReclassifyHandler(repo, detector, createTestLogger())
+
+	reclassify := func() map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ReclassifyKnoks(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/knoks/reclassify", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Nothing matches until the platform exists
+	if resp := reclassify(); resp["reclassified"] != float64(0) || resp["scanned"] != float64(2) {
+		t.Fatalf("response before adding platform = %v, want 2 scanned, 0 reclassified", resp)
+	}
+	if ntsKnok.Platform != domain.PlatformUnknown {
+		t.Fatalf("platform = %q before adding NTS, want unknown", ntsKnok.Platform)
+	}
+
+	loader.platforms = append(loader.platforms, &domain.Platform{
+		ID: "nts", Name: "NTS Radio", URLPatterns: []string{"nts.live"}, Enabled: true,
+	})
+
+	if resp := reclassify(); resp["reclassified"] != float64(1) {
+		t.Errorf("response after adding platform = %v, want 1 reclassified", resp)
+	}
+	if ntsKnok.Platform != "nts" {
+		t.Errorf("platform = %q, want nts", ntsKnok.Platform)
+	}
+	if otherKnok.Platform != domain.PlatformUnknown {
+		t.Errorf("unrelated knok platform = %q, want unknown", otherKnok.Platform)
+	}
+}
+
+func TestReclassifyKnoksTiedPostedAt(t *testing.T) {
+	loader := &detectorPlatformLoader{}
+	detector := urldetector.New(loader, nil, createTestLogger())
+
+	// More unknown knoks than fit on a page, all posted in the same message
+	now := time.Now()
+	repo := &platformKnokRepo{}
+	for i := 0; i < reclassifyPageSize+reclassifyPageSize/2; i++ {
+		repo.knoks = append(repo.knoks, &domain.Knok{
+			ID:       uuid.New(),
+			URL:      fmt.Sprintf("https://example.com/%d", i),
+			Platform: domain.PlatformUnknown,
+			PostedAt: now,
+		})
+	}
+	handler := NewAdminReclassifyHandler(repo, detector, createTestLogger())
+
+	rec := httptest.NewRecorder()
+	handler.ReclassifyKnoks(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/knoks/reclassify", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["scanned"] != float64(len(repo.knoks)) {
+		t.Errorf("scanned = %v, want %d", resp["scanned"], len(repo.knoks))
+	}
+}
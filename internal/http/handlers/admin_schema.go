@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+)
+
+// SchemaStatusSource reports which migrations have been applied to the database
+type SchemaStatusSource interface {
+	// CurrentVersion returns the newest migration version applied to the database
+	CurrentVersion(ctx context.Context) (int, error)
+
+	// LatestVersion returns the newest migration version this build knows about
+	LatestVersion() int
+
+	// AppliedMigrations returns the migrations recorded as applied, oldest first
+	AppliedMigrations(ctx context.Context) ([]domain.AppliedMigration, error)
+}
+
+// AdminSchemaHandler reports the database schema version for post-deploy checks
+type AdminSchemaHandler struct {
+	schemaStatus SchemaStatusSource
+	logger       *slog.Logger
+}
+
+// NewAdminSchemaHandler creates a new admin schema handler
+func NewAdminSchemaHandler(schemaStatus SchemaStatusSource, logger *slog.Logger) *AdminSchemaHandler {
+	return &AdminSchemaHandler{
+		schemaStatus: schemaStatus,
+		logger:       logger,
+	}
+}
+
+// SchemaStatusResponse is the migration state of the database
+type SchemaStatusResponse struct {
+	CurrentVersion  int                       `json:"current_version"`
+	ExpectedVersion int                       `json:"expected_version"`
+	UpToDate        bool                      `json:"up_to_date"`
+	Migrations      []domain.AppliedMigration `json:"migrations"`
+}
+
+// GetSchema handles GET /api/v1/admin/schema. The current version is the newest applied
+// migration and the expected version the newest one this build ships, so a deploy can
+// assert the two match.
+func (h *AdminSchemaHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	current, err := h.schemaStatus.CurrentVersion(ctx)
+	if err != nil {
+		h.logger.Error("Failed to get migration version", "error", err)
+		http.Error(w, "Failed to get migration version", http.StatusInternalServerError)
+		return
+	}
+
+	applied, err := h.schemaStatus.AppliedMigrations(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list applied migrations", "error", err)
+		http.Error(w, "Failed to list applied migrations", http.StatusInternalServerError)
+		return
+	}
+	if applied == nil {
+		applied = []domain.AppliedMigration{}
+	}
+
+	expected := h.schemaStatus.LatestVersion()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SchemaStatusResponse{
+		CurrentVersion:  current,
+		ExpectedVersion: expected,
+		UpToDate:        current == expected,
+		Migrations:      applied,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeSchemaStatus reports a fixed set of applied migrations
+type fakeSchemaStatus struct {
+	applied []domain.AppliedMigration
+	latest  int
+	err     error
+}
+
+func (s *fakeSchemaStatus) CurrentVersion(ctx context.Context) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if len(s.applied) == 0 {
+		return 0, nil
+	}
+	return s.applied[len(s.applied)-1].Version, nil
+}
+
+func (s *fakeSchemaStatus) LatestVersion() int {
+	return s.latest
+}
+
+func (s *fakeSchemaStatus) AppliedMigrations(ctx context.Context) ([]domain.AppliedMigration, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.applied, nil
+}
+
+func TestGetSchema(t *testing.T) {
+	appliedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	applied := []domain.AppliedMigration{
+		{Version: 1, Name: "initial_schema", AppliedAt: appliedAt},
+		{Version: 2, Name: "add_search", AppliedAt: appliedAt},
+	}
+
+	tests := []struct {
+		name           string
+		status         *fakeSchemaStatus
+		wantStatus     int
+		wantCurrent    int
+		wantUpToDate   bool
+		wantMigrations int
+	}{
+		{
+			name:           "Up to date",
+			status:         &fakeSchemaStatus{applied: applied, latest: 2},
+			wantStatus:     http.StatusOK,
+			wantCurrent:    2,
+			wantUpToDate:   true,
+			wantMigrations: 2,
+		},
+		{
+			name:           "Behind the build",
+			status:         &fakeSchemaStatus{applied: applied, latest: 3},
+			wantStatus:     http.StatusOK,
+			wantCurrent:    2,
+			wantMigrations: 2,
+		},
+		{
+			name:       "Database error",
+			status:     &fakeSchemaStatus{latest: 2, err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminSchemaHandler(tt.status, createTestLogger())
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/schema", nil)
+			rec := httptest.NewRecorder()
+
+			handler.GetSchema(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp SchemaStatusResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.CurrentVersion != tt.wantCurrent {
+				t.Errorf("current_version = %d, want %d", resp.CurrentVersion, tt.wantCurrent)
+			}
+			if resp.ExpectedVersion != tt.status.latest {
+				t.Errorf("expected_version = %d, want %d", resp.ExpectedVersion, tt.status.latest)
+			}
+			if resp.UpToDate != tt.wantUpToDate {
+				t.Errorf("up_to_date = %v, want %v", resp.UpToDate, tt.wantUpToDate)
+			}
+			if len(resp.Migrations) != tt.wantMigrations {
+				t.Errorf("got %d migrations, want %d", len(resp.Migrations), tt.wantMigrations)
+			}
+		})
+	}
+}
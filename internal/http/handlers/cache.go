@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"knock-fm/internal/domain"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// timelineMaxAge is how long clients and CDNs may cache timeline, search and daily
+	// responses. Kept short so new knoks show up quickly.
+	timelineMaxAge = 30 * time.Second
+
+	// knokMaxAge is how long clients and CDNs may cache a single knok, which rarely
+	// changes once extraction has completed
+	knokMaxAge = time.Hour
+)
+
+// knokVersion returns the time a knok last changed: updated_at if set, otherwise posted_at
+func knokVersion(knok *domain.Knok) time.Time {
+	if knok.UpdatedAt != nil {
+		return *knok.UpdatedAt
+	}
+	return knok.PostedAt
+}
+
+// knoksETag builds a weak ETag from the IDs and versions of the given knoks, so it
+// changes when a knok is added, removed or updated (e.g. once extraction completes)
+func knoksETag(knoks ...*domain.Knok) string {
+	hash := fnv.New64a()
+	for _, knok := range knoks {
+		fmt.Fprintf(hash, "%s:%d;", knok.ID, knokVersion(knok).UnixNano())
+	}
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// setCacheHeaders sets Cache-Control and ETag on a public response and reports whether
+// the request's If-None-Match already matches, in which case a 304 has been written
+func setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, maxAge time.Duration) bool {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag, using the weak
+// comparison required for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
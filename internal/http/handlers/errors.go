@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+)
+
+// errorStatus maps a repository error to an HTTP status and client-facing message.
+// Domain not-found errors become 404s and conflicts 409s; anything else is a 500 with a
+// generic message.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrKnokNotFound):
+		return http.StatusNotFound, "Knok not found"
+	case errors.Is(err, domain.ErrServerNotFound):
+		return http.StatusNotFound, "Server not found"
+	case errors.Is(err, domain.ErrPlatformNotFound):
+		return http.StatusNotFound, "Platform not found"
+	case errors.Is(err, domain.ErrPlatformExists):
+		return http.StatusConflict, "Platform already exists"
+	default:
+		return http.StatusInternalServerError, "Internal server error"
+	}
+}
+
+// writeRepositoryError writes the HTTP error for a repository error. Unexpected errors are
+// logged with msg and args; not-found errors are an expected outcome and aren't logged.
+func writeRepositoryError(w http.ResponseWriter, logger *slog.Logger, err error, msg string, args ...any) {
+	status, message := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		logger.Error(msg, append([]any{"error", err}, args...)...)
+	}
+	http.Error(w, message, status)
+}
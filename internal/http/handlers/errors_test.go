@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Knok not found", err: domain.ErrKnokNotFound, wantStatus: http.StatusNotFound},
+		{name: "Server not found", err: domain.ErrServerNotFound, wantStatus: http.StatusNotFound},
+		{name: "Platform not found", err: domain.ErrPlatformNotFound, wantStatus: http.StatusNotFound},
+		{name: "Platform exists", err: domain.ErrPlatformExists, wantStatus: http.StatusConflict},
+		{name: "Wrapped not found", err: fmt.Errorf("%w: abc", domain.ErrKnokNotFound), wantStatus: http.StatusNotFound},
+		{name: "Other error", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := errorStatus(tt.err); status != tt.wantStatus {
+				t.Errorf("errorStatus(%v) = %d, want %d", tt.err, status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// failingKnokRepo fails GetByID and Delete with fixed errors; other methods are unimplemented
+type failingKnokRepo struct {
+	domain.KnokRepository
+	getErr    error
+	deleteErr error
+}
+
+func (r *failingKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	return &domain.Knok{ID: id}, nil
+}
+
+func (r *failingKnokRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.deleteErr
+}
+
+func TestDeleteKnokErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		repo       *failingKnokRepo
+		wantStatus int
+	}{
+		{
+			name:       "Missing knok",
+			repo:       &failingKnokRepo{getErr: domain.ErrKnokNotFound},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Lookup failure isn't reported as missing",
+			repo:       &failingKnokRepo{getErr: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Knok deleted concurrently",
+			repo:       &failingKnokRepo{deleteErr: domain.ErrKnokNotFound},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Deleted",
+			repo:       &failingKnokRepo{},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewKnoksHandler(createTestLogger(), tt.repo, nil)
+			id := uuid.New().String()
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/knoks/"+id, nil)
+			req.SetPathValue("id", id)
+			rec := httptest.NewRecorder()
+
+			handler.DeleteKnok(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"knock-fm/internal/domain"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +24,13 @@ type KnoksHandler struct {
 	logger    *slog.Logger
 	knokRepo  domain.KnokRepository
 	queueRepo domain.QueueRepository
+
+	// platforms validates the global timeline's platform filter; nil accepts any platform
+	platforms PlatformLoader
+
+	// maxWaitTimeout and waitPollInterval bound long-polling in WaitForKnok
+	maxWaitTimeout   time.Duration
+	waitPollInterval time.Duration
 }
 
 // KnoksResponse represents the paginated response for knoks
@@ -30,21 +41,55 @@ type KnoksResponse struct {
 }
 
 type KnokDto struct {
-	Title    string                 `json:"title"`
-	PostedAt time.Time              `json:"posted_at"`
-	ID       string                 `json:"id"`
-	URL      string                 `json:"url"`
-	Metadata map[string]interface{} `json:"metadata"`
+	Title            string                 `json:"title"`
+	PostedAt         time.Time              `json:"posted_at"`
+	ID               string                 `json:"id"`
+	URL              string                 `json:"url"`
+	ExtractionStatus string                 `json:"extraction_status"`
+	ThumbnailURL     *string                `json:"thumbnail_url,omitempty"`
+	DurationSeconds  *int                   `json:"duration_seconds,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
 }
 
+// Placeholder titles for knoks without one. Pending knoks may still get a title, while
+// knoks whose extraction gave up won't unless they're refreshed
+const (
+	processingTitle  = "Processing..."
+	unavailableTitle = "Unavailable"
+)
+
 func NewKnoksHandler(logger *slog.Logger, knokRepo domain.KnokRepository, queueRepo domain.QueueRepository) *KnoksHandler {
 	return &KnoksHandler{
-		logger:    logger,
-		knokRepo:  knokRepo,
-		queueRepo: queueRepo,
+		logger:           logger,
+		knokRepo:         knokRepo,
+		queueRepo:        queueRepo,
+		maxWaitTimeout:   defaultMaxWaitTimeout,
+		waitPollInterval: defaultWaitPollInterval,
 	}
 }
 
+// SetPlatforms sets the platform list GetKnoks checks its platform filter against
+func (h *KnoksHandler) SetPlatforms(platforms PlatformLoader) {
+	h.platforms = platforms
+}
+
+// knownPlatform reports whether platform is an enabled platform or "unknown"
+func (h *KnoksHandler) knownPlatform(platform string) (bool, error) {
+	if h.platforms == nil || platform == domain.PlatformUnknown {
+		return true, nil
+	}
+	platforms, err := h.platforms.GetAll()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range platforms {
+		if p.ID == platform {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // parseCursor parses a cursor string into a time.Time pointer
 func (h *KnoksHandler) parseCursor(cursorStr string) (*time.Time, error) {
 	if cursorStr == "" {
@@ -57,8 +102,127 @@ func (h *KnoksHandler) parseCursor(cursorStr string) (*time.Time, error) {
 	return &parsed, nil
 }
 
-// buildKnokResponse creates paginated response from domain knoks
-func (h *KnoksHandler) buildKnokResponse(knoks []*domain.Knok, requestedLimit int) *KnoksResponse {
+// parseSort reads the sort and order query parameters, defaulting to posted_at. Time
+// sorts default to descending and title sorts to ascending
+func parseSort(query url.Values) (domain.KnokSort, error) {
+	sort := domain.DefaultKnokSort
+	if column := strings.ToLower(strings.TrimSpace(query.Get("sort"))); column != "" {
+		if !domain.IsValidKnokSortColumn(column) {
+			return sort, errors.New("Invalid sort, expected posted_at, created_at or title")
+		}
+		sort.Column = column
+		sort.Ascending = column == domain.KnokSortTitle
+	}
+
+	switch strings.ToLower(strings.TrimSpace(query.Get("order"))) {
+	case "":
+	case "asc":
+		sort.Ascending = true
+	case "desc":
+		sort.Ascending = false
+	default:
+		return sort, errors.New("Invalid order, expected asc or desc")
+	}
+	return sort, nil
+}
+
+// invalidStatusMessage is the error for a status filter that isn't an extraction status
+const invalidStatusMessage = "Invalid status, expected pending, processing, complete, failed, low_quality or restricted"
+
+// parseStatus reads the optional status query parameter filtering a timeline by extraction
+// status. An empty status leaves the timeline's implicit complete filter in place
+func parseStatus(query url.Values) (string, error) {
+	status := strings.ToLower(strings.TrimSpace(query.Get("status")))
+	if status != "" && !domain.IsValidExtractionStatus(status) {
+		return "", errors.New(invalidStatusMessage)
+	}
+	return status, nil
+}
+
+// parseKnokCursor parses a timeline cursor for sort: a timestamp for time sorts, or the
+// last knok's ID for title sorts
+func parseKnokCursor(cursorStr string, sort domain.KnokSort) (*domain.KnokCursor, error) {
+	if cursorStr == "" {
+		return nil, nil
+	}
+	if sort.Column == domain.KnokSortTitle {
+		knokID, err := uuid.Parse(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.KnokCursor{KnokID: knokID}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, cursorStr)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.KnokCursor{Time: parsed}, nil
+}
+
+// knokCursor returns the cursor continuing a timeline in sort order after knok.
+// created_at keeps sub-second precision since knoks from one message are created within
+// the same second
+func knokCursor(knok *domain.Knok, sort domain.KnokSort) string {
+	switch sort.Column {
+	case domain.KnokSortTitle:
+		return knok.ID.String()
+	case domain.KnokSortCreatedAt:
+		return knok.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return knok.PostedAt.Format(time.RFC3339)
+	}
+}
+
+// newKnokDto converts a knok for API responses
+func newKnokDto(knok *domain.Knok) *KnokDto {
+	title := placeholderTitle(knok.ExtractionStatus)
+	if knok.Title != nil {
+		title = *knok.Title
+	}
+
+	return &KnokDto{
+		Title:            title,
+		PostedAt:         knok.PostedAt,
+		ID:               knok.ID.String(),
+		URL:              knok.URL,
+		ExtractionStatus: knok.ExtractionStatus,
+		ThumbnailURL:     knok.ThumbnailURL,
+		DurationSeconds:  durationSeconds(knok.Metadata),
+		Metadata:         knok.Metadata,
+	}
+}
+
+// durationSeconds returns the track length stored in a knok's metadata. Metadata read
+// back from the database holds JSON numbers as float64
+func durationSeconds(metadata map[string]interface{}) *int {
+	var seconds int
+	switch value := metadata["duration_seconds"].(type) {
+	case int:
+		seconds = value
+	case float64:
+		seconds = int(value)
+	default:
+		return nil
+	}
+	if seconds <= 0 {
+		return nil
+	}
+	return &seconds
+}
+
+// placeholderTitle is the title shown for a knok that has none, which depends on whether
+// extraction is still expected to find one
+func placeholderTitle(status string) string {
+	switch status {
+	case domain.ExtractionStatusFailed, domain.ExtractionStatusRestricted:
+		return unavailableTitle
+	default:
+		return processingTitle
+	}
+}
+
+// buildKnokResponse creates paginated response from domain knoks listed in sort order
+func (h *KnoksHandler) buildKnokResponse(knoks []*domain.Knok, requestedLimit int, sort domain.KnokSort) *KnoksResponse {
 	// Determine if there are more results
 	hasMore := len(knoks) > requestedLimit
 	if hasMore {
@@ -68,19 +232,7 @@ func (h *KnoksHandler) buildKnokResponse(knoks []*domain.Knok, requestedLimit in
 
 	knokDtos := make([]*KnokDto, 0, len(knoks))
 	for _, knok := range knoks {
-		// Handle nil title gracefully (happens when extraction is still processing)
-		title := "Processing..."
-		if knok.Title != nil {
-			title = *knok.Title
-		}
-
-		knokDtos = append(knokDtos, &KnokDto{
-			Title:    title,
-			PostedAt: knok.PostedAt,
-			ID:       knok.ID.String(),
-			URL:      knok.URL,
-			Metadata: knok.Metadata,
-		})
+		knokDtos = append(knokDtos, newKnokDto(knok))
 	}
 
 	response := &KnoksResponse{
@@ -90,8 +242,7 @@ func (h *KnoksHandler) buildKnokResponse(knoks []*domain.Knok, requestedLimit in
 
 	// Set next cursor if there are more results
 	if hasMore && len(knoks) > 0 {
-		lastKnok := knoks[len(knoks)-1]
-		cursorStr := lastKnok.PostedAt.Format(time.RFC3339)
+		cursorStr := knokCursor(knoks[len(knoks)-1], sort)
 		response.Cursor = &cursorStr
 	}
 
@@ -111,28 +262,89 @@ func (h *KnoksHandler) GetRandomKnok(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	knok, err := h.knokRepo.GetRandom(ctx)
 	if err != nil {
-		h.logger.Error("Failed to retrieve knok", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve knok")
 		return
 	}
-	// Handle nil title gracefully (should not happen with completed knoks)
-	title := "Processing..."
-	if knok.Title != nil {
-		title = *knok.Title
+	response := newKnokDto(knok)
+	h.logger.Info("Retrieved random knok", "title", response.Title)
+
+	// Every request should get a fresh pick, so random knoks are never cached
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSONResponse(w, response)
+
+}
+
+// GetDailyKnok returns the knok of the day. The pick is stable for a given date, which
+// defaults to today (UTC) and can be set with ?date=YYYY-MM-DD
+func (h *KnoksHandler) GetDailyKnok(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	date := time.Now().UTC()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
 	}
 
-	response := &KnokDto{
-		Title:    title,
-		PostedAt: knok.PostedAt,
-		ID:       knok.ID.String(),
-		URL:      knok.URL,
-		Metadata: knok.Metadata,
+	knok, err := h.knokRepo.GetForDate(ctx, date)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve knok of the day")
+		return
+	}
+
+	response := newKnokDto(knok)
+	h.logger.Info("Retrieved knok of the day", "date", date.Format("2006-01-02"), "title", response.Title)
+
+	if setCacheHeaders(w, r, knoksETag(knok), timelineMaxAge) {
+		return
 	}
-	h.logger.Info("Retrieved random knok", "title", response.Title)
 	h.writeJSONResponse(w, response)
+}
 
+// GetKnokByID handles GET /api/v1/knoks/{id} - a single knok
+func (h *KnoksHandler) GetKnokByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	knokID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid knok ID format", http.StatusBadRequest)
+		return
+	}
+
+	knok, err := h.knokRepo.GetByID(ctx, knokID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve knok", "knok_id", knokID)
+		return
+	}
+
+	response := newKnokDto(knok)
+
+	if setCacheHeaders(w, r, knoksETag(knok), knokMaxAge) {
+		return
+	}
+	h.writeJSONResponse(w, response)
 }
+
+// SearchKnoks handles GET /api/v1/knoks/search - search across all servers
 func (h *KnoksHandler) SearchKnoks(w http.ResponseWriter, r *http.Request) {
+	h.searchKnoks(w, r, nil)
+}
+
+// SearchKnoksByServer handles GET /api/v1/knoks/server/{serverId}/search - search within a server
+func (h *KnoksHandler) SearchKnoksByServer(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverId")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+	h.searchKnoks(w, r, &serverID)
+}
+
+// searchKnoks runs the ?q= search, limited to a server when serverID is set
+func (h *KnoksHandler) searchKnoks(w http.ResponseWriter, r *http.Request, serverID *string) {
 	ctx := r.Context()
 
 	// Get and validate search query
@@ -167,29 +379,43 @@ func (h *KnoksHandler) SearchKnoks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Request one more item than the limit to determine if there are more results
-	knoks, err := h.knokRepo.Search(ctx, query, cursor, limit+1)
+	knoks, err := h.knokRepo.Search(ctx, serverID, query, cursor, limit+1)
 	if err != nil {
-		h.logger.Error("Failed to retrieve knoks", "error", err)
+		h.logger.Error("Failed to retrieve knoks", "error", err, "server_id", serverID)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := h.buildKnokResponse(knoks, limit)
-	h.logger.Info("Search completed", "query", query, "count", len(response.Knoks), "has_more", response.HasMore)
+	response := h.buildKnokResponse(knoks, limit, domain.DefaultKnokSort)
+	h.logger.Info("Search completed", "query", query, "server_id", serverID, "count", len(response.Knoks), "has_more", response.HasMore)
+
+	if setCacheHeaders(w, r, knoksETag(knoks...), timelineMaxAge) {
+		return
+	}
 	h.writeJSONResponse(w, response)
 }
 
-// GetKnoks handles GET /api/v1/knoks - global timeline across all servers
+// GetKnoks handles GET /api/v1/knoks - global timeline across all servers. from and to
+// (RFC 3339 or YYYY-MM-DD) limit it to knoks posted in [from, to]; without them the
+// repository's timeline window applies. platform limits it to one platform, and status
+// lists knoks with that extraction status instead of completed ones
 func (h *KnoksHandler) GetKnoks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Parse pagination parameters
 	limit := DefaultPaginationLimit
 
+	// Parse sort parameters; the cursor format depends on the sort column
+	sort, err := parseSort(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Parse cursor parameter
-	cursor, err := h.parseCursor(r.URL.Query().Get("cursor"))
+	cursor, err := parseKnokCursor(r.URL.Query().Get("cursor"), sort)
 	if err != nil {
-		h.logger.Warn("Invalid cursor format", "cursor", r.URL.Query().Get("cursor"), "error", err)
+		h.logger.Warn("Invalid cursor format", "cursor", r.URL.Query().Get("cursor"), "sort", sort.Column, "error", err)
 		http.Error(w, "Invalid cursor format", http.StatusBadRequest)
 		return
 	}
@@ -201,19 +427,90 @@ func (h *KnoksHandler) GetKnoks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse optional date range
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional extraction status filter, replacing the implicit complete one
+	status, err := parseStatus(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional platform filter; an empty one gets every platform
+	platform := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("platform")))
+	if platform != "" {
+		known, err := h.knownPlatform(platform)
+		if err != nil {
+			h.logger.Error("Failed to load platforms", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !known {
+			http.Error(w, "Unknown platform", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Request one more item than the limit to determine if there are more results
-	knoks, err := h.knokRepo.GetRecent(ctx, cursor, limit+1)
+	var knoks []*domain.Knok
+	if platform != "" {
+		knoks, err = h.knokRepo.GetRecentByPlatform(ctx, platform, sort, cursor, from, to, status, limit+1)
+	} else {
+		knoks, err = h.knokRepo.GetRecent(ctx, sort, cursor, from, to, status, limit+1)
+	}
 	if err != nil {
-		h.logger.Error("Failed to retrieve knoks (global)", "error", err)
+		h.logger.Error("Failed to retrieve knoks (global)", "error", err, "platform", platform, "status", status)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := h.buildKnokResponse(knoks, limit)
-	h.logger.Info("Retrieved knoks (global)", "count", len(response.Knoks), "has_more", response.HasMore)
+	response := h.buildKnokResponse(knoks, limit, sort)
+	h.logger.Info("Retrieved knoks (global)", "count", len(response.Knoks), "platform", platform, "has_more", response.HasMore)
+
+	if setCacheHeaders(w, r, knoksETag(knoks...), timelineMaxAge) {
+		return
+	}
 	h.writeJSONResponse(w, response)
 }
 
+// parseDateRange reads the optional from and to query parameters bounding a timeline to
+// knoks posted in [from, to]
+func parseDateRange(query url.Values) (*time.Time, *time.Time, error) {
+	from, err := parseOptionalTime(query.Get("from"))
+	if err != nil {
+		return nil, nil, errors.New("Invalid from, expected RFC 3339 or YYYY-MM-DD")
+	}
+	to, err := parseOptionalTime(query.Get("to"))
+	if err != nil {
+		return nil, nil, errors.New("Invalid to, expected RFC 3339 or YYYY-MM-DD")
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return nil, nil, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp or YYYY-MM-DD date, returning nil for
+// an empty value
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := parseActivityTime(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// GetKnoksByServer handles GET /api/v1/knoks/server/{serverId} - a server's timeline,
+// optionally limited to one platform, to knoks posted in [from, to] and to an extraction
+// status other than complete
 func (h *KnoksHandler) GetKnoksByServer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -227,10 +524,17 @@ func (h *KnoksHandler) GetKnoksByServer(w http.ResponseWriter, r *http.Request)
 	// Parse pagination parameters
 	limit := DefaultPaginationLimit
 
+	// Parse sort parameters; the cursor format depends on the sort column
+	sort, err := parseSort(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Parse cursor parameter
-	cursor, err := h.parseCursor(r.URL.Query().Get("cursor"))
+	cursor, err := parseKnokCursor(r.URL.Query().Get("cursor"), sort)
 	if err != nil {
-		h.logger.Warn("Invalid cursor format", "cursor", r.URL.Query().Get("cursor"), "error", err)
+		h.logger.Warn("Invalid cursor format", "cursor", r.URL.Query().Get("cursor"), "sort", sort.Column, "error", err)
 		http.Error(w, "Invalid cursor format", http.StatusBadRequest)
 		return
 	}
@@ -242,16 +546,42 @@ func (h *KnoksHandler) GetKnoksByServer(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Parse optional date range
+	from, to, err := parseDateRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional extraction status filter, replacing the implicit complete one
+	status, err := parseStatus(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional platform filter; an empty one gets every platform
+	platform := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("platform")))
+
 	// Request one more item than the limit to determine if there are more results
-	knoks, err := h.knokRepo.GetRecentByServer(ctx, serverID, cursor, limit+1)
+	var knoks []*domain.Knok
+	if platform != "" {
+		knoks, err = h.knokRepo.GetByPlatformCursor(ctx, serverID, platform, sort, cursor, from, to, status, limit+1)
+	} else {
+		knoks, err = h.knokRepo.GetRecentByServer(ctx, serverID, sort, cursor, from, to, status, limit+1)
+	}
 	if err != nil {
-		h.logger.Error("Failed to retrieve knoks", "error", err, "server_id", serverID)
+		h.logger.Error("Failed to retrieve knoks", "error", err, "server_id", serverID, "platform", platform, "status", status)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := h.buildKnokResponse(knoks, limit)
-	h.logger.Info("Retrieved knoks", "count", len(response.Knoks), "server_id", serverID, "has_more", response.HasMore)
+	response := h.buildKnokResponse(knoks, limit, sort)
+	h.logger.Info("Retrieved knoks", "count", len(response.Knoks), "server_id", serverID, "platform", platform, "has_more", response.HasMore)
+
+	if setCacheHeaders(w, r, knoksETag(knoks...), timelineMaxAge) {
+		return
+	}
 	h.writeJSONResponse(w, response)
 }
 
@@ -285,15 +615,13 @@ func (h *KnoksHandler) DeleteKnok(w http.ResponseWriter, r *http.Request) {
 	// Check if knok exists and get its details before deletion
 	knok, err := h.knokRepo.GetByID(ctx, knokID)
 	if err != nil {
-		h.logger.Error("Failed to get knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Knok not found", http.StatusNotFound)
+		writeRepositoryError(w, h.logger, err, "Failed to get knok", "knok_id", knokID)
 		return
 	}
 
 	// Delete the knok
 	if err := h.knokRepo.Delete(ctx, knokID); err != nil {
-		h.logger.Error("Failed to delete knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to delete knok", "knok_id", knokID)
 		return
 	}
 
@@ -319,6 +647,51 @@ func (h *KnoksHandler) DeleteKnok(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DeleteUserKnoksResponse represents the response when a user's knoks are deleted
+type DeleteUserKnoksResponse struct {
+	Message  string  `json:"message"`
+	UserID   string  `json:"user_id"`
+	ServerID *string `json:"server_id,omitempty"`
+	Deleted  int     `json:"deleted"`
+}
+
+// DeleteUserKnoks handles DELETE /api/v1/admin/users/{userId}/knoks?server_id=...
+// Every knok posted by the Discord user is soft-deleted, only within server_id if given,
+// so a user's contributions can be removed on request
+func (h *KnoksHandler) DeleteUserKnoks(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var serverID *string
+	if id := r.URL.Query().Get("server_id"); id != "" {
+		serverID = &id
+	}
+
+	deleted, err := h.knokRepo.SoftDeleteByUser(r.Context(), userID, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to delete user knoks", "discord_user_id", userID)
+		return
+	}
+
+	h.logger.Info("User knoks deleted via admin API", "discord_user_id", userID, "server_id", r.URL.Query().Get("server_id"), "deleted", deleted)
+
+	response := DeleteUserKnoksResponse{
+		Message:  "User knoks deleted successfully",
+		UserID:   userID,
+		ServerID: serverID,
+		Deleted:  deleted,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode delete response", "error", err)
+	}
+}
+
 // UpdateKnokRequest represents the request body for updating a knok
 type UpdateKnokRequest struct {
 	Title       *string `json:"title,omitempty"`
@@ -355,8 +728,7 @@ func (h *KnoksHandler) UpdateKnok(w http.ResponseWriter, r *http.Request) {
 	// Check if knok exists
 	knok, err := h.knokRepo.GetByID(ctx, knokID)
 	if err != nil {
-		h.logger.Error("Failed to get knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Knok not found", http.StatusNotFound)
+		writeRepositoryError(w, h.logger, err, "Failed to get knok", "knok_id", knokID)
 		return
 	}
 
@@ -383,26 +755,14 @@ func (h *KnoksHandler) UpdateKnok(w http.ResponseWriter, r *http.Request) {
 
 	// Update the knok
 	if err := h.knokRepo.Update(ctx, knok); err != nil {
-		h.logger.Error("Failed to update knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to update knok", "knok_id", knokID)
 		return
 	}
 
 	h.logger.Info("Knok updated successfully", "knok_id", knokID, "title", knok.Title)
 
 	// Return updated knok
-	title := "Processing..."
-	if knok.Title != nil {
-		title = *knok.Title
-	}
-
-	response := &KnokDto{
-		Title:    title,
-		PostedAt: knok.PostedAt,
-		ID:       knok.ID.String(),
-		URL:      knok.URL,
-		Metadata: knok.Metadata,
-	}
+	response := newKnokDto(knok)
 
 	h.writeJSONResponse(w, response)
 }
@@ -444,8 +804,7 @@ func (h *KnoksHandler) RefreshKnok(w http.ResponseWriter, r *http.Request) {
 	// Check if knok exists
 	knok, err := h.knokRepo.GetByID(ctx, knokID)
 	if err != nil {
-		h.logger.Error("Failed to get knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Knok not found", http.StatusNotFound)
+		writeRepositoryError(w, h.logger, err, "Failed to get knok", "knok_id", knokID)
 		return
 	}
 
@@ -464,19 +823,12 @@ func (h *KnoksHandler) RefreshKnok(w http.ResponseWriter, r *http.Request) {
 
 	// Update the knok in database
 	if err := h.knokRepo.Update(ctx, knok); err != nil {
-		h.logger.Error("Failed to update knok", "error", err, "knok_id", knokID)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeRepositoryError(w, h.logger, err, "Failed to update knok", "knok_id", knokID)
 		return
 	}
 
-	// Queue metadata extraction job
-	jobPayload := map[string]interface{}{
-		"knok_id":  knokID.String(),
-		"url":      urlToUse,
-		"platform": knok.Platform,
-	}
-
-	if err := h.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadata, jobPayload); err != nil {
+	// Admin refreshes jump ahead of any backlog of initial extractions
+	if err := h.queueExtraction(ctx, knok, true); err != nil {
 		h.logger.Error("Failed to queue metadata extraction job",
 			"error", err,
 			"knok_id", knokID,
@@ -498,18 +850,286 @@ func (h *KnoksHandler) RefreshKnok(w http.ResponseWriter, r *http.Request) {
 	)
 
 	// Return updated knok
-	title := "Processing..."
-	if knok.Title != nil {
-		title = *knok.Title
+	response := newKnokDto(knok)
+
+	h.writeJSONResponse(w, response)
+}
+
+// queueExtraction queues a metadata extraction job for knok's URL, ahead of the backlog of
+// initial extractions when priority is set
+func (h *KnoksHandler) queueExtraction(ctx context.Context, knok *domain.Knok, priority bool) error {
+	jobPayload := map[string]interface{}{
+		"knok_id":  knok.ID.String(),
+		"url":      knok.URL,
+		"platform": knok.Platform,
+	}
+
+	if priority {
+		return h.queueRepo.EnqueuePriority(ctx, domain.JobTypeExtractMetadata, jobPayload)
+	}
+	return h.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadata, jobPayload)
+}
+
+// requeueExtraction sets knok back to pending and queues it for extraction behind the
+// backlog, restoring its previous status if the job can't be queued so a later bulk refresh
+// picks it up again
+func (h *KnoksHandler) requeueExtraction(ctx context.Context, knok *domain.Knok) error {
+	status := knok.ExtractionStatus
+	if err := h.knokRepo.UpdateExtractionStatus(ctx, knok.ID, domain.ExtractionStatusPending); err != nil {
+		return fmt.Errorf("failed to update knok extraction status: %w", err)
+	}
+
+	if err := h.queueExtraction(ctx, knok, false); err != nil {
+		h.knokRepo.UpdateExtractionStatus(ctx, knok.ID, status)
+		return fmt.Errorf("failed to queue metadata extraction job: %w", err)
+	}
+	return nil
+}
+
+// reextractPageSize is how many knoks are loaded and queued at a time while re-extracting a platform
+const reextractPageSize = 100
+
+// reextractStatuses are the extraction statuses a platform re-extraction retries
+var reextractStatuses = []string{domain.ExtractionStatusFailed, domain.ExtractionStatusLowQuality}
+
+// ReextractPlatform handles POST /api/v1/admin/platforms/{id}/reextract. Every failed or
+// low quality knok on the platform is set back to pending and queued for extraction again,
+// for use after fixing an extractor for a platform that changed its pages. The jobs join
+// the normal queue rather than jumping ahead like a single refresh. Requeued knoks leave the
+// status filter, so the newest page is read again until it comes back short.
+func (h *KnoksHandler) ReextractPlatform(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	platform := r.PathValue("id")
+	known, err := h.knownPlatform(platform)
+	if err != nil {
+		h.logger.Error("Failed to load platforms", "error", err)
+		http.Error(w, "Failed to load platforms", http.StatusInternalServerError)
+		return
+	}
+	if !known {
+		http.Error(w, "Platform not found", http.StatusNotFound)
+		return
+	}
+
+	queued := 0
+	for {
+		knoks, err := h.knokRepo.GetByPlatformAndStatus(ctx, platform, reextractStatuses, reextractPageSize)
+		if err != nil {
+			h.logger.Error("Failed to list knoks to re-extract", "error", err, "platform", platform, "queued", queued)
+			http.Error(w, "Failed to list knoks to re-extract", http.StatusInternalServerError)
+			return
+		}
+
+		for _, knok := range knoks {
+			if err := h.requeueExtraction(ctx, knok); err != nil {
+				h.logger.Error("Failed to requeue knok for extraction", "error", err, "knok_id", knok.ID, "url", knok.URL, "queued", queued)
+				http.Error(w, "Failed to queue metadata extraction job", http.StatusInternalServerError)
+				return
+			}
+			queued++
+		}
+
+		if len(knoks) < reextractPageSize {
+			break
+		}
 	}
 
-	response := &KnokDto{
-		Title:    title,
-		PostedAt: knok.PostedAt,
-		ID:       knok.ID.String(),
-		URL:      knok.URL,
-		Metadata: knok.Metadata,
+	h.logger.Info("Platform knoks queued for re-extraction via admin API",
+		"platform", platform,
+		"queued", queued,
+	)
+
+	response := map[string]interface{}{
+		"message":   "Platform knoks queued for re-extraction",
+		"platform":  platform,
+		"queued":    queued,
+		"timestamp": time.Now().Format(time.RFC3339),
 	}
 
 	h.writeJSONResponse(w, response)
 }
+
+// Limits on how many failed knoks one POST /api/v1/admin/knoks/refresh-failed requeues
+const (
+	defaultRefreshFailedLimit = 100
+	maxRefreshFailedLimit     = 1000
+)
+
+// RefreshFailedKnoks handles POST /api/v1/admin/knoks/refresh-failed, queueing extraction
+// again for up to ?limit= failed knoks across all servers, newest first. Limits above the
+// maximum are cut to it. Requeued knoks are pending again, so the newest page is read again
+// until the limit is reached, and calling it repeatedly works through the rest.
+func (h *KnoksHandler) RefreshFailedKnoks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := defaultRefreshFailedLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = min(parsed, maxRefreshFailedLimit)
+		}
+	}
+
+	queued := 0
+	for queued < limit {
+		pageSize := min(reextractPageSize, limit-queued)
+		knoks, err := h.knokRepo.GetByStatus(ctx, domain.ExtractionStatusFailed, nil, pageSize)
+		if err != nil {
+			h.logger.Error("Failed to list failed knoks", "error", err, "queued", queued)
+			http.Error(w, "Failed to list failed knoks", http.StatusInternalServerError)
+			return
+		}
+
+		for _, knok := range knoks {
+			if err := h.requeueExtraction(ctx, knok); err != nil {
+				h.logger.Error("Failed to requeue knok for extraction", "error", err, "knok_id", knok.ID, "url", knok.URL, "queued", queued)
+				http.Error(w, "Failed to queue metadata extraction job", http.StatusInternalServerError)
+				return
+			}
+			queued++
+		}
+
+		if len(knoks) < pageSize {
+			break
+		}
+	}
+
+	h.logger.Info("Failed knoks queued for extraction via admin API", "queued", queued, "limit", limit)
+
+	response := map[string]interface{}{
+		"message":   "Failed knoks queued for extraction",
+		"queued":    queued,
+		"limit":     limit,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	h.writeJSONResponse(w, response)
+}
+
+// AdminKnokDto is the moderation view of a knok, including its extraction state
+type AdminKnokDto struct {
+	ID               string                 `json:"id"`
+	ServerID         string                 `json:"server_id"`
+	URL              string                 `json:"url"`
+	Platform         string                 `json:"platform"`
+	Title            *string                `json:"title"`
+	ExtractionStatus string                 `json:"extraction_status"`
+	ExtractionError  string                 `json:"extraction_error,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
+	DiscordMessageID string                 `json:"discord_message_id"`
+	DiscordChannelID string                 `json:"discord_channel_id"`
+	PostedAt         time.Time              `json:"posted_at"`
+	UpdatedAt        *time.Time             `json:"updated_at"`
+}
+
+// AdminKnoksResponse represents the paginated response for the admin knoks view
+type AdminKnoksResponse struct {
+	Knoks   []*AdminKnokDto `json:"knoks"`
+	HasMore bool            `json:"has_more"`
+	Cursor  *string         `json:"cursor,omitempty"`
+}
+
+// ListKnoksByStatus lists knoks across all servers by extraction status (default: failed),
+// or by extraction method when ?method= is given, for triaging extraction problems
+func (h *KnoksHandler) ListKnoksByStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	method := r.URL.Query().Get("method")
+	if method != "" {
+		if status != "" {
+			http.Error(w, "Filter by status or method, not both", http.StatusBadRequest)
+			return
+		}
+		if _, ok := domain.ExtractionMethodTiers[method]; !ok {
+			http.Error(w, "Invalid method: "+method, http.StatusBadRequest)
+			return
+		}
+	}
+	if status == "" && method == "" {
+		status = domain.ExtractionStatusFailed
+	}
+	if status != "" && !domain.IsValidExtractionStatus(status) {
+		http.Error(w, invalidStatusMessage, http.StatusBadRequest)
+		return
+	}
+
+	// Parse pagination parameters
+	limit := DefaultPaginationLimit
+
+	cursor, err := h.parseCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		h.logger.Warn("Invalid cursor format", "cursor", r.URL.Query().Get("cursor"), "error", err)
+		http.Error(w, "Invalid cursor format", http.StatusBadRequest)
+		return
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	// Request one more item than the limit to determine if there are more results
+	var knoks []*domain.Knok
+	if method != "" {
+		knoks, err = h.knokRepo.GetByExtractionMethod(ctx, method, cursor, limit+1)
+	} else {
+		knoks, err = h.knokRepo.GetByStatus(ctx, status, cursor, limit+1)
+	}
+	if err != nil {
+		h.logger.Error("Failed to retrieve knoks by status", "error", err, "status", status, "method", method)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	hasMore := len(knoks) > limit
+	if hasMore {
+		knoks = knoks[:limit]
+	}
+
+	knokDtos := make([]*AdminKnokDto, 0, len(knoks))
+	for _, knok := range knoks {
+		extractionError, _ := knok.Metadata["extraction_error"].(string)
+		knokDtos = append(knokDtos, &AdminKnokDto{
+			ID:               knok.ID.String(),
+			ServerID:         knok.ServerID,
+			URL:              knok.URL,
+			Platform:         knok.Platform,
+			Title:            knok.Title,
+			ExtractionStatus: knok.ExtractionStatus,
+			ExtractionError:  extractionError,
+			Metadata:         knok.Metadata,
+			DiscordMessageID: knok.DiscordMessageID,
+			DiscordChannelID: knok.DiscordChannelID,
+			PostedAt:         knok.PostedAt,
+			UpdatedAt:        knok.UpdatedAt,
+		})
+	}
+
+	response := &AdminKnoksResponse{
+		Knoks:   knokDtos,
+		HasMore: hasMore,
+	}
+	if hasMore && len(knoks) > 0 {
+		cursorStr := knoks[len(knoks)-1].PostedAt.Format(time.RFC3339)
+		response.Cursor = &cursorStr
+	}
+
+	h.logger.Info("Retrieved knoks by status", "status", status, "method", method, "count", len(knokDtos), "has_more", hasMore)
+	h.writeJSONResponse(w, response)
+}
+
+// GetExtractionReport handles GET /api/v1/admin/extraction-report - extraction
+// success rate and average tier per platform
+func (h *KnoksHandler) GetExtractionReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.knokRepo.GetExtractionReport(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to compute extraction report", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Computed extraction report", "platforms_count", len(report.Platforms))
+	h.writeJSONResponse(w, report)
+}
@@ -0,0 +1,1129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeKnokRepo serves knoks from memory; other methods are unimplemented
+type fakeKnokRepo struct {
+	domain.KnokRepository
+	knoks []*domain.Knok
+}
+
+// page returns up to limit of the knoks matching keep, in sort order after cursor
+func (r *fakeKnokRepo) page(sort domain.KnokSort, cursor *domain.KnokCursor, limit int, keep func(*domain.Knok) bool) []*domain.Knok {
+	compare := func(a, b *domain.Knok) int {
+		var c int
+		switch sort.Column {
+		case domain.KnokSortTitle:
+			c = strings.Compare(strings.ToLower(*a.Title), strings.ToLower(*b.Title))
+			if c == 0 {
+				c = strings.Compare(a.ID.String(), b.ID.String())
+			}
+		case domain.KnokSortCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		default:
+			c = a.PostedAt.Compare(b.PostedAt)
+		}
+		if !sort.Ascending {
+			c = -c
+		}
+		return c
+	}
+
+	var last *domain.Knok
+	if cursor != nil {
+		last = &domain.Knok{PostedAt: cursor.Time, CreatedAt: cursor.Time}
+		for _, knok := range r.knoks {
+			if knok.ID == cursor.KnokID {
+				last = knok
+			}
+		}
+	}
+
+	var knoks []*domain.Knok
+	for _, knok := range r.knoks {
+		if keep(knok) && (last == nil || compare(last, knok) < 0) {
+			knoks = append(knoks, knok)
+		}
+	}
+	slices.SortStableFunc(knoks, compare)
+	if len(knoks) > limit {
+		knoks = knoks[:limit]
+	}
+	return knoks
+}
+
+// postedIn reports whether knok was posted in [from, to]
+func postedIn(knok *domain.Knok, from, to *time.Time) bool {
+	return (from == nil || !knok.PostedAt.Before(*from)) && (to == nil || !knok.PostedAt.After(*to))
+}
+
+// hasStatus reports whether knok has extraction status, or is complete when status is empty
+func hasStatus(knok *domain.Knok, status string) bool {
+	if status == "" {
+		status = domain.ExtractionStatusComplete
+	}
+	return knok.ExtractionStatus == status
+}
+
+func (r *fakeKnokRepo) GetRecent(ctx context.Context, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return r.page(sort, cursor, limit, func(knok *domain.Knok) bool {
+		return hasStatus(knok, status) && postedIn(knok, from, to)
+	}), nil
+}
+
+func (r *fakeKnokRepo) GetRecentByPlatform(ctx context.Context, platform string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return r.page(sort, cursor, limit, func(knok *domain.Knok) bool {
+		return knok.Platform == platform && hasStatus(knok, status) && postedIn(knok, from, to)
+	}), nil
+}
+
+func (r *fakeKnokRepo) GetRecentByServer(ctx context.Context, serverID string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return r.page(sort, cursor, limit, func(knok *domain.Knok) bool {
+		return knok.ServerID == serverID && hasStatus(knok, status) && postedIn(knok, from, to)
+	}), nil
+}
+
+func (r *fakeKnokRepo) GetByPlatformCursor(ctx context.Context, serverID, platform string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return r.page(sort, cursor, limit, func(knok *domain.Knok) bool {
+		return knok.ServerID == serverID && knok.Platform == platform && hasStatus(knok, status) && postedIn(knok, from, to)
+	}), nil
+}
+
+func (r *fakeKnokRepo) GetByPlatformAndStatus(ctx context.Context, platform string, statuses []string, limit int) ([]*domain.Knok, error) {
+	return r.page(domain.DefaultKnokSort, nil, limit, func(knok *domain.Knok) bool {
+		return knok.Platform == platform && slices.Contains(statuses, knok.ExtractionStatus)
+	}), nil
+}
+
+func (r *fakeKnokRepo) GetByStatus(ctx context.Context, status string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
+	var after *domain.KnokCursor
+	if cursor != nil {
+		after = &domain.KnokCursor{Time: *cursor}
+	}
+	return r.page(domain.DefaultKnokSort, after, limit, func(knok *domain.Knok) bool { return knok.ExtractionStatus == status }), nil
+}
+
+func (r *fakeKnokRepo) UpdateExtractionStatus(ctx context.Context, id uuid.UUID, status string) error {
+	knok, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	knok.ExtractionStatus = status
+	return nil
+}
+
+func (r *fakeKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	for _, knok := range r.knoks {
+		if knok.ID == id {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) SoftDeleteByUser(ctx context.Context, userID string, serverID *string) (int, error) {
+	kept := r.knoks[:0]
+	deleted := 0
+	for _, knok := range r.knoks {
+		if knok.DiscordUserID != nil && *knok.DiscordUserID == userID && (serverID == nil || knok.ServerID == *serverID) {
+			deleted++
+			continue
+		}
+		kept = append(kept, knok)
+	}
+	r.knoks = kept
+	return deleted, nil
+}
+
+func newTestKnok(title string, postedAt time.Time) *domain.Knok {
+	return &domain.Knok{
+		ID:               uuid.New(),
+		URL:              "https://example.com/" + strings.ReplaceAll(title, " ", "-"),
+		Title:            &title,
+		ExtractionStatus: domain.ExtractionStatusComplete,
+		PostedAt:         postedAt,
+	}
+}
+
+func TestGetKnoksDateRange(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{
+		newTestKnok("march", base),
+		newTestKnok("february", base.AddDate(0, -1, 0)),
+		newTestKnok("january", base.AddDate(0, -2, 0)),
+	}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTitles []string
+	}{
+		{"No range", "", http.StatusOK, []string{"march", "february", "january"}},
+		{"From a date", "?from=2026-02-01", http.StatusOK, []string{"march", "february"}},
+		{"Explicit range", "?from=2026-01-01&to=2026-02-15T00:00:00Z", http.StatusOK, []string{"february", "january"}},
+		{"Invalid from", "?from=last-month", http.StatusBadRequest, nil},
+		{"Invalid to", "?to=03/01/2026", http.StatusBadRequest, nil},
+		{"Single instant", "?from=2026-02-01T12:00:00Z&to=2026-02-01T12:00:00Z", http.StatusOK, []string{"february"}},
+		{"Empty range", "?from=2026-03-01&to=2026-02-01", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.GetKnoks(rec, httptest.NewRequest(http.MethodGet, "/api/v1/knoks"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, len(resp.Knoks))
+			for i, knok := range resp.Knoks {
+				titles[i] = knok.Title
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func TestGetKnoksByServerDateRange(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	serverKnok := func(title, serverID string, days int) *domain.Knok {
+		knok := newTestKnok(title, base.AddDate(0, 0, -days))
+		knok.ServerID = serverID
+		knok.Platform = "spotify"
+		return knok
+	}
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{
+		serverKnok("today", "guild-1", 0),
+		serverKnok("yesterday", "guild-1", 1),
+		serverKnok("other server", "guild-2", 2),
+		serverKnok("last week", "guild-1", 7),
+		serverKnok("last month", "guild-1", 30),
+	}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTitles []string
+		wantCursor string
+	}{
+		{name: "From a date", query: "?from=2026-02-22", wantStatus: http.StatusOK, wantTitles: []string{"today", "yesterday", "last week"}},
+		{name: "To is inclusive", query: "?to=2026-02-22T12:00:00Z", wantStatus: http.StatusOK, wantTitles: []string{"last week", "last month"}},
+		{
+			name:       "First page of a range",
+			query:      "?from=2026-02-01&to=2026-02-28T12:00:00Z&limit=1",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"yesterday"},
+			wantCursor: base.AddDate(0, 0, -1).Format(time.RFC3339),
+		},
+		{
+			name:       "Next page keeps the range",
+			query:      "?from=2026-02-01&to=2026-02-28T12:00:00Z&limit=1&cursor=" + base.AddDate(0, 0, -1).Format(time.RFC3339),
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"last week"},
+		},
+		{name: "With a platform", query: "?platform=spotify&from=2026-02-28", wantStatus: http.StatusOK, wantTitles: []string{"today", "yesterday"}},
+		{name: "Invalid from", query: "?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "From after to", query: "?from=2026-03-01&to=2026-02-01", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/server/guild-1"+tt.query, nil)
+			req.SetPathValue("serverId", "guild-1")
+			rec := httptest.NewRecorder()
+
+			handler.GetKnoksByServer(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, 0, len(resp.Knoks))
+			for _, knok := range resp.Knoks {
+				titles = append(titles, knok.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+
+			gotCursor := ""
+			if resp.Cursor != nil {
+				gotCursor = *resp.Cursor
+			}
+			if gotCursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", gotCursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestGetKnoksStatus(t *testing.T) {
+	base := time.Now()
+	statusKnok := func(title, status string, minutes int) *domain.Knok {
+		knok := newTestKnok(title, base.Add(-time.Duration(minutes)*time.Minute))
+		knok.ServerID = "guild-1"
+		knok.Platform = "spotify"
+		knok.ExtractionStatus = status
+		return knok
+	}
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{
+		statusKnok("done", domain.ExtractionStatusComplete, 0),
+		statusKnok("broken", domain.ExtractionStatusFailed, 1),
+		statusKnok("queued", domain.ExtractionStatusPending, 2),
+		statusKnok("also broken", domain.ExtractionStatusFailed, 3),
+	}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name         string
+		server       bool
+		query        string
+		wantStatus   int
+		wantTitles   []string
+		wantStatuses []string
+	}{
+		{name: "Complete by default", query: "", wantStatus: http.StatusOK, wantTitles: []string{"done"}, wantStatuses: []string{"complete"}},
+		{name: "Failed", query: "?status=failed", wantStatus: http.StatusOK, wantTitles: []string{"broken", "also broken"}, wantStatuses: []string{"failed", "failed"}},
+		{name: "Pending with a platform", query: "?status=PENDING&platform=spotify", wantStatus: http.StatusOK, wantTitles: []string{"queued"}, wantStatuses: []string{"pending"}},
+		{name: "Server timeline", server: true, query: "?status=failed&limit=1", wantStatus: http.StatusOK, wantTitles: []string{"broken"}, wantStatuses: []string{"failed"}},
+		{name: "Server timeline with a platform", server: true, query: "?status=complete&platform=spotify", wantStatus: http.StatusOK, wantTitles: []string{"done"}, wantStatuses: []string{"complete"}},
+		{name: "Invalid status", query: "?status=broken", wantStatus: http.StatusBadRequest},
+		{name: "Invalid status on a server", server: true, query: "?status=done", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if tt.server {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/server/guild-1"+tt.query, nil)
+				req.SetPathValue("serverId", "guild-1")
+				handler.GetKnoksByServer(rec, req)
+			} else {
+				handler.GetKnoks(rec, httptest.NewRequest(http.MethodGet, "/api/v1/knoks"+tt.query, nil))
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, 0, len(resp.Knoks))
+			statuses := make([]string, 0, len(resp.Knoks))
+			for _, knok := range resp.Knoks {
+				titles = append(titles, knok.Title)
+				statuses = append(statuses, knok.ExtractionStatus)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("extraction statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
+
+func TestGetKnoksSort(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sortKnok := func(title string, postedAge, createdAge int) *domain.Knok {
+		knok := newTestKnok(title, base.Add(-time.Duration(postedAge)*time.Hour))
+		knok.CreatedAt = base.Add(-time.Duration(createdAge) * time.Minute)
+		return knok
+	}
+	charlie := sortKnok("charlie", 0, 2)
+	alpha := sortKnok("Alpha", 1, 0)
+	bravo := sortKnok("bravo", 2, 1)
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{charlie, alpha, bravo}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTitles []string
+		wantCursor string
+	}{
+		{name: "Default is newest posted", query: "", wantStatus: http.StatusOK, wantTitles: []string{"charlie", "Alpha", "bravo"}},
+		{name: "Posted oldest first", query: "?sort=posted_at&order=asc", wantStatus: http.StatusOK, wantTitles: []string{"bravo", "Alpha", "charlie"}},
+		{name: "Created newest first", query: "?sort=created_at", wantStatus: http.StatusOK, wantTitles: []string{"Alpha", "bravo", "charlie"}},
+		{name: "Title defaults to ascending", query: "?sort=title", wantStatus: http.StatusOK, wantTitles: []string{"Alpha", "bravo", "charlie"}},
+		{name: "Title descending", query: "?sort=title&order=DESC", wantStatus: http.StatusOK, wantTitles: []string{"charlie", "bravo", "Alpha"}},
+		{
+			name:       "Title cursor is the last knok",
+			query:      "?sort=title&limit=1",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"Alpha"},
+			wantCursor: alpha.ID.String(),
+		},
+		{
+			name:       "Title next page",
+			query:      "?sort=title&limit=1&cursor=" + alpha.ID.String(),
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"bravo"},
+			wantCursor: bravo.ID.String(),
+		},
+		{
+			name:       "Created cursor keeps sub-second precision",
+			query:      "?sort=created_at&limit=1",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"Alpha"},
+			wantCursor: alpha.CreatedAt.Format(time.RFC3339Nano),
+		},
+		{name: "Invalid sort", query: "?sort=url", wantStatus: http.StatusBadRequest},
+		{name: "Invalid order", query: "?sort=title&order=up", wantStatus: http.StatusBadRequest},
+		{name: "Timestamp cursor with title sort", query: "?sort=title&cursor=2026-03-01T12:00:00Z", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.GetKnoks(rec, httptest.NewRequest(http.MethodGet, "/api/v1/knoks"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, 0, len(resp.Knoks))
+			for _, knok := range resp.Knoks {
+				titles = append(titles, knok.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+
+			gotCursor := ""
+			if resp.Cursor != nil {
+				gotCursor = *resp.Cursor
+			}
+			if gotCursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", gotCursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestCachingHeaders(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	knok := newTestKnok("first", base)
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{newTestKnok("second", base.Add(time.Hour)), knok}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name         string
+		path         string
+		serve        http.HandlerFunc
+		pathValues   map[string]string
+		cacheControl string
+	}{
+		{
+			name:         "Timeline",
+			path:         "/api/v1/knoks",
+			serve:        handler.GetKnoks,
+			cacheControl: "public, max-age=30",
+		},
+		{
+			name:         "Knok by ID",
+			path:         "/api/v1/knoks/" + knok.ID.String(),
+			serve:        handler.GetKnokByID,
+			pathValues:   map[string]string{"id": knok.ID.String()},
+			cacheControl: "public, max-age=3600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newRequest := func(ifNoneMatch string) *http.Request {
+				req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				for key, value := range tt.pathValues {
+					req.SetPathValue(key, value)
+				}
+				if ifNoneMatch != "" {
+					req.Header.Set("If-None-Match", ifNoneMatch)
+				}
+				return req
+			}
+
+			rec := httptest.NewRecorder()
+			tt.serve(rec, newRequest(""))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+			etag := rec.Header().Get("ETag")
+			if etag == "" {
+				t.Fatal("ETag header not set")
+			}
+
+			// A matching If-None-Match gets a 304 with no body
+			rec = httptest.NewRecorder()
+			tt.serve(rec, newRequest(etag))
+
+			if rec.Code != http.StatusNotModified {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotModified)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("304 response has body %q", rec.Body.String())
+			}
+
+			// A stale ETag gets the full response
+			rec = httptest.NewRecorder()
+			tt.serve(rec, newRequest(`W/"stale"`))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d for stale ETag", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestKnoksETagChangesOnUpdate(t *testing.T) {
+	knok := newTestKnok("first", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	before := knoksETag(knok)
+
+	updatedAt := knok.PostedAt.Add(time.Minute)
+	knok.UpdatedAt = &updatedAt
+
+	if after := knoksETag(knok); after == before {
+		t.Errorf("ETag %s unchanged after knok update", after)
+	}
+}
+
+func TestGetKnokByIDNotFound(t *testing.T) {
+	handler := NewKnoksHandler(createTestLogger(), &fakeKnokRepo{}, nil)
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/"+id, nil)
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+
+	handler.GetKnokByID(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetKnokByIDPlaceholderTitle(t *testing.T) {
+	tests := []struct {
+		status    string
+		wantTitle string
+	}{
+		{domain.ExtractionStatusPending, "Processing..."},
+		{domain.ExtractionStatusProcessing, "Processing..."},
+		{domain.ExtractionStatusFailed, "Unavailable"},
+		{domain.ExtractionStatusRestricted, "Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			knok := newTestKnok("untitled", time.Now())
+			knok.Title = nil
+			knok.ExtractionStatus = tt.status
+			handler := NewKnoksHandler(createTestLogger(), &fakeKnokRepo{knoks: []*domain.Knok{knok}}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/"+knok.ID.String(), nil)
+			req.SetPathValue("id", knok.ID.String())
+			rec := httptest.NewRecorder()
+
+			handler.GetKnokByID(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			var dto KnokDto
+			if err := json.NewDecoder(rec.Body).Decode(&dto); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if dto.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", dto.Title, tt.wantTitle)
+			}
+			if dto.ExtractionStatus != tt.status {
+				t.Errorf("extraction_status = %q, want %q", dto.ExtractionStatus, tt.status)
+			}
+		})
+	}
+}
+
+func TestNewKnokDtoDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     *int
+	}{
+		{"Freshly extracted", map[string]interface{}{"duration_seconds": 213}, intPtr(213)},
+		{"Read back from the database", map[string]interface{}{"duration_seconds": float64(213)}, intPtr(213)},
+		{"No duration", map[string]interface{}{"title": "Late Night Mix"}, nil},
+		{"No metadata", nil, nil},
+		{"Invalid duration", map[string]interface{}{"duration_seconds": "3:33"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knok := newTestKnok("track", time.Now())
+			knok.Metadata = tt.metadata
+
+			got := newKnokDto(knok).DurationSeconds
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("DurationSeconds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKnokDtoThumbnailURL(t *testing.T) {
+	knok := newTestKnok("track", time.Now())
+	if got := newKnokDto(knok).ThumbnailURL; got != nil {
+		t.Errorf("ThumbnailURL = %q, want nil before extraction", *got)
+	}
+
+	thumbnail := "https://example.com/cover.jpg"
+	knok.ThumbnailURL = &thumbnail
+	if got := newKnokDto(knok).ThumbnailURL; got == nil || *got != thumbnail {
+		t.Errorf("ThumbnailURL = %v, want %s", got, thumbnail)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{"Empty header", "", `W/"abc"`, false},
+		{"Exact match", `W/"abc"`, `W/"abc"`, true},
+		{"Strong form of weak ETag", `"abc"`, `W/"abc"`, true},
+		{"Match in list", `"xyz", W/"abc"`, `W/"abc"`, true},
+		{"Wildcard", "*", `W/"abc"`, true},
+		{"No match", `W/"xyz"`, `W/"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteUserKnoks(t *testing.T) {
+	userKnok := func(userID, serverID string) *domain.Knok {
+		knok := newTestKnok("Track", time.Now())
+		knok.DiscordUserID = &userID
+		knok.ServerID = serverID
+		return knok
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		query       string
+		wantStatus  int
+		wantDeleted int
+		wantKept    int
+	}{
+		{name: "Deletes across servers", userID: "user-1", wantStatus: http.StatusOK, wantDeleted: 2, wantKept: 1},
+		{name: "Scoped to a server", userID: "user-1", query: "?server_id=guild-2", wantStatus: http.StatusOK, wantDeleted: 1, wantKept: 2},
+		{name: "User without knoks", userID: "user-3", wantStatus: http.StatusOK, wantDeleted: 0, wantKept: 3},
+		{name: "Missing user ID", userID: "", wantStatus: http.StatusBadRequest, wantKept: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeKnokRepo{knoks: []*domain.Knok{
+				userKnok("user-1", "guild-1"),
+				userKnok("user-1", "guild-2"),
+				userKnok("user-2", "guild-1"),
+			}}
+			handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/"+tt.userID+"/knoks"+tt.query, nil)
+			req.SetPathValue("userId", tt.userID)
+			rec := httptest.NewRecorder()
+			handler.DeleteUserKnoks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if len(repo.knoks) != tt.wantKept {
+				t.Errorf("%d knoks left, want %d", len(repo.knoks), tt.wantKept)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp DeleteUserKnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Deleted != tt.wantDeleted || resp.UserID != tt.userID {
+				t.Errorf("response = %+v, want %d deleted for %s", resp, tt.wantDeleted, tt.userID)
+			}
+		})
+	}
+}
+
+// fakeExtractionQueue records the knok IDs of enqueued extraction jobs, failing after
+// failAfter jobs when it's set; other methods are unimplemented
+type fakeExtractionQueue struct {
+	domain.QueueRepository
+	knokIDs   []string
+	failAfter int
+}
+
+func (q *fakeExtractionQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	if q.failAfter > 0 && len(q.knokIDs) >= q.failAfter {
+		return errors.New("queue unavailable")
+	}
+	q.knokIDs = append(q.knokIDs, payload.(map[string]interface{})["knok_id"].(string))
+	return nil
+}
+
+func TestReextractPlatform(t *testing.T) {
+	// More failed knoks than fit in one page, among others that shouldn't be queued
+	base := time.Now()
+	var knoks []*domain.Knok
+	for i := 0; i < 2*reextractPageSize+10; i++ {
+		knok := newTestKnok(fmt.Sprintf("Track %d", i), base.Add(-time.Duration(i)*time.Minute))
+		knok.Platform = "soundcloud"
+		knok.ExtractionStatus = domain.ExtractionStatusFailed
+		switch i % 10 {
+		case 1:
+			knok.ExtractionStatus = domain.ExtractionStatusLowQuality
+		case 2:
+			knok.ExtractionStatus = domain.ExtractionStatusComplete
+		case 3:
+			knok.Platform = "youtube"
+		}
+		knoks = append(knoks, knok)
+	}
+	var want []string
+	for _, knok := range knoks {
+		if knok.Platform == "soundcloud" && knok.ExtractionStatus != domain.ExtractionStatusComplete {
+			want = append(want, knok.ID.String())
+		}
+	}
+
+	tests := []struct {
+		name       string
+		platform   string
+		failAfter  int
+		wantStatus int
+		wantQueued int
+	}{
+		{name: "Queues failed and low quality knoks", platform: "soundcloud", wantStatus: http.StatusOK, wantQueued: len(want)},
+		{name: "Platform without failures", platform: "bandcamp", wantStatus: http.StatusOK, wantQueued: 0},
+		{name: "Unknown platform", platform: "myspace", wantStatus: http.StatusNotFound},
+		{name: "Queue failure", platform: "soundcloud", failAfter: 5, wantStatus: http.StatusInternalServerError, wantQueued: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seeded := make([]*domain.Knok, len(knoks))
+			for i, knok := range knoks {
+				copied := *knok
+				seeded[i] = &copied
+			}
+			repo := &fakeKnokRepo{knoks: seeded}
+			queue := &fakeExtractionQueue{failAfter: tt.failAfter}
+			handler := NewKnoksHandler(createTestLogger(), repo, queue)
+			handler.SetPlatforms(&fakePlatformLoader{platforms: []*domain.Platform{
+				{ID: "soundcloud"}, {ID: "youtube"}, {ID: "bandcamp"},
+			}})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/platforms/"+tt.platform+"/reextract", nil)
+			req.SetPathValue("id", tt.platform)
+			rec := httptest.NewRecorder()
+			handler.ReextractPlatform(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if len(queue.knokIDs) != tt.wantQueued {
+				t.Fatalf("%d jobs queued, want %d", len(queue.knokIDs), tt.wantQueued)
+			}
+
+			// Queued knoks are pending again; the knok whose job failed keeps its status
+			pending := 0
+			for _, knok := range repo.knoks {
+				if knok.ExtractionStatus == domain.ExtractionStatusPending {
+					pending++
+				}
+			}
+			if pending != tt.wantQueued {
+				t.Errorf("%d knoks pending, want %d", pending, tt.wantQueued)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if tt.wantQueued > 0 && !reflect.DeepEqual(queue.knokIDs, want) {
+				t.Errorf("queued knoks = %v, want %v", queue.knokIDs, want)
+			}
+			var resp map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp["queued"] != float64(tt.wantQueued) || resp["platform"] != tt.platform {
+				t.Errorf("response = %v, want %d queued for %s", resp, tt.wantQueued, tt.platform)
+			}
+		})
+	}
+}
+
+func TestReextractPlatformTiedPostedAt(t *testing.T) {
+	// Failed knoks from one message share a posted_at across page boundaries
+	postedAt := time.Now()
+	repo := &fakeKnokRepo{}
+	for i := 0; i < 2*reextractPageSize+10; i++ {
+		knok := newTestKnok(fmt.Sprintf("Track %d", i), postedAt)
+		knok.Platform = "soundcloud"
+		knok.ExtractionStatus = domain.ExtractionStatusFailed
+		repo.knoks = append(repo.knoks, knok)
+	}
+	queue := &fakeExtractionQueue{}
+	handler := NewKnoksHandler(createTestLogger(), repo, queue)
+	handler.SetPlatforms(&fakePlatformLoader{platforms: []*domain.Platform{{ID: "soundcloud"}}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/platforms/soundcloud/reextract", nil)
+	req.SetPathValue("id", "soundcloud")
+	rec := httptest.NewRecorder()
+	handler.ReextractPlatform(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if len(queue.knokIDs) != len(repo.knoks) {
+		t.Errorf("%d jobs queued, want %d", len(queue.knokIDs), len(repo.knoks))
+	}
+	for _, knok := range repo.knoks {
+		if knok.ExtractionStatus != domain.ExtractionStatusPending {
+			t.Errorf("knok %s status = %q, want pending", knok.ID, knok.ExtractionStatus)
+		}
+	}
+}
+
+func TestRefreshFailedKnoks(t *testing.T) {
+	base := time.Now()
+	repo := &fakeKnokRepo{}
+	for i := 0; i < 3*defaultRefreshFailedLimit; i++ {
+		knok := newTestKnok(fmt.Sprintf("Track %d", i), base.Add(-time.Duration(i)*time.Minute))
+		if i%5 != 4 {
+			knok.ExtractionStatus = domain.ExtractionStatusFailed
+		}
+		repo.knoks = append(repo.knoks, knok)
+	}
+	failed := func() int {
+		n := 0
+		for _, knok := range repo.knoks {
+			if knok.ExtractionStatus == domain.ExtractionStatusFailed {
+				n++
+			}
+		}
+		return n
+	}
+	total := failed()
+	queue := &fakeExtractionQueue{}
+	handler := NewKnoksHandler(createTestLogger(), repo, queue)
+
+	refresh := func(query string, wantStatus int) map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.RefreshFailedKnoks(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/knoks/refresh-failed"+query, nil))
+		if rec.Code != wantStatus {
+			t.Fatalf("status = %d, want %d (body: %s)", rec.Code, wantStatus, rec.Body.String())
+		}
+		var resp map[string]interface{}
+		if wantStatus == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return resp
+	}
+
+	// An explicit limit caps the batch, newest first
+	if resp := refresh("?limit=10", http.StatusOK); resp["queued"] != float64(10) {
+		t.Fatalf("response = %v, want 10 queued", resp)
+	}
+	if got := failed(); got != total-10 {
+		t.Fatalf("%d knoks still failed, want %d", got, total-10)
+	}
+	if queue.knokIDs[0] != repo.knoks[0].ID.String() {
+		t.Errorf("first queued knok = %s, want the newest failed knok", queue.knokIDs[0])
+	}
+
+	// An invalid limit falls back to the default cap
+	if resp := refresh("?limit=abc", http.StatusOK); resp["queued"] != float64(defaultRefreshFailedLimit) {
+		t.Fatalf("response = %v, want %d queued", resp, defaultRefreshFailedLimit)
+	}
+
+	// Requeued knoks are pending, so the next call picks up the rest. A limit above the
+	// maximum is cut to it rather than falling back to the default
+	remaining := total - 10 - defaultRefreshFailedLimit
+	if remaining <= defaultRefreshFailedLimit {
+		t.Fatalf("only %d knoks left to refresh, need more than the default limit", remaining)
+	}
+	if resp := refresh("?limit=5000", http.StatusOK); resp["queued"] != float64(remaining) {
+		t.Fatalf("response = %v, want the remaining %d queued", resp, remaining)
+	}
+	if got := failed(); got != 0 {
+		t.Errorf("%d knoks still failed, want 0", got)
+	}
+	if len(queue.knokIDs) != total {
+		t.Errorf("%d jobs queued, want %d", len(queue.knokIDs), total)
+	}
+
+	// A knok whose job can't be queued stays failed
+	repo.knoks[0].ExtractionStatus = domain.ExtractionStatusFailed
+	queue.failAfter = len(queue.knokIDs)
+	refresh("", http.StatusInternalServerError)
+	if got := failed(); got != 1 {
+		t.Errorf("%d knoks failed after a queue error, want 1", got)
+	}
+}
+
+func TestRefreshFailedKnoksTiedPostedAt(t *testing.T) {
+	// Failed knoks from one message share a posted_at across page boundaries
+	postedAt := time.Now()
+	repo := &fakeKnokRepo{}
+	for i := 0; i < 2*reextractPageSize; i++ {
+		knok := newTestKnok(fmt.Sprintf("Track %d", i), postedAt)
+		knok.ExtractionStatus = domain.ExtractionStatusFailed
+		repo.knoks = append(repo.knoks, knok)
+	}
+	queue := &fakeExtractionQueue{}
+	handler := NewKnoksHandler(createTestLogger(), repo, queue)
+
+	limit := reextractPageSize + reextractPageSize/2
+	rec := httptest.NewRecorder()
+	handler.RefreshFailedKnoks(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/admin/knoks/refresh-failed?limit=%d", limit), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if len(queue.knokIDs) != limit {
+		t.Errorf("%d jobs queued, want %d", len(queue.knokIDs), limit)
+	}
+	pending := 0
+	for _, knok := range repo.knoks {
+		if knok.ExtractionStatus == domain.ExtractionStatusPending {
+			pending++
+		}
+	}
+	if pending != limit {
+		t.Errorf("%d knoks pending, want %d", pending, limit)
+	}
+}
+
+func TestGetKnoksByServerPlatform(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	platformKnok := func(title, serverID, platform string, age int) *domain.Knok {
+		knok := newTestKnok(title, base.Add(-time.Duration(age)*time.Hour))
+		knok.ServerID = serverID
+		knok.Platform = platform
+		return knok
+	}
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{
+		platformKnok("one", "guild-1", "spotify", 0),
+		platformKnok("two", "guild-1", "youtube", 1),
+		platformKnok("three", "guild-1", "spotify", 2),
+		platformKnok("four", "guild-2", "spotify", 3),
+		platformKnok("five", "guild-1", "spotify", 4),
+	}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantTitles []string
+		wantCursor string
+	}{
+		{name: "Filters by platform", query: "?platform=spotify", wantTitles: []string{"one", "three", "five"}},
+		{name: "Platform is case-insensitive", query: "?platform=Spotify", wantTitles: []string{"one", "three", "five"}},
+		{
+			name:       "First page",
+			query:      "?platform=spotify&limit=2",
+			wantTitles: []string{"one", "three"},
+			wantCursor: base.Add(-2 * time.Hour).Format(time.RFC3339),
+		},
+		{
+			name:       "Next page",
+			query:      "?platform=spotify&limit=2&cursor=" + base.Add(-2*time.Hour).Format(time.RFC3339),
+			wantTitles: []string{"five"},
+		},
+		{name: "Unknown platform", query: "?platform=myspace", wantTitles: []string{}},
+		{name: "Empty platform is unfiltered", query: "?platform=", wantTitles: []string{"one", "two", "three", "five"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/server/guild-1"+tt.query, nil)
+			req.SetPathValue("serverId", "guild-1")
+			rec := httptest.NewRecorder()
+
+			handler.GetKnoksByServer(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, 0, len(resp.Knoks))
+			for _, knok := range resp.Knoks {
+				titles = append(titles, knok.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.wantTitles, ",") {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+
+			gotCursor := ""
+			if resp.Cursor != nil {
+				gotCursor = *resp.Cursor
+			}
+			if gotCursor != tt.wantCursor || resp.HasMore != (tt.wantCursor != "") {
+				t.Errorf("cursor = %q, has_more = %v, want cursor %q", gotCursor, resp.HasMore, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestGetKnoksPlatform(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	platformKnok := func(title, serverID, platform string, age int) *domain.Knok {
+		knok := newTestKnok(title, base.Add(-time.Duration(age)*time.Hour))
+		knok.ServerID = serverID
+		knok.Platform = platform
+		return knok
+	}
+	repo := &fakeKnokRepo{knoks: []*domain.Knok{
+		platformKnok("one", "guild-1", "spotify", 0),
+		platformKnok("two", "guild-1", "youtube", 1),
+		platformKnok("three", "guild-2", "spotify", 2),
+		platformKnok("four", "guild-2", domain.PlatformUnknown, 3),
+		platformKnok("five", "guild-1", "spotify", 4),
+	}}
+	handler := NewKnoksHandler(createTestLogger(), repo, nil)
+	handler.SetPlatforms(&fakePlatformLoader{platforms: []*domain.Platform{
+		{ID: "spotify", Enabled: true},
+		{ID: "youtube", Enabled: true},
+	}})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTitles []string
+		wantCursor string
+	}{
+		{name: "Filters by platform across servers", query: "?platform=Spotify", wantStatus: http.StatusOK, wantTitles: []string{"one", "three", "five"}},
+		{
+			name:       "First page",
+			query:      "?platform=spotify&limit=2",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"one", "three"},
+			wantCursor: base.Add(-2 * time.Hour).Format(time.RFC3339),
+		},
+		{
+			name:       "Next page",
+			query:      "?platform=spotify&limit=2&cursor=" + base.Add(-2*time.Hour).Format(time.RFC3339),
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"five"},
+		},
+		{name: "Unclassified knoks", query: "?platform=unknown", wantStatus: http.StatusOK, wantTitles: []string{"four"}},
+		{name: "Unknown platform", query: "?platform=myspace", wantStatus: http.StatusBadRequest},
+		{name: "Combined with a date range", query: "?platform=spotify&to=2026-03-01T10:00:00Z", wantStatus: http.StatusOK, wantTitles: []string{"three", "five"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.GetKnoks(rec, httptest.NewRequest(http.MethodGet, "/api/v1/knoks"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KnoksResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			titles := make([]string, 0, len(resp.Knoks))
+			for _, knok := range resp.Knoks {
+				titles = append(titles, knok.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.wantTitles, ",") {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+
+			gotCursor := ""
+			if resp.Cursor != nil {
+				gotCursor = *resp.Cursor
+			}
+			if gotCursor != tt.wantCursor || resp.HasMore != (tt.wantCursor != "") {
+				t.Errorf("cursor = %q, has_more = %v, want cursor %q", gotCursor, resp.HasMore, tt.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"knock-fm/internal/domain"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultMaxWaitTimeout caps ?timeout= on WaitForKnok unless SetMaxWaitTimeout changes it
+	defaultMaxWaitTimeout = 30 * time.Second
+
+	// defaultWaitPollInterval is how often WaitForKnok re-reads the knok
+	defaultWaitPollInterval = time.Second
+
+	// waitWriteGrace is added to the wait when extending the write deadline, leaving time
+	// to write the response after the last poll
+	waitWriteGrace = 5 * time.Second
+)
+
+// SetMaxWaitTimeout sets the longest WaitForKnok holds a request open; longer requested
+// timeouts are cut to it
+func (h *KnoksHandler) SetMaxWaitTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.maxWaitTimeout = timeout
+	}
+}
+
+// extractionSettled reports whether extraction is done with a knok, successfully or not
+func extractionSettled(status string) bool {
+	return status != domain.ExtractionStatusPending && status != domain.ExtractionStatusProcessing
+}
+
+// WaitForKnok handles GET /api/v1/knoks/{id}/wait?timeout=30s. The knok is polled until
+// its extraction finishes or the timeout elapses, and returned either way; clients tell
+// the two apart by its extraction_status.
+func (h *KnoksHandler) WaitForKnok(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	knokID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid knok ID format", http.StatusBadRequest)
+		return
+	}
+
+	timeout := h.maxWaitTimeout
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		parsed, err := time.ParseDuration(timeoutStr)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid timeout, expected a duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		timeout = min(parsed, h.maxWaitTimeout)
+	}
+
+	// The server's write timeout is shorter than a long wait. Writers that can't extend
+	// it (e.g. in tests) just keep the server default
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + waitWriteGrace))
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(h.waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		knok, err := h.knokRepo.GetByID(ctx, knokID)
+		if err != nil {
+			writeRepositoryError(w, h.logger, err, "Failed to retrieve knok", "knok_id", knokID)
+			return
+		}
+		if extractionSettled(knok.ExtractionStatus) {
+			h.writeWaitResponse(w, knok)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			h.logger.Debug("Timed out waiting for knok extraction", "knok_id", knokID, "timeout", timeout.String())
+			h.writeWaitResponse(w, knok)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeWaitResponse writes the knok as it stands; its status changes, so it's never cached
+func (h *KnoksHandler) writeWaitResponse(w http.ResponseWriter, knok *domain.Knok) {
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSONResponse(w, newKnokDto(knok))
+}
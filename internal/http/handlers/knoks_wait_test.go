@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// extractingKnokRepo serves one knok that stays pending until it has been read
+// completeAfter times; completeAfter 0 means it never completes
+type extractingKnokRepo struct {
+	domain.KnokRepository
+	knok          *domain.Knok
+	completeAfter int
+	reads         int
+}
+
+func (r *extractingKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	if id != r.knok.ID {
+		return nil, domain.ErrKnokNotFound
+	}
+	r.reads++
+	knok := *r.knok
+	if r.completeAfter > 0 && r.reads >= r.completeAfter {
+		knok.ExtractionStatus = domain.ExtractionStatusComplete
+	}
+	return &knok, nil
+}
+
+func TestWaitForKnok(t *testing.T) {
+	tests := []struct {
+		name          string
+		completeAfter int
+		timeout       string
+		wantStatus    string
+		wantMinWait   time.Duration
+	}{
+		{
+			name:          "Completes before timeout",
+			completeAfter: 3,
+			timeout:       "5s",
+			wantStatus:    domain.ExtractionStatusComplete,
+		},
+		{
+			name:        "Times out with current status",
+			timeout:     "50ms",
+			wantStatus:  domain.ExtractionStatusPending,
+			wantMinWait: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knok := newTestKnok("Track", time.Now())
+			knok.ExtractionStatus = domain.ExtractionStatusPending
+			repo := &extractingKnokRepo{knok: knok, completeAfter: tt.completeAfter}
+			handler := NewKnoksHandler(createTestLogger(), repo, nil)
+			handler.waitPollInterval = time.Millisecond
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/"+knok.ID.String()+"/wait?timeout="+tt.timeout, nil)
+			req.SetPathValue("id", knok.ID.String())
+			rec := httptest.NewRecorder()
+
+			start := time.Now()
+			handler.WaitForKnok(rec, req)
+			elapsed := time.Since(start)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			var dto KnokDto
+			if err := json.NewDecoder(rec.Body).Decode(&dto); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if dto.ExtractionStatus != tt.wantStatus {
+				t.Errorf("extraction_status = %q, want %q", dto.ExtractionStatus, tt.wantStatus)
+			}
+			if elapsed < tt.wantMinWait {
+				t.Errorf("returned after %s, want at least %s", elapsed, tt.wantMinWait)
+			}
+			if elapsed > time.Second {
+				t.Errorf("returned after %s, want well before the timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestWaitForKnokCapsTimeout(t *testing.T) {
+	knok := newTestKnok("Track", time.Now())
+	knok.ExtractionStatus = domain.ExtractionStatusProcessing
+	handler := NewKnoksHandler(createTestLogger(), &extractingKnokRepo{knok: knok}, nil)
+	handler.SetMaxWaitTimeout(20 * time.Millisecond)
+	handler.waitPollInterval = time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks/"+knok.ID.String()+"/wait?timeout=1h", nil)
+	req.SetPathValue("id", knok.ID.String())
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.WaitForKnok(rec, req)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, want the 20ms cap", elapsed)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
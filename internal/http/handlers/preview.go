@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"knock-fm/internal/pkg/netguard"
+	"knock-fm/internal/pkg/urldetector"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// previewTimeout bounds a synchronous preview extraction. It must stay below the API
+// server's write timeout so the response can still be sent.
+const previewTimeout = 10 * time.Second
+
+// URLDetector detects and normalizes supported URLs in text
+type URLDetector interface {
+	DetectURLs(content string) []urldetector.URLInfo
+}
+
+// MetadataExtractor extracts metadata for a URL without persisting anything. It should
+// refuse to connect to non-public addresses, failing with netguard.ErrNonPublicAddress
+type MetadataExtractor interface {
+	ExtractMetadata(ctx context.Context, url string) (map[string]string, string, error)
+}
+
+// PreviewHandler returns extracted metadata for arbitrary links
+type PreviewHandler struct {
+	logger    *slog.Logger
+	detector  URLDetector
+	extractor MetadataExtractor
+}
+
+// PreviewRequest is the body of a preview request
+type PreviewRequest struct {
+	URL string `json:"url"`
+}
+
+// PreviewResponse contains the metadata extracted for a link
+type PreviewResponse struct {
+	URL              string `json:"url"`
+	Platform         string `json:"platform"`
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	Image            string `json:"image"`
+	SiteName         string `json:"site_name"`
+	ExtractionMethod string `json:"extraction_method"`
+}
+
+func NewPreviewHandler(logger *slog.Logger, detector URLDetector, extractor MetadataExtractor) *PreviewHandler {
+	return &PreviewHandler{
+		logger:    logger,
+		detector:  detector,
+		extractor: extractor,
+	}
+}
+
+// Preview runs metadata extraction for a URL synchronously and returns the result
+func (h *PreviewHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req PreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		http.Error(w, "Request body must be a JSON object with a url", http.StatusBadRequest)
+		return
+	}
+
+	// Detection normalizes the URL and enforces the scheme and port rules
+	urls := h.detector.DetectURLs(req.URL)
+	if len(urls) == 0 {
+		http.Error(w, "Invalid or unsupported URL", http.StatusBadRequest)
+		return
+	}
+	urlInfo := urls[0]
+
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+
+	metadata, method, err := h.extractor.ExtractMetadata(ctx, urlInfo.URL)
+	if errors.Is(err, netguard.ErrNonPublicAddress) {
+		h.logger.Warn("Rejected preview of non-public URL", "url", urlInfo.URL, "error", err)
+		http.Error(w, "URL must point to a public host", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Warn("Preview extraction failed", "url", urlInfo.URL, "error", err)
+		http.Error(w, "Failed to extract metadata", http.StatusBadGateway)
+		return
+	}
+
+	response := &PreviewResponse{
+		URL:              urlInfo.URL,
+		Platform:         urlInfo.Platform,
+		Title:            metadata["title"],
+		Description:      metadata["description"],
+		Image:            metadata["image"],
+		SiteName:         metadata["site_name"],
+		ExtractionMethod: method,
+	}
+
+	h.logger.Info("Preview extracted", "url", urlInfo.URL, "platform", urlInfo.Platform, "extraction_method", method)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode preview response", "error", err)
+	}
+}
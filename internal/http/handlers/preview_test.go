@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"knock-fm/internal/pkg/netguard"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeDetector treats any http(s) string as a single URL on a fixed platform
+type fakeDetector struct{}
+
+func (fakeDetector) DetectURLs(content string) []urldetector.URLInfo {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "http://") && !strings.HasPrefix(content, "https://") {
+		return nil
+	}
+	return []urldetector.URLInfo{{URL: content, CanonicalURL: content, Platform: "bandcamp"}}
+}
+
+// fakeExtractor returns canned metadata and records the URLs it was asked for
+type fakeExtractor struct {
+	metadata map[string]string
+	method   string
+	err      error
+	calls    []string
+}
+
+func (e *fakeExtractor) ExtractMetadata(ctx context.Context, url string) (map[string]string, string, error) {
+	e.calls = append(e.calls, url)
+	if e.err != nil {
+		return nil, "", e.err
+	}
+	return e.metadata, e.method, nil
+}
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		extractErr   error
+		wantStatus   int
+		wantExtract  bool
+		wantResponse *PreviewResponse
+	}{
+		{
+			name:        "Extracts metadata for a public URL",
+			body:        `{"url": "https://artist.bandcamp.com/album/test"}`,
+			wantStatus:  http.StatusOK,
+			wantExtract: true,
+			wantResponse: &PreviewResponse{
+				URL:              "https://artist.bandcamp.com/album/test",
+				Platform:         "bandcamp",
+				Title:            "Test Album",
+				Description:      "A test album",
+				Image:            "https://f4.bcbits.com/img/test.jpg",
+				SiteName:         "Bandcamp",
+				ExtractionMethod: "oembed",
+			},
+		},
+		{
+			name:       "Missing URL",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Malformed body",
+			body:       `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not a URL",
+			body:       `{"url": "just some text"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Non-public host rejected",
+			body:        `{"url": "http://intranet.example/page"}`,
+			extractErr:  fmt.Errorf("failed to fetch URL: %w", netguard.ErrNonPublicAddress),
+			wantStatus:  http.StatusBadRequest,
+			wantExtract: true,
+		},
+		{
+			name:        "Extraction failure",
+			body:        `{"url": "https://artist.bandcamp.com/album/test"}`,
+			extractErr:  errors.New("all extraction methods failed"),
+			wantStatus:  http.StatusBadGateway,
+			wantExtract: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor := &fakeExtractor{
+				metadata: map[string]string{
+					"title":       "Test Album",
+					"description": "A test album",
+					"image":       "https://f4.bcbits.com/img/test.jpg",
+					"site_name":   "Bandcamp",
+				},
+				method: "oembed",
+				err:    tt.extractErr,
+			}
+			handler := NewPreviewHandler(createTestLogger(), fakeDetector{}, extractor)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/preview", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			handler.Preview(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := len(extractor.calls) > 0; got != tt.wantExtract {
+				t.Errorf("extractor called = %v, want %v", got, tt.wantExtract)
+			}
+
+			if tt.wantResponse != nil {
+				var got PreviewResponse
+				if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if got != *tt.wantResponse {
+					t.Errorf("response = %+v, want %+v", got, *tt.wantResponse)
+				}
+			}
+		})
+	}
+}
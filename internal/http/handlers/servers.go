@@ -8,18 +8,49 @@ import (
 	"strconv"
 )
 
+const (
+	// defaultSummaryKnoks is how many recent knoks a server summary includes by default
+	defaultSummaryKnoks = 10
+
+	// defaultLeaderboardPosters is how many posters a server leaderboard ranks by default
+	defaultLeaderboardPosters = 10
+)
+
 type ServersHandler struct {
 	logger     *slog.Logger
 	serverRepo domain.ServerRepository
+	knokRepo   domain.KnokRepository
 }
 
-func NewServersHandler(logger *slog.Logger, serverRepo domain.ServerRepository) *ServersHandler {
+func NewServersHandler(logger *slog.Logger, serverRepo domain.ServerRepository, knokRepo domain.KnokRepository) *ServersHandler {
 	return &ServersHandler{
 		logger:     logger,
 		serverRepo: serverRepo,
+		knokRepo:   knokRepo,
 	}
 }
 
+// ServerSummaryResponse combines a server's info, knok counts and most recent knoks
+type ServerSummaryResponse struct {
+	Server      *domain.Server     `json:"server"`
+	Counts      *domain.KnokCounts `json:"counts"`
+	RecentKnoks []*KnokDto         `json:"recent_knoks"`
+}
+
+// ServerLeaderboardResponse ranks a server's posters by completed knoks, most first
+type ServerLeaderboardResponse struct {
+	ServerID string                `json:"server_id"`
+	Posters  []*domain.PosterCount `json:"posters"`
+}
+
+// UpdateServerRequest is the body of PUT /api/v1/servers/{id}. Omitted fields are left
+// unchanged; an empty configured_channel_id clears the configured channel
+type UpdateServerRequest struct {
+	Name                *string                `json:"name,omitempty"`
+	ConfiguredChannelID *string                `json:"configured_channel_id,omitempty"`
+	Settings            map[string]interface{} `json:"settings,omitempty"`
+}
+
 func (h *ServersHandler) GetServers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -85,8 +116,7 @@ func (h *ServersHandler) GetServerByID(w http.ResponseWriter, r *http.Request) {
 	// Get server from repository
 	server, err := h.serverRepo.GetByID(ctx, serverID)
 	if err != nil {
-		h.logger.Error("Failed to retrieve server", "error", err, "server_id", serverID)
-		http.Error(w, "Server not found", http.StatusNotFound)
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
 		return
 	}
 
@@ -100,24 +130,297 @@ func (h *ServersHandler) GetServerByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetServerSummary handles GET /api/v1/servers/{id}/summary?limit=N - server info, knok
+// counts and the N most recent knoks (default 10, max 100) in one response
+func (h *ServersHandler) GetServerSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serverID := r.PathValue("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSummaryKnoks
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	server, err := h.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	counts, err := h.knokRepo.GetCountsByServer(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to count knoks", "server_id", serverID)
+		return
+	}
+
+	knoks, err := h.knokRepo.GetRecentByServer(ctx, serverID, domain.DefaultKnokSort, nil, nil, nil, "", limit)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve knoks", "server_id", serverID)
+		return
+	}
+
+	response := &ServerSummaryResponse{
+		Server:      server,
+		Counts:      counts,
+		RecentKnoks: make([]*KnokDto, 0, len(knoks)),
+	}
+	for _, knok := range knoks {
+		response.RecentKnoks = append(response.RecentKnoks, newKnokDto(knok))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode server summary", "error", err, "server_id", serverID)
+	}
+}
+
+// GetServerLeaderboard handles GET /api/v1/servers/{id}/leaderboard?limit=N - the N users
+// who shared the most completed knoks (default 10, max 100)
+func (h *ServersHandler) GetServerLeaderboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serverID := r.PathValue("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultLeaderboardPosters
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	if _, err := h.serverRepo.GetByID(ctx, serverID); err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	posters, err := h.knokRepo.GetTopPosters(ctx, serverID, limit)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to rank posters", "server_id", serverID)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&ServerLeaderboardResponse{ServerID: serverID, Posters: posters}); err != nil {
+		h.logger.Error("Failed to encode server leaderboard", "error", err, "server_id", serverID)
+	}
+}
+
+// UpdateServer handles PUT /api/v1/servers/{id}, changing a server's name, configured
+// channel and settings
 func (h *ServersHandler) UpdateServer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
 	serverID := r.PathValue("id")
 	if serverID == "" {
 		http.Error(w, "Server ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Implement server update
-	http.Error(w, "Not implemented yet", http.StatusNotImplemented)
+	var req UpdateServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn("Invalid request body", "error", err, "server_id", serverID)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate
+	if req.Name != nil && *req.Name == "" {
+		http.Error(w, "name cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if req.Settings != nil {
+		if err := domain.ValidateSettings(req.Settings); err != nil {
+			h.logger.Warn("Rejected invalid server settings", "error", err, "server_id", serverID)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	server, err := h.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	// Apply changes
+	if req.Name != nil {
+		server.Name = *req.Name
+	}
+	if req.ConfiguredChannelID != nil {
+		if *req.ConfiguredChannelID == "" {
+			server.ConfiguredChannelID = nil
+		} else {
+			server.ConfiguredChannelID = req.ConfiguredChannelID
+		}
+	}
+	if req.Settings != nil {
+		server.Settings = req.Settings
+	}
+
+	if err := h.serverRepo.Update(ctx, server); err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to update server", "server_id", serverID)
+		return
+	}
+
+	h.logger.Info("Server updated", "server_id", serverID, "name", server.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(server); err != nil {
+		h.logger.Error("Failed to encode server response", "error", err, "server_id", serverID)
+	}
 }
 
+// DeleteServer handles DELETE /api/v1/servers/{id}, removing the server and all its knoks
 func (h *ServersHandler) DeleteServer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
 	serverID := r.PathValue("id")
 	if serverID == "" {
 		http.Error(w, "Server ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Implement server deletion
-	http.Error(w, "Not implemented yet", http.StatusNotImplemented)
+	if err := h.serverRepo.Delete(ctx, serverID); err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to delete server", "server_id", serverID)
+		return
+	}
+
+	h.logger.Info("Server deleted", "server_id", serverID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServerSettings returns a server's raw settings map
+func (h *ServersHandler) GetServerSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serverID := r.PathValue("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	settings := server.Settings
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+
+	h.writeSettingsResponse(w, serverID, settings)
+}
+
+// UpdateServerSettings replaces a server's settings map after validating it against the
+// ServerSettings schema
+func (h *ServersHandler) UpdateServerSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serverID := r.PathValue("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var settings map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil || settings == nil {
+		h.logger.Warn("Invalid settings body", "error", err, "server_id", serverID)
+		http.Error(w, "Request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	if err := domain.ValidateSettings(settings); err != nil {
+		h.logger.Warn("Rejected invalid server settings", "error", err, "server_id", serverID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	// UpdateSettings merges, so replacing the whole map goes through Update
+	server.Settings = settings
+	if err := h.serverRepo.Update(ctx, server); err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to update server settings", "server_id", serverID)
+		return
+	}
+
+	h.logger.Info("Server settings updated", "server_id", serverID, "settings", settings)
+	h.writeSettingsResponse(w, serverID, settings)
+}
+
+// PatchServerSettings handles PATCH /api/v1/servers/{id}/settings, merging the given keys
+// into a server's settings so one setting (e.g. unknown_platform_mode) can be changed
+// without resending the rest. A null value removes the key
+func (h *ServersHandler) PatchServerSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serverID := r.PathValue("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		h.logger.Warn("Invalid settings body", "error", err, "server_id", serverID)
+		http.Error(w, "Request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to retrieve server", "server_id", serverID)
+		return
+	}
+
+	// Validate the merged result so settings that depend on each other (quiet hours) are
+	// checked together
+	merged := domain.MergeSettings(server.Settings, patch)
+	if err := domain.ValidateSettings(merged); err != nil {
+		h.logger.Warn("Rejected invalid server settings", "error", err, "server_id", serverID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.serverRepo.UpdateSettings(ctx, serverID, patch); err != nil {
+		writeRepositoryError(w, h.logger, err, "Failed to update server settings", "server_id", serverID)
+		return
+	}
+
+	h.logger.Info("Server settings patched", "server_id", serverID, "settings", patch)
+	h.writeSettingsResponse(w, serverID, merged)
+}
+
+// writeSettingsResponse writes a server's settings as JSON
+func (h *ServersHandler) writeSettingsResponse(w http.ResponseWriter, serverID string, settings map[string]interface{}) {
+	response := map[string]interface{}{
+		"server_id": serverID,
+		"settings":  settings,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode settings response", "error", err, "server_id", serverID)
+	}
 }
@@ -0,0 +1,628 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createTestLogger creates a logger for testing
+func createTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError, // Only show errors during tests
+	}))
+}
+
+// fakeServerRepo stores servers in memory; other methods are unimplemented
+type fakeServerRepo struct {
+	domain.ServerRepository
+	servers map[string]*domain.Server
+}
+
+func newFakeServerRepo(servers ...*domain.Server) *fakeServerRepo {
+	repo := &fakeServerRepo{servers: make(map[string]*domain.Server)}
+	for _, server := range servers {
+		repo.servers[server.ID] = server
+	}
+	return repo
+}
+
+func (r *fakeServerRepo) GetByID(ctx context.Context, id string) (*domain.Server, error) {
+	server, ok := r.servers[id]
+	if !ok {
+		return nil, domain.ErrServerNotFound
+	}
+	// Return a copy so handler changes only persist through Update
+	copied := *server
+	return &copied, nil
+}
+
+func (r *fakeServerRepo) Update(ctx context.Context, server *domain.Server) error {
+	if _, ok := r.servers[server.ID]; !ok {
+		return domain.ErrServerNotFound
+	}
+	now := time.Now()
+	server.UpdatedAt = &now
+	saved := *server
+	r.servers[server.ID] = &saved
+	return nil
+}
+
+func (r *fakeServerRepo) Delete(ctx context.Context, id string) error {
+	if _, ok := r.servers[id]; !ok {
+		return domain.ErrServerNotFound
+	}
+	delete(r.servers, id)
+	return nil
+}
+
+func (r *fakeServerRepo) UpdateSettings(ctx context.Context, id string, settings map[string]interface{}) error {
+	server, ok := r.servers[id]
+	if !ok {
+		return domain.ErrServerNotFound
+	}
+	server.Settings = domain.MergeSettings(server.Settings, settings)
+	return nil
+}
+
+func TestGetServerSettings(t *testing.T) {
+	repo := newFakeServerRepo(&domain.Server{
+		ID:       "guild-1",
+		Settings: map[string]interface{}{"notification_mode": "silent"},
+	})
+	handler := NewServersHandler(createTestLogger(), repo, nil)
+
+	t.Run("Existing server", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/servers/guild-1/settings", nil)
+		req.SetPathValue("id", "guild-1")
+		rec := httptest.NewRecorder()
+
+		handler.GetServerSettings(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		var body struct {
+			Settings map[string]interface{} `json:"settings"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Settings["notification_mode"] != "silent" {
+			t.Errorf("settings = %v, want notification_mode silent", body.Settings)
+		}
+	})
+
+	t.Run("Unknown server", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/servers/guild-2/settings", nil)
+		req.SetPathValue("id", "guild-2")
+		rec := httptest.NewRecorder()
+
+		handler.GetServerSettings(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestUpdateServerSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverID     string
+		body         string
+		wantStatus   int
+		wantErrorKey string
+		wantSaved    bool
+	}{
+		{
+			name:       "Valid settings replace the map",
+			serverID:   "guild-1",
+			body:       `{"notification_mode": "reply", "allowed_channels": ["123"], "max_context_words": 3}`,
+			wantStatus: http.StatusOK,
+			wantSaved:  true,
+		},
+		{
+			name:         "Unknown key",
+			serverID:     "guild-1",
+			body:         `{"unknown_platform_modee": "strict"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "unknown_platform_modee",
+		},
+		{
+			name:         "Wrong type",
+			serverID:     "guild-1",
+			body:         `{"allow_bot_authors": "yes"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "allow_bot_authors",
+		},
+		{
+			name:         "Invalid enum value",
+			serverID:     "guild-1",
+			body:         `{"unknown_platform_mode": "lenient"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "unknown_platform_mode",
+		},
+		{
+			name:         "Invalid quiet hours",
+			serverID:     "guild-1",
+			body:         `{"quiet_hours_start": "10pm", "quiet_hours_end": "07:00"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "quiet_hours_start",
+		},
+		{
+			name:       "Not a JSON object",
+			serverID:   "guild-1",
+			body:       `["notification_mode"]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown server",
+			serverID:   "guild-2",
+			body:       `{"notification_mode": "reply"}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := map[string]interface{}{"notification_mode": "silent"}
+			repo := newFakeServerRepo(&domain.Server{ID: "guild-1", Settings: original})
+			handler := NewServersHandler(createTestLogger(), repo, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/servers/"+tt.serverID+"/settings", strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.serverID)
+			rec := httptest.NewRecorder()
+
+			handler.UpdateServerSettings(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantErrorKey != "" && !strings.Contains(rec.Body.String(), tt.wantErrorKey) {
+				t.Errorf("error %q should name key %q", rec.Body.String(), tt.wantErrorKey)
+			}
+
+			saved := repo.servers["guild-1"].Settings
+			if tt.wantSaved {
+				if saved["notification_mode"] != "reply" || len(saved) != 3 {
+					t.Errorf("saved settings = %v, want the request body", saved)
+				}
+			} else if saved["notification_mode"] != "silent" || len(saved) != 1 {
+				t.Errorf("settings changed on rejected request: %v", saved)
+			}
+		})
+	}
+}
+
+func TestPatchServerSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverID     string
+		body         string
+		wantStatus   int
+		wantErrorKey string
+		wantSettings map[string]interface{}
+	}{
+		{
+			name:       "Switch to strict mode",
+			serverID:   "guild-1",
+			body:       `{"unknown_platform_mode": "strict"}`,
+			wantStatus: http.StatusOK,
+			wantSettings: map[string]interface{}{
+				"notification_mode":     "silent",
+				"unknown_platform_mode": "strict",
+				"allowed_channels":      []interface{}{"123"},
+			},
+		},
+		{
+			name:       "Null removes a key",
+			serverID:   "guild-1",
+			body:       `{"allowed_channels": null}`,
+			wantStatus: http.StatusOK,
+			wantSettings: map[string]interface{}{
+				"notification_mode":     "silent",
+				"unknown_platform_mode": "permissive",
+			},
+		},
+		{
+			name:         "Invalid unknown platform mode",
+			serverID:     "guild-1",
+			body:         `{"unknown_platform_mode": "lenient"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "unknown_platform_mode",
+		},
+		{
+			name:         "Validated together with existing settings",
+			serverID:     "guild-1",
+			body:         `{"quiet_hours_start": "22:00"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantErrorKey: "quiet_hours_end",
+		},
+		{
+			name:       "Not a JSON object",
+			serverID:   "guild-1",
+			body:       `"strict"`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown server",
+			serverID:   "guild-2",
+			body:       `{"unknown_platform_mode": "strict"}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := map[string]interface{}{
+				"notification_mode":     "silent",
+				"unknown_platform_mode": "permissive",
+				"allowed_channels":      []interface{}{"123"},
+			}
+			repo := newFakeServerRepo(&domain.Server{ID: "guild-1", Settings: original})
+			handler := NewServersHandler(createTestLogger(), repo, nil)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/servers/"+tt.serverID+"/settings", strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.serverID)
+			rec := httptest.NewRecorder()
+
+			handler.PatchServerSettings(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantErrorKey != "" && !strings.Contains(rec.Body.String(), tt.wantErrorKey) {
+				t.Errorf("error %q should name key %q", rec.Body.String(), tt.wantErrorKey)
+			}
+
+			saved := repo.servers["guild-1"].Settings
+			if tt.wantSettings == nil {
+				if !reflect.DeepEqual(saved, original) {
+					t.Errorf("settings changed on rejected request: %v", saved)
+				}
+				return
+			}
+			if !reflect.DeepEqual(saved, tt.wantSettings) {
+				t.Errorf("saved settings = %v, want %v", saved, tt.wantSettings)
+			}
+
+			var body struct {
+				Settings map[string]interface{} `json:"settings"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(body.Settings, tt.wantSettings) {
+				t.Errorf("response settings = %v, want %v", body.Settings, tt.wantSettings)
+			}
+		})
+	}
+}
+
+func TestUpdateServer(t *testing.T) {
+	channelID := "chan-1"
+	newChannelID := "chan-2"
+
+	tests := []struct {
+		name        string
+		serverID    string
+		body        string
+		wantStatus  int
+		wantName    string
+		wantChannel *string
+		wantMode    string
+	}{
+		{
+			name:        "All fields",
+			serverID:    "guild-1",
+			body:        `{"name": "Renamed", "configured_channel_id": "chan-2", "settings": {"notification_mode": "reply"}}`,
+			wantStatus:  http.StatusOK,
+			wantName:    "Renamed",
+			wantChannel: &newChannelID,
+			wantMode:    "reply",
+		},
+		{
+			name:        "Omitted fields are unchanged",
+			serverID:    "guild-1",
+			body:        `{"name": "Renamed"}`,
+			wantStatus:  http.StatusOK,
+			wantName:    "Renamed",
+			wantChannel: &channelID,
+			wantMode:    "silent",
+		},
+		{
+			name:       "Empty channel clears it",
+			serverID:   "guild-1",
+			body:       `{"configured_channel_id": ""}`,
+			wantStatus: http.StatusOK,
+			wantName:   "Guild",
+			wantMode:   "silent",
+		},
+		{
+			name:        "Empty name",
+			serverID:    "guild-1",
+			body:        `{"name": ""}`,
+			wantStatus:  http.StatusBadRequest,
+			wantName:    "Guild",
+			wantChannel: &channelID,
+			wantMode:    "silent",
+		},
+		{
+			name:        "Invalid settings",
+			serverID:    "guild-1",
+			body:        `{"name": "Renamed", "settings": {"notification_mode": "loud"}}`,
+			wantStatus:  http.StatusBadRequest,
+			wantName:    "Guild",
+			wantChannel: &channelID,
+			wantMode:    "silent",
+		},
+		{
+			name:        "Malformed body",
+			serverID:    "guild-1",
+			body:        `{"name":`,
+			wantStatus:  http.StatusBadRequest,
+			wantName:    "Guild",
+			wantChannel: &channelID,
+			wantMode:    "silent",
+		},
+		{
+			name:        "Unknown server",
+			serverID:    "guild-2",
+			body:        `{"name": "Renamed"}`,
+			wantStatus:  http.StatusNotFound,
+			wantName:    "Guild",
+			wantChannel: &channelID,
+			wantMode:    "silent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeServerRepo(&domain.Server{
+				ID:                  "guild-1",
+				Name:                "Guild",
+				ConfiguredChannelID: &channelID,
+				Settings:            map[string]interface{}{"notification_mode": "silent"},
+			})
+			handler := NewServersHandler(createTestLogger(), repo, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/servers/"+tt.serverID, strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.serverID)
+			rec := httptest.NewRecorder()
+
+			handler.UpdateServer(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			saved := repo.servers["guild-1"]
+			if saved.Name != tt.wantName {
+				t.Errorf("saved name = %q, want %q", saved.Name, tt.wantName)
+			}
+			if !reflect.DeepEqual(saved.ConfiguredChannelID, tt.wantChannel) {
+				t.Errorf("saved configured_channel_id = %v, want %v", saved.ConfiguredChannelID, tt.wantChannel)
+			}
+			if saved.Settings["notification_mode"] != tt.wantMode {
+				t.Errorf("saved notification_mode = %v, want %s", saved.Settings["notification_mode"], tt.wantMode)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var body domain.Server
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if body.Name != tt.wantName || body.UpdatedAt == nil {
+					t.Errorf("response = %+v, want the updated server", body)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteServer(t *testing.T) {
+	repo := newFakeServerRepo(&domain.Server{ID: "guild-1"})
+	handler := NewServersHandler(createTestLogger(), repo, nil)
+
+	for _, tt := range []struct {
+		serverID   string
+		wantStatus int
+	}{
+		{"guild-1", http.StatusNoContent},
+		{"guild-1", http.StatusNotFound},
+		{"guild-2", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/servers/"+tt.serverID, nil)
+		req.SetPathValue("id", tt.serverID)
+		rec := httptest.NewRecorder()
+
+		handler.DeleteServer(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("DELETE %s status = %d, want %d", tt.serverID, rec.Code, tt.wantStatus)
+		}
+	}
+
+	if _, ok := repo.servers["guild-1"]; ok {
+		t.Error("guild-1 should have been deleted")
+	}
+}
+
+// summaryKnokRepo serves fixed counts and recent knoks for one server; other methods are
+// unimplemented
+type summaryKnokRepo struct {
+	domain.KnokRepository
+	serverID string
+	counts   *domain.KnokCounts
+	knoks    []*domain.Knok
+}
+
+func (r *summaryKnokRepo) GetCountsByServer(ctx context.Context, serverID string) (*domain.KnokCounts, error) {
+	if serverID != r.serverID {
+		return &domain.KnokCounts{ByPlatform: map[string]int{}, ByStatus: map[string]int{}}, nil
+	}
+	return r.counts, nil
+}
+
+func (r *summaryKnokRepo) GetRecentByServer(ctx context.Context, serverID string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	if serverID != r.serverID {
+		return nil, nil
+	}
+	if len(r.knoks) > limit {
+		return r.knoks[:limit], nil
+	}
+	return r.knoks, nil
+}
+
+func TestGetServerSummary(t *testing.T) {
+	serverRepo := newFakeServerRepo(&domain.Server{ID: "guild-1", Name: "Late Night Club"})
+	title := "Late Night Mix"
+	knokRepo := &summaryKnokRepo{
+		serverID: "guild-1",
+		counts: &domain.KnokCounts{
+			Total:      3,
+			ByPlatform: map[string]int{"soundcloud": 2, "youtube": 1},
+			ByStatus:   map[string]int{domain.ExtractionStatusComplete: 2, domain.ExtractionStatusPending: 1},
+		},
+		knoks: []*domain.Knok{
+			{ID: uuid.New(), URL: "https://soundcloud.com/a/late-night-mix", Title: &title, PostedAt: time.Now()},
+			{ID: uuid.New(), URL: "https://youtube.com/watch?v=abcdefghijk", PostedAt: time.Now().Add(-time.Hour)},
+		},
+	}
+	handler := NewServersHandler(createTestLogger(), serverRepo, knokRepo)
+
+	tests := []struct {
+		name       string
+		serverID   string
+		query      string
+		wantStatus int
+		wantKnoks  int
+	}{
+		{name: "Summary", serverID: "guild-1", wantStatus: http.StatusOK, wantKnoks: 2},
+		{name: "Limited recent knoks", serverID: "guild-1", query: "?limit=1", wantStatus: http.StatusOK, wantKnoks: 1},
+		{name: "Missing server", serverID: "guild-2", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/servers/"+tt.serverID+"/summary"+tt.query, nil)
+			req.SetPathValue("id", tt.serverID)
+			rec := httptest.NewRecorder()
+
+			handler.GetServerSummary(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ServerSummaryResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Server == nil || resp.Server.ID != "guild-1" || resp.Server.Name != "Late Night Club" {
+				t.Errorf("server = %+v, want guild-1", resp.Server)
+			}
+			if !reflect.DeepEqual(resp.Counts, knokRepo.counts) {
+				t.Errorf("counts = %+v, want %+v", resp.Counts, knokRepo.counts)
+			}
+			if len(resp.RecentKnoks) != tt.wantKnoks {
+				t.Fatalf("recent knoks = %d, want %d", len(resp.RecentKnoks), tt.wantKnoks)
+			}
+			if resp.RecentKnoks[0].ID != knokRepo.knoks[0].ID.String() || resp.RecentKnoks[0].Title != title {
+				t.Errorf("first recent knok = %+v, want %s %q", resp.RecentKnoks[0], knokRepo.knoks[0].ID, title)
+			}
+			if tt.wantKnoks > 1 && resp.RecentKnoks[1].Title != "Processing..." {
+				t.Errorf("untitled knok title = %q, want Processing...", resp.RecentKnoks[1].Title)
+			}
+		})
+	}
+}
+
+// leaderboardKnokRepo ranks fixed posters for one server; other methods are unimplemented
+type leaderboardKnokRepo struct {
+	domain.KnokRepository
+	serverID string
+	posters  []*domain.PosterCount
+}
+
+func (r *leaderboardKnokRepo) GetTopPosters(ctx context.Context, serverID string, limit int) ([]*domain.PosterCount, error) {
+	if serverID != r.serverID {
+		return []*domain.PosterCount{}, nil
+	}
+	if len(r.posters) > limit {
+		return r.posters[:limit], nil
+	}
+	return r.posters, nil
+}
+
+func TestGetServerLeaderboard(t *testing.T) {
+	serverRepo := newFakeServerRepo(
+		&domain.Server{ID: "guild-1", Name: "Late Night Club"},
+		&domain.Server{ID: "guild-3", Name: "Quiet Club"},
+	)
+	knokRepo := &leaderboardKnokRepo{
+		serverID: "guild-1",
+		posters: []*domain.PosterCount{
+			{UserID: "111", Count: 7},
+			{UserID: "222", Count: 3},
+			{UserID: "333", Count: 1},
+		},
+	}
+	handler := NewServersHandler(createTestLogger(), serverRepo, knokRepo)
+
+	tests := []struct {
+		name        string
+		serverID    string
+		query       string
+		wantStatus  int
+		wantPosters []*domain.PosterCount
+	}{
+		{name: "Leaderboard", serverID: "guild-1", wantStatus: http.StatusOK, wantPosters: knokRepo.posters},
+		{name: "Limited posters", serverID: "guild-1", query: "?limit=2", wantStatus: http.StatusOK, wantPosters: knokRepo.posters[:2]},
+		{name: "Invalid limit uses the default", serverID: "guild-1", query: "?limit=500", wantStatus: http.StatusOK, wantPosters: knokRepo.posters},
+		{name: "No posters yet", serverID: "guild-3", wantStatus: http.StatusOK, wantPosters: []*domain.PosterCount{}},
+		{name: "Missing server", serverID: "guild-2", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/servers/"+tt.serverID+"/leaderboard"+tt.query, nil)
+			req.SetPathValue("id", tt.serverID)
+			rec := httptest.NewRecorder()
+
+			handler.GetServerLeaderboard(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ServerLeaderboardResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.ServerID != tt.serverID {
+				t.Errorf("server_id = %q, want %q", resp.ServerID, tt.serverID)
+			}
+			if !reflect.DeepEqual(resp.Posters, tt.wantPosters) {
+				t.Errorf("posters = %v, want %v", resp.Posters, tt.wantPosters)
+			}
+		})
+	}
+}
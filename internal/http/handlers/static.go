@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StaticHandler serves the web frontend build, falling back to index.html for unknown
+// paths so client-side routes work on a full page load
+type StaticHandler struct {
+	logger     *slog.Logger
+	root       http.FileSystem
+	fileServer http.Handler
+}
+
+func NewStaticHandler(logger *slog.Logger, dir string) *StaticHandler {
+	root := http.Dir(dir)
+	return &StaticHandler{
+		logger:     logger,
+		root:       root,
+		fileServer: http.FileServer(root),
+	}
+}
+
+// ServeHTTP serves a file from the build directory, or index.html if none matches.
+// API paths are never served from here, so unknown API routes still return 404.
+func (h *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api" || strings.HasPrefix(r.URL.Path, "/api/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.exists(r.URL.Path) {
+		h.fileServer.ServeHTTP(w, r)
+		return
+	}
+
+	h.serveIndex(w, r)
+}
+
+// exists reports whether a path names a file, or a directory containing index.html
+func (h *StaticHandler) exists(name string) bool {
+	name = path.Clean("/" + name)
+
+	f, err := h.root.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		return true
+	}
+
+	index, err := h.root.Open(path.Join(name, "index.html"))
+	if err != nil {
+		return false
+	}
+	index.Close()
+	return true
+}
+
+// serveIndex serves index.html for a client-side route. It is never cached so clients
+// pick up new builds, which reference freshly hashed assets.
+func (h *StaticHandler) serveIndex(w http.ResponseWriter, r *http.Request) {
+	index, err := h.root.Open("/index.html")
+	if err != nil {
+		h.logger.Error("Failed to open index.html", "error", err)
+		http.NotFound(w, r)
+		return
+	}
+	defer index.Close()
+
+	info, err := index.Stat()
+	if err != nil {
+		h.logger.Error("Failed to stat index.html", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "index.html", info.ModTime(), index)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticHandler(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":        "<html>knok.fm</html>",
+		"assets/app-abc.js": "console.log('knok')",
+		"favicon.svg":       "<svg></svg>",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	handler := NewStaticHandler(createTestLogger(), dir)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Root serves index", "/", http.StatusOK, files["index.html"]},
+		{"Serves file", "/assets/app-abc.js", http.StatusOK, files["assets/app-abc.js"]},
+		{"Client route falls back to index", "/servers/123", http.StatusOK, files["index.html"]},
+		{"Directory without index falls back", "/assets/", http.StatusOK, files["index.html"]},
+		{"Traversal stays in root", "/../../etc/passwd", http.StatusOK, files["index.html"]},
+		{"API path not shadowed", "/api/v1/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,23 +1,131 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"knock-fm/internal/domain"
 	"log/slog"
 	"net/http"
 	"time"
 )
 
+const (
+	// defaultActivityRange is how far back the activity histogram reaches when no
+	// "from" is given
+	defaultActivityRange = 30 * 24 * time.Hour
+
+	// maxActivityBuckets bounds the buckets in one histogram so a wide range with a
+	// small bucket can't generate an unbounded series
+	maxActivityBuckets = 1000
+)
+
 type StatsHandler struct {
-	logger *slog.Logger
+	logger   *slog.Logger
+	knokRepo domain.KnokRepository
 }
 
-func NewStatsHandler(logger *slog.Logger) *StatsHandler {
+func NewStatsHandler(logger *slog.Logger, knokRepo domain.KnokRepository) *StatsHandler {
 	return &StatsHandler{
-		logger: logger,
+		logger:   logger,
+		knokRepo: knokRepo,
 	}
 }
 
+// ActivityResponse is the knok posting histogram for a time range
+type ActivityResponse struct {
+	Bucket   string                   `json:"bucket"`
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	ServerID *string                  `json:"server_id,omitempty"`
+	Total    int                      `json:"total"`
+	Buckets  []*domain.ActivityBucket `json:"buckets"`
+}
+
 func (h *StatsHandler) HandleStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"ok","timestamp":"` + time.Now().Format(time.RFC3339) + `","message":"Stats endpoint coming soon"}`))
 }
+
+// HandleActivity handles GET /api/v1/stats/activity?bucket=day&from=...&to=...&server_id=...
+// from and to are RFC 3339 timestamps or YYYY-MM-DD dates (UTC); the range defaults to
+// the last 30 days and the bucket to "day"
+func (h *StatsHandler) HandleActivity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	bucket := query.Get("bucket")
+	if bucket == "" {
+		bucket = "day"
+	}
+	bucketSize, ok := domain.ActivityBucketSizes[bucket]
+	if !ok {
+		http.Error(w, "Invalid bucket, expected hour, day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	if toStr := query.Get("to"); toStr != "" {
+		parsed, err := parseActivityTime(toStr)
+		if err != nil {
+			http.Error(w, "Invalid to, expected RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultActivityRange)
+	if fromStr := query.Get("from"); fromStr != "" {
+		parsed, err := parseActivityTime(fromStr)
+		if err != nil {
+			http.Error(w, "Invalid from, expected RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from)/bucketSize >= maxActivityBuckets {
+		http.Error(w, fmt.Sprintf("Range too large for bucket %s (max %d buckets)", bucket, maxActivityBuckets), http.StatusBadRequest)
+		return
+	}
+
+	var serverID *string
+	if id := query.Get("server_id"); id != "" {
+		serverID = &id
+	}
+
+	buckets, err := h.knokRepo.GetActivityHistogram(r.Context(), serverID, from, to, bucket)
+	if err != nil {
+		h.logger.Error("Failed to compute activity histogram", "error", err, "bucket", bucket)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := ActivityResponse{
+		Bucket:   bucket,
+		From:     from,
+		To:       to,
+		ServerID: serverID,
+		Buckets:  buckets,
+	}
+	for _, b := range buckets {
+		response.Total += b.Count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(timelineMaxAge.Seconds())))
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// parseActivityTime parses an RFC 3339 timestamp or a YYYY-MM-DD date as UTC
+func parseActivityTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
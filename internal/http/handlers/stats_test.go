@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// histogramKnokRepo records the arguments of GetActivityHistogram and returns fixed
+// buckets; other methods are unimplemented
+type histogramKnokRepo struct {
+	domain.KnokRepository
+	buckets  []*domain.ActivityBucket
+	serverID *string
+	from, to time.Time
+	bucket   string
+}
+
+func (r *histogramKnokRepo) GetActivityHistogram(ctx context.Context, serverID *string, from, to time.Time, bucket string) ([]*domain.ActivityBucket, error) {
+	r.serverID, r.from, r.to, r.bucket = serverID, from, to, bucket
+	return r.buckets, nil
+}
+
+func TestHandleActivity(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name         string
+		query        string
+		wantStatus   int
+		wantBucket   string
+		wantFrom     time.Time
+		wantTo       time.Time
+		wantServerID string
+	}{
+		{
+			name:       "Dates",
+			query:      "?bucket=day&from=2024-03-01&to=2024-03-04",
+			wantStatus: http.StatusOK,
+			wantBucket: "day",
+			wantFrom:   day(1),
+			wantTo:     day(4),
+		},
+		{
+			name:         "RFC 3339 with server",
+			query:        "?bucket=hour&from=2024-03-01T01:00:00%2B01:00&to=2024-03-02T00:00:00Z&server_id=123",
+			wantStatus:   http.StatusOK,
+			wantBucket:   "hour",
+			wantFrom:     day(1),
+			wantTo:       day(2),
+			wantServerID: "123",
+		},
+		{
+			name:       "Default bucket and range",
+			query:      "?to=2024-03-31",
+			wantStatus: http.StatusOK,
+			wantBucket: "day",
+			wantFrom:   day(1),
+			wantTo:     day(31),
+		},
+		{name: "Unknown bucket", query: "?bucket=minute", wantStatus: http.StatusBadRequest},
+		{name: "Invalid from", query: "?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "Empty range", query: "?from=2024-03-04&to=2024-03-04", wantStatus: http.StatusBadRequest},
+		{name: "Too many buckets", query: "?bucket=hour&from=2023-01-01&to=2024-03-01", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &histogramKnokRepo{buckets: []*domain.ActivityBucket{
+				{Start: day(1), Count: 2},
+				{Start: day(2), Count: 0},
+				{Start: day(3), Count: 1},
+			}}
+			handler := NewStatsHandler(createTestLogger(), repo)
+			rec := httptest.NewRecorder()
+
+			handler.HandleActivity(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stats/activity"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if repo.bucket != tt.wantBucket || !repo.from.Equal(tt.wantFrom) || !repo.to.Equal(tt.wantTo) {
+				t.Errorf("GetActivityHistogram(%s, %s, %s), want (%s, %s, %s)",
+					repo.from, repo.to, repo.bucket, tt.wantFrom, tt.wantTo, tt.wantBucket)
+			}
+			var gotServerID string
+			if repo.serverID != nil {
+				gotServerID = *repo.serverID
+			}
+			if gotServerID != tt.wantServerID {
+				t.Errorf("server ID = %q, want %q", gotServerID, tt.wantServerID)
+			}
+
+			var resp ActivityResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Total != 3 || len(resp.Buckets) != 3 || resp.Bucket != tt.wantBucket {
+				t.Errorf("response = %+v, want 3 buckets totalling 3", resp)
+			}
+		})
+	}
+}
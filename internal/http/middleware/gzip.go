@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the smallest response body worth compressing; below this the gzip
+// header and CPU cost outweigh the savings
+const gzipMinSize = 1024
+
+// Gzip compresses response bodies for clients that accept gzip. Responses are buffered
+// so Content-Length can be set exactly, and small bodies are sent uncompressed.
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses vary by encoding whether or not this client gets gzip
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(gw, r)
+		gw.finish()
+	})
+}
+
+// gzipResponseWriter buffers the response so it can decide whether to compress it
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.status = status
+	gw.wroteHeader = true
+}
+
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	return gw.body.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to extend the
+// write deadline of long-polling handlers
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// finish writes the buffered response, compressed if it is large enough and compressible
+func (gw *gzipResponseWriter) finish() {
+	header := gw.ResponseWriter.Header()
+	body := gw.body.Bytes()
+
+	if gw.shouldCompress() {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(body); err == nil && zw.Close() == nil {
+			header.Set("Content-Encoding", "gzip")
+			body = compressed.Bytes()
+		}
+	}
+
+	if gw.status != http.StatusNotModified && gw.status != http.StatusNoContent {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	gw.ResponseWriter.WriteHeader(gw.status)
+	gw.ResponseWriter.Write(body)
+}
+
+func (gw *gzipResponseWriter) shouldCompress() bool {
+	header := gw.ResponseWriter.Header()
+
+	if gw.body.Len() < gzipMinSize || header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if gw.status == http.StatusNotModified || gw.status == http.StatusNoContent {
+		return false
+	}
+	// Byte ranges refer to the uncompressed body, so partial responses are sent as they are
+	if gw.status == http.StatusPartialContent || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	// Skip formats that are already compressed
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(gw.body.Bytes())
+	}
+	switch {
+	case strings.HasPrefix(contentType, "image/svg"):
+		return true
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "video/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "application/gzip"),
+		strings.HasPrefix(contentType, "application/zip"):
+		return false
+	}
+	return true
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip. An explicit gzip
+// entry takes precedence over a * wildcard, and q=0 means not acceptable.
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, wildcardQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch coding {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return wildcardQ > 0
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzip(t *testing.T) {
+	largeBody := `{"knoks":[` + strings.Repeat(`{"title":"A knok","url":"https://example.com"},`, 100) + `{}]}`
+	smallBody := `{"status":"ok"}`
+
+	tests := []struct {
+		name           string
+		body           string
+		status         int
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{
+			name:           "Large response compressed when gzip accepted",
+			body:           largeBody,
+			status:         http.StatusOK,
+			acceptEncoding: "gzip, deflate, br",
+			wantGzip:       true,
+		},
+		{
+			name:           "Large response uncompressed without Accept-Encoding",
+			body:           largeBody,
+			status:         http.StatusOK,
+			acceptEncoding: "",
+			wantGzip:       false,
+		},
+		{
+			name:           "Large response uncompressed when gzip refused",
+			body:           largeBody,
+			status:         http.StatusOK,
+			acceptEncoding: "gzip;q=0, br",
+			wantGzip:       false,
+		},
+		{
+			name:           "Small response left uncompressed",
+			body:           smallBody,
+			status:         http.StatusOK,
+			acceptEncoding: "gzip",
+			wantGzip:       false,
+		},
+		{
+			name:           "Error status preserved",
+			body:           largeBody,
+			status:         http.StatusBadRequest,
+			acceptEncoding: "gzip",
+			wantGzip:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			// Pass-through responses leave Content-Length to the server; buffered ones set it exactly
+			if got := rec.Header().Get("Content-Length"); got != "" && got != strconv.Itoa(rec.Body.Len()) {
+				t.Errorf("Content-Length = %q, want %d", got, rec.Body.Len())
+			}
+
+			body := rec.Body.String()
+			if tt.wantGzip {
+				if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+					t.Fatalf("Content-Encoding = %q, want gzip", got)
+				}
+				if rec.Header().Get("Content-Length") == "" {
+					t.Error("Content-Length not set on compressed response")
+				}
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("response is not valid gzip: %v", err)
+				}
+				decoded, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatalf("failed to decompress response: %v", err)
+				}
+				body = string(decoded)
+			} else if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestGzipNotModified(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"abc"`)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/knoks", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("304 response should have no body or encoding, got %q", rec.Body.String())
+	}
+}
+
+func TestGzipPartialContent(t *testing.T) {
+	content := strings.Repeat("0123456789", 500)
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "bundle.js", time.Time{}, strings.NewReader(content))
+	}))
+
+	tests := []struct {
+		name       string
+		rangeValue string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Satisfiable range",
+			rangeValue: "bytes=0-2999",
+			wantStatus: http.StatusPartialContent,
+			wantBody:   content[:3000],
+		},
+		{
+			name:       "Unsatisfiable range",
+			rangeValue: "bytes=9000-",
+			wantStatus: http.StatusRequestedRangeNotSatisfiable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/assets/bundle.js", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			req.Header.Set("Range", tt.rangeValue)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if rec.Header().Get("Content-Range") == "" {
+				t.Error("Content-Range not set")
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body has %d bytes, want the %d requested", rec.Body.Len(), len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"GZIP", true},
+		{"gzip;q=0", false},
+		{"br", false},
+		{"*", true},
+		{"*, gzip;q=0", false},
+		{"identity", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+				t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
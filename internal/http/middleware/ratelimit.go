@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitSweepSize is the number of tracked clients above which idle ones are dropped
+	rateLimitSweepSize = 10000
+
+	// rateLimitIdleTime is how long a client must be idle before it can be dropped
+	rateLimitIdleTime = 10 * time.Minute
+)
+
+// RateLimiter limits requests per client IP using a token bucket
+type RateLimiter struct {
+	logger         *slog.Logger
+	rate           float64 // tokens added per second
+	burst          float64
+	trustedProxies []*net.IPNet
+	mu             sync.Mutex
+	clients        map[string]*tokenBucket
+	now            func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing requestsPerMinute per client,
+// with bursts of up to requestsPerMinute requests
+func NewRateLimiter(logger *slog.Logger, requestsPerMinute int) *RateLimiter {
+	if requestsPerMinute < 1 {
+		requestsPerMinute = 1
+	}
+
+	return &RateLimiter{
+		logger:  logger,
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(requestsPerMinute),
+		clients: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// SetTrustedProxies sets the reverse proxies whose X-Forwarded-For header is believed. Without
+// any, clients are always identified by the address they connected from
+func (rl *RateLimiter) SetTrustedProxies(proxies []*net.IPNet) {
+	rl.trustedProxies = proxies
+}
+
+// Allow reports whether a request from the client may proceed, and if not,
+// how long until it may retry
+func (rl *RateLimiter) Allow(client string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+
+	if len(rl.clients) > rateLimitSweepSize {
+		for key, bucket := range rl.clients {
+			if now.Sub(bucket.lastSeen) > rateLimitIdleTime {
+				delete(rl.clients, key)
+			}
+		}
+	}
+
+	bucket, ok := rl.clients[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.clients[client] = bucket
+	}
+
+	// Refill tokens for the time since the client was last seen
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*rl.rate)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+		return false, wait
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// Middleware returns the rate limiting middleware handler
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := rl.clientIP(r)
+
+		allowed, retryAfter := rl.Allow(client)
+		if !allowed {
+			rl.logger.Warn("Request rate limited",
+				"path", r.URL.Path,
+				"client", client,
+				"retry_after", retryAfter,
+			)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the client address for a request. X-Forwarded-For is only believed when
+// the request came from a trusted proxy, since anyone else can set it: its entries are then
+// read from the right, skipping other trusted proxies, and the first one left is the client.
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !rl.trusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if ip == "" {
+			continue
+		}
+		if !rl.trusted(ip) {
+			return ip
+		}
+		host = ip
+	}
+	return host
+}
+
+// trusted reports whether ip is one of the trusted proxies
+func (rl *RateLimiter) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range rl.trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiterClientIP(t *testing.T) {
+	_, dockerNetwork, _ := net.ParseCIDR("172.16.0.0/12")
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/32")
+	trusted := []*net.IPNet{dockerNetwork, loopback}
+
+	tests := []struct {
+		name       string
+		proxies    []*net.IPNet
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "Direct request without header",
+			proxies:    trusted,
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Spoofed header from untrusted peer is ignored",
+			proxies:    trusted,
+			remoteAddr: "203.0.113.7:51234",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Header ignored without trusted proxies",
+			remoteAddr: "172.18.0.5:40000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "172.18.0.5",
+		},
+		{
+			name:       "Trusted proxy reports the client",
+			proxies:    trusted,
+			remoteAddr: "172.18.0.5:40000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Client-supplied entries before the proxy's are skipped",
+			proxies:    trusted,
+			remoteAddr: "172.18.0.5:40000",
+			forwarded:  []string{"10.9.9.9, 198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Chain of trusted proxies",
+			proxies:    trusted,
+			remoteAddr: "127.0.0.1:40000",
+			forwarded:  []string{"198.51.100.1, 172.18.0.5", "172.18.0.6"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Trusted proxy without header",
+			proxies:    trusted,
+			remoteAddr: "172.18.0.5:40000",
+			want:       "172.18.0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), 5)
+			rl.SetTrustedProxies(tt.proxies)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/preview", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := rl.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterIgnoresSpoofedForwardedFor(t *testing.T) {
+	rl := NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), 1)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// A fresh X-Forwarded-For on every request must not reset the limit
+	for i, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/preview", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		want := http.StatusOK
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		if rec.Code != want {
+			t.Errorf("request %d status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
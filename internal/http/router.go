@@ -6,7 +6,10 @@ import (
 	"knock-fm/internal/http/handlers"
 	"knock-fm/internal/http/middleware"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"time"
 )
 
 // PlatformLoader defines the interface for platform cache management
@@ -18,13 +21,22 @@ type PlatformLoader interface {
 
 type Router struct {
 	mux                  *http.ServeMux
+	adminMux             *http.ServeMux
 	logger               *slog.Logger
 	healthHandler        *handlers.HealthHandler
 	statsHandler         *handlers.StatsHandler
 	serversHandler       *handlers.ServersHandler
 	knoksHandler         *handlers.KnoksHandler
 	adminPlatformHandler *handlers.AdminPlatformHandler
+	adminQueueHandler    *handlers.AdminQueueHandler
+	adminReclassify      *handlers.AdminReclassifyHandler
+	adminSchemaHandler   *handlers.AdminSchemaHandler
+	adminOEmbedHandler   *handlers.AdminOEmbedHandler
+	previewHandler       *handlers.PreviewHandler
+	staticHandler        *handlers.StaticHandler
 	adminAuth            *middleware.AdminAuth
+	previewRateLimiter   *middleware.RateLimiter
+	middleware           []func(http.Handler) http.Handler
 }
 
 func NewRouter(
@@ -34,19 +46,92 @@ func NewRouter(
 	queueRepo domain.QueueRepository,
 	platformRepo handlers.PlatformRepository,
 	platformLoader PlatformLoader,
+	schemaStatus handlers.SchemaStatusSource,
+	urlDetector handlers.PlatformDetector,
+	extractor handlers.MetadataExtractor,
+	oembedReloader handlers.OEmbedReloader,
+	previewRateLimit int,
+	knokWaitMaxTimeout time.Duration,
+	staticDir string,
 ) *Router {
 	mux := http.NewServeMux()
 
+	// Serve the web build only if it is present; it may be deployed separately
+	var staticHandler *handlers.StaticHandler
+	if info, err := os.Stat(staticDir); staticDir != "" && err == nil && info.IsDir() {
+		staticHandler = handlers.NewStaticHandler(logger, staticDir)
+		logger.Info("Serving web frontend", "static_dir", staticDir)
+	} else {
+		logger.Info("Static directory not found, web frontend will not be served", "static_dir", staticDir)
+	}
+
+	knoksHandler := handlers.NewKnoksHandler(logger, knokRepo, queueRepo)
+	knoksHandler.SetMaxWaitTimeout(knokWaitMaxTimeout)
+	knoksHandler.SetPlatforms(platformLoader)
+
 	return &Router{
 		mux:                  mux,
 		logger:               logger,
 		healthHandler:        handlers.NewHealthHandler(logger),
-		statsHandler:         handlers.NewStatsHandler(logger),
-		serversHandler:       handlers.NewServersHandler(logger, serverRepo),
-		knoksHandler:         handlers.NewKnoksHandler(logger, knokRepo, queueRepo),
+		statsHandler:         handlers.NewStatsHandler(logger, knokRepo),
+		serversHandler:       handlers.NewServersHandler(logger, serverRepo, knokRepo),
+		knoksHandler:         knoksHandler,
 		adminPlatformHandler: handlers.NewAdminPlatformHandler(platformRepo, platformLoader, logger),
+		adminQueueHandler:    handlers.NewAdminQueueHandler(queueRepo, logger),
+		adminReclassify:      handlers.NewAdminReclassifyHandler(knokRepo, urlDetector, logger),
+		adminSchemaHandler:   handlers.NewAdminSchemaHandler(schemaStatus, logger),
+		adminOEmbedHandler:   handlers.NewAdminOEmbedHandler(oembedReloader, queueRepo, logger),
+		previewHandler:       handlers.NewPreviewHandler(logger, urlDetector, extractor),
+		staticHandler:        staticHandler,
 		adminAuth:            middleware.NewAdminAuth(logger),
+		previewRateLimiter:   middleware.NewRateLimiter(logger, previewRateLimit),
+	}
+}
+
+// knokAction serves GET /api/v1/knoks/{id}/{action} routes
+func (r *Router) knokAction(w http.ResponseWriter, req *http.Request) {
+	switch req.PathValue("action") {
+	case "wait":
+		r.knoksHandler.WaitForKnok(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// Use appends middleware applied around every route. The first middleware added is the outermost.
+func (r *Router) Use(middleware ...func(http.Handler) http.Handler) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// SetTrustedProxies sets the reverse proxies the preview rate limiter takes the client address
+// from X-Forwarded-For for
+func (r *Router) SetTrustedProxies(proxies []*net.IPNet) {
+	r.previewRateLimiter.SetTrustedProxies(proxies)
+}
+
+// SeparateAdminRoutes serves admin routes from AdminHandler instead of the public handler,
+// so they can be bound to an internal listener. It must be called before SetupRoutes.
+func (r *Router) SeparateAdminRoutes() {
+	r.adminMux = http.NewServeMux()
+}
+
+// handleAdmin registers an admin route behind the auth middleware, on the admin mux when
+// admin routes are separated and the public one otherwise
+func (r *Router) handleAdmin(pattern string, handler http.HandlerFunc) {
+	mux := r.mux
+	if r.adminMux != nil {
+		mux = r.adminMux
 	}
+	mux.Handle(pattern, r.adminAuth.Middleware(handler))
+}
+
+// AdminHandler returns the handler for the internal admin listener, or nil when admin routes
+// are served publicly. Browsers aren't expected to call it, so it skips CORS.
+func (r *Router) AdminHandler() http.Handler {
+	if r.adminMux == nil {
+		return nil
+	}
+	return middleware.Gzip(r.adminMux)
 }
 
 func (r *Router) SetupRoutes() http.Handler {
@@ -57,31 +142,83 @@ func (r *Router) SetupRoutes() http.Handler {
 	r.mux.HandleFunc("GET /api/v1/servers", r.serversHandler.GetServers)
 	r.mux.HandleFunc("POST /api/v1/servers", r.serversHandler.CreateServer)
 	r.mux.HandleFunc("GET /api/v1/servers/{id}", r.serversHandler.GetServerByID)
-	r.mux.HandleFunc("PUT /api/v1/servers/{id}", r.serversHandler.UpdateServer)
-	r.mux.HandleFunc("DELETE /api/v1/servers/{id}", r.serversHandler.DeleteServer)
+	r.mux.HandleFunc("GET /api/v1/servers/{id}/summary", r.serversHandler.GetServerSummary)
+	r.mux.HandleFunc("GET /api/v1/servers/{id}/leaderboard", r.serversHandler.GetServerLeaderboard)
+
+	// Admin server settings and management endpoints (protected by auth middleware)
+	r.handleAdmin("GET /api/v1/admin/servers/{id}/settings", r.serversHandler.GetServerSettings)
+	r.handleAdmin("PUT /api/v1/admin/servers/{id}/settings", r.serversHandler.UpdateServerSettings)
+	r.handleAdmin("PATCH /api/v1/servers/{id}/settings", r.serversHandler.PatchServerSettings)
+	r.handleAdmin("PUT /api/v1/servers/{id}", r.serversHandler.UpdateServer)
+	r.handleAdmin("DELETE /api/v1/servers/{id}", r.serversHandler.DeleteServer)
 
 	// API v1 routes - Stats
 	r.mux.HandleFunc("GET /api/v1/stats", r.statsHandler.HandleStats)
+	r.mux.HandleFunc("GET /api/v1/stats/activity", r.statsHandler.HandleActivity)
 
 	// API v1 routes - Get recent knoks (global and per-server)
 	r.mux.HandleFunc("GET /api/v1/knoks", r.knoksHandler.GetKnoks)                       // Global timeline
 	r.mux.HandleFunc("GET /api/v1/knoks/server/{serverId}", r.knoksHandler.GetKnoksByServer) // Server-specific
 	r.mux.HandleFunc("GET /api/v1/knoks/search", r.knoksHandler.SearchKnoks)
+	r.mux.HandleFunc("GET /api/v1/knoks/server/{serverId}/search", r.knoksHandler.SearchKnoksByServer)
 	r.mux.HandleFunc("GET /api/v1/knoks/random", r.knoksHandler.GetRandomKnok)
+	r.mux.HandleFunc("GET /api/v1/knoks/daily", r.knoksHandler.GetDailyKnok)
+	r.mux.HandleFunc("GET /api/v1/knoks/{id}", r.knoksHandler.GetKnokByID)
+	// GET /api/v1/knoks/{id}/wait would conflict with /knoks/server/{serverId}, so the action
+	// is a wildcard too and dispatched in knokAction
+	r.mux.HandleFunc("GET /api/v1/knoks/{id}/{action}", r.knokAction)
+
+	// API v1 routes - Link preview (runs extraction synchronously, so heavily rate limited)
+	r.mux.Handle("POST /api/v1/preview", r.previewRateLimiter.Middleware(http.HandlerFunc(r.previewHandler.Preview)))
 
 	// API v1 routes - Admin endpoints for managing knoks (protected by auth middleware)
-	r.mux.Handle("DELETE /api/v1/admin/knoks/{id}", r.adminAuth.Middleware(http.HandlerFunc(r.knoksHandler.DeleteKnok)))
-	r.mux.Handle("PATCH /api/v1/admin/knoks/{id}", r.adminAuth.Middleware(http.HandlerFunc(r.knoksHandler.UpdateKnok)))
-	r.mux.Handle("POST /api/v1/admin/knoks/{id}/refresh", r.adminAuth.Middleware(http.HandlerFunc(r.knoksHandler.RefreshKnok)))
+	r.handleAdmin("GET /api/v1/admin/knoks", r.knoksHandler.ListKnoksByStatus)
+	r.handleAdmin("GET /api/v1/admin/extraction-report", r.knoksHandler.GetExtractionReport)
+	r.handleAdmin("DELETE /api/v1/admin/knoks/{id}", r.knoksHandler.DeleteKnok)
+	r.handleAdmin("PATCH /api/v1/admin/knoks/{id}", r.knoksHandler.UpdateKnok)
+	r.handleAdmin("POST /api/v1/admin/knoks/{id}/refresh", r.knoksHandler.RefreshKnok)
+	r.handleAdmin("POST /api/v1/admin/knoks/reclassify", r.adminReclassify.ReclassifyKnoks)
+	r.handleAdmin("POST /api/v1/admin/knoks/refresh-failed", r.knoksHandler.RefreshFailedKnoks)
+	r.handleAdmin("DELETE /api/v1/admin/users/{userId}/knoks", r.knoksHandler.DeleteUserKnoks)
 
 	// Admin platform management endpoints (protected by auth middleware)
-	r.mux.Handle("GET /api/v1/admin/platforms", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.ListPlatforms)))
-	r.mux.Handle("POST /api/v1/admin/platforms", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.CreatePlatform)))
-	r.mux.Handle("PUT /api/v1/admin/platforms/{id}", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.UpdatePlatform)))
-	r.mux.Handle("PATCH /api/v1/admin/platforms/{id}", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.PatchPlatform)))
-	r.mux.Handle("DELETE /api/v1/admin/platforms/{id}", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.DeletePlatform)))
-	r.mux.Handle("POST /api/v1/admin/platforms/refresh", r.adminAuth.Middleware(http.HandlerFunc(r.adminPlatformHandler.RefreshCache)))
-
-	// Add CORS middleware
-	return middleware.CORS(r.mux)
+	r.handleAdmin("GET /api/v1/admin/platforms", r.adminPlatformHandler.ListPlatforms)
+	r.handleAdmin("POST /api/v1/admin/platforms", r.adminPlatformHandler.CreatePlatform)
+	r.handleAdmin("PUT /api/v1/admin/platforms/{id}", r.adminPlatformHandler.UpdatePlatform)
+	r.handleAdmin("PATCH /api/v1/admin/platforms/{id}", r.adminPlatformHandler.PatchPlatform)
+	r.handleAdmin("DELETE /api/v1/admin/platforms/{id}", r.adminPlatformHandler.DeletePlatform)
+	r.handleAdmin("POST /api/v1/admin/platforms/refresh", r.adminPlatformHandler.RefreshCache)
+	r.handleAdmin("POST /api/v1/admin/platforms/check-conflicts", r.adminPlatformHandler.CheckConflicts)
+	r.handleAdmin("POST /api/v1/admin/platforms/test", r.adminPlatformHandler.TestPatterns)
+	r.handleAdmin("POST /api/v1/admin/platforms/{id}/reextract", r.knoksHandler.ReextractPlatform)
+
+	// Admin queue endpoints for inspecting and reclaiming stuck jobs and repairing drifted
+	// stats (protected by auth middleware)
+	r.handleAdmin("GET /api/v1/admin/queue/{jobType}/processing", r.adminQueueHandler.ListProcessing)
+	r.handleAdmin("POST /api/v1/admin/queue/{jobType}/processing/reclaim", r.adminQueueHandler.ReclaimProcessing)
+	r.handleAdmin("POST /api/v1/admin/queue/{jobType}/stats/recompute", r.adminQueueHandler.RecomputeStats)
+
+	// Admin worker controls for pausing extraction during outages (protected by auth middleware)
+	r.handleAdmin("POST /api/v1/admin/worker/pause", r.adminQueueHandler.PauseWorker)
+	r.handleAdmin("POST /api/v1/admin/worker/resume", r.adminQueueHandler.ResumeWorker)
+
+	// Admin schema status for post-deploy verification (protected by auth middleware)
+	r.handleAdmin("GET /api/v1/admin/schema", r.adminSchemaHandler.GetSchema)
+
+	// Admin oEmbed providers reload after editing OEMBED_PROVIDERS_PATH (protected by auth middleware)
+	r.handleAdmin("POST /api/v1/admin/oembed/reload", r.adminOEmbedHandler.ReloadProviders)
+
+	// Web frontend - catch-all for non-API paths with SPA fallback to index.html
+	if r.staticHandler != nil {
+		r.mux.Handle("GET /", r.staticHandler)
+	}
+
+	// Add CORS and compression middleware
+	r.Use(middleware.CORS, middleware.Gzip)
+
+	var handler http.Handler = r.mux
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler
 }
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+
+	// With a key set, admin routes answer 401 before reaching their (nil) dependencies
+	t.Setenv("ADMIN_API_KEY", "test-key")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRouter(logger, nil, nil, nil, nil, nil, nil, nil, nil, nil, 5, time.Second, "")
+}
+
+func TestSeparateAdminRoutes(t *testing.T) {
+	adminPaths := []string{
+		"/api/v1/admin/knoks",
+		"/api/v1/admin/schema",
+		"/api/v1/admin/platforms",
+	}
+
+	t.Run("Shared listener", func(t *testing.T) {
+		router := newTestRouter(t)
+		public := router.SetupRoutes()
+
+		if router.AdminHandler() != nil {
+			t.Error("AdminHandler() should be nil when admin routes aren't separated")
+		}
+		for _, path := range adminPaths {
+			rec := httptest.NewRecorder()
+			public.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("public GET %s status = %d, want %d", path, rec.Code, http.StatusUnauthorized)
+			}
+		}
+	})
+
+	t.Run("Separate listener", func(t *testing.T) {
+		router := newTestRouter(t)
+		router.SeparateAdminRoutes()
+		public := router.SetupRoutes()
+		admin := router.AdminHandler()
+
+		for _, path := range adminPaths {
+			rec := httptest.NewRecorder()
+			public.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("public GET %s status = %d, want %d", path, rec.Code, http.StatusNotFound)
+			}
+
+			rec = httptest.NewRecorder()
+			admin.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("admin GET %s status = %d, want %d", path, rec.Code, http.StatusUnauthorized)
+			}
+		}
+
+		// Public routes stay on the public listener only
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("admin GET /health status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestServerWriteRoutesRequireAuth(t *testing.T) {
+	public := newTestRouter(t).SetupRoutes()
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		public.ServeHTTP(rec, httptest.NewRequest(method, "/api/v1/servers/123", nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s /api/v1/servers/123 status = %d, want %d", method, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
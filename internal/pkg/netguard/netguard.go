@@ -0,0 +1,54 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a connection to a non-public address is refused
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// IsPublicIP reports whether ip is routable on the public internet, i.e. not a loopback,
+// private, link-local or unspecified address
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified())
+}
+
+// Control is a net.Dialer Control function that refuses connections to non-public
+// addresses. It runs after the host is resolved, for every connection including those
+// made while following redirects, so a DNS answer that changes between lookups can't
+// slip through
+func Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid dial address %q: %w", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid dial address %q", address)
+	}
+	if !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+	}
+	return nil
+}
+
+// NewTransport returns an HTTP transport that only connects to public addresses. Proxy
+// environment variables are ignored, since the proxy's address is the one that would be
+// checked
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   Control,
+	}).DialContext
+	return transport
+}
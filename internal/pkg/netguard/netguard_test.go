@@ -0,0 +1,60 @@
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "Public IPv4", address: "151.101.1.1:443"},
+		{name: "Public IPv6", address: "[2606:4700::1111]:443"},
+		{name: "Loopback", address: "127.0.0.1:80", wantErr: true},
+		{name: "IPv6 loopback", address: "[::1]:80", wantErr: true},
+		{name: "Private", address: "10.0.0.5:8080", wantErr: true},
+		{name: "Docker network", address: "172.18.0.3:5432", wantErr: true},
+		{name: "Link-local metadata service", address: "169.254.169.254:80", wantErr: true},
+		{name: "Unspecified", address: "0.0.0.0:80", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Control("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Control(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNonPublicAddress) {
+				t.Errorf("Control(%q) error = %v, want ErrNonPublicAddress", tt.address, err)
+			}
+		})
+	}
+}
+
+func TestNewTransportRefusesNonPublicAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport()}
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Fatalf("Get() error = %v, want ErrNonPublicAddress", err)
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests, want 0", requests)
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("Get() error = %T, want the dial error wrapped in a *net.OpError", err)
+	}
+}
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	// DefaultInitialDelay is the wait after the first failed attempt when Backoff has none
+	DefaultInitialDelay = time.Second
+
+	// DefaultMaxDelay caps the doubling wait between attempts when Backoff has none
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// Backoff configures how often and how patiently an operation is retried
+type Backoff struct {
+	// Attempts is the total number of tries, including the first. Values below 1 mean 1
+	Attempts int
+
+	// InitialDelay is the wait after the first failure; it doubles after each further
+	// failure up to MaxDelay
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// permanentError marks an error that retrying can't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it straight away instead of retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls op until it succeeds, returns a Permanent error, the attempts run out or ctx is
+// done, waiting with exponential backoff between failures. Each failure that will be
+// retried is logged as a warning with the operation name. The last error from op is
+// returned (unwrapped from Permanent)
+func Do(ctx context.Context, b Backoff, logger *slog.Logger, operation string, op func() error) error {
+	attempts := max(b.Attempts, 1)
+	delay := b.InitialDelay
+	if delay <= 0 {
+		delay = DefaultInitialDelay
+	}
+	maxDelay := b.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= attempts {
+			return err
+		}
+
+		logger.Warn("Operation failed, retrying",
+			"operation", operation,
+			"attempt", attempt,
+			"max_attempts", attempts,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, maxDelay)
+	}
+}
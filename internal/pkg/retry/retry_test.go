@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// flakyOp fails with err for its first failures calls, then succeeds
+type flakyOp struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyOp) run() error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func TestDo(t *testing.T) {
+	errUnreachable := errors.New("dial tcp: connection refused")
+	errUnauthorized := errors.New("HTTP 401 Unauthorized")
+
+	tests := []struct {
+		name      string
+		attempts  int
+		op        *flakyOp
+		wrap      func(error) error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "Succeeds first time",
+			attempts:  3,
+			op:        &flakyOp{},
+			wantCalls: 1,
+		},
+		{
+			name:      "Fails then succeeds",
+			attempts:  3,
+			op:        &flakyOp{failures: 2, err: errUnreachable},
+			wantCalls: 3,
+		},
+		{
+			name:      "Gives up after the last attempt",
+			attempts:  3,
+			op:        &flakyOp{failures: 5, err: errUnreachable},
+			wantErr:   errUnreachable,
+			wantCalls: 3,
+		},
+		{
+			name:      "Zero attempts still tries once",
+			attempts:  0,
+			op:        &flakyOp{failures: 1, err: errUnreachable},
+			wantErr:   errUnreachable,
+			wantCalls: 1,
+		},
+		{
+			name:      "Permanent errors are not retried",
+			attempts:  3,
+			op:        &flakyOp{failures: 1, err: errUnauthorized},
+			wrap:      Permanent,
+			wantErr:   errUnauthorized,
+			wantCalls: 1,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := tt.op.run
+			if tt.wrap != nil {
+				op = func() error { return tt.wrap(tt.op.run()) }
+			}
+
+			b := Backoff{Attempts: tt.attempts, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+			err := Do(context.Background(), b, logger, "test", op)
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.op.calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", tt.op.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errUnreachable := errors.New("dial tcp: connection refused")
+	op := &flakyOp{failures: 10, err: errUnreachable}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	b := Backoff{Attempts: 10, InitialDelay: time.Hour}
+	err := Do(ctx, b, slog.New(slog.NewTextHandler(io.Discard, nil)), "test", op.run)
+	if err != errUnreachable {
+		t.Errorf("Do() error = %v, want %v", err, errUnreachable)
+	}
+	if op.calls != 1 {
+		t.Errorf("op called %d times, want 1", op.calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() took %s after cancel, want it to stop waiting", elapsed)
+	}
+}
@@ -0,0 +1,195 @@
+package urldetector
+
+import (
+	"knock-fm/internal/domain"
+	"regexp"
+	"sort"
+)
+
+// PatternConflict reports that a URL pattern of one platform is also matched by
+// another platform's patterns, so links for it are detected ambiguously
+type PatternConflict struct {
+	Pattern    string   `json:"pattern"`
+	Platform   string   `json:"platform"`
+	MatchedBy  []string `json:"matched_by"`
+	DetectedAs string   `json:"detected_as"`
+}
+
+// URLMatch lists the platforms whose patterns match a URL. Detection picks DetectedAs,
+// the highest priority match; more than one match means the URL is ambiguous.
+type URLMatch struct {
+	URL        string   `json:"url"`
+	Platforms  []string `json:"platforms"`
+	DetectedAs string   `json:"detected_as,omitempty"`
+	Ambiguous  bool     `json:"ambiguous"`
+}
+
+// platformRegexes holds the compiled patterns of a platform
+type platformRegexes struct {
+	platform *domain.Platform
+	regexes  []*regexp.Regexp
+}
+
+// compilePlatforms compiles platform patterns the same way the detector does, ordered
+// by priority (highest first) like detection
+func compilePlatforms(platforms []*domain.Platform) []platformRegexes {
+	sorted := make([]*domain.Platform, len(platforms))
+	copy(sorted, platforms)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	compiled := make([]platformRegexes, 0, len(sorted))
+	for _, platform := range sorted {
+		entry := platformRegexes{platform: platform}
+		for _, urlPattern := range platform.URLPatterns {
+			if regex, err := regexp.Compile(buildRegexPattern(urlPattern)); err == nil {
+				entry.regexes = append(entry.regexes, regex)
+			}
+		}
+		compiled = append(compiled, entry)
+	}
+	return compiled
+}
+
+// matchingPlatforms returns the IDs of platforms with a pattern matching text, in priority order
+func matchingPlatforms(compiled []platformRegexes, text string) []string {
+	var matches []string
+	for _, entry := range compiled {
+		for _, regex := range entry.regexes {
+			if regex.MatchString(text) {
+				matches = append(matches, entry.platform.ID)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// MatchURLs reports which platforms match each URL
+func MatchURLs(platforms []*domain.Platform, urls []string) []URLMatch {
+	compiled := compilePlatforms(platforms)
+
+	results := make([]URLMatch, 0, len(urls))
+	for _, url := range urls {
+		result := URLMatch{URL: url, Platforms: matchingPlatforms(compiled, url)}
+		if len(result.Platforms) > 0 {
+			result.DetectedAs = result.Platforms[0]
+		}
+		result.Ambiguous = len(result.Platforms) > 1
+		results = append(results, result)
+	}
+	return results
+}
+
+// FindPatternConflicts reports every pattern that the patterns of another platform also
+// match. Each pattern is tried as a link to the bare host and to a subdomain of it, since
+// some patterns (e.g. Bandcamp's) only match subdomains.
+func FindPatternConflicts(platforms []*domain.Platform) []PatternConflict {
+	compiled := compilePlatforms(platforms)
+
+	var conflicts []PatternConflict
+	for _, entry := range compiled {
+		for _, urlPattern := range entry.platform.URLPatterns {
+			matched := make(map[string]bool)
+			for _, sample := range []string{"https://" + urlPattern + "/", "https://artist." + urlPattern + "/"} {
+				for _, id := range matchingPlatforms(compiled, sample) {
+					matched[id] = true
+				}
+			}
+
+			// Walk platforms in priority order so the first match is the detected one
+			conflict := PatternConflict{Pattern: urlPattern, Platform: entry.platform.ID}
+			for _, other := range compiled {
+				id := other.platform.ID
+				if !matched[id] {
+					continue
+				}
+				if conflict.DetectedAs == "" {
+					conflict.DetectedAs = id
+				}
+				if id != entry.platform.ID {
+					conflict.MatchedBy = append(conflict.MatchedBy, id)
+				}
+			}
+			if len(conflict.MatchedBy) > 0 {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+	return conflicts
+}
+
+// PriorityTie reports two enabled platforms with the same priority whose patterns overlap.
+// Detection order between them isn't defined, so links matching Pattern may be detected
+// as either.
+type PriorityTie struct {
+	Platforms []string `json:"platforms"`
+	Priority  int      `json:"priority"`
+	Pattern   string   `json:"pattern"`
+}
+
+// FindPriorityTies reports each pair of enabled platforms that share a priority and have
+// conflicting patterns. Disabled platforms are ignored since they aren't detected.
+func FindPriorityTies(platforms []*domain.Platform) []PriorityTie {
+	enabled := make([]*domain.Platform, 0, len(platforms))
+	priorities := make(map[string]int)
+	for _, platform := range platforms {
+		if platform.Enabled {
+			enabled = append(enabled, platform)
+			priorities[platform.ID] = platform.Priority
+		}
+	}
+
+	var ties []PriorityTie
+	seen := make(map[[2]string]bool)
+	for _, conflict := range FindPatternConflicts(enabled) {
+		for _, other := range conflict.MatchedBy {
+			if priorities[other] != priorities[conflict.Platform] {
+				continue
+			}
+			pair := [2]string{conflict.Platform, other}
+			if pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if seen[pair] {
+				continue
+			}
+			seen[pair] = true
+			ties = append(ties, PriorityTie{
+				Platforms: pair[:],
+				Priority:  priorities[other],
+				Pattern:   conflict.Pattern,
+			})
+		}
+	}
+	return ties
+}
+
+// PatternMatch lists the URLs that a single URL pattern matches
+type PatternMatch struct {
+	Pattern string   `json:"pattern"`
+	Regex   string   `json:"regex"`
+	Matches []string `json:"matches"`
+}
+
+// MatchPatterns compiles each pattern as the detector would and reports which URLs it matches
+func MatchPatterns(patterns []string, urls []string) []PatternMatch {
+	results := make([]PatternMatch, 0, len(patterns))
+	for _, urlPattern := range patterns {
+		result := PatternMatch{
+			Pattern: urlPattern,
+			Regex:   buildRegexPattern(urlPattern),
+			Matches: []string{},
+		}
+		if regex, err := regexp.Compile(result.Regex); err == nil {
+			for _, url := range urls {
+				if regex.MatchString(url) {
+					result.Matches = append(result.Matches, url)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,131 @@
+package urldetector
+
+import (
+	"knock-fm/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestFindPatternConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []*domain.Platform
+		want      []PatternConflict
+	}{
+		{
+			name: "Default platforms don't conflict",
+			platforms: func() []*domain.Platform {
+				config := domain.GetDefaultPlatformConfig()
+				platforms := make([]*domain.Platform, 0, len(config.Platforms))
+				for _, platform := range config.Platforms {
+					p := platform
+					platforms = append(platforms, &p)
+				}
+				return platforms
+			}(),
+			want: nil,
+		},
+		{
+			name: "Same domain claimed twice",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com", "youtu.be"}, Priority: 10},
+				{ID: "yt_mirror", URLPatterns: []string{"youtube.com"}, Priority: 5},
+			},
+			want: []PatternConflict{
+				{Pattern: "youtube.com", Platform: "youtube", MatchedBy: []string{"yt_mirror"}, DetectedAs: "youtube"},
+				{Pattern: "youtube.com", Platform: "yt_mirror", MatchedBy: []string{"youtube"}, DetectedAs: "youtube"},
+			},
+		},
+		{
+			name: "Subdomain pattern shadowed by a broader one",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com"}, Priority: 10},
+				{ID: "youtube_music", URLPatterns: []string{"music.youtube.com"}, Priority: 5},
+			},
+			want: []PatternConflict{
+				{Pattern: "music.youtube.com", Platform: "youtube_music", MatchedBy: []string{"youtube"}, DetectedAs: "youtube"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindPatternConflicts(tt.platforms)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPatternConflicts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchURLs(t *testing.T) {
+	platforms := []*domain.Platform{
+		{ID: "soundcloud", URLPatterns: []string{"soundcloud.com"}, Priority: 5},
+		{ID: "sc_clone", URLPatterns: []string{"soundcloud.com"}, Priority: 8},
+		{ID: "bandcamp", URLPatterns: []string{"bandcamp.com"}, Priority: 5},
+	}
+
+	got := MatchURLs(platforms, []string{
+		"https://soundcloud.com/artist/track",
+		"https://artist.bandcamp.com/album/record",
+		"https://example.com/page",
+	})
+	want := []URLMatch{
+		{URL: "https://soundcloud.com/artist/track", Platforms: []string{"sc_clone", "soundcloud"}, DetectedAs: "sc_clone", Ambiguous: true},
+		{URL: "https://artist.bandcamp.com/album/record", Platforms: []string{"bandcamp"}, DetectedAs: "bandcamp"},
+		{URL: "https://example.com/page"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchURLs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindPriorityTies(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []*domain.Platform
+		want      []PriorityTie
+	}{
+		{
+			name: "Overlapping patterns with the same priority",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com"}, Priority: 5, Enabled: true},
+				{ID: "yt_mirror", URLPatterns: []string{"youtube.com"}, Priority: 5, Enabled: true},
+			},
+			want: []PriorityTie{{Platforms: []string{"youtube", "yt_mirror"}, Priority: 5, Pattern: "youtube.com"}},
+		},
+		{
+			name: "Different priorities decide the order",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com"}, Priority: 10, Enabled: true},
+				{ID: "yt_mirror", URLPatterns: []string{"youtube.com"}, Priority: 5, Enabled: true},
+			},
+			want: nil,
+		},
+		{
+			name: "Same priority without overlap",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com"}, Enabled: true},
+				{ID: "soundcloud", URLPatterns: []string{"soundcloud.com"}, Enabled: true},
+			},
+			want: nil,
+		},
+		{
+			name: "Disabled platforms are ignored",
+			platforms: []*domain.Platform{
+				{ID: "youtube", URLPatterns: []string{"youtube.com"}, Enabled: true},
+				{ID: "yt_mirror", URLPatterns: []string{"youtube.com"}, Enabled: false},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindPriorityTies(tt.platforms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPriorityTies() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
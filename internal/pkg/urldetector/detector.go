@@ -7,16 +7,19 @@ import (
 	"log/slog"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // URLInfo contains information about a detected URL
 type URLInfo struct {
-	URL          string // Original (normalized) URL as posted
-	CanonicalURL string // Resolved + canonicalized URL for dedup
-	Platform     string
+	URL            string // Original (normalized) URL as posted
+	CanonicalURL   string // Resolved + canonicalized URL for dedup
+	Platform       string
+	PlatformItemID string // Platform's ID for the item from its extraction patterns, empty if none matched
 }
 
 // PlatformLoader defines the interface for loading platform configurations
@@ -31,7 +34,21 @@ type Detector struct {
 	resolver *urlresolver.Resolver
 	logger   *slog.Logger
 	patterns []compiledPattern
-	mu       sync.RWMutex
+	// itemPatterns holds each platform's compiled extraction patterns, keyed by platform ID
+	itemPatterns map[string][]*regexp.Regexp
+	// domainRegex matches the platforms' domains written without a protocol; nil when
+	// there are no platforms
+	domainRegex *regexp.Regexp
+	// platformCache remembers the platform detected for each host; nil when disabled
+	platformCache *platformCache
+	// hostOnlyPatterns is true when no URL pattern has a path, so a URL's platform
+	// depends only on its host and can be cached by host
+	hostOnlyPatterns bool
+	// logRejected logs each candidate URL that fails normalization, with the reason
+	logRejected bool
+	// stripEmoji removes emoji written directly before links before detection
+	stripEmoji bool
+	mu         sync.RWMutex
 }
 
 type compiledPattern struct {
@@ -43,9 +60,11 @@ type compiledPattern struct {
 // If resolver is nil, short link resolution is skipped.
 func New(loader PlatformLoader, resolver *urlresolver.Resolver, logger *slog.Logger) *Detector {
 	detector := &Detector{
-		loader:   loader,
-		resolver: resolver,
-		logger:   logger,
+		loader:        loader,
+		resolver:      resolver,
+		logger:        logger,
+		platformCache: newPlatformCache(DefaultPlatformCacheSize),
+		stripEmoji:    true,
 	}
 	detector.buildPatterns()
 	return detector
@@ -56,10 +75,18 @@ func (d *Detector) buildPatterns() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// Cached results may be stale once the patterns change
+	if d.platformCache != nil {
+		d.platformCache.purge()
+	}
+	d.hostOnlyPatterns = false
+	d.domainRegex = nil
+
 	// Check if loader has loaded platforms
 	if !d.loader.IsLoaded() {
 		d.logger.Warn("Platform loader not ready, patterns not built yet")
 		d.patterns = make([]compiledPattern, 0)
+		d.itemPatterns = make(map[string][]*regexp.Regexp)
 		return
 	}
 
@@ -68,20 +95,28 @@ func (d *Detector) buildPatterns() {
 	if err != nil {
 		d.logger.Error("Failed to get platforms from loader", "error", err)
 		d.patterns = make([]compiledPattern, 0)
+		d.itemPatterns = make(map[string][]*regexp.Regexp)
 		return
 	}
 
 	d.patterns = make([]compiledPattern, 0)
+	d.itemPatterns = make(map[string][]*regexp.Regexp)
+	d.domainRegex = buildDomainRegex(platforms)
+	d.hostOnlyPatterns = true
 
 	// Build patterns for each platform (respecting priority order)
 	for _, platform := range platforms {
 		for _, urlPattern := range platform.URLPatterns {
+			if strings.Contains(urlPattern, "/") {
+				d.hostOnlyPatterns = false
+			}
+
 			// Build comprehensive regex pattern that handles:
 			// - Optional protocols (http/https)
 			// - Optional www subdomain
 			// - Exact domain matching
 			// - Word boundaries to avoid false positives
-			regexPattern := d.buildRegexPattern(urlPattern)
+			regexPattern := buildRegexPattern(urlPattern)
 
 			if compiled, err := regexp.Compile(regexPattern); err == nil {
 				d.patterns = append(d.patterns, compiledPattern{
@@ -90,6 +125,18 @@ func (d *Detector) buildPatterns() {
 				})
 			}
 		}
+
+		for _, extractionPattern := range platform.ExtractionPatterns {
+			compiled, err := CompileExtractionPattern(extractionPattern)
+			if err != nil {
+				d.logger.Warn("Skipping invalid extraction pattern",
+					"platform", platform.ID,
+					"error", err,
+				)
+				continue
+			}
+			d.itemPatterns[platform.ID] = append(d.itemPatterns[platform.ID], compiled)
+		}
 	}
 
 	d.logger.Info("Built URL detection patterns",
@@ -99,7 +146,7 @@ func (d *Detector) buildPatterns() {
 }
 
 // buildRegexPattern creates an optimized regex from a simple URL pattern
-func (d *Detector) buildRegexPattern(urlPattern string) string {
+func buildRegexPattern(urlPattern string) string {
 	// Handle special cases for different patterns
 	switch {
 	case strings.HasPrefix(urlPattern, "www."):
@@ -125,6 +172,64 @@ func (d *Detector) buildRegexPattern(urlPattern string) string {
 	}
 }
 
+// Patterns for the DetectURLs stages, compiled once rather than on every message
+var (
+	// markdownRegex matches Markdown links [text](url). The URL may contain balanced
+	// parentheses, as Wikipedia URLs do
+	markdownRegex = regexp.MustCompile(`\[([^\]]+)\]\(((?:[^()\s]|\([^()\s]*\))+)\)`)
+
+	// suppressedRegex matches Discord suppressed embeds <https://...>
+	suppressedRegex = regexp.MustCompile(`<(https?://[^>]+)>`)
+
+	// urlRegex matches http(s):// URLs with domain, path, query, fragment.
+	// Stops at @ to avoid capturing Discord mentions after URLs, and at a parenthesis
+	// unless it's part of a balanced pair like Wikipedia's /wiki/Daft_Punk_(band)
+	urlRegex = regexp.MustCompile(
+		`(?i)https?://[\w\-]+(?:\.[\w\-]+)+` +
+			`(?:/` + urlPartChars + `)?(?:\?` + urlPartChars + `)?(?:#` + urlPartChars + `)?`,
+	)
+)
+
+// urlPartChars matches the characters of a URL path, query or fragment, allowing
+// parentheses only in balanced pairs. Unicode spaces such as a non-breaking space end a
+// URL like ASCII whitespace does
+const urlPartChars = `(?:[^\s\p{Z}<>\[\]()@]|\([^\s\p{Z}<>\[\]()@]*\))*`
+
+// buildDomainRegex returns a regex matching plain domains without a protocol, e.g.
+// "spotify.com/track/..." or "www.youtube.com/watch?v=...", for the hosts in the platforms'
+// URL patterns and their subdomains. Only platform domains match, to avoid false positives.
+// Returns nil if there are no patterns
+func buildDomainRegex(platforms []*domain.Platform) *regexp.Regexp {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, platform := range platforms {
+		for _, urlPattern := range platform.URLPatterns {
+			host, _, _ := strings.Cut(strings.ToLower(urlPattern), "/")
+			host = strings.TrimPrefix(host, "www.")
+			if host == "" || seen[host] {
+				continue
+			}
+			seen[host] = true
+			hosts = append(hosts, regexp.QuoteMeta(host))
+		}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	// Longest first so the alternation order is stable across reloads
+	sort.Slice(hosts, func(i, j int) bool {
+		if len(hosts[i]) != len(hosts[j]) {
+			return len(hosts[i]) > len(hosts[j])
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	return regexp.MustCompile(
+		`(?i)(?:^|\s)((?:[\w-]+\.)*(?:` + strings.Join(hosts, "|") + `)\b(?:/` + urlPartChars + `)?)`,
+	)
+}
+
 // DetectURLs finds all supported music URLs in content and returns them with platform info.
 // Uses a multi-stage approach to handle various URL formats:
 // - Stage 1: Markdown links [text](url)
@@ -140,11 +245,20 @@ func (d *Detector) DetectURLs(content string) []URLInfo {
 	var urls []URLInfo
 	seen := make(map[string]bool)
 
+	// Invisible characters pasted into a link would otherwise split or corrupt it
+	content = StripInvisibleChars(content)
+
+	// Emoji stuck to a plain domain ("🔥soundcloud.com/...") keep it from starting a word
+	if d.stripEmoji {
+		content = stripEmojiBeforeURLs(content)
+	}
+
 	// Stage 1: Extract Markdown Links [text](url)
-	markdownRegex := regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
 	for _, match := range markdownRegex.FindAllStringSubmatch(content, -1) {
 		if len(match) > 2 {
-			d.addIfSupported(match[2], &urls, seen)
+			// Masked links can suppress their embed too: [text](<url>)
+			url := strings.TrimSuffix(strings.TrimPrefix(match[2], "<"), ">")
+			d.addIfSupported(url, &urls, seen)
 		}
 	}
 
@@ -152,7 +266,6 @@ func (d *Detector) DetectURLs(content string) []URLInfo {
 	cleanContent := markdownRegex.ReplaceAllString(content, " ")
 
 	// Stage 2: Extract Discord Suppressed Embeds <https://...>
-	suppressedRegex := regexp.MustCompile(`<(https?://[^>]+)>`)
 	cleanContent = suppressedRegex.ReplaceAllStringFunc(cleanContent, func(s string) string {
 		url := s[1 : len(s)-1] // Remove angle brackets
 		d.addIfSupported(url, &urls, seen)
@@ -160,28 +273,18 @@ func (d *Detector) DetectURLs(content string) []URLInfo {
 	})
 
 	// Stage 3: Extract Standard URLs (comprehensive pattern)
-	// Matches http(s):// URLs with domain, path, query, fragment
-	// Stop at @ symbol to avoid capturing Discord mentions after URLs
-	urlRegex := regexp.MustCompile(
-		`(?i)https?://[\w\-]+(?:\.[\w\-]+)+(?:/[^\s<>\[\]()@]*)?(?:\?[^\s<>\[\]()@]*)?(?:#[^\s<>\[\]()@]*)?`,
-	)
-
 	for _, match := range urlRegex.FindAllString(cleanContent, -1) {
 		cleaned := cleanTrailingPunctuation(match)
 		d.addIfSupported(cleaned, &urls, seen)
 	}
 
-	// Stage 4: Extract Plain Domains (no protocol)
-	// Common case: "spotify.com/track/..." or "www.youtube.com/watch?v=..."
-	// Only match known music platform domains to avoid false positives
-	domainRegex := regexp.MustCompile(
-		`(?i)(?:^|\s)((?:www\.)?(?:spotify|youtube|youtu|soundcloud|bandcamp|mixcloud|tidal|deezer|apple)\.(?:com|be|fm|live)(?:/[^\s<>\[\]()]*)?)`,
-	)
-
-	for _, match := range domainRegex.FindAllStringSubmatch(cleanContent, -1) {
-		if len(match) > 1 {
-			cleaned := cleanTrailingPunctuation(match[1])
-			d.addIfSupported(cleaned, &urls, seen)
+	// Stage 4: Extract Plain Domains (no protocol) of known platforms
+	if d.domainRegex != nil {
+		for _, match := range d.domainRegex.FindAllStringSubmatch(cleanContent, -1) {
+			if len(match) > 1 {
+				cleaned := cleanTrailingPunctuation(match[1])
+				d.addIfSupported(cleaned, &urls, seen)
+			}
 		}
 	}
 
@@ -199,6 +302,33 @@ func (d *Detector) DetectURLs(content string) []URLInfo {
 	return urls
 }
 
+// contextURLRegex matches anything URL-like (markdown links, suppressed embeds, http(s) URLs
+// and bare domains with a path) so it can be stripped when measuring surrounding context
+var contextURLRegex = regexp.MustCompile(
+	`(?i)\[[^\]]*\]\([^)]*\)|<https?://[^>]+>|https?://\S+|(?:www\.)?[\w-]+(?:\.[\w-]+)+/\S*`,
+)
+
+// mentionRegex matches Discord user, role, channel and custom emoji tokens
+var mentionRegex = regexp.MustCompile(`<(?:@[!&]?|#|a?:\w+:)\w*>`)
+
+// CountContextWords returns the number of non-URL words in a message.
+// URLs, Discord mentions/emoji tokens and tokens without any letters or digits
+// (punctuation, unicode emoji) are not counted, so a bare shared link returns 0.
+func CountContextWords(content string) int {
+	stripped := contextURLRegex.ReplaceAllString(content, " ")
+	stripped = mentionRegex.ReplaceAllString(stripped, " ")
+
+	count := 0
+	for _, word := range strings.Fields(stripped) {
+		if strings.IndexFunc(word, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
 // addIfSupported normalizes a URL, detects its platform, and adds it to the results if valid.
 // This helper prevents duplicates and ensures all URLs are properly normalized.
 func (d *Detector) addIfSupported(rawURL string, urls *[]URLInfo, seen map[string]bool) {
@@ -226,6 +356,13 @@ func (d *Detector) addIfSupported(rawURL string, urls *[]URLInfo, seen map[strin
 	normalizedURL, err := NormalizeURL(decodedURL)
 	if err != nil {
 		// Invalid URL, skip it
+		if d.logRejected {
+			d.logger.Debug("URL rejected",
+				"raw_url", rawURL,
+				"decoded_url", decodedURL,
+				"reason", err.Error(),
+			)
+		}
 		return
 	}
 
@@ -258,12 +395,23 @@ func (d *Detector) addIfSupported(rawURL string, urls *[]URLInfo, seen map[strin
 	// Detect platform using resolved URL (better match after short link resolution)
 	platform := d.detectPlatformFromURL(resolvedURL)
 
+	// Different URL variants of the same item only need to be reported once
+	itemID := extractItemID(d.itemPatterns[platform], resolvedURL)
+	if itemID != "" {
+		itemKey := platform + ":" + itemID
+		if seen[itemKey] {
+			return
+		}
+		seen[itemKey] = true
+	}
+
 	// Add to results (including unknown platforms)
 	seen[canonicalURL] = true
 	*urls = append(*urls, URLInfo{
-		URL:          normalizedURL,
-		CanonicalURL: canonicalURL,
-		Platform:     platform,
+		URL:            normalizedURL,
+		CanonicalURL:   canonicalURL,
+		Platform:       platform,
+		PlatformItemID: itemID,
 	})
 }
 
@@ -287,13 +435,43 @@ func fixMalformedQueryString(rawURL string) string {
 	return base + queryPart
 }
 
-// detectPlatformFromURL detects platform using database-loaded patterns
+// detectPlatformFromURL detects platform using database-loaded patterns, caching the
+// result by host when the patterns only match hosts
 // Note: This is called from addIfSupported which already holds a read lock,
 // so we don't acquire another lock here to avoid deadlock
-func (d *Detector) detectPlatformFromURL(url string) string {
+func (d *Detector) detectPlatformFromURL(rawURL string) string {
+	host := d.platformCacheKey(rawURL)
+	if host == "" {
+		return d.matchPlatform(rawURL)
+	}
+
+	if platform, ok := d.platformCache.get(host); ok {
+		return platform
+	}
+	platform := d.matchPlatform(host)
+	d.platformCache.add(host, platform)
+	return platform
+}
+
+// platformCacheKey returns the lowercased host a URL's platform is cached under, or an
+// empty string if caching is disabled or the URL has no host
+func (d *Detector) platformCacheKey(rawURL string) string {
+	if d.platformCache == nil || !d.hostOnlyPatterns {
+		return ""
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// matchPlatform runs the compiled patterns against s, returning the first (highest
+// priority) matching platform
+func (d *Detector) matchPlatform(s string) string {
 	// Use the compiled patterns from the database (respects priority)
 	for _, pattern := range d.patterns {
-		if pattern.regex.MatchString(url) {
+		if pattern.regex.MatchString(s) {
 			return pattern.platform
 		}
 	}
@@ -302,6 +480,42 @@ func (d *Detector) detectPlatformFromURL(url string) string {
 	return domain.PlatformUnknown
 }
 
+// SetLogRejectedURLs enables debug logging of every candidate URL dropped because it
+// couldn't be normalized, with the reason, to diagnose links that weren't detected
+func (d *Detector) SetLogRejectedURLs(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logRejected = enabled
+}
+
+// SetStripEmojiBeforeURLs sets whether emoji written directly before a link are removed
+// before detection, as in "🔥soundcloud.com/...". Enabled by default
+func (d *Detector) SetStripEmojiBeforeURLs(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stripEmoji = enabled
+}
+
+// SetPlatformCacheSize sets how many hosts' detected platforms are cached, dropping
+// anything already cached. 0 disables the cache
+func (d *Detector) SetPlatformCacheSize(size int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.platformCache = newPlatformCache(size)
+}
+
+// ExtractItemID returns the platform item ID for a URL using the platform's extraction
+// patterns, or an empty string if the platform has none or none match
+func (d *Detector) ExtractItemID(platform, rawURL string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return extractItemID(d.itemPatterns[platform], rawURL)
+}
+
 // IsSupported checks if a URL matches any supported platform pattern
 func (d *Detector) IsSupported(url string) bool {
 	d.mu.RLock()
@@ -331,6 +545,27 @@ func (d *Detector) GetSupportedPlatforms() []string {
 	return platformIDs
 }
 
+// GetSupportedPlatformNames returns the display names of all supported platforms, highest
+// priority first. Platforms without a name are listed by ID
+func (d *Detector) GetSupportedPlatformNames() []string {
+	platforms, err := d.loader.GetAllByPriority()
+	if err != nil {
+		d.logger.Warn("Failed to get platforms from loader", "error", err)
+		return []string{}
+	}
+
+	names := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		name := platform.Name
+		if name == "" {
+			name = platform.ID
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
 // Refresh rebuilds patterns from the current domain configuration
 // Useful if platform config is updated at runtime
 func (d *Detector) Refresh() {
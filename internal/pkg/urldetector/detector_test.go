@@ -1,6 +1,13 @@
 package urldetector
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -46,3 +53,440 @@ func TestFixMalformedQueryString(t *testing.T) {
 		})
 	}
 }
+
+func TestCountContextWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{
+			name:    "Bare link",
+			content: "https://soundcloud.com/artist/track",
+			want:    0,
+		},
+		{
+			name:    "Link with emoji and punctuation",
+			content: "🔥 https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8 !!",
+			want:    0,
+		},
+		{
+			name:    "Suppressed embed with mention",
+			content: "<@123456> <https://youtube.com/watch?v=dQw4w9WgXcQ>",
+			want:    0,
+		},
+		{
+			name:    "Link with short caption",
+			content: "new one https://artist.bandcamp.com/album/record",
+			want:    2,
+		},
+		{
+			name:    "Link buried in a paragraph",
+			content: "so yesterday we were talking about that gig and someone mentioned youtube.com/watch?v=dQw4w9WgXcQ which reminded me of the whole thing",
+			want:    18,
+		},
+		{
+			name:    "Markdown link",
+			content: "[listen](https://mixcloud.com/show/episode)",
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountContextWords(tt.content); got != tt.want {
+				t.Errorf("CountContextWords() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkMessages are typical Discord messages: bare links, captions, markdown and
+// suppressed embeds, wrapped URLs and chatter without links
+var benchmarkMessages = []string{
+	"https://soundcloud.com/artist/track",
+	"new one https://artist.bandcamp.com/album/record 🔥",
+	"[listen](https://www.mixcloud.com/show/episode/) <@123456>",
+	"<https://youtube.com/watch?v=dQw4w9WgXcQ> and open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8",
+	"so yesterday we were talking about that gig and someone mentioned youtube.com/watch?v=dQw4w9WgXcQ which reminded me",
+	"https://open.spotify.com/album/4PTG3Z6ehGkB\nFwjybzWkR8?si=abc",
+	"anyone going to the show on friday? should be a good one",
+}
+
+func BenchmarkDetectURLs(b *testing.B) {
+	detector := newTestDetector(defaultPlatforms())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		detector.DetectURLs(benchmarkMessages[i%len(benchmarkMessages)])
+	}
+}
+
+func TestDetectURLsInProse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // URLs as posted, before normalization
+	}{
+		{
+			name:    "Exclamation after plain domain",
+			content: "check spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8!",
+			want:    []string{"spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8"},
+		},
+		{
+			name:    "Full stop ends the sentence",
+			content: "Best record of the year: https://artist.bandcamp.com/album/record.",
+			want:    []string{"https://artist.bandcamp.com/album/record"},
+		},
+		{
+			name:    "Comma in a list",
+			content: "https://soundcloud.com/artist/one, https://soundcloud.com/artist/two and more",
+			want:    []string{"https://soundcloud.com/artist/one", "https://soundcloud.com/artist/two"},
+		},
+		{
+			name:    "Ellipsis",
+			content: "this one... https://soundcloud.com/artist/track...",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Question and exclamation marks",
+			content: "have you heard https://www.mixcloud.com/show/episode/?!",
+			want:    []string{"https://www.mixcloud.com/show/episode/"},
+		},
+		{
+			name:    "Semicolon and colon",
+			content: "https://soundcloud.com/artist/one; then https://soundcloud.com/artist/two:",
+			want:    []string{"https://soundcloud.com/artist/one", "https://soundcloud.com/artist/two"},
+		},
+		{
+			name:    "Wrapped in parentheses",
+			content: "(see https://youtube.com/watch?v=dQw4w9WgXcQ)",
+			want:    []string{"https://youtube.com/watch?v=dQw4w9WgXcQ"},
+		},
+		{
+			name:    "Plain domain wrapped in parentheses",
+			content: "(see youtube.com/watch?v=dQw4w9WgXcQ)",
+			want:    []string{"youtube.com/watch?v=dQw4w9WgXcQ"},
+		},
+		{
+			name:    "Parenthesis then full stop",
+			content: "a classic (https://soundcloud.com/artist/track).",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Balanced parentheses in the path",
+			content: "https://en.wikipedia.org/wiki/Daft_Punk_(band)",
+			want:    []string{"https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		},
+		{
+			name:    "Balanced parentheses inside parentheses",
+			content: "the band (https://en.wikipedia.org/wiki/Daft_Punk_(band)).",
+			want:    []string{"https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		},
+		{
+			name:    "Balanced parentheses in a Markdown link",
+			content: "[Daft Punk](https://en.wikipedia.org/wiki/Daft_Punk_(band))",
+			want:    []string{"https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		},
+		{
+			name:    "Straight quotes",
+			content: `he said "https://soundcloud.com/artist/track" twice`,
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Curly quotes",
+			content: "he said “https://soundcloud.com/artist/track” twice",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Discord bold",
+			content: "**https://soundcloud.com/artist/track**",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Discord spoiler",
+			content: "||https://soundcloud.com/artist/track||",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+		{
+			name:    "Query string kept before punctuation",
+			content: "https://youtube.com/watch?v=dQw4w9WgXcQ&t=42, seriously",
+			want:    []string{"https://youtube.com/watch?v=dQw4w9WgXcQ&t=42"},
+		},
+		{
+			name:    "Closing bracket",
+			content: "[https://soundcloud.com/artist/track]",
+			want:    []string{"https://soundcloud.com/artist/track"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := newTestDetector(defaultPlatforms())
+
+			urls := detector.DetectURLs(tt.content)
+
+			got := make([]string, len(urls))
+			for i, info := range urls {
+				got[i] = info.URL
+			}
+			want := make([]string, len(tt.want))
+			for i, raw := range tt.want {
+				normalized, err := NormalizeURL(raw)
+				if err != nil {
+					t.Fatalf("NormalizeURL(%q) error = %v", raw, err)
+				}
+				want[i] = normalized
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DetectURLs(%q) = %q, want %q", tt.content, got, want)
+			}
+		})
+	}
+}
+
+func TestDetectURLsPlainDomainsFromPlatforms(t *testing.T) {
+	loader := &staticLoader{platforms: defaultPlatforms()}
+	detector := New(loader, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	content := "new post up at blog.example-label.com/posts/new-release and open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8"
+
+	urls := detector.DetectURLs(content)
+	if len(urls) != 1 || urls[0].Platform != domain.PlatformSpotify {
+		t.Fatalf("DetectURLs() = %+v, want only the Spotify subdomain link", urls)
+	}
+
+	// Adding a platform enables bare-domain detection for it
+	loader.platforms = append(defaultPlatforms(), &domain.Platform{
+		ID:          "example_label",
+		URLPatterns: []string{"example-label.com"},
+		Enabled:     true,
+	})
+	detector.Refresh()
+
+	urls = detector.DetectURLs(content)
+	if len(urls) != 2 {
+		t.Fatalf("DetectURLs() after adding a platform = %+v, want 2 URLs", urls)
+	}
+	if urls[0].Platform != "example_label" || urls[0].URL != "https://blog.example-label.com/posts/new-release" {
+		t.Errorf("first URL = %+v, want the new platform's bare domain", urls[0])
+	}
+}
+
+func TestDetectURLsEmojiBeforeURLs(t *testing.T) {
+	detector := newTestDetector(defaultPlatforms())
+
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"🔥soundcloud.com/artist/track", []string{"https://soundcloud.com/artist/track"}},
+		{"🔥https://soundcloud.com/artist/track", []string{"https://soundcloud.com/artist/track"}},
+		{"new one 👉🏽open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8", []string{"https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8"}},
+		{"❤️ ➡️youtu.be/dQw4w9WgXcQ", []string{"https://youtu.be/dQw4w9WgXcQ"}},
+		{"→soundcloud.com/artist/one 🎶soundcloud.com/artist/two", []string{
+			"https://soundcloud.com/artist/one",
+			"https://soundcloud.com/artist/two",
+		}},
+		// Emoji inside a link's path are left alone
+		{"https://soundcloud.com/artist/🔥track.mp3", []string{"https://soundcloud.com/artist/%F0%9F%94%A5track.mp3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			urls := detector.DetectURLs(tt.content)
+			got := make([]string, len(urls))
+			for i, info := range urls {
+				got[i] = info.URL
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectURLs(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+
+	detector.SetStripEmojiBeforeURLs(false)
+	if urls := detector.DetectURLs("🔥soundcloud.com/artist/track"); len(urls) != 0 {
+		t.Errorf("DetectURLs() with emoji stripping disabled = %+v, want none", urls)
+	}
+}
+
+func TestDetectURLsLogsRejectedURLs(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	detector := New(&staticLoader{platforms: defaultPlatforms()}, nil, logger)
+	content := "[listen](not-a-link) or <https://soundcloud.com:8080/artist/track>"
+
+	detector.DetectURLs(content)
+	if strings.Contains(logs.String(), "URL rejected") {
+		t.Fatalf("rejected URLs logged without SetLogRejectedURLs: %s", logs.String())
+	}
+
+	detector.SetLogRejectedURLs(true)
+	if urls := detector.DetectURLs(content); len(urls) != 0 {
+		t.Fatalf("DetectURLs() = %+v, want no URLs", urls)
+	}
+
+	reasons := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record struct {
+			Msg    string `json:"msg"`
+			RawURL string `json:"raw_url"`
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to parse log line %q: %v", line, err)
+		}
+		if record.Msg == "URL rejected" {
+			reasons[record.RawURL] = record.Reason
+		}
+	}
+
+	want := map[string]string{
+		"not-a-link": "invalid URL: no domain found",
+		"https://soundcloud.com:8080/artist/track": "invalid URL: port 8080 not allowed",
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("rejection reasons = %v, want %v", reasons, want)
+	}
+}
+
+// goldenLinks are tricky messages collected from real servers and issues. Each lists the
+// links DetectURLs should find, as posted (before normalization), with their platform
+var goldenLinks = []struct {
+	name    string
+	content string
+	want    []goldenLink
+}{
+	{
+		name:    "Zero-width space inside a URL",
+		content: "https://soundcloud.com/\u200bartist/track",
+		want:    []goldenLink{{"https://soundcloud.com/artist/track", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Zero-width space before a URL",
+		content: "listen\u200bhttps://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8",
+		want:    []goldenLink{{"https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8", domain.PlatformSpotify}},
+	},
+	{
+		name:    "Zero-width joiner, non-joiner, word joiner and BOM",
+		content: "https://www.mix\u200dcloud.com/\u200cshow/\u2060episode/\ufeff",
+		want:    []goldenLink{{"https://www.mixcloud.com/show/episode/", domain.PlatformMixcloud}},
+	},
+	{
+		name:    "Soft hyphen from a copy-paste",
+		content: "https://artist.band\u00adcamp.com/album/record",
+		want:    []goldenLink{{"https://artist.bandcamp.com/album/record", domain.PlatformBandcamp}},
+	},
+	{
+		name:    "Non-breaking space after a URL",
+		content: "https://soundcloud.com/artist/track\u00a0so good",
+		want:    []goldenLink{{"https://soundcloud.com/artist/track", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Spotify share sheet with si parameter",
+		content: "https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8?si=1a2b3c4d5e6f",
+		want:    []goldenLink{{"https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8", domain.PlatformSpotify}},
+	},
+	{
+		name:    "Spotify localized path",
+		content: "https://open.spotify.com/intl-de/track/4PTG3Z6ehGkBFwjybzWkR8",
+		want:    []goldenLink{{"https://open.spotify.com/intl-de/track/4PTG3Z6ehGkBFwjybzWkR8", domain.PlatformSpotify}},
+	},
+	{
+		name:    "Spotify short link",
+		content: "https://spotify.link/AbCdEfGhIjK",
+		want:    []goldenLink{{"https://spotify.link/AbCdEfGhIjK", domain.PlatformSpotify}},
+	},
+	{
+		name:    "YouTube short link with share parameter",
+		content: "https://youtu.be/dQw4w9WgXcQ?si=AbCdEfGh",
+		want:    []goldenLink{{"https://youtu.be/dQw4w9WgXcQ", domain.PlatformYouTube}},
+	},
+	{
+		name:    "YouTube Shorts",
+		content: "https://youtube.com/shorts/dQw4w9WgXcQ?feature=share",
+		want:    []goldenLink{{"https://youtube.com/shorts/dQw4w9WgXcQ", domain.PlatformYouTube}},
+	},
+	{
+		name:    "YouTube Music",
+		content: "https://music.youtube.com/watch?v=dQw4w9WgXcQ&list=RDAMVM",
+		want:    []goldenLink{{"https://music.youtube.com/watch?v=dQw4w9WgXcQ&list=RDAMVM", domain.PlatformYouTube}},
+	},
+	{
+		name:    "Discord double-encoded query",
+		content: "https://youtube.com/watch?v=dQw4w9WgXcQ%3Ft%3D42",
+		want:    []goldenLink{{"https://youtube.com/watch?v=dQw4w9WgXcQ&t=42", domain.PlatformYouTube}},
+	},
+	{
+		name:    "Apple Music song in an album",
+		content: "https://music.apple.com/us/album/record/1234567890?i=1234567891",
+		want:    []goldenLink{{"https://music.apple.com/us/album/record/1234567890?i=1234567891", domain.PlatformAppleMusic}},
+	},
+	{
+		name:    "SoundCloud mobile share link",
+		content: "https://on.soundcloud.com/AbCdEfGhIjKlMn",
+		want:    []goldenLink{{"https://on.soundcloud.com/AbCdEfGhIjKlMn", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Uppercase scheme and host",
+		content: "HTTPS://SoundCloud.com/Artist/Track",
+		want:    []goldenLink{{"https://soundcloud.com/Artist/Track", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Masked link with a suppressed embed",
+		content: "[new single](<https://soundcloud.com/artist/single>)",
+		want:    []goldenLink{{"https://soundcloud.com/artist/single", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Mention straight after a URL",
+		content: "https://soundcloud.com/artist/track<@123456789012345678>",
+		want:    []goldenLink{{"https://soundcloud.com/artist/track", domain.PlatformSoundCloud}},
+	},
+	{
+		name:    "Emoji straight before a URL",
+		content: "🔥https://www.nts.live/shows/show/episodes/episode",
+		want:    []goldenLink{{"https://www.nts.live/shows/show/episodes/episode", domain.PlatformNTS}},
+	},
+	{
+		name:    "Discord app directory link",
+		content: "add it from https://discord.com/application-directory/123456789012345678",
+		want:    []goldenLink{{"https://discord.com/application-directory/123456789012345678", domain.PlatformUnknown}},
+	},
+	{
+		name:    "Username and invite without a protocol aren't links",
+		content: "ping @someone.music or join discord.gg/abcdef",
+		want:    nil,
+	},
+}
+
+type goldenLink struct {
+	url      string
+	platform string
+}
+
+func TestDetectURLsGolden(t *testing.T) {
+	detector := newTestDetector(defaultPlatforms())
+
+	for _, tt := range goldenLinks {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []goldenLink
+			for _, info := range detector.DetectURLs(tt.content) {
+				got = append(got, goldenLink{info.URL, info.Platform})
+			}
+
+			var want []goldenLink
+			for _, link := range tt.want {
+				normalized, err := NormalizeURL(link.url)
+				if err != nil {
+					t.Fatalf("NormalizeURL(%q) error = %v", link.url, err)
+				}
+				want = append(want, goldenLink{normalized, link.platform})
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DetectURLs(%q) = %+v, want %+v", tt.content, got, want)
+			}
+		})
+	}
+}
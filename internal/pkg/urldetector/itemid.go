@@ -0,0 +1,40 @@
+package urldetector
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CompileExtractionPattern compiles a platform extraction pattern. The pattern must have
+// at least one capture group; the first group yields the platform item ID.
+func CompileExtractionPattern(pattern string) (*regexp.Regexp, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extraction pattern %q: %w", pattern, err)
+	}
+	if compiled.NumSubexp() < 1 {
+		return nil, fmt.Errorf("extraction pattern %q has no capture group for the item ID", pattern)
+	}
+	return compiled, nil
+}
+
+// ValidateExtractionPatterns checks that every pattern compiles and has a capture group
+func ValidateExtractionPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := CompileExtractionPattern(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractItemID returns the first capture group of the first pattern matching the URL,
+// or an empty string if none match
+func extractItemID(patterns []*regexp.Regexp, rawURL string) string {
+	for _, pattern := range patterns {
+		if match := pattern.FindStringSubmatch(rawURL); len(match) > 1 && match[1] != "" {
+			return match[1]
+		}
+	}
+	return ""
+}
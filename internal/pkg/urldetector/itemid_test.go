@@ -0,0 +1,181 @@
+package urldetector
+
+import (
+	"io"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"regexp"
+	"testing"
+)
+
+// staticLoader serves a fixed set of platforms
+type staticLoader struct {
+	platforms []*domain.Platform
+}
+
+func (l *staticLoader) GetAllByPriority() ([]*domain.Platform, error) { return l.platforms, nil }
+
+func (l *staticLoader) IsLoaded() bool { return true }
+
+// defaultItemPatterns compiles the default extraction patterns for a platform
+func defaultItemPatterns(t *testing.T, platformID string) []*regexp.Regexp {
+	t.Helper()
+	platform := domain.GetDefaultPlatformConfig().Platforms[platformID]
+	var patterns []*regexp.Regexp
+	for _, pattern := range platform.ExtractionPatterns {
+		compiled, err := CompileExtractionPattern(pattern)
+		if err != nil {
+			t.Fatalf("default %s pattern doesn't compile: %v", platformID, err)
+		}
+		patterns = append(patterns, compiled)
+	}
+	return patterns
+}
+
+func TestExtractItemID(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		url      string
+		want     string
+	}{
+		{
+			name:     "YouTube watch URL",
+			platform: domain.PlatformYouTube,
+			url:      "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			want:     "dQw4w9WgXcQ",
+		},
+		{
+			name:     "YouTube watch URL with v after other params",
+			platform: domain.PlatformYouTube,
+			url:      "https://youtube.com/watch?list=PL123&v=dQw4w9WgXcQ&t=42",
+			want:     "dQw4w9WgXcQ",
+		},
+		{
+			name:     "YouTube short link",
+			platform: domain.PlatformYouTube,
+			url:      "https://youtu.be/dQw4w9WgXcQ?si=abc",
+			want:     "dQw4w9WgXcQ",
+		},
+		{
+			name:     "YouTube Music",
+			platform: domain.PlatformYouTube,
+			url:      "https://music.youtube.com/watch?v=dQw4w9WgXcQ",
+			want:     "dQw4w9WgXcQ",
+		},
+		{
+			name:     "YouTube shorts",
+			platform: domain.PlatformYouTube,
+			url:      "https://youtube.com/shorts/dQw4w9WgXcQ",
+			want:     "dQw4w9WgXcQ",
+		},
+		{
+			name:     "YouTube channel has no item ID",
+			platform: domain.PlatformYouTube,
+			url:      "https://youtube.com/@someartist",
+			want:     "",
+		},
+		{
+			name:     "Spotify track",
+			platform: domain.PlatformSpotify,
+			url:      "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=xyz",
+			want:     "track/4uLU6hMCjMI75M1A2tKUQC",
+		},
+		{
+			name:     "Spotify localized album",
+			platform: domain.PlatformSpotify,
+			url:      "https://open.spotify.com/intl-de/album/1DFixLWuPkv3KT3TnV35m3",
+			want:     "album/1DFixLWuPkv3KT3TnV35m3",
+		},
+		{
+			name:     "Spotify user profile has no item ID",
+			platform: domain.PlatformSpotify,
+			url:      "https://open.spotify.com/user/someone",
+			want:     "",
+		},
+		{
+			name:     "Deezer localized track",
+			platform: domain.PlatformDeezer,
+			url:      "https://www.deezer.com/us/track/3135556",
+			want:     "track/3135556",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractItemID(defaultItemPatterns(t, tt.platform), tt.url)
+			if got != tt.want {
+				t.Errorf("extractItemID(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileExtractionPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Valid pattern", pattern: `example\.com/item/(\d+)`, wantErr: false},
+		{name: "No capture group", pattern: `example\.com/item/\d+`, wantErr: true},
+		{name: "Only a non-capturing group", pattern: `example\.com/(?:item)/\d+`, wantErr: true},
+		{name: "Invalid regex", pattern: `example\.com/(\d+`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileExtractionPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CompileExtractionPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectURLsPlatformItemID(t *testing.T) {
+	deezer := domain.GetDefaultPlatformConfig().Platforms[domain.PlatformDeezer]
+	loader := &staticLoader{platforms: []*domain.Platform{
+		&deezer,
+		{ID: "broken", URLPatterns: []string{"example.com"}, ExtractionPatterns: []string{`example\.com/(\d+`}},
+	}}
+	detector := New(loader, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	urls := detector.DetectURLs("https://www.deezer.com/us/track/3135556 and https://example.com/123")
+	if len(urls) != 2 {
+		t.Fatalf("DetectURLs() returned %d URLs, want 2: %+v", len(urls), urls)
+	}
+	if urls[0].PlatformItemID != "track/3135556" {
+		t.Errorf("Deezer URL got item ID %q, want %q", urls[0].PlatformItemID, "track/3135556")
+	}
+	if urls[1].Platform != "broken" || urls[1].PlatformItemID != "" {
+		t.Errorf("invalid extraction pattern gave %+v, want platform broken with no item ID", urls[1])
+	}
+
+	// Localized Deezer links canonicalize differently, so only the item ID ties them together
+	localized := detector.DetectURLs("https://deezer.com/fr/track/3135556")
+	if len(localized) != 1 {
+		t.Fatalf("DetectURLs() returned %d URLs, want 1: %+v", len(localized), localized)
+	}
+	if localized[0].CanonicalURL == urls[0].CanonicalURL {
+		t.Errorf("Deezer URL variants share canonical URL %q, want them to differ", urls[0].CanonicalURL)
+	}
+	if localized[0].PlatformItemID != urls[0].PlatformItemID {
+		t.Errorf("Deezer URL variants got item IDs %q and %q, want the same",
+			urls[0].PlatformItemID, localized[0].PlatformItemID)
+	}
+}
+
+func TestDetectURLsDedupsItemVariants(t *testing.T) {
+	deezer := domain.GetDefaultPlatformConfig().Platforms[domain.PlatformDeezer]
+	detector := New(&staticLoader{platforms: []*domain.Platform{&deezer}}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Two localized links to the same track in one message are reported once
+	urls := detector.DetectURLs("https://www.deezer.com/us/track/3135556 and https://deezer.com/fr/track/3135556")
+	if len(urls) != 1 {
+		t.Fatalf("DetectURLs() returned %d URLs, want 1: %+v", len(urls), urls)
+	}
+	if urls[0].URL != "https://www.deezer.com/us/track/3135556" || urls[0].PlatformItemID != "track/3135556" {
+		t.Errorf("first Deezer variant gave %+v, want it kept with item ID %q", urls[0], "track/3135556")
+	}
+}
@@ -6,11 +6,57 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 )
 
+// schemeRegex matches an explicit scheme at the start of a URL (e.g. "ftp://")
+var schemeRegex = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*)://`)
+
+// allowedSchemes are the only URL schemes NormalizeURL accepts
+var allowedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+// defaultAllowedPorts are the explicit ports NormalizeURL accepts unless overridden
+var defaultAllowedPorts = []string{"80", "443"}
+
+var (
+	allowedPortsMu sync.RWMutex
+	allowedPorts   = toPortSet(defaultAllowedPorts)
+)
+
+// SetAllowedPorts replaces the set of explicit ports NormalizeURL accepts.
+// URLs without a port are always accepted. An empty list restores the defaults (80, 443).
+func SetAllowedPorts(ports []string) {
+	if len(ports) == 0 {
+		ports = defaultAllowedPorts
+	}
+
+	allowedPortsMu.Lock()
+	defer allowedPortsMu.Unlock()
+	allowedPorts = toPortSet(ports)
+}
+
+// isPortAllowed reports whether an explicit port is in the allowed set
+func isPortAllowed(port string) bool {
+	allowedPortsMu.RLock()
+	defer allowedPortsMu.RUnlock()
+	return allowedPorts[port]
+}
+
+func toPortSet(ports []string) map[string]bool {
+	set := make(map[string]bool, len(ports))
+	for _, port := range ports {
+		set[port] = true
+	}
+	return set
+}
+
 // NormalizeURL creates a canonical form of a URL for storage and deduplication.
 // It handles:
 // - Adding https:// protocol if missing
+// - Rejecting schemes other than http/https and ports outside the allowed set
 // - Lowercasing the domain (keeps www. as posted)
 // - Removing tracking parameters (utm_*, si, fbclid, ref, source)
 // - Validating the URL structure
@@ -20,6 +66,14 @@ func NormalizeURL(rawURL string) (string, error) {
 		return "", fmt.Errorf("empty URL")
 	}
 
+	// Reject explicit non-web schemes (file://, ftp://, ...) before the https:// prefix
+	// below turns them into a URL with a bogus host
+	if match := schemeRegex.FindStringSubmatch(rawURL); match != nil {
+		if !allowedSchemes[strings.ToLower(match[1])] {
+			return "", fmt.Errorf("invalid URL: scheme %q not allowed", match[1])
+		}
+	}
+
 	// Step 1: Add protocol if missing (required for url.Parse to work correctly)
 	if !strings.HasPrefix(strings.ToLower(rawURL), "http://") &&
 		!strings.HasPrefix(strings.ToLower(rawURL), "https://") {
@@ -37,10 +91,13 @@ func NormalizeURL(rawURL string) (string, error) {
 		return "", fmt.Errorf("failed to parse URL: %w", err)
 	}
 
-	// Step 3: Validate URL has a host
-	if u.Host == "" {
+	// Step 3: Validate URL has a host and an allowed port
+	if u.Host == "" || u.Hostname() == "" {
 		return "", fmt.Errorf("invalid URL: no host found")
 	}
+	if port := u.Port(); port != "" && !isPortAllowed(port) {
+		return "", fmt.Errorf("invalid URL: port %s not allowed", port)
+	}
 
 	// Step 4: Normalize domain (lowercase only - keep www. as posted)
 	u.Host = strings.ToLower(u.Host)
@@ -59,21 +116,58 @@ func NormalizeURL(rawURL string) (string, error) {
 	return u.String(), nil
 }
 
+// invisibleChars removes characters that render as nothing but split a URL when pasted
+// into one: zero-width space, non-joiner and joiner, word joiner, BOM and soft hyphen
+var invisibleChars = strings.NewReplacer(
+	"\u200B", "",
+	"\u200C", "",
+	"\u200D", "",
+	"\u2060", "",
+	"\uFEFF", "",
+	"\u00AD", "",
+)
+
+// StripInvisibleChars removes zero-width and other invisible characters from message
+// content so links containing them are detected intact
+func StripInvisibleChars(content string) string {
+	return invisibleChars.Replace(content)
+}
+
+// emojiBeforeURLRegex matches a run of emoji (with their skin tone, variation and keycap
+// modifiers) or arrows starting a word and written directly before a link, e.g.
+// "🔥soundcloud.com/..." or "👉🏽 https://...", capturing what precedes and follows the run
+var emojiBeforeURLRegex = regexp.MustCompile(
+	`(^|\s)[\p{So}\p{Sk}\x{2190}-\x{21FF}\x{FE0E}\x{FE0F}\x{20E3}]+\s*(https?://|[\w-]+\.\w)`,
+)
+
+// stripEmojiBeforeURLs removes emoji written directly before links so a plain domain starts
+// a word and is detected
+func stripEmojiBeforeURLs(content string) string {
+	return emojiBeforeURLRegex.ReplaceAllString(content, "${1}${2}")
+}
+
+// trailingPunctuation is prose and Discord markup that can follow a URL but rarely ends one:
+// sentence punctuation, straight and curly quotes, an ellipsis, and bold/spoiler markers
+const trailingPunctuation = ".,!?;:\"'“”‘’…»*|"
+
 // cleanTrailingPunctuation removes trailing punctuation from a URL intelligently.
-// It preserves closing parentheses if they're balanced (for Wikipedia-style URLs).
+// It preserves closing parentheses if they're balanced (for Wikipedia-style URLs), so
+// "(https://en.wikipedia.org/wiki/Daft_Punk_(band))." keeps only the inner pair.
 func cleanTrailingPunctuation(urlStr string) string {
-	// Check for balanced parentheses (Wikipedia-style URLs)
-	if strings.Contains(urlStr, "(") && strings.HasSuffix(urlStr, ")") {
-		openCount := strings.Count(urlStr, "(")
-		closeCount := strings.Count(urlStr, ")")
-		// If parentheses are balanced or more open than close, keep the closing paren
-		if openCount >= closeCount {
-			return urlStr
+	for {
+		// Remove common sentence-ending punctuation
+		cleaned := strings.TrimRight(urlStr, trailingPunctuation)
+
+		// Drop a closing paren that has no opening partner in the URL
+		if strings.HasSuffix(cleaned, ")") && strings.Count(cleaned, "(") < strings.Count(cleaned, ")") {
+			cleaned = cleaned[:len(cleaned)-1]
 		}
-	}
 
-	// Remove common sentence-ending punctuation
-	return strings.TrimRight(urlStr, ".,!?;:\"'")
+		if cleaned == urlStr {
+			return cleaned
+		}
+		urlStr = cleaned
+	}
 }
 
 // trackingParams is the consolidated list of tracking parameters to strip.
@@ -168,3 +168,110 @@ func TestNormalizeURL_ExpandedTrackingParams(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeURL_SchemesAndPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "No scheme defaults to https",
+			input: "soundcloud.com/artist/track",
+			want:  "https://soundcloud.com/artist/track",
+		},
+		{
+			name:  "Uppercase http scheme",
+			input: "HTTP://soundcloud.com/artist/track",
+			want:  "http://soundcloud.com/artist/track",
+		},
+		{
+			name:  "Standard https port",
+			input: "https://soundcloud.com:443/artist/track",
+			want:  "https://soundcloud.com:443/artist/track",
+		},
+		{
+			name:    "file scheme",
+			input:   "file:///etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:    "file scheme with host",
+			input:   "file://server.local/share/track.mp3",
+			wantErr: true,
+		},
+		{
+			name:    "ftp scheme",
+			input:   "ftp://files.example.com/track.mp3",
+			wantErr: true,
+		},
+		{
+			name:    "Nonstandard port",
+			input:   "https://soundcloud.com:8080/artist/track",
+			wantErr: true,
+		},
+		{
+			name:    "Nonstandard port without scheme",
+			input:   "internal.example.com:6379/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL()\n  got  = %v\n  want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetAllowedPorts(t *testing.T) {
+	defer SetAllowedPorts(nil)
+
+	SetAllowedPorts([]string{"443", "8443"})
+
+	if _, err := NormalizeURL("https://music.example.com:8443/track"); err != nil {
+		t.Errorf("port 8443 should be allowed after SetAllowedPorts: %v", err)
+	}
+	if _, err := NormalizeURL("http://music.example.com:80/track"); err == nil {
+		t.Error("port 80 should be rejected after SetAllowedPorts")
+	}
+
+	SetAllowedPorts(nil)
+
+	if _, err := NormalizeURL("http://music.example.com:80/track"); err != nil {
+		t.Errorf("port 80 should be allowed after restoring defaults: %v", err)
+	}
+}
+
+func TestCleanTrailingPunctuation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://soundcloud.com/artist/track", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track.", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track?!", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track…", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track”", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track**", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track||", "https://soundcloud.com/artist/track"},
+		{"https://soundcloud.com/artist/track).", "https://soundcloud.com/artist/track"},
+		{"https://en.wikipedia.org/wiki/Daft_Punk_(band)", "https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		{"https://en.wikipedia.org/wiki/Daft_Punk_(band)).", "https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		{"https://en.wikipedia.org/wiki/Daft_Punk_(band),", "https://en.wikipedia.org/wiki/Daft_Punk_(band)"},
+		{"https://youtube.com/watch?v=dQw4w9WgXcQ&t=42", "https://youtube.com/watch?v=dQw4w9WgXcQ&t=42"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanTrailingPunctuation(tt.input); got != tt.want {
+			t.Errorf("cleanTrailingPunctuation(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package urldetector
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultPlatformCacheSize is how many hosts' detected platforms a Detector remembers
+const DefaultPlatformCacheSize = 1024
+
+// platformCache is a concurrency-safe LRU of detected platforms keyed by host
+type platformCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+type platformCacheEntry struct {
+	host     string
+	platform string
+}
+
+// newPlatformCache returns a cache holding up to capacity hosts, or nil (caching
+// disabled) if capacity isn't positive
+func newPlatformCache(capacity int) *platformCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &platformCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+	}
+}
+
+// get returns the platform cached for host and marks it recently used
+func (c *platformCache) get(host string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[host]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*platformCacheEntry).platform, true
+}
+
+// add caches the platform detected for host, evicting the least recently used host when full
+func (c *platformCache) add(host, platform string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[host]; ok {
+		elem.Value.(*platformCacheEntry).platform = platform
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[host] = c.order.PushFront(&platformCacheEntry{host: host, platform: platform})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*platformCacheEntry).host)
+	}
+}
+
+// purge empties the cache
+func (c *platformCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element, c.capacity)
+}
+
+// len returns how many hosts are cached
+func (c *platformCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,170 @@
+package urldetector
+
+import (
+	"fmt"
+	"io"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// defaultPlatforms returns the default platform config in priority order
+func defaultPlatforms() []*domain.Platform {
+	config := domain.GetDefaultPlatformConfig()
+	platforms := make([]*domain.Platform, 0, len(config.Platforms))
+	for id := range config.Platforms {
+		platform := config.Platforms[id]
+		platforms = append(platforms, &platform)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		if platforms[i].Priority != platforms[j].Priority {
+			return platforms[i].Priority > platforms[j].Priority
+		}
+		return platforms[i].ID < platforms[j].ID
+	})
+	return platforms
+}
+
+func newTestDetector(platforms []*domain.Platform) *Detector {
+	return New(&staticLoader{platforms: platforms}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestPlatformCacheEviction(t *testing.T) {
+	cache := newPlatformCache(2)
+	cache.add("a.com", "a")
+	cache.add("b.com", "b")
+
+	// Reading a.com makes b.com the least recently used
+	if platform, ok := cache.get("a.com"); !ok || platform != "a" {
+		t.Fatalf("get(a.com) = %q, %v, want a, true", platform, ok)
+	}
+	cache.add("c.com", "c")
+
+	if _, ok := cache.get("b.com"); ok {
+		t.Error("b.com should have been evicted")
+	}
+	for host, want := range map[string]string{"a.com": "a", "c.com": "c"} {
+		if platform, ok := cache.get(host); !ok || platform != want {
+			t.Errorf("get(%s) = %q, %v, want %s, true", host, platform, ok, want)
+		}
+	}
+
+	if newPlatformCache(0) != nil {
+		t.Error("newPlatformCache(0) should disable caching")
+	}
+}
+
+func TestDetectPlatformCache(t *testing.T) {
+	detector := newTestDetector(defaultPlatforms())
+
+	if got := detector.detectPlatformFromURL("https://soundcloud.com/artist/track-1"); got != domain.PlatformSoundCloud {
+		t.Fatalf("detectPlatformFromURL() = %q, want %q", got, domain.PlatformSoundCloud)
+	}
+	if platform, ok := detector.platformCache.get("soundcloud.com"); !ok || platform != domain.PlatformSoundCloud {
+		t.Fatalf("cache after miss = %q, %v, want soundcloud.com cached", platform, ok)
+	}
+
+	// A hit is served from the cache, so a poisoned entry shows it was used
+	detector.platformCache.add("soundcloud.com", "cached")
+	if got := detector.detectPlatformFromURL("https://SoundCloud.com/artist/track-2"); got != "cached" {
+		t.Errorf("detectPlatformFromURL() on a cached host = %q, want the cached platform", got)
+	}
+
+	// Unknown hosts are cached too
+	if got := detector.detectPlatformFromURL("https://example.com/page"); got != domain.PlatformUnknown {
+		t.Errorf("detectPlatformFromURL() = %q, want %q", got, domain.PlatformUnknown)
+	}
+	if n := detector.platformCache.len(); n != 2 {
+		t.Errorf("cache holds %d hosts, want 2", n)
+	}
+
+	detector.SetPlatformCacheSize(0)
+	if got := detector.detectPlatformFromURL("https://soundcloud.com/artist/track-3"); got != domain.PlatformSoundCloud {
+		t.Errorf("detectPlatformFromURL() with the cache disabled = %q, want %q", got, domain.PlatformSoundCloud)
+	}
+}
+
+func TestDetectPlatformCacheRefresh(t *testing.T) {
+	loader := &staticLoader{platforms: []*domain.Platform{
+		{ID: "first", URLPatterns: []string{"example.com"}},
+	}}
+	detector := New(loader, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if got := detector.detectPlatformFromURL("https://example.com/1"); got != "first" {
+		t.Fatalf("detectPlatformFromURL() = %q, want first", got)
+	}
+
+	loader.platforms = []*domain.Platform{{ID: "second", URLPatterns: []string{"example.com"}}}
+	detector.Refresh()
+
+	if n := detector.platformCache.len(); n != 0 {
+		t.Errorf("cache holds %d hosts after Refresh, want 0", n)
+	}
+	if got := detector.detectPlatformFromURL("https://example.com/2"); got != "second" {
+		t.Errorf("detectPlatformFromURL() after Refresh = %q, want second", got)
+	}
+}
+
+func TestDetectPlatformCacheSkipsPathPatterns(t *testing.T) {
+	detector := newTestDetector([]*domain.Platform{
+		{ID: "shorts", URLPatterns: []string{"youtube.com/shorts"}, Priority: 10},
+		{ID: "youtube", URLPatterns: []string{"youtube.com"}},
+	})
+
+	if got := detector.detectPlatformFromURL("https://youtube.com/watch?v=1"); got != "youtube" {
+		t.Errorf("detectPlatformFromURL() = %q, want youtube", got)
+	}
+	if got := detector.detectPlatformFromURL("https://youtube.com/shorts/1"); got != "shorts" {
+		t.Errorf("detectPlatformFromURL() = %q, want shorts", got)
+	}
+	if n := detector.platformCache.len(); n != 0 {
+		t.Errorf("cache holds %d hosts, want none when a pattern has a path", n)
+	}
+}
+
+// benchmarkURLs returns n URLs shaped like a music server's traffic: mostly a handful of
+// big platforms, a long tail of Bandcamp subdomains and some unrelated links
+func benchmarkURLs(n int) []string {
+	rng := rand.New(rand.NewSource(1))
+	urls := make([]string, n)
+	for i := range urls {
+		id := rng.Intn(1_000_000)
+		switch r := rng.Intn(100); {
+		case r < 30:
+			urls[i] = fmt.Sprintf("https://www.youtube.com/watch?v=%011d", id)
+		case r < 50:
+			urls[i] = fmt.Sprintf("https://open.spotify.com/track/%022d", id)
+		case r < 65:
+			urls[i] = fmt.Sprintf("https://soundcloud.com/artist-%d/track-%d", id%500, id)
+		case r < 75:
+			urls[i] = fmt.Sprintf("https://artist-%d.bandcamp.com/album/record-%d", id%300, id)
+		case r < 80:
+			urls[i] = fmt.Sprintf("https://youtu.be/%011d", id)
+		case r < 85:
+			urls[i] = fmt.Sprintf("https://www.mixcloud.com/show/episode-%d/", id)
+		case r < 90:
+			urls[i] = fmt.Sprintf("https://www.nts.live/shows/show-%d", id%200)
+		default:
+			urls[i] = fmt.Sprintf("https://site-%d.example.com/post/%d", id%100, id)
+		}
+	}
+	return urls
+}
+
+func BenchmarkDetectPlatformFromURL(b *testing.B) {
+	urls := benchmarkURLs(10_000)
+
+	for _, size := range []int{0, DefaultPlatformCacheSize} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			detector := newTestDetector(defaultPlatforms())
+			detector.SetPlatformCacheSize(size)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				detector.detectPlatformFromURL(urls[i%len(urls)])
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"knock-fm/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRepositoriesReturnNotFoundErrors(t *testing.T) {
+	db := openTestDB(t)
+	knokRepo := NewKnokRepository(db, createTestLogger())
+	serverRepo := NewServerRepository(db, createTestLogger())
+	platformRepo := NewPlatformRepository(db, createTestLogger())
+	ctx := context.Background()
+	missingID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{
+			name: "Knok GetByID",
+			call: func() error { _, err := knokRepo.GetByID(ctx, missingID); return err },
+			want: domain.ErrKnokNotFound,
+		},
+		{
+			name: "Knok GetByCanonicalURL",
+			call: func() error {
+				_, err := knokRepo.GetByCanonicalURL(ctx, "missing-server", "https://example.com/missing")
+				return err
+			},
+			want: domain.ErrKnokNotFound,
+		},
+		{
+			name: "Knok Delete",
+			call: func() error { return knokRepo.Delete(ctx, missingID) },
+			want: domain.ErrKnokNotFound,
+		},
+		{
+			name: "Knok UpdatePlatform",
+			call: func() error { return knokRepo.UpdatePlatform(ctx, missingID, "youtube") },
+			want: domain.ErrKnokNotFound,
+		},
+		{
+			name: "Knok UpdateExtractionStatus",
+			call: func() error {
+				return knokRepo.UpdateExtractionStatus(ctx, missingID, domain.ExtractionStatusComplete)
+			},
+			want: domain.ErrKnokNotFound,
+		},
+		{
+			name: "Server GetByID",
+			call: func() error { _, err := serverRepo.GetByID(ctx, "missing-server"); return err },
+			want: domain.ErrServerNotFound,
+		},
+		{
+			name: "Platform UpdatePlatform",
+			call: func() error {
+				return platformRepo.UpdatePlatform(ctx, &domain.Platform{ID: "missing_platform", Name: "Missing", UpdatedAt: &now})
+			},
+			want: domain.ErrPlatformNotFound,
+		},
+		{
+			name: "Platform CreatePlatform with a seeded ID",
+			call: func() error {
+				return platformRepo.CreatePlatform(ctx, &domain.Platform{ID: "youtube", Name: "YouTube", URLPatterns: []string{"youtube.com"}})
+			},
+			want: domain.ErrPlatformExists,
+		},
+		{
+			name: "Platform DeletePlatform",
+			call: func() error { return platformRepo.DeletePlatform(ctx, "missing_platform") },
+			want: domain.ErrPlatformNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
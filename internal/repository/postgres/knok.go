@@ -6,26 +6,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/tracing"
 	"log/slog"
 	"math/rand"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const knokSelectFields = `
-	SELECT id, server_id, url, canonical_url, platform, title,
-		   discord_message_id, discord_channel_id,
+	SELECT id, server_id, url, canonical_url, platform, platform_item_id, title,
+		   discord_message_id, discord_channel_id, discord_user_id,
 		   message_content, metadata, extraction_status, posted_at,
-		   created_at, updated_at
+		   created_at, updated_at, thumbnail_url
 	FROM knoks`
 
+// startKnokSpan starts a trace span for a write to a knok, so it shows up in the knok's
+// lifecycle trace
+func startKnokSpan(ctx context.Context, name string, id uuid.UUID) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attribute.String("knok.id", id.String())))
+}
+
 // KnokRepository implements the domain.KnokRepository interface using PostgreSQL
 type KnokRepository struct {
 	db     *sql.DB
 	logger *slog.Logger
+
+	// timelineWindow limits the global timeline to knoks posted this recently unless a
+	// date range is given; 0 shows every knok
+	timelineWindow time.Duration
 }
 
 // NewKnokRepository creates a new PostgreSQL knok repository
@@ -36,6 +51,12 @@ func NewKnokRepository(db *sql.DB, logger *slog.Logger) *KnokRepository {
 	}
 }
 
+// SetTimelineWindow limits the global timeline to knoks posted within window when
+// GetRecent or GetRecentByPlatform isn't given a date range. 0 shows every knok
+func (r *KnokRepository) SetTimelineWindow(window time.Duration) {
+	r.timelineWindow = window
+}
+
 // sanitizeSearchQuery escapes special tsquery characters and prepares for prefix search
 func (r *KnokRepository) sanitizeSearchQuery(query string) string {
 	// Remove leading/trailing whitespace
@@ -73,7 +94,7 @@ func (r *KnokRepository) sanitizeSearchQuery(query string) string {
 // scanKnokRow scans a database row into a Knok struct and handles nullable fields
 func (r *KnokRepository) scanKnokRow(scanner interface{ Scan(...interface{}) error }) (*domain.Knok, error) {
 	knok := &domain.Knok{}
-	var title, messageContent sql.NullString
+	var title, discordUserID, messageContent, platformItemID, thumbnailURL sql.NullString
 	var updatedAt sql.NullTime
 	var metadataBytes []byte
 
@@ -83,21 +104,33 @@ func (r *KnokRepository) scanKnokRow(scanner interface{ Scan(...interface{}) err
 		&knok.URL,
 		&knok.CanonicalURL,
 		&knok.Platform,
+		&platformItemID,
 		&title,
 		&knok.DiscordMessageID,
 		&knok.DiscordChannelID,
+		&discordUserID,
 		&messageContent,
 		&metadataBytes,
 		&knok.ExtractionStatus,
 		&knok.PostedAt,
 		&knok.CreatedAt,
 		&updatedAt,
+		&thumbnailURL,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	r.processNullableFields(knok, title, messageContent, updatedAt)
+	if platformItemID.Valid {
+		knok.PlatformItemID = &platformItemID.String
+	}
+	if discordUserID.Valid {
+		knok.DiscordUserID = &discordUserID.String
+	}
+	if thumbnailURL.Valid {
+		knok.ThumbnailURL = &thumbnailURL.String
+	}
 	if err := r.processMetadata(knok, metadataBytes); err != nil {
 		return nil, err
 	}
@@ -138,7 +171,7 @@ func (r *KnokRepository) processMetadata(knok *domain.Knok, metadataBytes []byte
 func (r *KnokRepository) GetRandom(ctx context.Context) (*domain.Knok, error) {
 	// First get total completed knoks count
 	var count int
-	countQuery := "SELECT COUNT(*) FROM knoks WHERE extraction_status = 'complete'"
+	countQuery := "SELECT COUNT(*) FROM knoks WHERE deleted_at IS NULL AND extraction_status = 'complete'"
 	err := r.db.QueryRowContext(ctx, countQuery).Scan(&count)
 	if err != nil {
 		r.logger.Error("Failed to count knoks", "error", err)
@@ -146,14 +179,14 @@ func (r *KnokRepository) GetRandom(ctx context.Context) (*domain.Knok, error) {
 	}
 
 	if count == 0 {
-		return nil, sql.ErrNoRows
+		return nil, domain.ErrKnokNotFound
 	}
 
 	// Generate random offset
 	offset := rand.Intn(count)
 
 	query := knokSelectFields + `
-		WHERE extraction_status = 'complete'
+		WHERE deleted_at IS NULL AND extraction_status = 'complete'
 		OFFSET $1 LIMIT 1`
 	row := r.db.QueryRowContext(ctx, query, offset)
 
@@ -161,7 +194,7 @@ func (r *KnokRepository) GetRandom(ctx context.Context) (*domain.Knok, error) {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("No knoks to randomly choose")
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrKnokNotFound
 		}
 		r.logger.Error("Failed to query random knok", "error", err)
 		return nil, fmt.Errorf("failed to query knok: %w", err)
@@ -171,10 +204,36 @@ func (r *KnokRepository) GetRandom(ctx context.Context) (*domain.Knok, error) {
 	return knok, nil
 }
 
+// GetForDate deterministically selects one completed knok for a calendar date.
+// Knoks are ordered by a hash of their ID seeded with the date, so every caller gets
+// the same knok for a given day and the pick changes from day to day.
+func (r *KnokRepository) GetForDate(ctx context.Context, date time.Time) (*domain.Knok, error) {
+	dateKey := date.UTC().Format("2006-01-02")
+
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND extraction_status = 'complete'
+		ORDER BY md5(id::text || $1), id
+		LIMIT 1`
+	row := r.db.QueryRowContext(ctx, query, dateKey)
+
+	knok, err := r.scanKnokRow(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("No knoks to choose for date", "date", dateKey)
+			return nil, domain.ErrKnokNotFound
+		}
+		r.logger.Error("Failed to query knok for date", "error", err, "date", dateKey)
+		return nil, fmt.Errorf("failed to query knok for date: %w", err)
+	}
+
+	r.logger.Debug("Knok of the day found", "date", dateKey, "knok_id", knok.ID)
+	return knok, nil
+}
+
 // GetByID retrieves a knok by its UUID
 func (r *KnokRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
 	query := knokSelectFields + `
-		WHERE id = $1`
+		WHERE deleted_at IS NULL AND id = $1`
 
 	row := r.db.QueryRowContext(ctx, query, id)
 
@@ -182,7 +241,7 @@ func (r *KnokRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Kno
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("Knok not found", "knok_id", id)
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrKnokNotFound
 		}
 		r.logger.Error("Failed to query knok", "error", err, "knok_id", id)
 		return nil, fmt.Errorf("failed to query knok: %w", err)
@@ -195,7 +254,7 @@ func (r *KnokRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Kno
 // GetByDiscordMessage retrieves a knok by Discord message ID
 func (r *KnokRepository) GetByDiscordMessage(ctx context.Context, messageID string) (*domain.Knok, error) {
 	query := knokSelectFields + `
-		WHERE discord_message_id = $1`
+		WHERE deleted_at IS NULL AND discord_message_id = $1`
 
 	row := r.db.QueryRowContext(ctx, query, messageID)
 
@@ -203,7 +262,7 @@ func (r *KnokRepository) GetByDiscordMessage(ctx context.Context, messageID stri
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("Knok not found by message ID", "message_id", messageID)
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrKnokNotFound
 		}
 		r.logger.Error("Failed to query knok by message ID", "error", err, "message_id", messageID)
 		return nil, fmt.Errorf("failed to query knok by message ID: %w", err)
@@ -213,9 +272,10 @@ func (r *KnokRepository) GetByDiscordMessage(ctx context.Context, messageID stri
 	return knok, nil
 }
 
-// Search performs full-text search on knoks within a server with cursor pagination
-func (r *KnokRepository) Search(ctx context.Context, searchQuery string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
-	r.logger.Info("Search called", "query", searchQuery, "cursor", cursor, "limit", limit)
+// Search performs full-text search on knoks with cursor pagination, within one server or
+// across all servers when serverID is nil
+func (r *KnokRepository) Search(ctx context.Context, serverID *string, searchQuery string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
+	r.logger.Info("Search called", "server_id", serverID, "query", searchQuery, "cursor", cursor, "limit", limit)
 
 	// Sanitize and prepare the search query for prefix matching
 	sanitizedQuery := r.sanitizeSearchQuery(searchQuery)
@@ -231,16 +291,18 @@ func (r *KnokRepository) Search(ctx context.Context, searchQuery string, cursor
 
 	if cursor == nil {
 		query = knokSelectFields + `
-			WHERE search_vector @@ to_tsquery('english', $1)
+			WHERE deleted_at IS NULL AND search_vector @@ to_tsquery('english', $1)
+			AND ($2::text IS NULL OR server_id = $2)
 			ORDER BY posted_at DESC
-			LIMIT $2`
-		args = []interface{}{sanitizedQuery, limit}
+			LIMIT $3`
+		args = []interface{}{sanitizedQuery, serverID, limit}
 	} else {
 		query = knokSelectFields + `
-			WHERE search_vector @@ to_tsquery('english', $1) AND posted_at < $2
+			WHERE deleted_at IS NULL AND search_vector @@ to_tsquery('english', $1)
+			AND ($2::text IS NULL OR server_id = $2) AND posted_at < $3
 			ORDER BY posted_at DESC
-			LIMIT $3`
-		args = []interface{}{sanitizedQuery, *cursor, limit}
+			LIMIT $4`
+		args = []interface{}{sanitizedQuery, serverID, *cursor, limit}
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -271,18 +333,21 @@ func (r *KnokRepository) Search(ctx context.Context, searchQuery string, cursor
 
 // Create inserts a new knok
 func (r *KnokRepository) Create(ctx context.Context, knok *domain.Knok) error {
+	ctx, span := startKnokSpan(ctx, "postgres.knoks.create", knok.ID)
+	defer span.End()
+
 	query := `
 		INSERT INTO knoks (
 			id, server_id, url, canonical_url, platform, title,
 			discord_message_id, discord_channel_id,
 			message_content, metadata, extraction_status, posted_at,
-			created_at, updated_at
+			created_at, updated_at, platform_item_id, discord_user_id, thumbnail_url
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
 		)`
 
 	// Handle nullable fields
-	var title, messageContent interface{}
+	var title, messageContent, platformItemID, discordUserID, thumbnailURL interface{}
 
 	if knok.Title != nil {
 		title = *knok.Title
@@ -292,6 +357,18 @@ func (r *KnokRepository) Create(ctx context.Context, knok *domain.Knok) error {
 		messageContent = *knok.MessageContent
 	}
 
+	if knok.PlatformItemID != nil {
+		platformItemID = *knok.PlatformItemID
+	}
+
+	if knok.DiscordUserID != nil {
+		discordUserID = *knok.DiscordUserID
+	}
+
+	if knok.ThumbnailURL != nil {
+		thumbnailURL = *knok.ThumbnailURL
+	}
+
 	// Convert metadata to JSON
 	metadata := knok.Metadata
 	if metadata == nil {
@@ -330,6 +407,9 @@ func (r *KnokRepository) Create(ctx context.Context, knok *domain.Knok) error {
 		knok.PostedAt,
 		knok.CreatedAt,
 		updatedAt,
+		platformItemID,
+		discordUserID,
+		thumbnailURL,
 	)
 
 	if err != nil {
@@ -353,6 +433,9 @@ func (r *KnokRepository) Create(ctx context.Context, knok *domain.Knok) error {
 
 // Update modifies an existing knok
 func (r *KnokRepository) Update(ctx context.Context, knok *domain.Knok) error {
+	ctx, span := startKnokSpan(ctx, "postgres.knoks.update", knok.ID)
+	defer span.End()
+
 	query := `
 		UPDATE knoks SET
 			server_id = $2,
@@ -366,11 +449,14 @@ func (r *KnokRepository) Update(ctx context.Context, knok *domain.Knok) error {
 			metadata = $10,
 			extraction_status = $11,
 			posted_at = $12,
-			updated_at = $13
+			updated_at = $13,
+			platform_item_id = $14,
+			thumbnail_url = $15,
+			discord_user_id = $16
 		WHERE id = $1`
 
 	// Handle nullable fields
-	var title, messageContent interface{}
+	var title, messageContent, platformItemID, thumbnailURL, discordUserID interface{}
 
 	if knok.Title != nil {
 		title = *knok.Title
@@ -380,6 +466,18 @@ func (r *KnokRepository) Update(ctx context.Context, knok *domain.Knok) error {
 		messageContent = *knok.MessageContent
 	}
 
+	if knok.PlatformItemID != nil {
+		platformItemID = *knok.PlatformItemID
+	}
+
+	if knok.ThumbnailURL != nil {
+		thumbnailURL = *knok.ThumbnailURL
+	}
+
+	if knok.DiscordUserID != nil {
+		discordUserID = *knok.DiscordUserID
+	}
+
 	// Convert metadata to JSON
 	metadata := knok.Metadata
 	if metadata == nil {
@@ -415,6 +513,9 @@ func (r *KnokRepository) Update(ctx context.Context, knok *domain.Knok) error {
 		knok.ExtractionStatus,
 		knok.PostedAt,
 		knok.UpdatedAt,
+		platformItemID,
+		thumbnailURL,
+		discordUserID,
 	)
 
 	if err != nil {
@@ -452,17 +553,41 @@ func (r *KnokRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	if rowsAffected == 0 {
 		r.logger.Warn("No knok found to delete", "knok_id", id)
-		return fmt.Errorf("knok not found")
+		return domain.ErrKnokNotFound
 	}
 
 	r.logger.Info("Knok deleted from database", "knok_id", id, "rows_affected", rowsAffected)
 	return nil
 }
 
+// SoftDeleteByUser marks every knok posted by a Discord user as deleted, optionally only
+// within one server. Deleted knoks are left out of every read. Returns the number deleted.
+func (r *KnokRepository) SoftDeleteByUser(ctx context.Context, userID string, serverID *string) (int, error) {
+	query := `
+		UPDATE knoks
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE discord_user_id = $1 AND deleted_at IS NULL
+			AND ($2::text IS NULL OR server_id = $2)`
+
+	result, err := r.db.ExecContext(ctx, query, userID, serverID)
+	if err != nil {
+		r.logger.Error("Failed to soft delete user knoks", "error", err, "discord_user_id", userID)
+		return 0, fmt.Errorf("failed to soft delete user knoks: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	r.logger.Info("User knoks soft deleted", "discord_user_id", userID, "deleted", deleted)
+	return int(deleted), nil
+}
+
 // GetByURL finds knoks by URL within a server (for duplicate detection)
 func (r *KnokRepository) GetByURL(ctx context.Context, serverID, url string) (*domain.Knok, error) {
 	query := knokSelectFields + `
-		WHERE server_id = $1 AND url = $2
+		WHERE deleted_at IS NULL AND server_id = $1 AND url = $2
 		ORDER BY created_at DESC
 		LIMIT 1`
 
@@ -472,7 +597,7 @@ func (r *KnokRepository) GetByURL(ctx context.Context, serverID, url string) (*d
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("No duplicate knok found", "server_id", serverID, "url", url)
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrKnokNotFound
 		}
 		r.logger.Error("Failed to query knok by URL", "error", err, "server_id", serverID, "url", url)
 		return nil, fmt.Errorf("failed to query knok by URL: %w", err)
@@ -485,7 +610,7 @@ func (r *KnokRepository) GetByURL(ctx context.Context, serverID, url string) (*d
 // GetByCanonicalURL finds knoks by canonical URL within a server (for duplicate detection)
 func (r *KnokRepository) GetByCanonicalURL(ctx context.Context, serverID, canonicalURL string) (*domain.Knok, error) {
 	query := knokSelectFields + `
-		WHERE server_id = $1 AND canonical_url = $2
+		WHERE deleted_at IS NULL AND server_id = $1 AND canonical_url = $2
 		ORDER BY created_at DESC
 		LIMIT 1`
 
@@ -495,7 +620,7 @@ func (r *KnokRepository) GetByCanonicalURL(ctx context.Context, serverID, canoni
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("No duplicate knok found by canonical URL", "server_id", serverID, "canonical_url", canonicalURL)
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrKnokNotFound
 		}
 		r.logger.Error("Failed to query knok by canonical URL", "error", err, "server_id", serverID, "canonical_url", canonicalURL)
 		return nil, fmt.Errorf("failed to query knok by canonical URL: %w", err)
@@ -505,31 +630,369 @@ func (r *KnokRepository) GetByCanonicalURL(ctx context.Context, serverID, canoni
 	return knok, nil
 }
 
-// GetRecentByServer gets the most recent knoks for a server with cursor pagination
-func (r *KnokRepository) GetRecentByServer(ctx context.Context, serverID string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
-	r.logger.Info("GetRecentByServer called", "server_id", serverID, "cursor", cursor, "limit", limit)
+// GetByPlatformItemID finds knoks by platform item ID within a server (for duplicate detection
+// across URL variants of the same item)
+func (r *KnokRepository) GetByPlatformItemID(ctx context.Context, serverID, platform, itemID string) (*domain.Knok, error) {
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND server_id = $1 AND platform = $2 AND platform_item_id = $3
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	row := r.db.QueryRowContext(ctx, query, serverID, platform, itemID)
+
+	knok, err := r.scanKnokRow(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("No duplicate knok found by platform item ID", "server_id", serverID, "platform", platform, "platform_item_id", itemID)
+			return nil, domain.ErrKnokNotFound
+		}
+		r.logger.Error("Failed to query knok by platform item ID", "error", err, "server_id", serverID, "platform", platform, "platform_item_id", itemID)
+		return nil, fmt.Errorf("failed to query knok by platform item ID: %w", err)
+	}
+
+	r.logger.Debug("Found existing knok by platform item ID", "knok_id", knok.ID, "server_id", serverID, "platform_item_id", itemID)
+	return knok, nil
+}
+
+// BackfillPlatformItemIDs sets platform_item_id on knoks that don't have one yet, using extract
+// to derive the ID from the knok's platform and URL (canonical URL first, then URL as posted).
+// Returns the number of knoks updated.
+func (r *KnokRepository) BackfillPlatformItemIDs(ctx context.Context, extract func(platform, url string) string) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, platform, canonical_url, url
+		FROM knoks
+		WHERE platform_item_id IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query knoks without platform item ID: %w", err)
+	}
+
+	itemIDs := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var platform, canonicalURL, url string
+		if err := rows.Scan(&id, &platform, &canonicalURL, &url); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan knok: %w", err)
+		}
+
+		itemID := extract(platform, canonicalURL)
+		if itemID == "" {
+			itemID = extract(platform, url)
+		}
+		if itemID != "" {
+			itemIDs[id] = itemID
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating knoks: %w", err)
+	}
+
+	updated := 0
+	for id, itemID := range itemIDs {
+		if _, err := r.db.ExecContext(ctx, `UPDATE knoks SET platform_item_id = $2 WHERE id = $1`, id, itemID); err != nil {
+			return updated, fmt.Errorf("failed to set platform item ID on knok %s: %w", id, err)
+		}
+		updated++
+	}
+
+	r.logger.Info("Backfilled platform item IDs", "updated", updated)
+	return updated, nil
+}
+
+// RedactMessageContent rewrites the message_content of knoks that have one with redact,
+// clearing it when redact returns nil. Only knoks with one of the given extraction statuses
+// are touched; nil statuses means every knok. Returns the number of knoks changed.
+func (r *KnokRepository) RedactMessageContent(ctx context.Context, statuses []string, redact func(content string) *string) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, message_content
+		FROM knoks
+		WHERE message_content IS NOT NULL
+			AND ($1::text[] IS NULL OR extraction_status = ANY($1))`, pq.Array(statuses))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query knoks with message content: %w", err)
+	}
+
+	redacted := make(map[uuid.UUID]*string)
+	for rows.Next() {
+		var id uuid.UUID
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan knok: %w", err)
+		}
+
+		newContent := redact(content)
+		if newContent == nil || *newContent != content {
+			redacted[id] = newContent
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating knoks: %w", err)
+	}
+
+	updated := 0
+	for id, content := range redacted {
+		if _, err := r.db.ExecContext(ctx, `UPDATE knoks SET message_content = $2 WHERE id = $1`, id, content); err != nil {
+			return updated, fmt.Errorf("failed to redact message content of knok %s: %w", id, err)
+		}
+		updated++
+	}
+
+	r.logger.Info("Redacted knok message content", "updated", updated)
+	return updated, nil
+}
+
+// requireMetadataFilter hides completed knoks without real metadata when the server's
+// require_metadata setting is true: the title must be set and the extraction method must
+// be one of $2, so fallback and unknown methods are excluded. Knoks with other statuses
+// have no metadata yet, so they're only hidden by filtering on status
+const requireMetadataFilter = `
+			AND (
+				extraction_status <> 'complete'
+				OR COALESCE((SELECT settings->'require_metadata' FROM servers WHERE id = $1), 'false'::jsonb) <> 'true'::jsonb
+				OR (title IS NOT NULL AND extraction_method = ANY($2))
+			)`
+
+// metadataExtractionMethods returns the extraction methods that produce real metadata,
+// i.e. every tier below the title fallback
+func metadataExtractionMethods() []string {
+	var methods []string
+	for method, tier := range domain.ExtractionMethodTiers {
+		if tier < domain.ExtractionMethodTiers["title_fallback"] {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// knokSortExpressions maps each sort column to the expression knoks are ordered by.
+// Titles sort case-insensitively
+var knokSortExpressions = map[string]string{
+	domain.KnokSortPostedAt:  "posted_at",
+	domain.KnokSortCreatedAt: "created_at",
+	domain.KnokSortTitle:     "LOWER(COALESCE(title, ''))",
+}
+
+// knokSortClauses returns the ORDER BY clause for sort and, given a cursor, a condition
+// keeping the knoks after it, to be appended to a WHERE clause with the cursor's value as
+// argument n. Time sorts compare timestamps like the original posted_at pagination, while
+// title sorts compare (title, id) with the cursor knok's so equal titles aren't skipped
+func knokSortClauses(sort domain.KnokSort, cursor *domain.KnokCursor, n int) (string, string, []interface{}, error) {
+	expr, ok := knokSortExpressions[sort.Column]
+	if !ok {
+		return "", "", nil, fmt.Errorf("invalid sort column: %q", sort.Column)
+	}
+
+	direction, after := "DESC", "<"
+	if sort.Ascending {
+		direction, after = "ASC", ">"
+	}
+
+	if sort.Column != domain.KnokSortTitle {
+		orderBy := fmt.Sprintf("ORDER BY %s %s", expr, direction)
+		if cursor == nil {
+			return "", orderBy, nil, nil
+		}
+		return fmt.Sprintf(" AND %s %s $%d", expr, after, n), orderBy, []interface{}{cursor.Time}, nil
+	}
+
+	orderBy := fmt.Sprintf("ORDER BY %s %s, id %s", expr, direction, direction)
+	if cursor == nil {
+		return "", orderBy, nil, nil
+	}
+	condition := fmt.Sprintf(" AND (%s, id) %s (SELECT %s, id FROM knoks WHERE id = $%d)", expr, after, expr, n)
+	return condition, orderBy, []interface{}{cursor.KnokID}, nil
+}
+
+// postedAtRangeFilter returns a condition keeping knoks posted in [from, to], to be
+// appended to a WHERE clause with from and to as arguments n and n+1. Either may be NULL
+// to leave that end open
+func postedAtRangeFilter(n int) string {
+	return fmt.Sprintf(`
+		  AND ($%d::timestamptz IS NULL OR posted_at >= $%d)
+		  AND ($%d::timestamptz IS NULL OR posted_at <= $%d)`, n, n, n+1, n+1)
+}
+
+// timelineStatus returns the extraction status a timeline filters on: status, or complete
+// when it's empty
+func timelineStatus(status string) string {
+	if status == "" {
+		return domain.ExtractionStatusComplete
+	}
+	return status
+}
+
+// timelineFrom returns from, or the start of the timeline window when neither end of the
+// range is given
+func (r *KnokRepository) timelineFrom(from, to *time.Time) *time.Time {
+	if from == nil && to == nil && r.timelineWindow > 0 {
+		windowStart := time.Now().Add(-r.timelineWindow)
+		return &windowStart
+	}
+	return from
+}
+
+// GetRecentByServer gets a server's knoks with an extraction status (complete when status
+// is empty) in sort order with cursor pagination, posted in [from, to] when either is
+// given. Knoks without real metadata are left out when the server requires metadata.
+func (r *KnokRepository) GetRecentByServer(ctx context.Context, serverID string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	r.logger.Info("GetRecentByServer called", "server_id", serverID, "sort", sort.Column, "ascending", sort.Ascending, "cursor", cursor, "from", from, "to", to, "status", status, "limit", limit)
+
+	args := []interface{}{serverID, pq.Array(metadataExtractionMethods()), from, to, timelineStatus(status)}
+	cursorFilter, orderBy, cursorArgs, err := knokSortClauses(sort, cursor, len(args)+1)
+	if err != nil {
+		return nil, err
+	}
+	args = append(append(args, cursorArgs...), limit)
+
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND server_id = $1 AND extraction_status = $5` + requireMetadataFilter + postedAtRangeFilter(3) + cursorFilter + `
+		` + orderBy + fmt.Sprintf(`
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query recent knoks", "error", err, "server_id", serverID, "limit", limit)
+		return nil, fmt.Errorf("failed to query recent knoks: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Recent knoks retrieved successfully", "server_id", serverID, "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// GetRecent gets knoks with an extraction status (complete when status is empty) across all
+// servers (global timeline) in sort order, posted in [from, to] when either is given.
+// Without a range, only knoks within the timeline window are returned, on every page, so
+// older knoks need an explicit range
+func (r *KnokRepository) GetRecent(ctx context.Context, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	from = r.timelineFrom(from, to)
+
+	r.logger.Info("GetRecent called (global)", "sort", sort.Column, "ascending", sort.Ascending, "cursor", cursor, "from", from, "to", to, "status", status, "limit", limit)
+
+	args := []interface{}{from, to, timelineStatus(status)}
+	cursorFilter, orderBy, cursorArgs, err := knokSortClauses(sort, cursor, len(args)+1)
+	if err != nil {
+		return nil, err
+	}
+	args = append(append(args, cursorArgs...), limit)
+
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND extraction_status = $3` + postedAtRangeFilter(1) + cursorFilter + `
+		` + orderBy + fmt.Sprintf(`
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query recent knoks (global)", "error", err, "limit", limit)
+		return nil, fmt.Errorf("failed to query recent knoks: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Recent knoks retrieved successfully (global)", "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// GetRecentByPlatform gets knoks on a platform across all servers in sort order, with the
+// same status filter, date range and timeline window as GetRecent
+func (r *KnokRepository) GetRecentByPlatform(ctx context.Context, platform string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	from = r.timelineFrom(from, to)
+
+	args := []interface{}{platform, from, to, timelineStatus(status)}
+	cursorFilter, orderBy, cursorArgs, err := knokSortClauses(sort, cursor, len(args)+1)
+	if err != nil {
+		return nil, err
+	}
+	args = append(append(args, cursorArgs...), limit)
+
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND extraction_status = $4 AND platform = $1` + postedAtRangeFilter(2) + cursorFilter + `
+		` + orderBy + fmt.Sprintf(`
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query recent knoks by platform (global)", "error", err, "platform", platform, "limit", limit)
+		return nil, fmt.Errorf("failed to query recent knoks by platform: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
 
+	r.logger.Debug("Recent knoks retrieved by platform (global)", "platform", platform, "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// GetByStatus gets knoks with the given extraction status across all servers,
+// newest first, with cursor pagination on posted_at
+func (r *KnokRepository) GetByStatus(ctx context.Context, status string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
 	var query string
 	var args []interface{}
 
 	if cursor == nil {
 		query = knokSelectFields + `
-			WHERE server_id = $1 AND extraction_status = 'complete'
+			WHERE deleted_at IS NULL AND extraction_status = $1
 			ORDER BY posted_at DESC
 			LIMIT $2`
-		args = []interface{}{serverID, limit}
+		args = []interface{}{status, limit}
 	} else {
 		query = knokSelectFields + `
-			WHERE server_id = $1 AND posted_at < $2 AND extraction_status = 'complete'
+			WHERE deleted_at IS NULL AND extraction_status = $1 AND posted_at < $2
 			ORDER BY posted_at DESC
 			LIMIT $3`
-		args = []interface{}{serverID, *cursor, limit}
+		args = []interface{}{status, *cursor, limit}
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		r.logger.Error("Failed to query recent knoks", "error", err, "server_id", serverID, "limit", limit)
-		return nil, fmt.Errorf("failed to query recent knoks: %w", err)
+		r.logger.Error("Failed to query knoks by status", "error", err, "status", status, "limit", limit)
+		return nil, fmt.Errorf("failed to query knoks by status: %w", err)
 	}
 	defer rows.Close()
 
@@ -548,35 +1011,34 @@ func (r *KnokRepository) GetRecentByServer(ctx context.Context, serverID string,
 		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
 	}
 
-	r.logger.Debug("Recent knoks retrieved successfully", "server_id", serverID, "limit", limit, "knoks_count", len(knoks))
+	r.logger.Debug("Knoks retrieved by status", "status", status, "limit", limit, "knoks_count", len(knoks))
 	return knoks, nil
 }
 
-// GetRecent gets recent knoks across all servers (global timeline)
-func (r *KnokRepository) GetRecent(ctx context.Context, cursor *time.Time, limit int) ([]*domain.Knok, error) {
-	r.logger.Info("GetRecent called (global)", "cursor", cursor, "limit", limit)
-
+// GetByExtractionMethod gets knoks whose metadata was extracted with method across all
+// servers with cursor pagination
+func (r *KnokRepository) GetByExtractionMethod(ctx context.Context, method string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
 	var query string
 	var args []interface{}
 
 	if cursor == nil {
 		query = knokSelectFields + `
-			WHERE extraction_status = 'complete'
+			WHERE deleted_at IS NULL AND extraction_method = $1
 			ORDER BY posted_at DESC
-			LIMIT $1`
-		args = []interface{}{limit}
+			LIMIT $2`
+		args = []interface{}{method, limit}
 	} else {
 		query = knokSelectFields + `
-			WHERE posted_at < $1 AND extraction_status = 'complete'
+			WHERE deleted_at IS NULL AND extraction_method = $1 AND posted_at < $2
 			ORDER BY posted_at DESC
-			LIMIT $2`
-		args = []interface{}{*cursor, limit}
+			LIMIT $3`
+		args = []interface{}{method, *cursor, limit}
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		r.logger.Error("Failed to query recent knoks (global)", "error", err, "limit", limit)
-		return nil, fmt.Errorf("failed to query recent knoks: %w", err)
+		r.logger.Error("Failed to query knoks by extraction method", "error", err, "method", method, "limit", limit)
+		return nil, fmt.Errorf("failed to query knoks by extraction method: %w", err)
 	}
 	defer rows.Close()
 
@@ -595,20 +1057,507 @@ func (r *KnokRepository) GetRecent(ctx context.Context, cursor *time.Time, limit
 		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
 	}
 
-	r.logger.Debug("Recent knoks retrieved successfully (global)", "limit", limit, "knoks_count", len(knoks))
+	r.logger.Debug("Knoks retrieved by extraction method", "method", method, "limit", limit, "knoks_count", len(knoks))
 	return knoks, nil
 }
 
-// GetByPlatform gets knoks filtered by platform within a server
+// extractionOutcome is the number of knoks sharing a platform, status, extraction method
+// and failure reason
+type extractionOutcome struct {
+	platform string
+	status   string
+	method   string
+	reason   string
+	count    int
+}
+
+// GetExtractionReport aggregates extraction outcomes of complete and failed knoks by
+// platform, using the extraction_method column and metadata.extraction_error
+func (r *KnokRepository) GetExtractionReport(ctx context.Context) (*domain.ExtractionReport, error) {
+	query := `
+		SELECT platform, extraction_status,
+			COALESCE(extraction_method, ''),
+			COALESCE(metadata->>'extraction_error', ''),
+			COUNT(*)
+		FROM knoks
+		WHERE deleted_at IS NULL AND extraction_status IN ($1, $2)
+		GROUP BY 1, 2, 3, 4`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.ExtractionStatusComplete, domain.ExtractionStatusFailed)
+	if err != nil {
+		r.logger.Error("Failed to query extraction outcomes", "error", err)
+		return nil, fmt.Errorf("failed to query extraction outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []extractionOutcome
+	for rows.Next() {
+		var outcome extractionOutcome
+		if err := rows.Scan(&outcome.platform, &outcome.status, &outcome.method, &outcome.reason, &outcome.count); err != nil {
+			r.logger.Error("Failed to scan extraction outcome", "error", err)
+			return nil, fmt.Errorf("failed to scan extraction outcome: %w", err)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	report := buildExtractionReport(outcomes)
+	r.logger.Debug("Extraction report computed", "platforms_count", len(report.Platforms))
+	return report, nil
+}
+
+// buildExtractionReport aggregates outcomes into per-platform stats, least reliable
+// platform first
+func buildExtractionReport(outcomes []extractionOutcome) *domain.ExtractionReport {
+	statsByPlatform := make(map[string]*domain.PlatformExtractionStats)
+	tierSums := make(map[string]int)
+	tierCounts := make(map[string]int)
+
+	for _, outcome := range outcomes {
+		stats, ok := statsByPlatform[outcome.platform]
+		if !ok {
+			stats = &domain.PlatformExtractionStats{
+				Platform: outcome.platform,
+				Methods:  make(map[string]int),
+			}
+			statsByPlatform[outcome.platform] = stats
+		}
+		stats.Total += outcome.count
+
+		if outcome.status == domain.ExtractionStatusFailed {
+			stats.Failed += outcome.count
+			if outcome.reason != "" {
+				if stats.FailureReasons == nil {
+					stats.FailureReasons = make(map[string]int)
+				}
+				stats.FailureReasons[outcome.reason] += outcome.count
+			}
+			continue
+		}
+
+		method := outcome.method
+		if method == "" {
+			method = "unknown"
+		}
+		stats.Methods[method] += outcome.count
+
+		tier, known := domain.ExtractionMethodTiers[outcome.method]
+		if known {
+			tierSums[outcome.platform] += tier * outcome.count
+			tierCounts[outcome.platform] += outcome.count
+		}
+		if known && tier < domain.ExtractionMethodTiers["title_fallback"] {
+			stats.Succeeded += outcome.count
+		} else {
+			stats.Fallback += outcome.count
+		}
+	}
+
+	report := &domain.ExtractionReport{
+		Platforms:   make([]*domain.PlatformExtractionStats, 0, len(statsByPlatform)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for platform, stats := range statsByPlatform {
+		if stats.Total > 0 {
+			stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Total)
+		}
+		if tierCounts[platform] > 0 {
+			averageTier := float64(tierSums[platform]) / float64(tierCounts[platform])
+			stats.AverageTier = &averageTier
+		}
+		report.Platforms = append(report.Platforms, stats)
+	}
+
+	sort.Slice(report.Platforms, func(i, j int) bool {
+		a, b := report.Platforms[i], report.Platforms[j]
+		if a.SuccessRate != b.SuccessRate {
+			return a.SuccessRate < b.SuccessRate
+		}
+		return a.Platform < b.Platform
+	})
+
+	return report
+}
+
+// GetCountsByServer counts a server's knoks in total, per platform and per extraction status
+func (r *KnokRepository) GetCountsByServer(ctx context.Context, serverID string) (*domain.KnokCounts, error) {
+	query := `
+		SELECT platform, extraction_status, COUNT(*)
+		FROM knoks
+		WHERE deleted_at IS NULL AND server_id = $1
+		GROUP BY 1, 2`
+
+	rows, err := r.db.QueryContext(ctx, query, serverID)
+	if err != nil {
+		r.logger.Error("Failed to query knok counts", "error", err, "server_id", serverID)
+		return nil, fmt.Errorf("failed to query knok counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := &domain.KnokCounts{
+		ByPlatform: make(map[string]int),
+		ByStatus:   make(map[string]int),
+	}
+	for rows.Next() {
+		var platform, status string
+		var count int
+		if err := rows.Scan(&platform, &status, &count); err != nil {
+			r.logger.Error("Failed to scan knok count", "error", err)
+			return nil, fmt.Errorf("failed to scan knok count: %w", err)
+		}
+		counts.Total += count
+		counts.ByPlatform[platform] += count
+		counts.ByStatus[status] += count
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Knok counts computed", "server_id", serverID, "total", counts.Total)
+	return counts, nil
+}
+
+// GetServerStats summarizes a server's completed knoks per platform, most knoks first
+func (r *KnokRepository) GetServerStats(ctx context.Context, serverID string) (*domain.ServerStats, error) {
+	query := `
+		SELECT platform, COUNT(*), MAX(posted_at)
+		FROM knoks
+		WHERE deleted_at IS NULL AND server_id = $1 AND extraction_status = $2
+		GROUP BY platform
+		ORDER BY 2 DESC, 1`
+
+	rows, err := r.db.QueryContext(ctx, query, serverID, domain.ExtractionStatusComplete)
+	if err != nil {
+		r.logger.Error("Failed to query server stats", "error", err, "server_id", serverID)
+		return nil, fmt.Errorf("failed to query server stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &domain.ServerStats{Platforms: make([]*domain.PlatformCount, 0)}
+	for rows.Next() {
+		platform := &domain.PlatformCount{}
+		var latest time.Time
+		if err := rows.Scan(&platform.Platform, &platform.Count, &latest); err != nil {
+			r.logger.Error("Failed to scan server stats", "error", err)
+			return nil, fmt.Errorf("failed to scan server stats: %w", err)
+		}
+		stats.Total += platform.Count
+		stats.Platforms = append(stats.Platforms, platform)
+		if stats.LatestPostedAt == nil || latest.After(*stats.LatestPostedAt) {
+			stats.LatestPostedAt = &latest
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Server stats computed", "server_id", serverID, "total", stats.Total)
+	return stats, nil
+}
+
+// GetTopPosters counts a server's completed knoks per poster, most knoks first. Knoks
+// stored before posters were recorded have no user ID and aren't counted
+func (r *KnokRepository) GetTopPosters(ctx context.Context, serverID string, limit int) ([]*domain.PosterCount, error) {
+	query := `
+		SELECT discord_user_id, COUNT(*)
+		FROM knoks
+		WHERE deleted_at IS NULL AND server_id = $1 AND extraction_status = $2
+		  AND discord_user_id IS NOT NULL
+		GROUP BY discord_user_id
+		ORDER BY 2 DESC, 1
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, serverID, domain.ExtractionStatusComplete, limit)
+	if err != nil {
+		r.logger.Error("Failed to query top posters", "error", err, "server_id", serverID)
+		return nil, fmt.Errorf("failed to query top posters: %w", err)
+	}
+	defer rows.Close()
+
+	posters := make([]*domain.PosterCount, 0)
+	for rows.Next() {
+		poster := &domain.PosterCount{}
+		if err := rows.Scan(&poster.UserID, &poster.Count); err != nil {
+			r.logger.Error("Failed to scan top poster", "error", err)
+			return nil, fmt.Errorf("failed to scan top poster: %w", err)
+		}
+		posters = append(posters, poster)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Top posters computed", "server_id", serverID, "count", len(posters))
+	return posters, nil
+}
+
+// GetActivityHistogram counts knoks posted in [from, to) per UTC bucket. Every bucket in
+// the range is returned, including empty ones, oldest first.
+func (r *KnokRepository) GetActivityHistogram(ctx context.Context, serverID *string, from, to time.Time, bucket string) ([]*domain.ActivityBucket, error) {
+	if _, ok := domain.ActivityBucketSizes[bucket]; !ok {
+		return nil, fmt.Errorf("unknown activity bucket %q", bucket)
+	}
+
+	// The series stops just before "to" so a range ending on a bucket boundary doesn't
+	// get an extra, always empty, bucket
+	query := `
+		SELECT series.start, COUNT(k.id)
+		FROM generate_series(
+			date_trunc($1::text, $2::timestamptz AT TIME ZONE 'UTC'),
+			$3::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+			('1 ' || $1::text)::interval
+		) AS series(start)
+		LEFT JOIN knoks k
+			ON date_trunc($1::text, k.posted_at AT TIME ZONE 'UTC') = series.start
+			AND k.posted_at >= $2 AND k.posted_at < $3
+			AND k.deleted_at IS NULL
+			AND ($4::text IS NULL OR k.server_id = $4)
+		GROUP BY series.start
+		ORDER BY series.start`
+
+	rows, err := r.db.QueryContext(ctx, query, bucket, from, to, serverID)
+	if err != nil {
+		r.logger.Error("Failed to query activity histogram", "error", err, "bucket", bucket)
+		return nil, fmt.Errorf("failed to query activity histogram: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []*domain.ActivityBucket{}
+	for rows.Next() {
+		var b domain.ActivityBucket
+		if err := rows.Scan(&b.Start, &b.Count); err != nil {
+			r.logger.Error("Failed to scan activity bucket", "error", err)
+			return nil, fmt.Errorf("failed to scan activity bucket: %w", err)
+		}
+		b.Start = b.Start.UTC()
+		buckets = append(buckets, &b)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Activity histogram computed", "bucket", bucket, "buckets_count", len(buckets))
+	return buckets, nil
+}
+
+// GetByPlatform gets a server's completed knoks on a platform, newest first, with offset
+// pagination. The second value is the total number of matching knoks. Like the server
+// timeline, it leaves out knoks without real metadata when the server requires it
 func (r *KnokRepository) GetByPlatform(ctx context.Context, serverID, platform string, offset, limit int) ([]*domain.Knok, int, error) {
-	r.logger.Info("GetByPlatform called (not implemented yet)",
+	const filter = `
+			WHERE deleted_at IS NULL AND server_id = $1 AND platform = $3 AND extraction_status = 'complete'` + requireMetadataFilter
+
+	methods := pq.Array(metadataExtractionMethods())
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM knoks` + filter
+	if err := r.db.QueryRowContext(ctx, countQuery, serverID, methods, platform).Scan(&total); err != nil {
+		r.logger.Error("Failed to count knoks by platform", "error", err, "server_id", serverID, "platform", platform)
+		return nil, 0, fmt.Errorf("failed to count knoks by platform: %w", err)
+	}
+
+	query := knokSelectFields + filter + `
+			ORDER BY posted_at DESC
+			LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.QueryContext(ctx, query, serverID, methods, platform, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to query knoks by platform", "error", err, "server_id", serverID, "platform", platform)
+		return nil, 0, fmt.Errorf("failed to query knoks by platform: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, 0, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Knoks retrieved by platform",
 		"server_id", serverID,
 		"platform", platform,
 		"offset", offset,
 		"limit", limit,
+		"knoks_count", len(knoks),
+		"total", total,
 	)
-	// TODO: Implement actual PostgreSQL query
-	return nil, 0, nil
+	return knoks, total, nil
+}
+
+// GetByPlatformCursor gets a server's knoks on a platform in sort order, with the same
+// status filter, date range and cursor pagination as GetRecentByServer. An empty platform
+// doesn't filter
+func (r *KnokRepository) GetByPlatformCursor(ctx context.Context, serverID, platform string, sort domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	if platform == "" {
+		return r.GetRecentByServer(ctx, serverID, sort, cursor, from, to, status, limit)
+	}
+
+	args := []interface{}{serverID, pq.Array(metadataExtractionMethods()), platform, from, to, timelineStatus(status)}
+	cursorFilter, orderBy, cursorArgs, err := knokSortClauses(sort, cursor, len(args)+1)
+	if err != nil {
+		return nil, err
+	}
+	args = append(append(args, cursorArgs...), limit)
+
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND server_id = $1 AND platform = $3 AND extraction_status = $6` + requireMetadataFilter + postedAtRangeFilter(4) + cursorFilter + `
+		` + orderBy + fmt.Sprintf(`
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query knoks by platform", "error", err, "server_id", serverID, "platform", platform, "limit", limit)
+		return nil, fmt.Errorf("failed to query knoks by platform: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Knoks retrieved by platform", "server_id", serverID, "platform", platform, "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// GetAllByPlatform gets knoks on a platform across all servers, newest first, with keyset
+// pagination on (posted_at, id). The ID breaks ties between knoks posted at the same time,
+// e.g. several links in one seeded message
+func (r *KnokRepository) GetAllByPlatform(ctx context.Context, platform string, cursor *domain.KnokCursor, limit int) ([]*domain.Knok, error) {
+	var query string
+	var args []interface{}
+
+	if cursor == nil {
+		query = knokSelectFields + `
+			WHERE deleted_at IS NULL AND platform = $1
+			ORDER BY posted_at DESC, id DESC
+			LIMIT $2`
+		args = []interface{}{platform, limit}
+	} else {
+		query = knokSelectFields + `
+			WHERE deleted_at IS NULL AND platform = $1 AND (posted_at, id) < ($2, $3)
+			ORDER BY posted_at DESC, id DESC
+			LIMIT $4`
+		args = []interface{}{platform, cursor.Time, cursor.KnokID, limit}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query knoks by platform", "error", err, "platform", platform, "limit", limit)
+		return nil, fmt.Errorf("failed to query knoks by platform: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Knoks retrieved by platform", "platform", platform, "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// GetByPlatformAndStatus gets up to limit of the newest knoks on a platform with one of the
+// given extraction statuses across all servers
+func (r *KnokRepository) GetByPlatformAndStatus(ctx context.Context, platform string, statuses []string, limit int) ([]*domain.Knok, error) {
+	query := knokSelectFields + `
+		WHERE deleted_at IS NULL AND platform = $1 AND extraction_status = ANY($2)
+		ORDER BY posted_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, pq.Array(statuses), limit)
+	if err != nil {
+		r.logger.Error("Failed to query knoks by platform and status", "error", err, "platform", platform, "statuses", statuses)
+		return nil, fmt.Errorf("failed to query knoks by platform and status: %w", err)
+	}
+	defer rows.Close()
+
+	var knoks []*domain.Knok
+	for rows.Next() {
+		knok, err := r.scanKnokRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan knok", "error", err)
+			return nil, fmt.Errorf("failed to scan knok: %w", err)
+		}
+		knoks = append(knoks, knok)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Knoks retrieved by platform and status", "platform", platform, "statuses", statuses, "limit", limit, "knoks_count", len(knoks))
+	return knoks, nil
+}
+
+// UpdatePlatform changes the platform a knok is classified as
+func (r *KnokRepository) UpdatePlatform(ctx context.Context, id uuid.UUID, platform string) error {
+	query := `
+		UPDATE knoks
+		SET platform = $1, updated_at = NOW()
+		WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, platform, id)
+	if err != nil {
+		r.logger.Error("Failed to update platform", "error", err, "knok_id", id, "platform", platform)
+		return fmt.Errorf("failed to update platform: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected", "error", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("No knok found for platform update", "knok_id", id)
+		return fmt.Errorf("%w: %s", domain.ErrKnokNotFound, id)
+	}
+
+	r.logger.Info("Knok platform updated", "knok_id", id, "platform", platform)
+	return nil
 }
 
 // UpdateExtractionStatus updates the metadata extraction status
@@ -636,7 +1585,7 @@ func (r *KnokRepository) UpdateExtractionStatus(ctx context.Context, id uuid.UUI
 
 	if rowsAffected == 0 {
 		r.logger.Warn("No knok found for status update", "knok_id", id)
-		return fmt.Errorf("knok not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrKnokNotFound, id)
 	}
 
 	r.logger.Info("Extraction status updated successfully",
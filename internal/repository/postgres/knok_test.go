@@ -0,0 +1,1418 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_URL and runs migrations.
+// Tests that need PostgreSQL are skipped when it isn't set.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping PostgreSQL test")
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := RunMigrations(db, createTestLogger()); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createTestLogger creates a logger for testing
+func createTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError, // Only show errors during tests
+	}))
+}
+
+// createTestServer inserts a throwaway server and removes it and its knoks when the test ends
+func createTestServer(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	// Server IDs are VARCHAR(20), the length of a Discord snowflake
+	serverID := fmt.Sprintf("test%d", time.Now().UnixNano()%1e15)
+	if _, err := db.Exec(`INSERT INTO servers (id, name) VALUES ($1, $2)`, serverID, "Test Server"); err != nil {
+		t.Fatalf("Failed to create test server: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM knoks WHERE server_id = $1`, serverID)
+		db.Exec(`DELETE FROM servers WHERE id = $1`, serverID)
+	})
+
+	return serverID
+}
+
+// createTestKnok inserts a knok with a unique URL and message ID
+func createTestKnok(t *testing.T, repo *KnokRepository, serverID string, i int, status string, postedAt time.Time) *domain.Knok {
+	t.Helper()
+
+	title := fmt.Sprintf("Track %d", i)
+	url := fmt.Sprintf("https://soundcloud.com/artist/track-%d", i)
+	knok := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         serverID,
+		URL:              url,
+		CanonicalURL:     url,
+		Platform:         "soundcloud",
+		Title:            &title,
+		DiscordMessageID: fmt.Sprintf("%d", time.Now().UnixNano()),
+		DiscordChannelID: "channel-1",
+		Metadata:         map[string]interface{}{},
+		ExtractionStatus: status,
+		PostedAt:         postedAt,
+		CreatedAt:        time.Now(),
+	}
+	if err := repo.Create(context.Background(), knok); err != nil {
+		t.Fatalf("Failed to create knok: %v", err)
+	}
+
+	return knok
+}
+
+func TestKnokRepositoryGetForDate(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, time.Now())
+	}
+
+	date := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Same date returns same knok", func(t *testing.T) {
+		first, err := repo.GetForDate(ctx, date)
+		if err != nil {
+			t.Fatalf("GetForDate() error = %v", err)
+		}
+		// A different time on the same day must give the same pick
+		second, err := repo.GetForDate(ctx, date.Add(15*time.Hour))
+		if err != nil {
+			t.Fatalf("GetForDate() error = %v", err)
+		}
+		if first.ID != second.ID {
+			t.Errorf("GetForDate() returned %s then %s for the same date", first.ID, second.ID)
+		}
+	})
+
+	t.Run("Different dates generally differ", func(t *testing.T) {
+		seen := make(map[uuid.UUID]bool)
+		for day := 0; day < 30; day++ {
+			knok, err := repo.GetForDate(ctx, date.AddDate(0, 0, day))
+			if err != nil {
+				t.Fatalf("GetForDate() error = %v", err)
+			}
+			if knok.ExtractionStatus != domain.ExtractionStatusComplete {
+				t.Errorf("GetForDate() returned knok with status %q", knok.ExtractionStatus)
+			}
+			seen[knok.ID] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("GetForDate() returned the same knok for 30 different dates")
+		}
+	})
+}
+
+func TestKnokRepositoryGetByStatus(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Post far in the future so these knoks sort ahead of any existing data
+	base := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	var failed []*domain.Knok
+	for i := 0; i < 3; i++ {
+		failed = append(failed, createTestKnok(t, repo, serverID, i, domain.ExtractionStatusFailed, base.Add(time.Duration(-i)*time.Hour)))
+	}
+	createTestKnok(t, repo, serverID, 3, domain.ExtractionStatusComplete, base.Add(time.Minute))
+	createTestKnok(t, repo, serverID, 4, domain.ExtractionStatusPending, base.Add(-time.Minute))
+
+	t.Run("Returns only the requested status, newest first", func(t *testing.T) {
+		knoks, err := repo.GetByStatus(ctx, domain.ExtractionStatusFailed, nil, 3)
+		if err != nil {
+			t.Fatalf("GetByStatus() error = %v", err)
+		}
+		if len(knoks) != 3 {
+			t.Fatalf("GetByStatus() returned %d knoks, want 3", len(knoks))
+		}
+		for i, knok := range knoks {
+			if knok.ID != failed[i].ID {
+				t.Errorf("knoks[%d] = %s, want %s", i, knok.ID, failed[i].ID)
+			}
+			if knok.ExtractionStatus != domain.ExtractionStatusFailed {
+				t.Errorf("knoks[%d] status = %q, want failed", i, knok.ExtractionStatus)
+			}
+		}
+	})
+
+	t.Run("Cursor continues after the previous page", func(t *testing.T) {
+		firstPage, err := repo.GetByStatus(ctx, domain.ExtractionStatusFailed, nil, 2)
+		if err != nil {
+			t.Fatalf("GetByStatus() error = %v", err)
+		}
+		if len(firstPage) != 2 {
+			t.Fatalf("first page has %d knoks, want 2", len(firstPage))
+		}
+
+		cursor := firstPage[len(firstPage)-1].PostedAt
+		secondPage, err := repo.GetByStatus(ctx, domain.ExtractionStatusFailed, &cursor, 2)
+		if err != nil {
+			t.Fatalf("GetByStatus() error = %v", err)
+		}
+		if len(secondPage) == 0 || secondPage[0].ID != failed[2].ID {
+			t.Errorf("second page should start with %s", failed[2].ID)
+		}
+	})
+}
+
+func TestKnokRepositoryGetByPlatformItemID(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	knok := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+	createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusComplete, time.Now())
+
+	itemID := "track-0"
+	knok.PlatformItemID = &itemID
+	if err := repo.Update(ctx, knok); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	found, err := repo.GetByPlatformItemID(ctx, serverID, "soundcloud", itemID)
+	if err != nil {
+		t.Fatalf("GetByPlatformItemID() error = %v", err)
+	}
+	if found.ID != knok.ID || found.PlatformItemID == nil || *found.PlatformItemID != itemID {
+		t.Errorf("GetByPlatformItemID() = %s (item %v), want %s", found.ID, found.PlatformItemID, knok.ID)
+	}
+
+	// The item ID is only unique within a platform
+	if _, err := repo.GetByPlatformItemID(ctx, serverID, "youtube", itemID); !errors.Is(err, domain.ErrKnokNotFound) {
+		t.Errorf("GetByPlatformItemID() for another platform error = %v, want domain.ErrKnokNotFound", err)
+	}
+}
+
+func TestKnokRepositoryBackfillPlatformItemIDs(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	withID := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+	withoutID := createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusComplete, time.Now())
+
+	// Only track-0 yields an ID; unrelated knoks from other tests must not break the backfill
+	_, err := repo.BackfillPlatformItemIDs(ctx, func(platform, url string) string {
+		if url == withID.URL {
+			return "track-0"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("BackfillPlatformItemIDs() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, withID.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.PlatformItemID == nil || *got.PlatformItemID != "track-0" {
+		t.Errorf("platform item ID = %v, want track-0", got.PlatformItemID)
+	}
+
+	got, err = repo.GetByID(ctx, withoutID.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.PlatformItemID != nil {
+		t.Errorf("platform item ID = %q, want nil", *got.PlatformItemID)
+	}
+}
+
+func TestKnokRepositorySearchByServer(t *testing.T) {
+	db := openTestDB(t)
+	serverA := createTestServer(t, db)
+	serverB := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// A word no other test knok's title contains, so only these two knoks match
+	word := fmt.Sprintf("searchable%d", time.Now().UnixNano())
+	inA := createTestKnok(t, repo, serverA, 0, domain.ExtractionStatusComplete, time.Now())
+	inB := createTestKnok(t, repo, serverB, 1, domain.ExtractionStatusComplete, time.Now())
+	for _, knok := range []*domain.Knok{inA, inB} {
+		if _, err := db.ExecContext(ctx, `UPDATE knoks SET title = $2 WHERE id = $1`, knok.ID, "Track "+word); err != nil {
+			t.Fatalf("failed to set knok title: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		serverID *string
+		want     []uuid.UUID
+	}{
+		{name: "Global", serverID: nil, want: []uuid.UUID{inA.ID, inB.ID}},
+		{name: "Scoped to server A", serverID: &serverA, want: []uuid.UUID{inA.ID}},
+		{name: "Scoped to server B", serverID: &serverB, want: []uuid.UUID{inB.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knoks, err := repo.Search(ctx, tt.serverID, word, nil, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+
+			got := make(map[uuid.UUID]bool)
+			for _, knok := range knoks {
+				got[knok.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("Search() returned %d knoks, want %d", len(got), len(tt.want))
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("Search() is missing knok %s", id)
+				}
+			}
+		})
+	}
+}
+
+func TestKnokRepositoryGetByExtractionMethod(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// A method no other test knok uses, so only these knoks match
+	method := fmt.Sprintf("test_method_%d", time.Now().UnixNano())
+	base := time.Now().Add(-time.Hour)
+	var want []uuid.UUID
+	for i := 0; i < 3; i++ {
+		knok := createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, base.Add(time.Duration(i)*time.Minute))
+		knok.Metadata["extraction_method"] = method
+		if err := repo.Update(ctx, knok); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		want = append([]uuid.UUID{knok.ID}, want...)
+	}
+	other := createTestKnok(t, repo, serverID, 3, domain.ExtractionStatusComplete, base)
+	other.Metadata["extraction_method"] = "oembed"
+	if err := repo.Update(ctx, other); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	firstPage, err := repo.GetByExtractionMethod(ctx, method, nil, 2)
+	if err != nil {
+		t.Fatalf("GetByExtractionMethod() error = %v", err)
+	}
+	if len(firstPage) != 2 || firstPage[0].ID != want[0] || firstPage[1].ID != want[1] {
+		t.Fatalf("first page = %v, want the two newest of %v", knokIDs(firstPage), want)
+	}
+
+	cursor := firstPage[1].PostedAt
+	secondPage, err := repo.GetByExtractionMethod(ctx, method, &cursor, 2)
+	if err != nil {
+		t.Fatalf("GetByExtractionMethod() error = %v", err)
+	}
+	if len(secondPage) != 1 || secondPage[0].ID != want[2] {
+		t.Errorf("second page = %v, want [%s]", knokIDs(secondPage), want[2])
+	}
+}
+
+// knokIDs lists the IDs of knoks for failure messages
+func knokIDs(knoks []*domain.Knok) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(knoks))
+	for _, knok := range knoks {
+		ids = append(ids, knok.ID)
+	}
+	return ids
+}
+
+func TestKnokRepositorySoftDeleteByUser(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	userID := fmt.Sprintf("%d", time.Now().UnixNano())
+	otherUserID := userID + "0"
+	mine := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+	theirs := createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusComplete, time.Now())
+	for knok, author := range map[*domain.Knok]string{mine: userID, theirs: otherUserID} {
+		if _, err := db.ExecContext(ctx, `UPDATE knoks SET discord_user_id = $2 WHERE id = $1`, knok.ID, author); err != nil {
+			t.Fatalf("failed to set knok author: %v", err)
+		}
+	}
+
+	otherServer := "other-server"
+	deleted, err := repo.SoftDeleteByUser(ctx, userID, &otherServer)
+	if err != nil {
+		t.Fatalf("SoftDeleteByUser() error = %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("SoftDeleteByUser() in another server = %d, want 0", deleted)
+	}
+
+	deleted, err = repo.SoftDeleteByUser(ctx, userID, nil)
+	if err != nil {
+		t.Fatalf("SoftDeleteByUser() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("SoftDeleteByUser() = %d, want 1", deleted)
+	}
+
+	if _, err := repo.GetByID(ctx, mine.ID); !errors.Is(err, domain.ErrKnokNotFound) {
+		t.Errorf("GetByID() for deleted knok error = %v, want domain.ErrKnokNotFound", err)
+	}
+	got, err := repo.GetByID(ctx, theirs.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.DiscordUserID == nil || *got.DiscordUserID != otherUserID {
+		t.Errorf("discord user ID = %v, want %s", got.DiscordUserID, otherUserID)
+	}
+}
+
+func TestKnokRepositoryRedactMessageContent(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	content := "check this out https://soundcloud.com/artist/track-0 so good"
+	complete := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+	pending := createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusPending, time.Now())
+	for _, knok := range []*domain.Knok{complete, pending} {
+		knok.MessageContent = &content
+		if err := repo.Update(ctx, knok); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	// Only touch this test's knoks; other tests share the database
+	_, err := repo.RedactMessageContent(ctx, []string{domain.ExtractionStatusComplete}, func(c string) *string {
+		if c != content {
+			return &c
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RedactMessageContent() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, complete.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.MessageContent != nil {
+		t.Errorf("complete knok message content = %q, want nil", *got.MessageContent)
+	}
+
+	got, err = repo.GetByID(ctx, pending.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.MessageContent == nil || *got.MessageContent != content {
+		t.Errorf("pending knok message content = %v, want it kept", got.MessageContent)
+	}
+}
+
+func TestBuildExtractionReport(t *testing.T) {
+	outcomes := []extractionOutcome{
+		{platform: "bandcamp", status: domain.ExtractionStatusComplete, method: "oembed", count: 8},
+		{platform: "bandcamp", status: domain.ExtractionStatusComplete, method: "http_static", count: 2},
+		{platform: "dublab", status: domain.ExtractionStatusComplete, method: "rod_browser", count: 2},
+		{platform: "dublab", status: domain.ExtractionStatusComplete, method: "title_fallback", count: 1},
+		{platform: "dublab", status: domain.ExtractionStatusFailed, reason: "job timed out after 1m30s", count: 3},
+		{platform: "dublab", status: domain.ExtractionStatusFailed, count: 1},
+	}
+
+	report := buildExtractionReport(outcomes)
+
+	if len(report.Platforms) != 2 {
+		t.Fatalf("report has %d platforms, want 2", len(report.Platforms))
+	}
+
+	// Least reliable platform sorts first
+	dublab, bandcamp := report.Platforms[0], report.Platforms[1]
+	if dublab.Platform != "dublab" || bandcamp.Platform != "bandcamp" {
+		t.Fatalf("platforms = [%s %s], want [dublab bandcamp]", dublab.Platform, bandcamp.Platform)
+	}
+
+	tests := []struct {
+		name        string
+		stats       *domain.PlatformExtractionStats
+		total       int
+		succeeded   int
+		fallback    int
+		failed      int
+		successRate float64
+		averageTier float64
+	}{
+		{"bandcamp", bandcamp, 10, 10, 0, 0, 1.0, 0.2},
+		{"dublab", dublab, 7, 2, 1, 4, 2.0 / 7.0, (2*2 + 3) / 3.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stats
+			if s.Total != tt.total || s.Succeeded != tt.succeeded || s.Fallback != tt.fallback || s.Failed != tt.failed {
+				t.Errorf("counts = total %d succeeded %d fallback %d failed %d, want %d %d %d %d",
+					s.Total, s.Succeeded, s.Fallback, s.Failed, tt.total, tt.succeeded, tt.fallback, tt.failed)
+			}
+			if math.Abs(s.SuccessRate-tt.successRate) > 1e-9 {
+				t.Errorf("SuccessRate = %v, want %v", s.SuccessRate, tt.successRate)
+			}
+			if s.AverageTier == nil || math.Abs(*s.AverageTier-tt.averageTier) > 1e-9 {
+				t.Errorf("AverageTier = %v, want %v", s.AverageTier, tt.averageTier)
+			}
+		})
+	}
+
+	if got := dublab.FailureReasons["job timed out after 1m30s"]; got != 3 {
+		t.Errorf("dublab timeout failures = %d, want 3", got)
+	}
+	if got := bandcamp.Methods["oembed"]; got != 8 {
+		t.Errorf("bandcamp oembed count = %d, want 8", got)
+	}
+}
+
+func TestKnokRepositoryGetExtractionReport(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// A platform name unique to this run keeps existing data out of the assertions
+	platform := fmt.Sprintf("test%d", time.Now().UnixNano()%1e9)
+	knoks := []struct {
+		status   string
+		metadata map[string]interface{}
+	}{
+		{domain.ExtractionStatusComplete, map[string]interface{}{"extraction_method": "oembed"}},
+		{domain.ExtractionStatusComplete, map[string]interface{}{"extraction_method": "rod_browser"}},
+		{domain.ExtractionStatusComplete, map[string]interface{}{"extraction_method": "title_fallback"}},
+		{domain.ExtractionStatusFailed, map[string]interface{}{"extraction_error": "navigation timeout"}},
+		{domain.ExtractionStatusPending, map[string]interface{}{}},
+	}
+	for i, k := range knoks {
+		knok := createTestKnok(t, repo, serverID, i, k.status, time.Now())
+		knok.Platform = platform
+		knok.Metadata = k.metadata
+		if err := repo.Update(ctx, knok); err != nil {
+			t.Fatalf("Failed to update knok: %v", err)
+		}
+	}
+
+	report, err := repo.GetExtractionReport(ctx)
+	if err != nil {
+		t.Fatalf("GetExtractionReport() error = %v", err)
+	}
+
+	var stats *domain.PlatformExtractionStats
+	for _, s := range report.Platforms {
+		if s.Platform == platform {
+			stats = s
+		}
+	}
+	if stats == nil {
+		t.Fatalf("report has no entry for platform %s", platform)
+	}
+
+	// The pending knok is not counted
+	if stats.Total != 4 || stats.Succeeded != 2 || stats.Fallback != 1 || stats.Failed != 1 {
+		t.Errorf("stats = %+v, want total 4, succeeded 2, fallback 1, failed 1", stats)
+	}
+	if math.Abs(stats.SuccessRate-0.5) > 1e-9 {
+		t.Errorf("SuccessRate = %v, want 0.5", stats.SuccessRate)
+	}
+	if stats.AverageTier == nil || math.Abs(*stats.AverageTier-5.0/3.0) > 1e-9 {
+		t.Errorf("AverageTier = %v, want %v", stats.AverageTier, 5.0/3.0)
+	}
+	if stats.FailureReasons["navigation timeout"] != 1 {
+		t.Errorf("FailureReasons = %v, want navigation timeout once", stats.FailureReasons)
+	}
+}
+
+func TestKnokRepositoryGetRecentByServerRequireMetadata(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	now := time.Now()
+	knoks := []struct {
+		metadata map[string]interface{}
+		noTitle  bool
+	}{
+		{metadata: map[string]interface{}{"extraction_method": "oembed"}},
+		{metadata: map[string]interface{}{"extraction_method": "http_static"}},
+		{metadata: map[string]interface{}{"extraction_method": "title_fallback"}},
+		{metadata: map[string]interface{}{"extraction_method": "error_fallback"}},
+		{metadata: map[string]interface{}{}},
+		{metadata: map[string]interface{}{"extraction_method": "rod_browser"}, noTitle: true},
+	}
+	var all, withMetadata []uuid.UUID
+	for i, k := range knoks {
+		knok := createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, now.Add(-time.Duration(i)*time.Minute))
+		knok.Metadata = k.metadata
+		if k.noTitle {
+			knok.Title = nil
+		}
+		if err := repo.Update(ctx, knok); err != nil {
+			t.Fatalf("Failed to update knok: %v", err)
+		}
+		all = append(all, knok.ID)
+		if i < 2 {
+			withMetadata = append(withMetadata, knok.ID)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		settings string
+		cursor   *domain.KnokCursor
+		wantIDs  []uuid.UUID
+	}{
+		{name: "Setting unset", settings: `{}`, wantIDs: all},
+		{name: "Setting false", settings: `{"require_metadata": false}`, wantIDs: all},
+		{name: "Setting true", settings: `{"require_metadata": true}`, wantIDs: withMetadata},
+		{name: "Setting true with cursor", settings: `{"require_metadata": true}`, cursor: &domain.KnokCursor{Time: now}, wantIDs: withMetadata[1:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Exec(`UPDATE servers SET settings = $2 WHERE id = $1`, serverID, tt.settings); err != nil {
+				t.Fatalf("Failed to update server settings: %v", err)
+			}
+
+			got, err := repo.GetRecentByServer(ctx, serverID, domain.DefaultKnokSort, tt.cursor, nil, nil, "", 20)
+			if err != nil {
+				t.Fatalf("GetRecentByServer() error = %v", err)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("GetRecentByServer() returned %d knoks, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, knok := range got {
+				if knok.ID != tt.wantIDs[i] {
+					t.Errorf("knok %d = %s, want %s", i, knok.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKnokRepositoryGetRecentByServerStatus(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Failed knoks have no real metadata, but shouldn't be hidden by require_metadata
+	if _, err := db.Exec(`UPDATE servers SET settings = '{"require_metadata": true}' WHERE id = $1`, serverID); err != nil {
+		t.Fatalf("Failed to update server settings: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	statuses := []string{
+		domain.ExtractionStatusFailed,
+		domain.ExtractionStatusPending,
+		domain.ExtractionStatusFailed,
+	}
+	knoks := make([]*domain.Knok, len(statuses))
+	for i, status := range statuses {
+		knoks[i] = createTestKnok(t, repo, serverID, i, status, base.Add(-time.Duration(i)*time.Minute))
+	}
+
+	tests := []struct {
+		name    string
+		status  string
+		wantIDs []uuid.UUID
+	}{
+		{name: "Failed", status: domain.ExtractionStatusFailed, wantIDs: knokIDs([]*domain.Knok{knoks[0], knoks[2]})},
+		{name: "Pending", status: domain.ExtractionStatusPending, wantIDs: knokIDs(knoks[1:2])},
+		{name: "Complete by default", status: "", wantIDs: knokIDs(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetRecentByServer(ctx, serverID, domain.DefaultKnokSort, nil, nil, nil, tt.status, 10)
+			if err != nil {
+				t.Fatalf("GetRecentByServer() error = %v", err)
+			}
+			if !reflect.DeepEqual(knokIDs(got), tt.wantIDs) {
+				t.Errorf("GetRecentByServer() = %v, want %v", knokIDs(got), tt.wantIDs)
+			}
+
+			got, err = repo.GetByPlatformCursor(ctx, serverID, "soundcloud", domain.DefaultKnokSort, nil, nil, nil, tt.status, 10)
+			if err != nil {
+				t.Fatalf("GetByPlatformCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(knokIDs(got), tt.wantIDs) {
+				t.Errorf("GetByPlatformCursor() = %v, want %v", knokIDs(got), tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestKnokRepositoryGetRecentByServerSort(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	add := func(i int, title string, posted, created time.Duration) *domain.Knok {
+		knok := createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, base.Add(posted))
+		if _, err := db.Exec(`UPDATE knoks SET title = $2, created_at = $3 WHERE id = $1`, knok.ID, title, base.Add(created)); err != nil {
+			t.Fatalf("Failed to update knok: %v", err)
+		}
+		return knok
+	}
+	alpha := add(0, "alpha", 0, 2*time.Minute)
+	beta1 := add(1, "Beta", -time.Minute, time.Minute)
+	beta2 := add(2, "beta", -2*time.Minute, 3*time.Minute)
+
+	// Equal titles are ordered by ID
+	betas := []uuid.UUID{beta1.ID, beta2.ID}
+	if betas[1].String() < betas[0].String() {
+		betas[0], betas[1] = betas[1], betas[0]
+	}
+
+	tests := []struct {
+		name    string
+		sort    domain.KnokSort
+		wantIDs []uuid.UUID
+	}{
+		{"Posted newest first", domain.DefaultKnokSort, []uuid.UUID{alpha.ID, beta1.ID, beta2.ID}},
+		{"Posted oldest first", domain.KnokSort{Column: domain.KnokSortPostedAt, Ascending: true}, []uuid.UUID{beta2.ID, beta1.ID, alpha.ID}},
+		{"Created newest first", domain.KnokSort{Column: domain.KnokSortCreatedAt}, []uuid.UUID{beta2.ID, alpha.ID, beta1.ID}},
+		{"Title ascending", domain.KnokSort{Column: domain.KnokSortTitle, Ascending: true}, []uuid.UUID{alpha.ID, betas[0], betas[1]}},
+		{"Title descending", domain.KnokSort{Column: domain.KnokSortTitle}, []uuid.UUID{betas[1], betas[0], alpha.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetRecentByServer(ctx, serverID, tt.sort, nil, nil, nil, "", 10)
+			if err != nil {
+				t.Fatalf("GetRecentByServer() error = %v", err)
+			}
+			if !reflect.DeepEqual(knokIDs(got), tt.wantIDs) {
+				t.Errorf("GetRecentByServer() = %v, want %v", knokIDs(got), tt.wantIDs)
+			}
+
+			// Paging one knok at a time with cursors on the sort column gives the same order
+			var paged []*domain.Knok
+			var cursor *domain.KnokCursor
+			for len(paged) <= len(tt.wantIDs) {
+				page, err := repo.GetRecentByServer(ctx, serverID, tt.sort, cursor, nil, nil, "", 1)
+				if err != nil {
+					t.Fatalf("GetRecentByServer() error = %v", err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				paged = append(paged, page[0])
+				switch tt.sort.Column {
+				case domain.KnokSortTitle:
+					cursor = &domain.KnokCursor{KnokID: page[0].ID}
+				case domain.KnokSortCreatedAt:
+					cursor = &domain.KnokCursor{Time: page[0].CreatedAt}
+				default:
+					cursor = &domain.KnokCursor{Time: page[0].PostedAt}
+				}
+			}
+			if !reflect.DeepEqual(knokIDs(paged), tt.wantIDs) {
+				t.Errorf("paged = %v, want %v", knokIDs(paged), tt.wantIDs)
+			}
+		})
+	}
+
+	if _, err := repo.GetRecentByServer(ctx, serverID, domain.KnokSort{Column: "url"}, nil, nil, nil, "", 10); err == nil {
+		t.Error("GetRecentByServer() with an invalid sort column should fail")
+	}
+}
+
+func TestKnokRepositoryGetRecentByServerDateRange(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	knoks := make([]*domain.Knok, 4)
+	for i := range knoks {
+		knoks[i] = createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, base.AddDate(0, 0, -i))
+	}
+
+	// Both ends are inclusive
+	from, to := base.AddDate(0, 0, -2), base.AddDate(0, 0, -1)
+	got, err := repo.GetRecentByServer(ctx, serverID, domain.DefaultKnokSort, nil, &from, &to, "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentByServer() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(got), knokIDs(knoks[1:3])) {
+		t.Errorf("GetRecentByServer() in range = %v, want %v", knokIDs(got), knokIDs(knoks[1:3]))
+	}
+
+	// The range composes with the cursor
+	got, err = repo.GetRecentByServer(ctx, serverID, domain.DefaultKnokSort, &domain.KnokCursor{Time: to}, &from, nil, "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentByServer() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(got), knokIDs(knoks[2:3])) {
+		t.Errorf("GetRecentByServer() after cursor = %v, want %v", knokIDs(got), knokIDs(knoks[2:3]))
+	}
+
+	got, err = repo.GetByPlatformCursor(ctx, serverID, "soundcloud", domain.DefaultKnokSort, nil, nil, &to, "", 10)
+	if err != nil {
+		t.Fatalf("GetByPlatformCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(got), knokIDs(knoks[1:])) {
+		t.Errorf("GetByPlatformCursor() up to to = %v, want %v", knokIDs(got), knokIDs(knoks[1:]))
+	}
+}
+
+func TestKnokRepositoryGetActivityHistogram(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	otherServerID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Dates far in the past keep knoks from other tests out of the global histogram
+	day := func(d, hour int) time.Time { return time.Date(2001, 3, d, hour, 0, 0, 0, time.UTC) }
+	seeded := []struct {
+		serverID string
+		postedAt time.Time
+	}{
+		{serverID, day(1, 9)},
+		{serverID, day(1, 23)},
+		{serverID, day(3, 0)},
+		{serverID, day(5, 12)},
+		{otherServerID, day(2, 8)},
+	}
+	for i, k := range seeded {
+		createTestKnok(t, repo, k.serverID, i, domain.ExtractionStatusComplete, k.postedAt)
+	}
+
+	tests := []struct {
+		name       string
+		serverID   *string
+		from, to   time.Time
+		bucket     string
+		wantStarts []time.Time
+		wantCounts []int
+	}{
+		{
+			name:       "Daily for one server, empty days included",
+			serverID:   &serverID,
+			from:       day(1, 0),
+			to:         day(4, 0),
+			bucket:     "day",
+			wantStarts: []time.Time{day(1, 0), day(2, 0), day(3, 0)},
+			wantCounts: []int{2, 0, 1},
+		},
+		{
+			name:       "Daily across servers",
+			from:       day(1, 0),
+			to:         day(3, 0),
+			bucket:     "day",
+			wantStarts: []time.Time{day(1, 0), day(2, 0)},
+			wantCounts: []int{2, 1},
+		},
+		{
+			name:       "Range starting mid-bucket",
+			serverID:   &serverID,
+			from:       day(1, 12),
+			to:         day(2, 0),
+			bucket:     "day",
+			wantStarts: []time.Time{day(1, 0)},
+			wantCounts: []int{1},
+		},
+		{
+			name:       "Weekly",
+			serverID:   &serverID,
+			from:       day(1, 0),
+			to:         day(8, 0),
+			bucket:     "week",
+			wantStarts: []time.Time{time.Date(2001, 2, 26, 0, 0, 0, 0, time.UTC), day(5, 0)},
+			wantCounts: []int{3, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetActivityHistogram(ctx, tt.serverID, tt.from, tt.to, tt.bucket)
+			if err != nil {
+				t.Fatalf("GetActivityHistogram() error = %v", err)
+			}
+			if len(got) != len(tt.wantStarts) {
+				t.Fatalf("GetActivityHistogram() returned %d buckets, want %d", len(got), len(tt.wantStarts))
+			}
+			for i, b := range got {
+				if !b.Start.Equal(tt.wantStarts[i]) || b.Count != tt.wantCounts[i] {
+					t.Errorf("bucket %d = %s: %d, want %s: %d", i, b.Start, b.Count, tt.wantStarts[i], tt.wantCounts[i])
+				}
+			}
+		})
+	}
+
+	if _, err := repo.GetActivityHistogram(ctx, nil, day(1, 0), day(2, 0), "minute"); err == nil {
+		t.Error("GetActivityHistogram() with an unknown bucket succeeded, want error")
+	}
+}
+
+func TestKnokRepositoryThumbnailURL(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	knok := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusPending, time.Now())
+	got, err := repo.GetByID(ctx, knok.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.ThumbnailURL != nil {
+		t.Errorf("thumbnail_url = %q, want NULL before extraction", *got.ThumbnailURL)
+	}
+
+	thumbnail := "https://example.com/cover.jpg"
+	got.ThumbnailURL = &thumbnail
+	got.Metadata = map[string]interface{}{"image": thumbnail}
+	got.ExtractionStatus = domain.ExtractionStatusComplete
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err = repo.GetByID(ctx, knok.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.ThumbnailURL == nil || *got.ThumbnailURL != thumbnail {
+		t.Errorf("thumbnail_url = %v, want %s", got.ThumbnailURL, thumbnail)
+	}
+	if got.Metadata["image"] != thumbnail {
+		t.Errorf("metadata image = %v, want it kept alongside the column", got.Metadata["image"])
+	}
+}
+
+func TestKnokRepositoryDiscordUserID(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Knoks stored before posters were recorded get one on Update
+	knok := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusPending, time.Now())
+	got, err := repo.GetByID(ctx, knok.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.DiscordUserID != nil {
+		t.Fatalf("discord_user_id = %q, want NULL", *got.DiscordUserID)
+	}
+
+	userID := "123456789012345678"
+	got.DiscordUserID = &userID
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err = repo.GetByID(ctx, knok.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.DiscordUserID == nil || *got.DiscordUserID != userID {
+		t.Errorf("discord_user_id = %v, want %s", got.DiscordUserID, userID)
+	}
+}
+
+func TestKnokRepositoryGetTopPosters(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	otherServerID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	i := 0
+	add := func(serverID string, userID *string, status string) {
+		knok := createTestKnok(t, repo, serverID, i, status, time.Now())
+		i++
+		knok.DiscordUserID = userID
+		if err := repo.Update(ctx, knok); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	alice, bob, carol := "111", "222", "333"
+	for _, userID := range []*string{&alice, &bob, &alice, &carol, &alice, &bob} {
+		add(serverID, userID, domain.ExtractionStatusComplete)
+	}
+	// Pending knoks, knoks without a poster and other servers' knoks aren't counted
+	add(serverID, &carol, domain.ExtractionStatusPending)
+	add(serverID, &carol, domain.ExtractionStatusPending)
+	add(serverID, nil, domain.ExtractionStatusComplete)
+	add(otherServerID, &carol, domain.ExtractionStatusComplete)
+
+	posters, err := repo.GetTopPosters(ctx, serverID, 10)
+	if err != nil {
+		t.Fatalf("GetTopPosters() error = %v", err)
+	}
+	want := []*domain.PosterCount{{UserID: alice, Count: 3}, {UserID: bob, Count: 2}, {UserID: carol, Count: 1}}
+	if !reflect.DeepEqual(posters, want) {
+		t.Errorf("GetTopPosters() = %v, want %v", posters, want)
+	}
+
+	posters, err = repo.GetTopPosters(ctx, serverID, 1)
+	if err != nil {
+		t.Fatalf("GetTopPosters() error = %v", err)
+	}
+	if len(posters) != 1 || posters[0].UserID != alice {
+		t.Errorf("GetTopPosters() with limit 1 = %v, want only %s", posters, alice)
+	}
+}
+
+func TestKnokRepositoryGetRecentTimelineWindow(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	repo.SetTimelineWindow(7 * 24 * time.Hour)
+	ctx := context.Background()
+
+	now := time.Now()
+	recent := createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, now)
+	old := createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusComplete, now.Add(-30*24*time.Hour))
+
+	contains := func(knoks []*domain.Knok, id uuid.UUID) bool {
+		for _, knok := range knoks {
+			if knok.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	from, to := now.Add(-31*24*time.Hour), now.Add(-29*24*time.Hour)
+	tests := []struct {
+		name       string
+		from, to   *time.Time
+		wantRecent bool
+		wantOld    bool
+	}{
+		{name: "window", wantRecent: true},
+		{name: "from", from: &from, wantRecent: true, wantOld: true},
+		{name: "range", from: &from, to: &to, wantOld: true},
+		{name: "to only", to: &to, wantOld: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetRecent(ctx, domain.DefaultKnokSort, nil, tt.from, tt.to, "", 1000)
+			if err != nil {
+				t.Fatalf("GetRecent() error = %v", err)
+			}
+			if contains(got, recent.ID) != tt.wantRecent {
+				t.Errorf("recent knok returned = %v, want %v", !tt.wantRecent, tt.wantRecent)
+			}
+			if contains(got, old.ID) != tt.wantOld {
+				t.Errorf("old knok returned = %v, want %v", !tt.wantOld, tt.wantOld)
+			}
+		})
+	}
+}
+
+func TestKnokRepositoryUpdatePlatform(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// A platform name unique to this run keeps existing data out of the assertions
+	platform := fmt.Sprintf("test%d", time.Now().UnixNano()%1e9)
+	knoks := make([]*domain.Knok, 3)
+	for i := range knoks {
+		knoks[i] = createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, time.Now().Add(-time.Duration(i)*time.Minute))
+		if err := repo.UpdatePlatform(ctx, knoks[i].ID, platform); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, knoks[0].ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Platform != platform {
+		t.Errorf("platform = %q, want %q", got.Platform, platform)
+	}
+
+	page, err := repo.GetAllByPlatform(ctx, platform, nil, 2)
+	if err != nil {
+		t.Fatalf("GetAllByPlatform() error = %v", err)
+	}
+	if len(page) != 2 || page[0].ID != knoks[0].ID || page[1].ID != knoks[1].ID {
+		t.Fatalf("first page = %v, want the two newest knoks", page)
+	}
+
+	page, err = repo.GetAllByPlatform(ctx, platform, &domain.KnokCursor{Time: page[1].PostedAt, KnokID: page[1].ID}, 2)
+	if err != nil {
+		t.Fatalf("GetAllByPlatform() error = %v", err)
+	}
+	if len(page) != 1 || page[0].ID != knoks[2].ID {
+		t.Errorf("second page = %v, want the oldest knok", page)
+	}
+}
+
+func TestKnokRepositoryGetAllByPlatformTiedPostedAt(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Links seeded from one message share its timestamp; more of them than fit on a page
+	// must all be returned
+	platform := fmt.Sprintf("test%d", time.Now().UnixNano()%1e9)
+	postedAt := time.Now().Truncate(time.Microsecond)
+	want := make(map[uuid.UUID]bool)
+	for i := 0; i < 5; i++ {
+		knok := createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, postedAt)
+		if err := repo.UpdatePlatform(ctx, knok.ID, platform); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+		want[knok.ID] = true
+	}
+
+	got := make(map[uuid.UUID]bool)
+	var cursor *domain.KnokCursor
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("paging didn't finish after %d pages", pages)
+		}
+		page, err := repo.GetAllByPlatform(ctx, platform, cursor, 2)
+		if err != nil {
+			t.Fatalf("GetAllByPlatform() error = %v", err)
+		}
+		for _, knok := range page {
+			if got[knok.ID] {
+				t.Errorf("knok %s returned twice", knok.ID)
+			}
+			got[knok.ID] = true
+		}
+		if len(page) < 2 {
+			break
+		}
+		last := page[len(page)-1]
+		cursor = &domain.KnokCursor{Time: last.PostedAt, KnokID: last.ID}
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged through %d knoks, want all %d", len(got), len(want))
+	}
+}
+
+func TestKnokRepositoryGetByPlatformAndStatus(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	platform := fmt.Sprintf("test%d", time.Now().UnixNano()%1e9)
+	statuses := []string{
+		domain.ExtractionStatusFailed,
+		domain.ExtractionStatusComplete,
+		domain.ExtractionStatusLowQuality,
+		domain.ExtractionStatusFailed,
+	}
+	knoks := make([]*domain.Knok, len(statuses))
+	for i, status := range statuses {
+		knoks[i] = createTestKnok(t, repo, serverID, i, status, time.Now().Add(-time.Duration(i)*time.Minute))
+		if err := repo.UpdatePlatform(ctx, knoks[i].ID, platform); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+	}
+	retry := []string{domain.ExtractionStatusFailed, domain.ExtractionStatusLowQuality}
+
+	page, err := repo.GetByPlatformAndStatus(ctx, platform, retry, 2)
+	if err != nil {
+		t.Fatalf("GetByPlatformAndStatus() error = %v", err)
+	}
+	if want := []*domain.Knok{knoks[0], knoks[2]}; !reflect.DeepEqual(knokIDs(page), knokIDs(want)) {
+		t.Fatalf("first page = %v, want %v", knokIDs(page), knokIDs(want))
+	}
+
+	// Requeued knoks drop out, so the next read returns the rest
+	for _, knok := range page {
+		if err := repo.UpdateExtractionStatus(ctx, knok.ID, domain.ExtractionStatusPending); err != nil {
+			t.Fatalf("UpdateExtractionStatus() error = %v", err)
+		}
+	}
+	page, err = repo.GetByPlatformAndStatus(ctx, platform, retry, 2)
+	if err != nil {
+		t.Fatalf("GetByPlatformAndStatus() error = %v", err)
+	}
+	if want := knoks[3:]; !reflect.DeepEqual(knokIDs(page), knokIDs(want)) {
+		t.Errorf("second page = %v, want %v", knokIDs(page), knokIDs(want))
+	}
+
+	page, err = repo.GetByPlatformAndStatus(ctx, "soundcloud", retry, 10)
+	if err != nil {
+		t.Fatalf("GetByPlatformAndStatus() error = %v", err)
+	}
+	for _, knok := range page {
+		if knok.ServerID == serverID {
+			t.Errorf("GetByPlatformAndStatus() on another platform returned %s", knok.ID)
+		}
+	}
+}
+
+func TestKnokRepositoryGetByPlatform(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	otherServerID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	spotify := make([]*domain.Knok, 3)
+	for i := range spotify {
+		spotify[i] = createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, base.Add(-time.Duration(i)*time.Minute))
+	}
+	pending := createTestKnok(t, repo, serverID, 3, domain.ExtractionStatusPending, base)
+	otherServer := createTestKnok(t, repo, otherServerID, 4, domain.ExtractionStatusComplete, base)
+	for _, knok := range append(spotify, pending, otherServer) {
+		if err := repo.UpdatePlatform(ctx, knok.ID, "spotify"); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+	}
+	createTestKnok(t, repo, serverID, 5, domain.ExtractionStatusComplete, base)
+
+	knoks, total, err := repo.GetByPlatform(ctx, serverID, "spotify", 0, 2)
+	if err != nil {
+		t.Fatalf("GetByPlatform() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want the 3 completed spotify knoks of the server", total)
+	}
+	if !reflect.DeepEqual(knokIDs(knoks), knokIDs(spotify[:2])) {
+		t.Errorf("first page = %v, want the two newest spotify knoks", knokIDs(knoks))
+	}
+
+	knoks, total, err = repo.GetByPlatform(ctx, serverID, "spotify", 2, 2)
+	if err != nil {
+		t.Fatalf("GetByPlatform() error = %v", err)
+	}
+	if total != 3 || !reflect.DeepEqual(knokIDs(knoks), knokIDs(spotify[2:])) {
+		t.Errorf("second page = %v (total %d), want the oldest spotify knok", knokIDs(knoks), total)
+	}
+
+	for _, platform := range []string{"", "myspace"} {
+		knoks, total, err := repo.GetByPlatform(ctx, serverID, platform, 0, 10)
+		if err != nil {
+			t.Fatalf("GetByPlatform(%q) error = %v", platform, err)
+		}
+		if len(knoks) != 0 || total != 0 {
+			t.Errorf("GetByPlatform(%q) = %d knoks (total %d), want none", platform, len(knoks), total)
+		}
+	}
+}
+
+func TestKnokRepositoryGetByPlatformCursor(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	spotify := make([]*domain.Knok, 3)
+	for i := range spotify {
+		spotify[i] = createTestKnok(t, repo, serverID, i, domain.ExtractionStatusComplete, base.Add(-time.Duration(i)*time.Minute))
+		if err := repo.UpdatePlatform(ctx, spotify[i].ID, "spotify"); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+	}
+	soundcloud := createTestKnok(t, repo, serverID, 3, domain.ExtractionStatusComplete, base.Add(-30*time.Second))
+
+	page, err := repo.GetByPlatformCursor(ctx, serverID, "spotify", domain.DefaultKnokSort, nil, nil, nil, "", 2)
+	if err != nil {
+		t.Fatalf("GetByPlatformCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(page), knokIDs(spotify[:2])) {
+		t.Fatalf("first page = %v, want the two newest spotify knoks", knokIDs(page))
+	}
+
+	page, err = repo.GetByPlatformCursor(ctx, serverID, "spotify", domain.DefaultKnokSort, &domain.KnokCursor{Time: page[1].PostedAt}, nil, nil, "", 2)
+	if err != nil {
+		t.Fatalf("GetByPlatformCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(page), knokIDs(spotify[2:])) {
+		t.Errorf("second page = %v, want the oldest spotify knok", knokIDs(page))
+	}
+
+	// An empty platform is the unfiltered server timeline
+	page, err = repo.GetByPlatformCursor(ctx, serverID, "", domain.DefaultKnokSort, nil, nil, nil, "", 10)
+	if err != nil {
+		t.Fatalf("GetByPlatformCursor() error = %v", err)
+	}
+	want := []uuid.UUID{spotify[0].ID, soundcloud.ID, spotify[1].ID, spotify[2].ID}
+	if !reflect.DeepEqual(knokIDs(page), want) {
+		t.Errorf("unfiltered page = %v, want %v", knokIDs(page), want)
+	}
+}
+
+func TestKnokRepositoryGetRecentByPlatform(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	otherServerID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// A platform of its own keeps other knoks in the database out of the results
+	platform := fmt.Sprintf("platform%d", time.Now().UnixNano()%1e12)
+	base := time.Now().Add(-time.Hour)
+	add := func(serverID string, i int, status string, postedAt time.Time) *domain.Knok {
+		knok := createTestKnok(t, repo, serverID, i, status, postedAt)
+		if err := repo.UpdatePlatform(ctx, knok.ID, platform); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+		return knok
+	}
+	knoks := []*domain.Knok{
+		add(serverID, 0, domain.ExtractionStatusComplete, base),
+		add(otherServerID, 1, domain.ExtractionStatusComplete, base.Add(-time.Minute)),
+		add(serverID, 2, domain.ExtractionStatusComplete, base.Add(-2*time.Minute)),
+	}
+	add(serverID, 3, domain.ExtractionStatusPending, base.Add(-30*time.Second))
+	old := add(serverID, 4, domain.ExtractionStatusComplete, base.Add(-30*24*time.Hour))
+
+	page, err := repo.GetRecentByPlatform(ctx, platform, domain.DefaultKnokSort, nil, nil, nil, "", 2)
+	if err != nil {
+		t.Fatalf("GetRecentByPlatform() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(page), knokIDs(knoks[:2])) {
+		t.Fatalf("first page = %v, want the two newest complete knoks from both servers", knokIDs(page))
+	}
+
+	page, err = repo.GetRecentByPlatform(ctx, platform, domain.DefaultKnokSort, &domain.KnokCursor{Time: page[1].PostedAt}, nil, nil, "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentByPlatform() error = %v", err)
+	}
+	want := []uuid.UUID{knoks[2].ID, old.ID}
+	if !reflect.DeepEqual(knokIDs(page), want) {
+		t.Errorf("second page = %v, want %v", knokIDs(page), want)
+	}
+
+	// The timeline window always applies
+	repo.SetTimelineWindow(7 * 24 * time.Hour)
+	page, err = repo.GetRecentByPlatform(ctx, platform, domain.DefaultKnokSort, nil, nil, nil, "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentByPlatform() error = %v", err)
+	}
+	if !reflect.DeepEqual(knokIDs(page), knokIDs(knoks)) {
+		t.Errorf("windowed page = %v, want %v", knokIDs(page), knokIDs(knoks))
+	}
+}
+
+func TestKnokRepositoryGetCountsByServer(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+	createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusPending, time.Now())
+	youtube := createTestKnok(t, repo, serverID, 2, domain.ExtractionStatusComplete, time.Now())
+	if err := repo.UpdatePlatform(ctx, youtube.ID, "youtube"); err != nil {
+		t.Fatalf("UpdatePlatform() error = %v", err)
+	}
+
+	counts, err := repo.GetCountsByServer(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetCountsByServer() error = %v", err)
+	}
+	if counts.Total != 3 {
+		t.Errorf("Total = %d, want 3", counts.Total)
+	}
+	if counts.ByPlatform["soundcloud"] != 2 || counts.ByPlatform["youtube"] != 1 {
+		t.Errorf("ByPlatform = %v, want soundcloud 2, youtube 1", counts.ByPlatform)
+	}
+	if counts.ByStatus[domain.ExtractionStatusComplete] != 2 || counts.ByStatus[domain.ExtractionStatusPending] != 1 {
+		t.Errorf("ByStatus = %v, want complete 2, pending 1", counts.ByStatus)
+	}
+}
+
+func TestKnokRepositoryGetServerStats(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	stats, err := repo.GetServerStats(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetServerStats() error = %v", err)
+	}
+	if stats.Total != 0 || len(stats.Platforms) != 0 || stats.LatestPostedAt != nil {
+		t.Errorf("empty server stats = %+v, want none", stats)
+	}
+
+	latest := time.Now().Add(-time.Hour).Truncate(time.Second)
+	createTestKnok(t, repo, serverID, 0, domain.ExtractionStatusComplete, latest.Add(-time.Hour))
+	createTestKnok(t, repo, serverID, 1, domain.ExtractionStatusComplete, latest.Add(-2*time.Hour))
+	createTestKnok(t, repo, serverID, 2, domain.ExtractionStatusPending, time.Now())
+	youtube := []*domain.Knok{
+		createTestKnok(t, repo, serverID, 3, domain.ExtractionStatusComplete, latest),
+		createTestKnok(t, repo, serverID, 4, domain.ExtractionStatusComplete, latest.Add(-3*time.Hour)),
+		createTestKnok(t, repo, serverID, 5, domain.ExtractionStatusComplete, latest.Add(-4*time.Hour)),
+	}
+	for _, knok := range youtube {
+		if err := repo.UpdatePlatform(ctx, knok.ID, "youtube"); err != nil {
+			t.Fatalf("UpdatePlatform() error = %v", err)
+		}
+	}
+
+	stats, err = repo.GetServerStats(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetServerStats() error = %v", err)
+	}
+	if stats.Total != 5 {
+		t.Errorf("Total = %d, want 5 completed knoks", stats.Total)
+	}
+	want := []domain.PlatformCount{{Platform: "youtube", Count: 3}, {Platform: "soundcloud", Count: 2}}
+	if len(stats.Platforms) != len(want) {
+		t.Fatalf("Platforms = %d entries, want %d", len(stats.Platforms), len(want))
+	}
+	for i, platform := range stats.Platforms {
+		if *platform != want[i] {
+			t.Errorf("Platforms[%d] = %+v, want %+v", i, *platform, want[i])
+		}
+	}
+	if stats.LatestPostedAt == nil || !stats.LatestPostedAt.Equal(latest) {
+		t.Errorf("LatestPostedAt = %v, want %v", stats.LatestPostedAt, latest)
+	}
+}
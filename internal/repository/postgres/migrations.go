@@ -183,6 +183,83 @@ var migrations = []Migration{
 				WHERE id = 'spotify';
 		`,
 	},
+	{
+		Version: 8,
+		Name:    "add_platform_item_id",
+		SQL: `
+			-- Add platform_item_id for dedup across URL variants of the same item (youtu.be vs youtube.com/watch)
+			ALTER TABLE knoks ADD COLUMN IF NOT EXISTS platform_item_id TEXT;
+			CREATE INDEX IF NOT EXISTS idx_knoks_server_platform_item_id
+				ON knoks(server_id, platform, platform_item_id) WHERE platform_item_id IS NOT NULL;
+
+			-- Seed extraction patterns for platforms that don't have any yet
+			UPDATE platforms SET extraction_patterns = '["youtu\\.be/([\\w-]{11})", "youtube\\.com/(?:watch\\?(?:.*&)?v=|shorts/|embed/|live/|v/)([\\w-]{11})"]'
+				WHERE id = 'youtube' AND extraction_patterns IS NULL;
+			UPDATE platforms SET extraction_patterns = '["spotify\\.com/(?:intl-[\\w-]+/)?((?:track|album|playlist|artist|episode|show)/[A-Za-z0-9]+)"]'
+				WHERE id = 'spotify' AND extraction_patterns IS NULL;
+			UPDATE platforms SET extraction_patterns = '["tidal\\.com/(?:browse/)?((?:track|album|playlist|video)/[\\w-]+)"]'
+				WHERE id = 'tidal' AND extraction_patterns IS NULL;
+			UPDATE platforms SET extraction_patterns = '["deezer\\.com/(?:[a-z]{2}/)?((?:track|album|playlist)/\\d+)"]'
+				WHERE id = 'deezer' AND extraction_patterns IS NULL;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "add_low_quality_status",
+		SQL: `
+			-- Allow extractions that only produced a URL-like title to be kept apart from complete ones
+			ALTER TABLE knoks DROP CONSTRAINT IF EXISTS knoks_extraction_status_check;
+			ALTER TABLE knoks ADD CONSTRAINT knoks_extraction_status_check
+				CHECK (extraction_status IN ('pending', 'processing', 'complete', 'failed', 'low_quality'));
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "add_knok_author_and_soft_delete",
+		SQL: `
+			-- Record who posted each knok so a user's contributions can be removed on request
+			ALTER TABLE knoks ADD COLUMN IF NOT EXISTS discord_user_id VARCHAR(20);
+			CREATE INDEX IF NOT EXISTS idx_knoks_discord_user
+				ON knoks(discord_user_id) WHERE discord_user_id IS NOT NULL;
+
+			-- Soft-deleted knoks are kept but hidden from every read
+			ALTER TABLE knoks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_restricted_status",
+		SQL: `
+			-- Private, age-restricted and login-walled pages get their own status instead of a login page title
+			ALTER TABLE knoks DROP CONSTRAINT IF EXISTS knoks_extraction_status_check;
+			ALTER TABLE knoks ADD CONSTRAINT knoks_extraction_status_check
+				CHECK (extraction_status IN ('pending', 'processing', 'complete', 'failed', 'low_quality', 'restricted'));
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "add_extraction_method_column",
+		SQL: `
+			-- Promote metadata.extraction_method to a column so reports and filters can use an
+			-- index. It's generated from the metadata, which backfills existing knoks and keeps
+			-- it in sync without the writers having to set it
+			ALTER TABLE knoks ADD COLUMN IF NOT EXISTS extraction_method TEXT
+				GENERATED ALWAYS AS (metadata->>'extraction_method') STORED;
+			CREATE INDEX IF NOT EXISTS idx_knoks_extraction_method
+				ON knoks(extraction_method, posted_at DESC) WHERE deleted_at IS NULL;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "add_thumbnail_url_column",
+		SQL: `
+			-- Give the extracted image its own column so it can be queried without digging
+			-- into the metadata. metadata.image is still written for older readers
+			ALTER TABLE knoks ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
+			UPDATE knoks SET thumbnail_url = NULLIF(metadata->>'image', '')
+				WHERE thumbnail_url IS NULL;
+		`,
+	},
 }
 
 // RunMigrations executes all pending database migrations
@@ -265,6 +342,61 @@ func GetMigrationStatus(db *sql.DB) (int, error) {
 	return version, nil
 }
 
+// LatestMigrationVersion returns the version of the newest migration this build knows about,
+// which is the version a fully migrated database reports
+func LatestMigrationVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// GetAppliedMigrations returns the migrations recorded as applied, oldest first
+func GetAppliedMigrations(ctx context.Context, db *sql.DB) ([]domain.AppliedMigration, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, name, applied_at FROM migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []domain.AppliedMigration
+	for rows.Next() {
+		var m domain.AppliedMigration
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&m.Version, &m.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		m.AppliedAt = appliedAt.Time
+		applied = append(applied, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// SchemaStatus reports the migration state of a database
+type SchemaStatus struct {
+	db *sql.DB
+}
+
+// NewSchemaStatus creates a schema status source for db
+func NewSchemaStatus(db *sql.DB) *SchemaStatus {
+	return &SchemaStatus{db: db}
+}
+
+// CurrentVersion returns the newest migration version applied to the database
+func (s *SchemaStatus) CurrentVersion(ctx context.Context) (int, error) {
+	return GetMigrationStatus(s.db)
+}
+
+// LatestVersion returns the newest migration version this build knows about
+func (s *SchemaStatus) LatestVersion() int {
+	return LatestMigrationVersion()
+}
+
+// AppliedMigrations returns the migrations recorded as applied, oldest first
+func (s *SchemaStatus) AppliedMigrations(ctx context.Context) ([]domain.AppliedMigration, error) {
+	return GetAppliedMigrations(ctx, s.db)
+}
+
 // ResetDatabase drops all tables (for testing)
 func ResetDatabase(ctx context.Context, db *sql.DB, logger *slog.Logger) error {
 	logger.Warn("Resetting database - all data will be lost")
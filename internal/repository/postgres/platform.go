@@ -103,11 +103,13 @@ func (r *PlatformRepository) scanPlatformRow(scanner interface{ Scan(...interfac
 
 }
 
-// CreatePlatform inserts a new platform into the database
+// CreatePlatform inserts a new platform into the database. Returns domain.ErrPlatformExists
+// if a platform with the same ID already exists.
 func (r *PlatformRepository) CreatePlatform(ctx context.Context, platform *domain.Platform) error {
 	query := `
         INSERT INTO platforms (id, name, url_patterns, priority, enabled, extraction_patterns, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (id) DO NOTHING`
 
 	// Handle ExtractionPatterns ([]string -> JSONB)
 	var extractionPatternsJSON []byte
@@ -134,7 +136,7 @@ func (r *PlatformRepository) CreatePlatform(ctx context.Context, platform *domai
 	}
 	platform.UpdatedAt = &now // Always set UpdatedAt on creation as well
 
-	_, err = r.db.ExecContext(ctx, query,
+	result, err := r.db.ExecContext(ctx, query,
 		platform.ID,
 		platform.Name,
 		pq.Array(platform.URLPatterns), // Use pq.Array for PostgreSQL TEXT[] type
@@ -154,6 +156,15 @@ func (r *PlatformRepository) CreatePlatform(ctx context.Context, platform *domai
 		return fmt.Errorf("failed to create platform: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		r.logger.Debug("Platform already exists", "platform_id", platform.ID)
+		return domain.ErrPlatformExists
+	}
+
 	r.logger.Info("Platform created successfully",
 		"platform_id", platform.ID,
 		"platform_name", platform.Name,
@@ -196,7 +207,7 @@ func (r *PlatformRepository) UpdatePlatform(ctx context.Context, platform *domai
 	now := time.Now()
 	platform.UpdatedAt = &now // Ensure updated_at is set for the database
 
-	_, err = r.db.ExecContext(ctx, query,
+	res, err := r.db.ExecContext(ctx, query,
 		platform.ID,
 		platform.Name,
 		pq.Array(platform.URLPatterns), // Use pq.Array for PostgreSQL TEXT[] type
@@ -215,6 +226,15 @@ func (r *PlatformRepository) UpdatePlatform(ctx context.Context, platform *domai
 		return fmt.Errorf("failed to update platform: %w", err)
 	}
 
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		r.logger.Debug("No platform found to update", "platform_id", platform.ID)
+		return domain.ErrPlatformNotFound
+	}
+
 	r.logger.Info("Platform updated successfully",
 		"platform_id", platform.ID,
 		"platform_name", platform.Name,
@@ -247,7 +267,7 @@ func (r *PlatformRepository) DeletePlatform(ctx context.Context, id string) erro
 
 	if rowsAffected == 0 {
 		r.logger.Debug("No platform found to delete", "platform_id", id)
-		return sql.ErrNoRows // Or a custom "not found" error
+		return domain.ErrPlatformNotFound
 	}
 
 	r.logger.Info("Platform deleted successfully", "platform_id", id)
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"knock-fm/internal/domain"
+	"testing"
+)
+
+// The repositories are only ever used through the domain interfaces, so a signature drift
+// between the two should fail to compile here rather than at the call sites
+var (
+	_ domain.KnokRepository   = (*KnokRepository)(nil)
+	_ domain.ServerRepository = (*ServerRepository)(nil)
+)
+
+func TestRepositoriesSatisfyDomainInterfaces(t *testing.T) {
+	var knokRepo interface{} = NewKnokRepository(nil, createTestLogger())
+	if _, ok := knokRepo.(domain.KnokRepository); !ok {
+		t.Error("KnokRepository does not implement domain.KnokRepository")
+	}
+
+	var serverRepo interface{} = NewServerRepository(nil, createTestLogger())
+	if _, ok := serverRepo.(domain.ServerRepository); !ok {
+		t.Error("ServerRepository does not implement domain.ServerRepository")
+	}
+}
@@ -24,21 +24,41 @@ func NewServerRepository(db *sql.DB, logger *slog.Logger) *ServerRepository {
 	}
 }
 
+// serverSelectFields lists the columns scanServerRow expects, in order
+const serverSelectFields = `
+		SELECT id, name, configured_channel_id, settings, created_at, updated_at
+		FROM servers`
+
 // GetByID retrieves a server by its Discord ID
 func (r *ServerRepository) GetByID(ctx context.Context, id string) (*domain.Server, error) {
-	query := `
-		SELECT id, name, configured_channel_id, settings, created_at, updated_at
-		FROM servers
+	query := serverSelectFields + `
 		WHERE id = $1`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	server, err := r.scanServerRow(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("Server not found", "server_id", id)
+			return nil, domain.ErrServerNotFound
+		}
+		r.logger.Error("Failed to query server",
+			"error", err,
+			"server_id", id,
+		)
+		return nil, fmt.Errorf("failed to query server: %w", err)
+	}
+
+	r.logger.Debug("Server found", "server_id", id, "name", server.Name)
+	return server, nil
+}
 
+// scanServerRow scans a row selected with serverSelectFields into a server
+func (r *ServerRepository) scanServerRow(scanner interface{ Scan(...interface{}) error }) (*domain.Server, error) {
 	server := &domain.Server{}
 	var configuredChannelID sql.NullString
 	var updatedAt sql.NullTime
 	var settingsBytes []byte // Use []byte for JSONB column
 
-	err := row.Scan(
+	err := scanner.Scan(
 		&server.ID,
 		&server.Name,
 		&configuredChannelID,
@@ -46,17 +66,8 @@ func (r *ServerRepository) GetByID(ctx context.Context, id string) (*domain.Serv
 		&server.CreatedAt,
 		&updatedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			r.logger.Debug("Server not found", "server_id", id)
-			return nil, sql.ErrNoRows
-		}
-		r.logger.Error("Failed to query server",
-			"error", err,
-			"server_id", id,
-		)
-		return nil, fmt.Errorf("failed to query server: %w", err)
+		return nil, err
 	}
 
 	// Handle nullable fields
@@ -69,12 +80,11 @@ func (r *ServerRepository) GetByID(ctx context.Context, id string) (*domain.Serv
 
 	// Convert JSONB bytes to map[string]interface{}
 	if len(settingsBytes) > 0 {
-		// Import encoding/json at the top of the file
 		var settings map[string]interface{}
 		if err := json.Unmarshal(settingsBytes, &settings); err != nil {
 			r.logger.Warn("Failed to unmarshal server settings",
 				"error", err,
-				"server_id", id,
+				"server_id", server.ID,
 				"settings_bytes", string(settingsBytes),
 			)
 			// Use empty map if unmarshaling fails
@@ -86,7 +96,6 @@ func (r *ServerRepository) GetByID(ctx context.Context, id string) (*domain.Serv
 		server.Settings = make(map[string]interface{})
 	}
 
-	r.logger.Debug("Server found", "server_id", id, "name", server.Name)
 	return server, nil
 }
 
@@ -156,38 +165,237 @@ func (r *ServerRepository) Create(ctx context.Context, server *domain.Server) er
 
 // Update modifies an existing server configuration
 func (r *ServerRepository) Update(ctx context.Context, server *domain.Server) error {
-	r.logger.Info("Update called (not implemented yet)", "server_id", server.ID)
-	// TODO: Implement actual PostgreSQL update
+	query := `
+		UPDATE servers
+		SET name = $2, configured_channel_id = $3, settings = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	// Handle nullable fields
+	var configuredChannelID interface{}
+	if server.ConfiguredChannelID != nil {
+		configuredChannelID = *server.ConfiguredChannelID
+	}
+
+	// Default settings if nil
+	settings := server.Settings
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+
+	// Convert settings map to JSON for JSONB column
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		r.logger.Error("Failed to marshal server settings",
+			"error", err,
+			"server_id", server.ID,
+			"settings", settings,
+		)
+		return fmt.Errorf("failed to marshal server settings: %w", err)
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRowContext(ctx, query,
+		server.ID,
+		server.Name,
+		configuredChannelID,
+		settingsJSON,
+	).Scan(&updatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Warn("No server found to update", "server_id", server.ID)
+			return domain.ErrServerNotFound
+		}
+		r.logger.Error("Failed to update server",
+			"error", err,
+			"server_id", server.ID,
+		)
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+	server.UpdatedAt = &updatedAt
+
+	r.logger.Info("Server updated successfully",
+		"server_id", server.ID,
+		"name", server.Name,
+	)
+
 	return nil
 }
 
-// Delete removes a server configuration
+// Delete removes a server configuration. Its knoks are deleted with it by the knoks
+// table's ON DELETE CASCADE
 func (r *ServerRepository) Delete(ctx context.Context, id string) error {
-	r.logger.Info("Delete called (not implemented yet)", "server_id", id)
-	// TODO: Implement actual PostgreSQL delete
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the server row blocks new knoks referencing it until the delete commits, so
+	// the count matches what the cascade removes
+	var locked string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM servers WHERE id = $1 FOR UPDATE`, id).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Warn("No server found to delete", "server_id", id)
+			return domain.ErrServerNotFound
+		}
+		r.logger.Error("Failed to lock server", "error", err, "server_id", id)
+		return fmt.Errorf("failed to lock server: %w", err)
+	}
+
+	// Count what the cascade will remove so it's on record
+	var knokCount int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM knoks WHERE server_id = $1`, id).Scan(&knokCount); err != nil {
+		r.logger.Error("Failed to count server knoks", "error", err, "server_id", id)
+		return fmt.Errorf("failed to count server knoks: %w", err)
+	}
+
+	r.logger.Warn("Deleting server and all its knoks",
+		"server_id", id,
+		"knok_count", knokCount,
+	)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM servers WHERE id = $1`, id); err != nil {
+		r.logger.Error("Failed to delete server", "error", err, "server_id", id)
+		return fmt.Errorf("failed to delete server: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit server deletion: %w", err)
+	}
+
+	r.logger.Info("Server deleted successfully", "server_id", id, "knok_count", knokCount)
 	return nil
 }
 
-// List retrieves all configured servers with pagination
+// List retrieves configured servers, newest first, with offset pagination. The second value
+// is the total number of servers
 func (r *ServerRepository) List(ctx context.Context, offset, limit int) ([]*domain.Server, int, error) {
-	r.logger.Info("List called (not implemented yet)", "offset", offset, "limit", limit)
-	// TODO: Implement actual PostgreSQL query
-	return nil, 0, nil
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM servers`).Scan(&total); err != nil {
+		r.logger.Error("Failed to count servers", "error", err)
+		return nil, 0, fmt.Errorf("failed to count servers: %w", err)
+	}
+
+	query := serverSelectFields + `
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to query servers", "error", err)
+		return nil, 0, fmt.Errorf("failed to query servers: %w", err)
+	}
+	defer rows.Close()
+
+	servers := make([]*domain.Server, 0, limit)
+	for rows.Next() {
+		server, err := r.scanServerRow(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan server", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan server: %w", err)
+		}
+		servers = append(servers, server)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error occurred during rows iteration", "error", err)
+		return nil, 0, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	r.logger.Debug("Servers retrieved",
+		"offset", offset,
+		"limit", limit,
+		"count", len(servers),
+		"total", total,
+	)
+
+	return servers, total, nil
 }
 
-// GetByChannelID finds a server that has the specified channel configured
+// GetByChannelID finds a server that has the specified channel configured. If several
+// servers have it configured, the one configured first (oldest) wins
 func (r *ServerRepository) GetByChannelID(ctx context.Context, channelID string) (*domain.Server, error) {
-	r.logger.Info("GetByChannelID called (not implemented yet)", "channel_id", channelID)
-	// TODO: Implement actual PostgreSQL query
-	return nil, sql.ErrNoRows
+	query := serverSelectFields + `
+		WHERE configured_channel_id = $1
+		ORDER BY created_at, id
+		LIMIT 1`
+
+	server, err := r.scanServerRow(r.db.QueryRowContext(ctx, query, channelID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("No server found for channel", "channel_id", channelID)
+			return nil, domain.ErrServerNotFound
+		}
+		r.logger.Error("Failed to query server by channel",
+			"error", err,
+			"channel_id", channelID,
+		)
+		return nil, fmt.Errorf("failed to query server by channel: %w", err)
+	}
+
+	r.logger.Debug("Server found for channel", "channel_id", channelID, "server_id", server.ID)
+	return server, nil
 }
 
-// UpdateSettings updates just the settings field for a server
+// UpdateSettings merges settings into a server's existing settings, removing keys set to nil.
+// The existing settings are locked while merging so concurrent updates don't drop each other's keys
 func (r *ServerRepository) UpdateSettings(ctx context.Context, id string, settings map[string]interface{}) error {
-	r.logger.Info("UpdateSettings called (not implemented yet)",
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var settingsBytes []byte
+	err = tx.QueryRowContext(ctx, `SELECT settings FROM servers WHERE id = $1 FOR UPDATE`, id).Scan(&settingsBytes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Warn("No server found to update settings", "server_id", id)
+			return domain.ErrServerNotFound
+		}
+		r.logger.Error("Failed to query server settings",
+			"error", err,
+			"server_id", id,
+		)
+		return fmt.Errorf("failed to query server settings: %w", err)
+	}
+
+	existing := make(map[string]interface{})
+	if len(settingsBytes) > 0 {
+		if err := json.Unmarshal(settingsBytes, &existing); err != nil {
+			return fmt.Errorf("failed to unmarshal server settings: %w", err)
+		}
+	}
+
+	// Convert merged settings map to JSON for JSONB column
+	settingsJSON, err := json.Marshal(domain.MergeSettings(existing, settings))
+	if err != nil {
+		r.logger.Error("Failed to marshal server settings",
+			"error", err,
+			"server_id", id,
+			"settings", settings,
+		)
+		return fmt.Errorf("failed to marshal server settings: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE servers SET settings = $2, updated_at = NOW() WHERE id = $1`, id, settingsJSON); err != nil {
+		r.logger.Error("Failed to update server settings",
+			"error", err,
+			"server_id", id,
+		)
+		return fmt.Errorf("failed to update server settings: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit server settings update: %w", err)
+	}
+
+	r.logger.Info("Server settings updated",
 		"server_id", id,
 		"settings", settings,
 	)
-	// TODO: Implement actual PostgreSQL update
+
 	return nil
 }
@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"knock-fm/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerRepositoryUpdate(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewServerRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	server, err := repo.GetByID(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+
+	channelID := "123456789"
+	server.Name = "Renamed Server"
+	server.ConfiguredChannelID = &channelID
+	server.Settings = map[string]interface{}{"notification_mode": "reply"}
+	if err := repo.Update(ctx, server); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if server.UpdatedAt == nil {
+		t.Error("Update() should set UpdatedAt")
+	}
+
+	got, err := repo.GetByID(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "Renamed Server" {
+		t.Errorf("name = %q, want %q", got.Name, "Renamed Server")
+	}
+	if got.ConfiguredChannelID == nil || *got.ConfiguredChannelID != channelID {
+		t.Errorf("configured_channel_id = %v, want %s", got.ConfiguredChannelID, channelID)
+	}
+	if got.Settings["notification_mode"] != "reply" {
+		t.Errorf("settings = %v, want notification_mode reply", got.Settings)
+	}
+
+	// Clearing the channel stores NULL
+	got.ConfiguredChannelID = nil
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err = repo.GetByID(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.ConfiguredChannelID != nil {
+		t.Errorf("configured_channel_id = %v, want nil", *got.ConfiguredChannelID)
+	}
+
+	missing := &domain.Server{ID: serverID + "x", Name: "Missing"}
+	if err := repo.Update(ctx, missing); !errors.Is(err, domain.ErrServerNotFound) {
+		t.Errorf("Update() of a missing server error = %v, want ErrServerNotFound", err)
+	}
+}
+
+func TestServerRepositoryUpdateSettings(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewServerRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	if err := repo.UpdateSettings(ctx, serverID, map[string]interface{}{
+		"notification_mode": "reply",
+		"allowed_channels":  []string{"123"},
+	}); err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+
+	// A second update merges into the first and removes keys set to nil
+	if err := repo.UpdateSettings(ctx, serverID, map[string]interface{}{
+		"unknown_platform_mode": "strict",
+		"allowed_channels":      nil,
+	}); err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, serverID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	want := map[string]interface{}{"notification_mode": "reply", "unknown_platform_mode": "strict"}
+	if !reflect.DeepEqual(got.Settings, want) {
+		t.Errorf("settings = %v, want %v", got.Settings, want)
+	}
+	if got.UpdatedAt == nil {
+		t.Error("UpdateSettings() should set updated_at")
+	}
+
+	if err := repo.UpdateSettings(ctx, serverID+"x", want); !errors.Is(err, domain.ErrServerNotFound) {
+		t.Errorf("UpdateSettings() of a missing server error = %v, want ErrServerNotFound", err)
+	}
+}
+
+func TestServerRepositoryList(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewServerRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	_, before, err := repo.List(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	// Creation times far in the future put these servers ahead of any existing ones
+	base := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	prefix := fmt.Sprintf("list%d", time.Now().UnixNano()%1e12)
+	ids := make([]string, 3)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s%d", prefix, i)
+		channelID := fmt.Sprintf("chan%d", i)
+		server := &domain.Server{
+			ID:                  ids[i],
+			Name:                fmt.Sprintf("Server %d", i),
+			ConfiguredChannelID: &channelID,
+			Settings:            map[string]interface{}{"notification_mode": "reply"},
+			CreatedAt:           base.Add(time.Duration(i) * time.Hour),
+		}
+		if err := repo.Create(ctx, server); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM servers WHERE id = $1`, server.ID) })
+	}
+
+	page, total, err := repo.List(ctx, 0, 2)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != before+3 {
+		t.Errorf("total = %d, want %d", total, before+3)
+	}
+	if len(page) != 2 || page[0].ID != ids[2] || page[1].ID != ids[1] {
+		t.Fatalf("first page = %v, want the two newest servers", serverIDs(page))
+	}
+	if page[0].ConfiguredChannelID == nil || *page[0].ConfiguredChannelID != "chan2" || page[0].Settings["notification_mode"] != "reply" {
+		t.Errorf("first server = %+v, want its channel and settings", page[0])
+	}
+
+	page, total, err = repo.List(ctx, 2, 2)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != before+3 {
+		t.Errorf("total = %d, want %d", total, before+3)
+	}
+	if len(page) == 0 || page[0].ID != ids[0] {
+		t.Errorf("second page = %v, want it to start with the oldest test server", serverIDs(page))
+	}
+}
+
+func serverIDs(servers []*domain.Server) []string {
+	ids := make([]string, len(servers))
+	for i, server := range servers {
+		ids[i] = server.ID
+	}
+	return ids
+}
+
+func TestServerRepositoryGetByChannelID(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewServerRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	// Two servers sharing a channel; the older one should be returned
+	channelID := fmt.Sprintf("chan%d", time.Now().UnixNano()%1e15)
+	prefix := fmt.Sprintf("bych%d", time.Now().UnixNano()%1e12)
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		server := &domain.Server{
+			ID:                  fmt.Sprintf("%s%d", prefix, i),
+			Name:                fmt.Sprintf("Server %d", i),
+			ConfiguredChannelID: &channelID,
+			Settings:            map[string]interface{}{"unknown_platform_mode": "strict"},
+			CreatedAt:           base.Add(time.Duration(1-i) * time.Minute),
+		}
+		if err := repo.Create(ctx, server); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM servers WHERE id = $1`, server.ID) })
+	}
+
+	got, err := repo.GetByChannelID(ctx, channelID)
+	if err != nil {
+		t.Fatalf("GetByChannelID() error = %v", err)
+	}
+	if got.ID != prefix+"1" {
+		t.Errorf("GetByChannelID() = %s, want the oldest server %s1", got.ID, prefix)
+	}
+	if got.Settings["unknown_platform_mode"] != "strict" {
+		t.Errorf("settings = %v, want them parsed", got.Settings)
+	}
+
+	if _, err := repo.GetByChannelID(ctx, channelID+"x"); !errors.Is(err, domain.ErrServerNotFound) {
+		t.Errorf("GetByChannelID() of an unconfigured channel error = %v, want ErrServerNotFound", err)
+	}
+}
+
+func TestServerRepositoryDelete(t *testing.T) {
+	db := openTestDB(t)
+	serverID := createTestServer(t, db)
+	repo := NewServerRepository(db, createTestLogger())
+	knokRepo := NewKnokRepository(db, createTestLogger())
+	ctx := context.Background()
+
+	knok := createTestKnok(t, knokRepo, serverID, 0, domain.ExtractionStatusComplete, time.Now())
+
+	if err := repo.Delete(ctx, serverID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, serverID); !errors.Is(err, domain.ErrServerNotFound) {
+		t.Errorf("GetByID() after Delete() error = %v, want ErrServerNotFound", err)
+	}
+	if _, err := knokRepo.GetByID(ctx, knok.ID); !errors.Is(err, domain.ErrKnokNotFound) {
+		t.Errorf("knok GetByID() after Delete() error = %v, want ErrKnokNotFound from the cascade", err)
+	}
+
+	if err := repo.Delete(ctx, serverID); !errors.Is(err, domain.ErrServerNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrServerNotFound", err)
+	}
+}
@@ -5,39 +5,73 @@ import (
 	"encoding/json"
 	"fmt"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/tracing"
 	"log/slog"
 	"math"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // QueueRepository implements the domain.QueueRepository interface using Redis
 type QueueRepository struct {
-	client *redis.Client
-	logger *slog.Logger
+	client          *redis.Client
+	keyPrefix       string
+	maxPayloadBytes int
+	logger          *slog.Logger
+}
+
+// QueueOptions configures a QueueRepository
+type QueueOptions struct {
+	// KeyPrefix namespaces every key so several environments can share one Redis;
+	// a ":" separator is added if missing
+	KeyPrefix string
+
+	// MaxPayloadBytes caps the marshaled size of a job payload. Oversized payloads have
+	// their message_content truncated, and are rejected if that isn't enough. 0 = no limit
+	MaxPayloadBytes int
 }
 
 // NewQueueRepository creates a new Redis queue repository
-func NewQueueRepository(client *redis.Client, logger *slog.Logger) *QueueRepository {
+func NewQueueRepository(client *redis.Client, opts QueueOptions, logger *slog.Logger) *QueueRepository {
+	keyPrefix := opts.KeyPrefix
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, ":") {
+		keyPrefix += ":"
+	}
 	return &QueueRepository{
-		client: client,
-		logger: logger,
+		client:          client,
+		keyPrefix:       keyPrefix,
+		maxPayloadBytes: opts.MaxPayloadBytes,
+		logger:          logger,
 	}
 }
 
 // Redis key patterns
 const (
 	queueKeyPrefix   = "queue:"      // queue:job_type
+	priorityPrefix   = "priority:"   // priority:job_type
 	jobKeyPrefix     = "job:"        // job:job_id
 	processingPrefix = "processing:" // processing:job_type
 	retryKeyPrefix   = "retry:"      // retry:job_type
 	deadLetterPrefix = "dead:"       // dead:job_type
 	statsKeyPrefix   = "stats:"      // stats:job_type
+	pausedKey        = "worker:paused"
+	oembedReloadKey  = "worker:oembed_reload"
+	knokLockPrefix   = "lock:knok:" // lock:knok:knok_id
+	messagePrefix    = "message:"   // message:message_id:url
 )
 
+// key returns a key pattern prefix namespaced with the configured key prefix
+func (r *QueueRepository) key(pattern string) string {
+	return r.keyPrefix + pattern
+}
+
 // Job retry configuration
 const (
 	maxRetries        = 5
@@ -58,10 +92,30 @@ type QueueJob struct {
 	MaxRetries int                    `json:"max_retries"`
 	NextRetry  *time.Time             `json:"next_retry,omitempty"`
 	Error      string                 `json:"error,omitempty"`
+
+	// TraceContext is the trace context the job was queued in, see tracing.Inject
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // Enqueue adds a new job to the queue
 func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	return r.enqueue(ctx, jobType, payload, false)
+}
+
+// EnqueuePriority adds a new job to the high-priority queue, which Dequeue drains before
+// the regular queue. Retries of a priority job go back on the regular queue.
+func (r *QueueRepository) EnqueuePriority(ctx context.Context, jobType string, payload interface{}) error {
+	return r.enqueue(ctx, jobType, payload, true)
+}
+
+// enqueue stores a new job and pushes it onto the regular or high-priority queue
+func (r *QueueRepository) enqueue(ctx context.Context, jobType string, payload interface{}, priority bool) error {
+	ctx, span := tracing.Tracer().Start(ctx, "queue.enqueue", trace.WithAttributes(
+		attribute.String("job.type", jobType),
+		attribute.Bool("job.priority", priority),
+	))
+	defer span.End()
+
 	// Convert payload to map[string]interface{}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -73,6 +127,25 @@ func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload i
 		return fmt.Errorf("failed to unmarshal payload to map: %w", err)
 	}
 
+	if r.maxPayloadBytes > 0 && len(payloadBytes) > r.maxPayloadBytes {
+		originalSize := len(payloadBytes)
+		payloadBytes, err = fitPayload(payloadMap, r.maxPayloadBytes)
+		if err != nil {
+			r.logger.Error("Rejected oversized job payload",
+				"job_type", jobType,
+				"payload_size", originalSize,
+				"max_payload_size", r.maxPayloadBytes,
+			)
+			return err
+		}
+		r.logger.Warn("Truncated message_content in oversized job payload",
+			"job_type", jobType,
+			"payload_size", originalSize,
+			"truncated_size", len(payloadBytes),
+			"max_payload_size", r.maxPayloadBytes,
+		)
+	}
+
 	// Create job
 	job := &QueueJob{
 		ID:         uuid.New().String(),
@@ -82,6 +155,8 @@ func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload i
 		CreatedAt:  time.Now(),
 		RetryCount: 0,
 		MaxRetries: maxRetries,
+
+		TraceContext: tracing.Inject(ctx),
 	}
 
 	// Serialize job
@@ -93,7 +168,7 @@ func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload i
 	pipe := r.client.TxPipeline()
 
 	// Store job metadata in hash
-	jobKey := jobKeyPrefix + job.ID
+	jobKey := r.key(jobKeyPrefix) + job.ID
 	pipe.HMSet(ctx, jobKey, map[string]interface{}{
 		"data":        string(jobData),
 		"status":      job.Status,
@@ -104,11 +179,14 @@ func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload i
 	pipe.Expire(ctx, jobKey, time.Duration(jobTTLSec)*time.Second)
 
 	// Add job ID to queue
-	queueKey := queueKeyPrefix + jobType
+	queueKey := r.key(queueKeyPrefix) + jobType
+	if priority {
+		queueKey = r.key(priorityPrefix) + jobType
+	}
 	pipe.LPush(ctx, queueKey, job.ID)
 
 	// Update stats
-	statsKey := statsKeyPrefix + jobType
+	statsKey := r.key(statsKeyPrefix) + jobType
 	pipe.HIncrBy(ctx, statsKey, "total_enqueued", 1)
 	pipe.HIncrBy(ctx, statsKey, "pending", 1)
 
@@ -120,40 +198,116 @@ func (r *QueueRepository) Enqueue(ctx context.Context, jobType string, payload i
 	r.logger.Info("Job enqueued",
 		"job_id", job.ID,
 		"job_type", jobType,
+		"priority", priority,
 		"payload_size", len(payloadBytes),
 	)
 
 	return nil
 }
 
-// Dequeue retrieves the next job from the queue with blocking
-func (r *QueueRepository) Dequeue(ctx context.Context, jobType string) (*domain.QueueJob, error) {
-	queueKey := queueKeyPrefix + jobType
-	processingKey := processingPrefix + jobType
-
-	// Use BRPOPLPUSH for atomic move from queue to processing list
-	// This ensures jobs aren't lost if worker crashes
-	result, err := r.client.BRPopLPush(ctx, queueKey, processingKey, 30*time.Second).Result()
+// fitPayload truncates the payload's message_content to the longest prefix that lets the
+// marshaled payload fit in maxBytes, returning the marshaled result. It returns
+// domain.ErrPayloadTooLarge if the payload doesn't fit even with message_content emptied.
+func fitPayload(payload map[string]interface{}, maxBytes int) ([]byte, error) {
+	data, err := json.Marshal(payload)
 	if err != nil {
-		if err == redis.Nil {
-			// No jobs available (timeout)
-			return nil, nil
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if len(data) <= maxBytes {
+		return data, nil
+	}
+
+	content, _ := payload["message_content"].(string)
+	tooLarge := fmt.Errorf("%w: %d bytes exceeds limit of %d", domain.ErrPayloadTooLarge, len(data), maxBytes)
+	if content == "" {
+		return nil, tooLarge
+	}
+
+	// Escaping means marshaled size isn't linear in the content length, so binary search
+	// for the longest prefix that fits
+	best := -1
+	low, high := 0, len(content)-1
+	for low <= high {
+		mid := (low + high) / 2
+		payload["message_content"] = truncateUTF8(content, mid)
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		if len(data) <= maxBytes {
+			best = mid
+			low = mid + 1
+		} else {
+			high = mid - 1
 		}
-		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	if best < 0 {
+		payload["message_content"] = content
+		return nil, tooLarge
 	}
 
-	jobID := result
+	payload["message_content"] = truncateUTF8(content, best)
+	return json.Marshal(payload)
+}
 
-	// Get job data
-	jobKey := jobKeyPrefix + jobID
-	jobData, err := r.client.HGet(ctx, jobKey, "data").Result()
-	if err != nil {
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Dequeue retrieves the next job from the queue with blocking
+func (r *QueueRepository) Dequeue(ctx context.Context, jobType string) (*domain.QueueJob, error) {
+	queueKey := r.key(queueKeyPrefix) + jobType
+	priorityKey := r.key(priorityPrefix) + jobType
+	processingKey := r.key(processingPrefix) + jobType
+
+	var jobID, jobKey, jobData string
+	for {
+		// Take a high-priority job first if one is waiting. BRPOPLPUSH can only watch one
+		// list, so this check doesn't block.
+		result, err := r.client.RPopLPush(ctx, priorityKey, processingKey).Result()
 		if err == redis.Nil {
-			r.logger.Warn("Job data not found, removing from processing", "job_id", jobID)
-			r.client.LRem(ctx, processingKey, 1, jobID)
-			return nil, fmt.Errorf("job data not found: %s", jobID)
+			// Use BRPOPLPUSH for atomic move from queue to processing list
+			// This ensures jobs aren't lost if worker crashes
+			result, err = r.client.BRPopLPush(ctx, queueKey, processingKey, 30*time.Second).Result()
+		}
+		if err != nil {
+			if err == redis.Nil {
+				// No jobs available (timeout)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+
+		jobID = result
+
+		// Get job data
+		jobKey = r.key(jobKeyPrefix) + jobID
+		jobData, err = r.client.HGet(ctx, jobKey, "data").Result()
+		if err == nil {
+			break
+		}
+		if err != redis.Nil {
+			return nil, fmt.Errorf("failed to get job data: %w", err)
+		}
+
+		// The job's hash expired while its ID was still queued, so there's nothing left to
+		// run. Drop the ID, count it as expired rather than pending, and try the next job
+		r.logger.Warn("Job data not found, skipping expired job",
+			"job_id", jobID,
+			"job_type", jobType,
+		)
+		pipe := r.client.TxPipeline()
+		pipe.LRem(ctx, processingKey, 1, jobID)
+		statsKey := r.key(statsKeyPrefix) + jobType
+		pipe.HIncrBy(ctx, statsKey, "pending", -1)
+		pipe.HIncrBy(ctx, statsKey, "expired", 1)
+		if _, err := pipe.Exec(ctx); err != nil {
+			r.logger.Error("Failed to remove expired job", "error", err, "job_id", jobID)
 		}
-		return nil, fmt.Errorf("failed to get job data: %w", err)
 	}
 
 	// Parse job
@@ -177,40 +331,62 @@ func (r *QueueRepository) Dequeue(ctx context.Context, jobType string) (*domain.
 	})
 
 	// Update stats
-	statsKey := statsKeyPrefix + jobType
+	statsKey := r.key(statsKeyPrefix) + jobType
 	pipe.HIncrBy(ctx, statsKey, "pending", -1)
 	pipe.HIncrBy(ctx, statsKey, "processing", 1)
 
-	_, err = pipe.Exec(ctx)
-	if err != nil {
+	if _, err := pipe.Exec(ctx); err != nil {
 		r.logger.Error("Failed to update job status", "error", err, "job_id", jobID)
 	}
 
-	// Convert to domain.QueueJob
-	domainJob := &domain.QueueJob{
-		ID:        queueJob.ID,
-		Type:      queueJob.Type,
-		Payload:   queueJob.Payload,
-		Status:    queueJob.Status,
-		CreatedAt: queueJob.CreatedAt.Format(time.RFC3339),
-	}
-	if queueJob.UpdatedAt != nil {
-		updatedAtStr := queueJob.UpdatedAt.Format(time.RFC3339)
-		domainJob.UpdatedAt = &updatedAtStr
-	}
-
 	r.logger.Info("Job dequeued",
 		"job_id", queueJob.ID,
 		"job_type", jobType,
 		"retry_count", queueJob.RetryCount,
 	)
 
-	return domainJob, nil
+	return queueJob.toDomain(), nil
+}
+
+// toDomain converts a stored job to a domain.QueueJob
+func (j *QueueJob) toDomain() *domain.QueueJob {
+	domainJob := &domain.QueueJob{
+		ID:        j.ID,
+		Type:      j.Type,
+		Payload:   j.Payload,
+		Status:    j.Status,
+		CreatedAt: j.CreatedAt.Format(time.RFC3339),
+
+		TraceContext: j.TraceContext,
+	}
+	if j.UpdatedAt != nil {
+		updatedAtStr := j.UpdatedAt.Format(time.RFC3339)
+		domainJob.UpdatedAt = &updatedAtStr
+	}
+	return domainJob
+}
+
+// GetJob returns a job by ID as it's stored, without moving it between lists or changing
+// its status. Jobs expire some hours after completing, after which they can't be found
+func (r *QueueRepository) GetJob(ctx context.Context, jobID string) (*domain.QueueJob, error) {
+	jobData, err := r.client.HGet(ctx, r.key(jobKeyPrefix)+jobID, "data").Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("job not found: %s", jobID)
+		}
+		return nil, fmt.Errorf("failed to get job data: %w", err)
+	}
+
+	var queueJob QueueJob
+	if err := json.Unmarshal([]byte(jobData), &queueJob); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return queueJob.toDomain(), nil
 }
 
 // Complete marks a job as completed and removes it from processing
 func (r *QueueRepository) Complete(ctx context.Context, jobID string) error {
-	jobKey := jobKeyPrefix + jobID
+	jobKey := r.key(jobKeyPrefix) + jobID
 
 	// Get job to determine type
 	jobData, err := r.client.HGet(ctx, jobKey, "data").Result()
@@ -223,7 +399,7 @@ func (r *QueueRepository) Complete(ctx context.Context, jobID string) error {
 		return fmt.Errorf("failed to unmarshal job for completion: %w", err)
 	}
 
-	processingKey := processingPrefix + job.Type
+	processingKey := r.key(processingPrefix) + job.Type
 	now := time.Now()
 
 	// Update job status
@@ -245,7 +421,7 @@ func (r *QueueRepository) Complete(ctx context.Context, jobID string) error {
 	pipe.LRem(ctx, processingKey, 1, jobID)
 
 	// Update stats
-	statsKey := statsKeyPrefix + job.Type
+	statsKey := r.key(statsKeyPrefix) + job.Type
 	pipe.HIncrBy(ctx, statsKey, "processing", -1)
 	pipe.HIncrBy(ctx, statsKey, "completed", 1)
 
@@ -261,22 +437,23 @@ func (r *QueueRepository) Complete(ctx context.Context, jobID string) error {
 	return nil
 }
 
-// Fail marks a job as failed and handles retry logic
-func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg string) error {
-	jobKey := jobKeyPrefix + jobID
+// Fail marks a job as failed and handles retry logic. It reports whether the job ran out of
+// retries and was moved to the dead letter list
+func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg string) (bool, error) {
+	jobKey := r.key(jobKeyPrefix) + jobID
 
 	// Get current job data
 	jobData, err := r.client.HGet(ctx, jobKey, "data").Result()
 	if err != nil {
-		return fmt.Errorf("failed to get job for failure: %w", err)
+		return false, fmt.Errorf("failed to get job for failure: %w", err)
 	}
 
 	var job QueueJob
 	if err := json.Unmarshal([]byte(jobData), &job); err != nil {
-		return fmt.Errorf("failed to unmarshal job for failure: %w", err)
+		return false, fmt.Errorf("failed to unmarshal job for failure: %w", err)
 	}
 
-	processingKey := processingPrefix + job.Type
+	processingKey := r.key(processingPrefix) + job.Type
 	now := time.Now()
 
 	// Update job with error
@@ -287,7 +464,8 @@ func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg strin
 	pipe := r.client.TxPipeline()
 
 	// Check if we should retry
-	if job.RetryCount <= job.MaxRetries {
+	deadLettered := job.RetryCount > job.MaxRetries
+	if !deadLettered {
 		// Calculate backoff delay with exponential backoff
 		backoffSec := int(math.Min(
 			float64(initialBackoffSec)*math.Pow(2, float64(job.RetryCount-1)),
@@ -298,7 +476,7 @@ func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg strin
 		job.Status = domain.JobStatusPending
 
 		// Re-queue the job for retry (with delay)
-		retryKey := retryKeyPrefix + job.Type
+		retryKey := r.key(retryKeyPrefix) + job.Type
 		pipe.ZAdd(ctx, retryKey, redis.Z{
 			Score:  float64(nextRetry.Unix()),
 			Member: jobID,
@@ -314,11 +492,11 @@ func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg strin
 	} else {
 		// Max retries exceeded, move to dead letter queue
 		job.Status = domain.JobStatusFailed
-		deadKey := deadLetterPrefix + job.Type
+		deadKey := r.key(deadLetterPrefix) + job.Type
 		pipe.LPush(ctx, deadKey, jobID)
 
 		// Update stats
-		statsKey := statsKeyPrefix + job.Type
+		statsKey := r.key(statsKeyPrefix) + job.Type
 		pipe.HIncrBy(ctx, statsKey, "failed", 1)
 
 		r.logger.Error("Job failed permanently",
@@ -343,31 +521,109 @@ func (r *QueueRepository) Fail(ctx context.Context, jobID string, errorMsg strin
 	pipe.LRem(ctx, processingKey, 1, jobID)
 
 	// Update stats
-	statsKey := statsKeyPrefix + job.Type
+	statsKey := r.key(statsKeyPrefix) + job.Type
 	pipe.HIncrBy(ctx, statsKey, "processing", -1)
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to handle job failure: %w", err)
+		return false, fmt.Errorf("failed to handle job failure: %w", err)
 	}
 
-	return nil
+	return deadLettered, nil
 }
 
-// GetPendingCount returns the number of pending jobs for a job type
+// GetPendingCount returns the number of pending jobs for a job type, including
+// high-priority ones
 func (r *QueueRepository) GetPendingCount(ctx context.Context, jobType string) (int, error) {
-	queueKey := queueKeyPrefix + jobType
-	count, err := r.client.LLen(ctx, queueKey).Result()
-	if err != nil {
+	pipe := r.client.Pipeline()
+	queueLen := pipe.LLen(ctx, r.key(queueKeyPrefix)+jobType)
+	priorityLen := pipe.LLen(ctx, r.key(priorityPrefix)+jobType)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return 0, fmt.Errorf("failed to get pending count: %w", err)
 	}
-	return int(count), nil
+	return int(queueLen.Val() + priorityLen.Val()), nil
+}
+
+// GetProcessingJobIDs returns the IDs of jobs in the processing list for a job type,
+// most recently dequeued first
+func (r *QueueRepository) GetProcessingJobIDs(ctx context.Context, jobType string) ([]string, error) {
+	processingKey := r.key(processingPrefix) + jobType
+	jobIDs, err := r.client.LRange(ctx, processingKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processing jobs: %w", err)
+	}
+	return jobIDs, nil
+}
+
+// ReclaimProcessing moves every job in the processing list back onto the queue as
+// pending. Jobs still held by a live worker will be processed again, so this is a
+// manual override for jobs stuck after a worker died mid-job.
+func (r *QueueRepository) ReclaimProcessing(ctx context.Context, jobType string) (int, error) {
+	queueKey := r.key(queueKeyPrefix) + jobType
+	processingKey := r.key(processingPrefix) + jobType
+	statsKey := r.key(statsKeyPrefix) + jobType
+
+	reclaimed := 0
+	for {
+		// RPOPLPUSH moves one job at a time atomically, so a job is never in neither list
+		jobID, err := r.client.RPopLPush(ctx, processingKey, queueKey).Result()
+		if err != nil {
+			if err == redis.Nil {
+				break
+			}
+			return reclaimed, fmt.Errorf("failed to reclaim processing job: %w", err)
+		}
+		reclaimed++
+
+		pipe := r.client.TxPipeline()
+		pipe.HSet(ctx, r.key(jobKeyPrefix)+jobID, "status", domain.JobStatusPending)
+		pipe.HIncrBy(ctx, statsKey, "processing", -1)
+		pipe.HIncrBy(ctx, statsKey, "pending", 1)
+		if _, err := pipe.Exec(ctx); err != nil {
+			r.logger.Error("Failed to update reclaimed job status", "error", err, "job_id", jobID)
+		}
+	}
+
+	if reclaimed > 0 {
+		r.logger.Warn("Reclaimed processing jobs",
+			"job_type", jobType,
+			"count", reclaimed,
+		)
+	}
+
+	return reclaimed, nil
+}
+
+// SetPaused sets or clears the flag that stops workers from dequeuing jobs. Enqueueing
+// continues while paused, so jobs accumulate until processing resumes.
+func (r *QueueRepository) SetPaused(ctx context.Context, paused bool) error {
+	var err error
+	if paused {
+		err = r.client.Set(ctx, r.key(pausedKey), time.Now().Unix(), 0).Err()
+	} else {
+		err = r.client.Del(ctx, r.key(pausedKey)).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set paused state: %w", err)
+	}
+
+	r.logger.Warn("Job processing pause state changed", "paused", paused)
+	return nil
+}
+
+// IsPaused reports whether workers should stop dequeuing jobs
+func (r *QueueRepository) IsPaused(ctx context.Context) (bool, error) {
+	count, err := r.client.Exists(ctx, r.key(pausedKey)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to get paused state: %w", err)
+	}
+	return count > 0, nil
 }
 
 // ProcessRetryJobs moves jobs from retry queue back to main queue when ready
 func (r *QueueRepository) ProcessRetryJobs(ctx context.Context, jobType string) error {
-	retryKey := retryKeyPrefix + jobType
-	queueKey := queueKeyPrefix + jobType
+	retryKey := r.key(retryKeyPrefix) + jobType
+	queueKey := r.key(queueKeyPrefix) + jobType
 	now := time.Now()
 
 	// Get jobs ready for retry (score <= current timestamp)
@@ -394,7 +650,7 @@ func (r *QueueRepository) ProcessRetryJobs(ctx context.Context, jobType string)
 		pipe.LPush(ctx, queueKey, jobID)
 
 		// Update stats
-		statsKey := statsKeyPrefix + jobType
+		statsKey := r.key(statsKeyPrefix) + jobType
 		pipe.HIncrBy(ctx, statsKey, "pending", 1)
 	}
 
@@ -413,7 +669,7 @@ func (r *QueueRepository) ProcessRetryJobs(ctx context.Context, jobType string)
 
 // GetQueueStats returns statistics for a job type
 func (r *QueueRepository) GetQueueStats(ctx context.Context, jobType string) (map[string]int64, error) {
-	statsKey := statsKeyPrefix + jobType
+	statsKey := r.key(statsKeyPrefix) + jobType
 	stats, err := r.client.HGetAll(ctx, statsKey).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get queue stats: %w", err)
@@ -427,15 +683,19 @@ func (r *QueueRepository) GetQueueStats(ctx context.Context, jobType string) (ma
 	}
 
 	// Add current queue lengths
-	queueKey := queueKeyPrefix + jobType
-	processingKey := processingPrefix + jobType
-	retryKey := retryKeyPrefix + jobType
-	deadKey := deadLetterPrefix + jobType
+	queueKey := r.key(queueKeyPrefix) + jobType
+	processingKey := r.key(processingPrefix) + jobType
+	retryKey := r.key(retryKeyPrefix) + jobType
+	deadKey := r.key(deadLetterPrefix) + jobType
 
 	if pending, err := r.client.LLen(ctx, queueKey).Result(); err == nil {
 		result["current_pending"] = pending
 	}
 
+	if priority, err := r.client.LLen(ctx, r.key(priorityPrefix)+jobType).Result(); err == nil {
+		result["current_priority"] = priority
+	}
+
 	if processing, err := r.client.LLen(ctx, processingKey).Result(); err == nil {
 		result["current_processing"] = processing
 	}
@@ -450,3 +710,133 @@ func (r *QueueRepository) GetQueueStats(ctx context.Context, jobType string) (ma
 
 	return result, nil
 }
+
+// RecomputeStats rebuilds the stats counters of a job type that drift when the HIncrBy
+// bookkeeping misses or repeats an update. pending, processing and failed are set from the
+// queue, processing and dead letter lists. completed and expired can't be rebuilt once
+// completed jobs' data expires, so they're kept, and total_enqueued is raised to at least
+// the number of jobs accounted for. Returns the stats after the repair
+func (r *QueueRepository) RecomputeStats(ctx context.Context, jobType string) (map[string]int64, error) {
+	statsKey := r.key(statsKeyPrefix) + jobType
+
+	pipe := r.client.Pipeline()
+	queueLen := pipe.LLen(ctx, r.key(queueKeyPrefix)+jobType)
+	priorityLen := pipe.LLen(ctx, r.key(priorityPrefix)+jobType)
+	processingLen := pipe.LLen(ctx, r.key(processingPrefix)+jobType)
+	retryLen := pipe.ZCard(ctx, r.key(retryKeyPrefix)+jobType)
+	deadLen := pipe.LLen(ctx, r.key(deadLetterPrefix)+jobType)
+	current := pipe.HGetAll(ctx, statsKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read queue state: %w", err)
+	}
+
+	before := make(map[string]int64)
+	for key, value := range current.Val() {
+		if val, err := strconv.ParseInt(value, 10, 64); err == nil {
+			before[key] = val
+		}
+	}
+
+	after := map[string]int64{
+		"pending":    queueLen.Val() + priorityLen.Val(),
+		"processing": processingLen.Val(),
+		"failed":     deadLen.Val(),
+		"completed":  max(before["completed"], 0),
+		"expired":    max(before["expired"], 0),
+	}
+	accounted := after["pending"] + after["processing"] + retryLen.Val() +
+		after["failed"] + after["completed"] + after["expired"]
+	after["total_enqueued"] = max(before["total_enqueued"], accounted)
+
+	fields := make(map[string]interface{}, len(after))
+	for key, value := range after {
+		fields[key] = value
+	}
+	if err := r.client.HSet(ctx, statsKey, fields).Err(); err != nil {
+		return nil, fmt.Errorf("failed to write queue stats: %w", err)
+	}
+
+	for key, value := range after {
+		if before[key] != value {
+			r.logger.Warn("Corrected drifted queue stat",
+				"job_type", jobType,
+				"stat", key,
+				"was", before[key],
+				"now", value,
+			)
+		}
+	}
+
+	return after, nil
+}
+
+// RequestOEmbedReload records the time of a providers reload request; workers compare it
+// with the last one they saw on each poll
+func (r *QueueRepository) RequestOEmbedReload(ctx context.Context) error {
+	if err := r.client.Set(ctx, r.key(oembedReloadKey), time.Now().UnixNano(), 0).Err(); err != nil {
+		return fmt.Errorf("failed to request oEmbed providers reload: %w", err)
+	}
+	return nil
+}
+
+// GetOEmbedReloadRequest returns the time of the last providers reload request in unix
+// nanoseconds, or 0 if none was made
+func (r *QueueRepository) GetOEmbedReloadRequest(ctx context.Context) (int64, error) {
+	requested, err := r.client.Get(ctx, r.key(oembedReloadKey)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oEmbed providers reload request: %w", err)
+	}
+	return requested, nil
+}
+
+// releaseKnokLockScript deletes a knok lock only if it still holds the caller's token, so a
+// worker whose lock expired can't release the lock another worker has since taken
+var releaseKnokLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireKnokLock claims a knok for extraction until ttl passes or the lock is released.
+// Returns the token to release it with, or false if another worker holds it
+func (r *QueueRepository) AcquireKnokLock(ctx context.Context, knokID uuid.UUID, ttl time.Duration) (string, bool, error) {
+	token := uuid.New().String()
+	acquired, err := r.client.SetNX(ctx, r.key(knokLockPrefix)+knokID.String(), token, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to acquire knok lock: %w", err)
+	}
+	if !acquired {
+		return "", false, nil
+	}
+	return token, true, nil
+}
+
+// ReleaseKnokLock releases a knok's lock if it's still held with token
+func (r *QueueRepository) ReleaseKnokLock(ctx context.Context, knokID uuid.UUID, token string) error {
+	if err := releaseKnokLockScript.Run(ctx, r.client, []string{r.key(knokLockPrefix) + knokID.String()}, token).Err(); err != nil {
+		return fmt.Errorf("failed to release knok lock: %w", err)
+	}
+	return nil
+}
+
+// ClaimMessageURL marks a message's URL as handled until ttl passes. Returns false if it
+// already was
+func (r *QueueRepository) ClaimMessageURL(ctx context.Context, messageID, url string, ttl time.Duration) (bool, error) {
+	claimed, err := r.client.SetNX(ctx, r.key(messagePrefix)+messageID+":"+url, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim message URL: %w", err)
+	}
+	return claimed, nil
+}
+
+// ReleaseMessageURL drops a message URL's claim
+func (r *QueueRepository) ReleaseMessageURL(ctx context.Context, messageID, url string) error {
+	if err := r.client.Del(ctx, r.key(messagePrefix)+messageID+":"+url).Err(); err != nil {
+		return fmt.Errorf("failed to release message URL: %w", err)
+	}
+	return nil
+}
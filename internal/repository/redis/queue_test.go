@@ -0,0 +1,597 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/tracing"
+	"log/slog"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// keyRecorder is a go-redis hook that records the key of every command instead of
+// sending it, so key layout can be tested without a Redis server
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys []string
+	cmds [][]interface{}
+}
+
+func (h *keyRecorder) record(cmd redis.Cmder) {
+	switch cmd.Name() {
+	case "multi", "exec":
+		return
+	}
+	if args := cmd.Args(); len(args) > 1 {
+		h.mu.Lock()
+		h.keys = append(h.keys, args[1].(string))
+		h.cmds = append(h.cmds, args)
+		h.mu.Unlock()
+	}
+}
+
+func (h *keyRecorder) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, net.ErrClosed
+	}
+}
+
+func (h *keyRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.record(cmd)
+		return nil
+	}
+}
+
+func (h *keyRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.record(cmd)
+		}
+		return nil
+	}
+}
+
+func TestQueueRepositoryKeyPrefix(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyPrefix  string
+		wantPrefix string
+	}{
+		{name: "Prefix without separator", keyPrefix: "staging", wantPrefix: "staging:"},
+		{name: "Prefix with separator", keyPrefix: "staging:", wantPrefix: "staging:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+			defer client.Close()
+			recorder := &keyRecorder{}
+			client.AddHook(recorder)
+
+			r := NewQueueRepository(client, QueueOptions{KeyPrefix: tt.keyPrefix}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			ctx := context.Background()
+			if err := r.Enqueue(ctx, "extract_metadata", map[string]string{"url": "https://example.com"}); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+			if _, err := r.GetQueueStats(ctx, "extract_metadata"); err != nil {
+				t.Fatalf("GetQueueStats() error = %v", err)
+			}
+
+			if len(recorder.keys) == 0 {
+				t.Fatal("no Redis commands were recorded")
+			}
+			for _, key := range recorder.keys {
+				if !strings.HasPrefix(key, tt.wantPrefix) || strings.HasPrefix(key, tt.wantPrefix+":") {
+					t.Errorf("key %q doesn't start with %q", key, tt.wantPrefix)
+				}
+			}
+		})
+	}
+
+	t.Run("No prefix", func(t *testing.T) {
+		r := NewQueueRepository(nil, QueueOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if got := r.key(queueKeyPrefix) + "extract_metadata"; got != "queue:extract_metadata" {
+			t.Errorf("key = %q, want %q", got, "queue:extract_metadata")
+		}
+	})
+}
+
+func TestFitPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     map[string]interface{}
+		maxBytes    int
+		wantErr     bool
+		wantContent string
+	}{
+		{
+			name:        "Small payload is unchanged",
+			payload:     map[string]interface{}{"url": "https://example.com", "message_content": "hello"},
+			maxBytes:    1024,
+			wantContent: "hello",
+		},
+		{
+			name:        "Message content is truncated to fit",
+			payload:     map[string]interface{}{"url": "https://example.com", "message_content": strings.Repeat("a", 500)},
+			maxBytes:    200,
+			wantContent: strings.Repeat("a", 500)[:200-len(`{"message_content":"","url":"https://example.com"}`)],
+		},
+		{
+			name:     "Truncation keeps whole runes",
+			payload:  map[string]interface{}{"message_content": strings.Repeat("é", 100)},
+			maxBytes: 51,
+			// {"message_content":""} is 22 bytes, leaving 29 for content: 14 two-byte runes
+			wantContent: strings.Repeat("é", 14),
+		},
+		{
+			name:     "Escaped content still fits",
+			payload:  map[string]interface{}{"message_content": strings.Repeat("<", 100)},
+			maxBytes: 100,
+			// Each < marshals to the 6-byte \u003c escape
+			wantContent: strings.Repeat("<", 13),
+		},
+		{
+			name:     "Rejected without message content to truncate",
+			payload:  map[string]interface{}{"url": strings.Repeat("a", 500)},
+			maxBytes: 200,
+			wantErr:  true,
+		},
+		{
+			name:     "Rejected when other fields alone are too large",
+			payload:  map[string]interface{}{"url": strings.Repeat("a", 500), "message_content": "hello"},
+			maxBytes: 200,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fitPayload(tt.payload, tt.maxBytes)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrPayloadTooLarge) {
+					t.Errorf("fitPayload() error = %v, want ErrPayloadTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fitPayload() error = %v", err)
+			}
+			if len(data) > tt.maxBytes {
+				t.Errorf("payload is %d bytes, want at most %d", len(data), tt.maxBytes)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("fitted payload isn't valid JSON: %v", err)
+			}
+			if got["message_content"] != tt.wantContent {
+				t.Errorf("message_content = %q, want %q", got["message_content"], tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestEnqueueOversizedPayload(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	recorder := &keyRecorder{}
+	client.AddHook(recorder)
+	r := NewQueueRepository(client, QueueOptions{MaxPayloadBytes: 256}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	t.Run("Truncated", func(t *testing.T) {
+		payload := map[string]interface{}{"knok_id": "k1", "message_content": strings.Repeat("a", 10000)}
+		if err := r.Enqueue(ctx, domain.JobTypeExtractMetadata, payload); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+
+		var stored QueueJob
+		for _, args := range recorder.cmds {
+			if args[0] != "hmset" {
+				continue
+			}
+			for i := 2; i+1 < len(args); i += 2 {
+				if args[i] == "data" {
+					if err := json.Unmarshal([]byte(args[i+1].(string)), &stored); err != nil {
+						t.Fatalf("stored job isn't valid JSON: %v", err)
+					}
+				}
+			}
+		}
+		content, _ := stored.Payload["message_content"].(string)
+		if content == "" || len(content) >= 256 {
+			t.Errorf("stored message_content has %d bytes, want it truncated under the limit", len(content))
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		recorder.cmds = nil
+		payload := map[string]interface{}{"knok_id": strings.Repeat("k", 1000)}
+		if err := r.Enqueue(ctx, domain.JobTypeExtractMetadata, payload); !errors.Is(err, domain.ErrPayloadTooLarge) {
+			t.Fatalf("Enqueue() error = %v, want ErrPayloadTooLarge", err)
+		}
+		if len(recorder.cmds) != 0 {
+			t.Errorf("rejected payload sent %d Redis commands, want none", len(recorder.cmds))
+		}
+	})
+}
+
+// memoryRedis is a go-redis hook that serves the list and hash commands the queue uses from
+// memory, so queue ordering can be tested without a Redis server. Sorted sets are only
+// counted, so they're kept as lists, and expiry is ignored
+type memoryRedis struct {
+	lists   map[string][]string // head of the list first
+	hashes  map[string]map[string]string
+	strings map[string]string
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{
+		lists:   make(map[string][]string),
+		hashes:  make(map[string]map[string]string),
+		strings: make(map[string]string),
+	}
+}
+
+func (m *memoryRedis) exec(cmd redis.Cmder) {
+	args := make([]string, len(cmd.Args()))
+	for i, arg := range cmd.Args() {
+		args[i] = fmt.Sprint(arg)
+	}
+
+	switch cmd.Name() {
+	case "lpush":
+		m.lists[args[1]] = append([]string{args[2]}, m.lists[args[1]]...)
+	case "rpoplpush", "brpoplpush":
+		src := m.lists[args[1]]
+		if len(src) == 0 {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		value := src[len(src)-1]
+		m.lists[args[1]] = src[:len(src)-1]
+		m.lists[args[2]] = append([]string{value}, m.lists[args[2]]...)
+		cmd.(*redis.StringCmd).SetVal(value)
+	case "llen":
+		cmd.(*redis.IntCmd).SetVal(int64(len(m.lists[args[1]])))
+	case "zcard":
+		cmd.(*redis.IntCmd).SetVal(int64(len(m.lists[args[1]])))
+	case "hgetall":
+		hash := make(map[string]string, len(m.hashes[args[1]]))
+		for field, value := range m.hashes[args[1]] {
+			hash[field] = value
+		}
+		cmd.(*redis.MapStringStringCmd).SetVal(hash)
+	case "hmset", "hset":
+		if m.hashes[args[1]] == nil {
+			m.hashes[args[1]] = make(map[string]string)
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			m.hashes[args[1]][args[i]] = args[i+1]
+		}
+	case "hget":
+		value, ok := m.hashes[args[1]][args[2]]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.(*redis.StringCmd).SetVal(value)
+	case "hincrby":
+		if m.hashes[args[1]] == nil {
+			m.hashes[args[1]] = make(map[string]string)
+		}
+		value, _ := strconv.ParseInt(m.hashes[args[1]][args[2]], 10, 64)
+		by, _ := strconv.ParseInt(args[3], 10, 64)
+		m.hashes[args[1]][args[2]] = strconv.FormatInt(value+by, 10)
+	case "set":
+		// Only SET key value EX seconds NX, as used by SetNX
+		if _, exists := m.strings[args[1]]; exists {
+			cmd.(*redis.BoolCmd).SetVal(false)
+			return
+		}
+		m.strings[args[1]] = args[2]
+		cmd.(*redis.BoolCmd).SetVal(true)
+	case "evalsha":
+		// The only script is releaseKnokLockScript: EVALSHA sha 1 key token
+		deleted := int64(0)
+		if value, ok := m.strings[args[3]]; ok && value == args[4] {
+			delete(m.strings, args[3])
+			deleted = 1
+		}
+		cmd.(*redis.Cmd).SetVal(deleted)
+	case "del":
+		deleted := int64(0)
+		for _, key := range args[1:] {
+			if _, ok := m.strings[key]; ok {
+				delete(m.strings, key)
+				deleted++
+			}
+		}
+		cmd.(*redis.IntCmd).SetVal(deleted)
+	case "lrem":
+		list := m.lists[args[1]]
+		for i, value := range list {
+			if value == args[3] {
+				m.lists[args[1]] = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (m *memoryRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, net.ErrClosed
+	}
+}
+
+func (m *memoryRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		m.exec(cmd)
+		return cmd.Err()
+	}
+}
+
+func (m *memoryRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			m.exec(cmd)
+		}
+		return nil
+	}
+}
+
+func TestDequeuePriorityFirst(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(newMemoryRedis())
+	r := NewQueueRepository(client, QueueOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	for _, url := range []string{"https://example.com/seeded-1", "https://example.com/seeded-2"} {
+		if err := r.Enqueue(ctx, domain.JobTypeExtractMetadata, map[string]string{"url": url}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if err := r.EnqueuePriority(ctx, domain.JobTypeExtractMetadata, map[string]string{"url": "https://example.com/refresh"}); err != nil {
+		t.Fatalf("EnqueuePriority() error = %v", err)
+	}
+
+	if count, err := r.GetPendingCount(ctx, domain.JobTypeExtractMetadata); err != nil || count != 3 {
+		t.Fatalf("GetPendingCount() = %d, %v, want 3 including the priority job", count, err)
+	}
+
+	want := []string{"https://example.com/refresh", "https://example.com/seeded-1", "https://example.com/seeded-2"}
+	for i, wantURL := range want {
+		job, err := r.Dequeue(ctx, domain.JobTypeExtractMetadata)
+		if err != nil || job == nil {
+			t.Fatalf("Dequeue() #%d = %v, %v, want a job", i+1, job, err)
+		}
+		if job.Payload["url"] != wantURL {
+			t.Errorf("Dequeue() #%d url = %v, want %s", i+1, job.Payload["url"], wantURL)
+		}
+	}
+
+	if job, err := r.Dequeue(ctx, domain.JobTypeExtractMetadata); job != nil || err != nil {
+		t.Errorf("Dequeue() on an empty queue = %v, %v, want nil, nil", job, err)
+	}
+}
+
+func TestDequeueSkipsExpiredJobs(t *testing.T) {
+	memory := newMemoryRedis()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(memory)
+	r := NewQueueRepository(client, QueueOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	for _, url := range []string{"https://example.com/expired", "https://example.com/live"} {
+		if err := r.Enqueue(ctx, domain.JobTypeExtractMetadata, map[string]string{"url": url}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	// The oldest job's hash expires while its ID is still queued
+	queueKey := queueKeyPrefix + domain.JobTypeExtractMetadata
+	expiredID := memory.lists[queueKey][len(memory.lists[queueKey])-1]
+	delete(memory.hashes, jobKeyPrefix+expiredID)
+
+	job, err := r.Dequeue(ctx, domain.JobTypeExtractMetadata)
+	if err != nil || job == nil {
+		t.Fatalf("Dequeue() = %v, %v, want the next live job", job, err)
+	}
+	if job.Payload["url"] != "https://example.com/live" {
+		t.Errorf("Dequeue() url = %v, want the live job", job.Payload["url"])
+	}
+
+	processing := memory.lists[processingPrefix+domain.JobTypeExtractMetadata]
+	if len(processing) != 1 || processing[0] != job.ID {
+		t.Errorf("processing list = %v, want only %s", processing, job.ID)
+	}
+	stats := memory.hashes[statsKeyPrefix+domain.JobTypeExtractMetadata]
+	if stats["expired"] != "1" || stats["pending"] != "0" || stats["processing"] != "1" {
+		t.Errorf("stats = %v, want 1 expired, 0 pending and 1 processing", stats)
+	}
+
+	if job, err := r.Dequeue(ctx, domain.JobTypeExtractMetadata); job != nil || err != nil {
+		t.Errorf("Dequeue() on an empty queue = %v, %v, want nil, nil", job, err)
+	}
+}
+
+func TestRecomputeStats(t *testing.T) {
+	memory := newMemoryRedis()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(memory)
+	r := NewQueueRepository(client, QueueOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	jobType := domain.JobTypeExtractMetadata
+	for i := 0; i < 3; i++ {
+		if err := r.Enqueue(ctx, jobType, map[string]string{"url": fmt.Sprintf("https://example.com/%d", i)}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if _, err := r.Dequeue(ctx, jobType); err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	memory.lists[retryKeyPrefix+jobType] = []string{"retrying"}
+	memory.lists[deadLetterPrefix+jobType] = []string{"dead"}
+
+	// A repeated Complete and a lost update leave the counters behind the lists
+	statsKey := statsKeyPrefix + jobType
+	memory.hashes[statsKey]["processing"] = "-1"
+	memory.hashes[statsKey]["pending"] = "7"
+	memory.hashes[statsKey]["completed"] = "4"
+
+	stats, err := r.RecomputeStats(ctx, jobType)
+	if err != nil {
+		t.Fatalf("RecomputeStats() error = %v", err)
+	}
+	want := map[string]int64{
+		"pending":        2,
+		"processing":     1,
+		"failed":         1,
+		"completed":      4,
+		"expired":        0,
+		"total_enqueued": 9, // 2 pending + 1 processing + 1 retrying + 1 failed + 4 completed
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("RecomputeStats() = %v, want %v", stats, want)
+	}
+	for key, value := range want {
+		if got := memory.hashes[statsKey][key]; got != strconv.FormatInt(value, 10) {
+			t.Errorf("stored %s = %s, want %d", key, got, value)
+		}
+	}
+}
+
+func TestKnokLock(t *testing.T) {
+	memory := newMemoryRedis()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(memory)
+	r := NewQueueRepository(client, QueueOptions{KeyPrefix: "staging"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	knokID := uuid.New()
+
+	token, acquired, err := r.AcquireKnokLock(ctx, knokID, time.Minute)
+	if err != nil || !acquired || token == "" {
+		t.Fatalf("AcquireKnokLock() = %q, %v, %v, want a token", token, acquired, err)
+	}
+	lockKey := "staging:" + knokLockPrefix + knokID.String()
+	if memory.strings[lockKey] != token {
+		t.Errorf("lock %s = %q, want %q", lockKey, memory.strings[lockKey], token)
+	}
+
+	if _, acquired, err := r.AcquireKnokLock(ctx, knokID, time.Minute); err != nil || acquired {
+		t.Errorf("second AcquireKnokLock() = %v, %v, want false while held", acquired, err)
+	}
+	if _, acquired, err := r.AcquireKnokLock(ctx, uuid.New(), time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireKnokLock() of another knok = %v, %v, want true", acquired, err)
+	}
+
+	// A stale token, e.g. from a worker whose lock expired, leaves the lock alone
+	if err := r.ReleaseKnokLock(ctx, knokID, "stale"); err != nil {
+		t.Fatalf("ReleaseKnokLock() error = %v", err)
+	}
+	if memory.strings[lockKey] != token {
+		t.Error("ReleaseKnokLock() with a stale token released the lock")
+	}
+
+	if err := r.ReleaseKnokLock(ctx, knokID, token); err != nil {
+		t.Fatalf("ReleaseKnokLock() error = %v", err)
+	}
+	if _, acquired, err := r.AcquireKnokLock(ctx, knokID, time.Minute); err != nil || !acquired {
+		t.Errorf("AcquireKnokLock() after release = %v, %v, want true", acquired, err)
+	}
+}
+
+func TestDequeueCarriesTraceContext(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(newMemoryRedis())
+	r := NewQueueRepository(client, QueueOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	if err := r.Enqueue(ctx, domain.JobTypeExtractMetadata, map[string]string{"url": "https://example.com/traced"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := r.Enqueue(context.Background(), domain.JobTypeExtractMetadata, map[string]string{"url": "https://example.com/untraced"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	job, err := r.Dequeue(context.Background(), domain.JobTypeExtractMetadata)
+	if err != nil || job == nil {
+		t.Fatalf("Dequeue() = %v, %v, want a job", job, err)
+	}
+	got := trace.SpanContextFromContext(tracing.Extract(context.Background(), job.TraceContext))
+	if got.TraceID() != traceID || !got.IsRemote() || !got.IsSampled() {
+		t.Errorf("dequeued span context = %+v, want remote sampled trace %s", got, traceID)
+	}
+
+	job, err = r.Dequeue(context.Background(), domain.JobTypeExtractMetadata)
+	if err != nil || job == nil {
+		t.Fatalf("Dequeue() = %v, %v, want a job", job, err)
+	}
+	if job.TraceContext != nil {
+		t.Errorf("untraced job TraceContext = %v, want nil", job.TraceContext)
+	}
+}
+
+func TestClaimMessageURL(t *testing.T) {
+	memory := newMemoryRedis()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	client.AddHook(memory)
+	r := NewQueueRepository(client, QueueOptions{KeyPrefix: "staging"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	const url = "https://soundcloud.com/artist/track"
+
+	if claimed, err := r.ClaimMessageURL(ctx, "msg-1", url, time.Minute); err != nil || !claimed {
+		t.Fatalf("ClaimMessageURL() = %v, %v, want true", claimed, err)
+	}
+	key := "staging:" + messagePrefix + "msg-1:" + url
+	if _, ok := memory.strings[key]; !ok {
+		t.Errorf("claim key %s not set", key)
+	}
+
+	if claimed, err := r.ClaimMessageURL(ctx, "msg-1", url, time.Minute); err != nil || claimed {
+		t.Errorf("second ClaimMessageURL() = %v, %v, want false", claimed, err)
+	}
+	if claimed, err := r.ClaimMessageURL(ctx, "msg-1", url+"-2", time.Minute); err != nil || !claimed {
+		t.Errorf("ClaimMessageURL() of another URL in the message = %v, %v, want true", claimed, err)
+	}
+	if claimed, err := r.ClaimMessageURL(ctx, "msg-2", url, time.Minute); err != nil || !claimed {
+		t.Errorf("ClaimMessageURL() of another message = %v, %v, want true", claimed, err)
+	}
+
+	if err := r.ReleaseMessageURL(ctx, "msg-1", url); err != nil {
+		t.Fatalf("ReleaseMessageURL() error = %v", err)
+	}
+	if claimed, err := r.ClaimMessageURL(ctx, "msg-1", url, time.Minute); err != nil || !claimed {
+		t.Errorf("ClaimMessageURL() after release = %v, %v, want true", claimed, err)
+	}
+}
@@ -29,8 +29,9 @@ type APIService struct {
 	platformRepo   handlers.PlatformRepository
 	platformLoader PlatformLoader
 
-	// HTTP server
-	server *http.Server
+	// HTTP server, and the internal admin server when admin routes are separated
+	server      *http.Server
+	adminServer *http.Server
 }
 
 // New creates a new API service
@@ -42,8 +43,13 @@ func New(
 	queueRepo domain.QueueRepository,
 	platformRepo handlers.PlatformRepository,
 	platformLoader PlatformLoader,
+	schemaStatus handlers.SchemaStatusSource,
+	urlDetector handlers.PlatformDetector,
+	extractor handlers.MetadataExtractor,
+	oembedReloader handlers.OEmbedReloader,
 ) (*APIService, error) {
-	router := knokhttp.NewRouter(logger, serverRepo, knokRepo, queueRepo, platformRepo, platformLoader)
+	router := knokhttp.NewRouter(logger, serverRepo, knokRepo, queueRepo, platformRepo, platformLoader,
+		schemaStatus, urlDetector, extractor, oembedReloader, config.PreviewRateLimit, config.KnokWaitMaxTimeout, config.StaticDir)
 
 	apiService := &APIService{
 		config:         config,
@@ -56,6 +62,11 @@ func New(
 		platformLoader: platformLoader,
 	}
 
+	router.SetTrustedProxies(config.TrustedProxies)
+	if config.AdminAddr != "" {
+		router.SeparateAdminRoutes()
+	}
+
 	// Create HTTP server with router and middleware
 	handler := router.SetupRoutes()
 	apiService.server = &http.Server{
@@ -66,11 +77,30 @@ func New(
 		IdleTimeout:  60 * time.Second,
 	}
 
+	if adminHandler := router.AdminHandler(); adminHandler != nil {
+		apiService.adminServer = &http.Server{
+			Addr:         config.AdminAddr,
+			Handler:      adminHandler,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+	}
+
 	return apiService, nil
 }
 
 // Start begins serving the API
 func (s *APIService) Start() error {
+	if s.adminServer != nil {
+		go func() {
+			s.logger.Info("Starting admin server", "addr", s.config.AdminAddr)
+			if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("Admin server failed", "error", err)
+			}
+		}()
+	}
+
 	s.logger.Info("Starting API server", "port", s.config.Port)
 	return s.server.ListenAndServe()
 }
@@ -78,5 +108,10 @@ func (s *APIService) Start() error {
 // Stop gracefully shuts down the API server
 func (s *APIService) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping API server...")
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(ctx); err != nil {
+			s.logger.Error("Failed to stop admin server", "error", err)
+		}
+	}
 	return s.server.Shutdown(ctx)
 }
@@ -1,18 +1,112 @@
 package bot
 
 import (
+	"context"
 	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"strconv"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// defaultRecentKnoks is how many knoks /recent shows without a count
+	defaultRecentKnoks = 5
+
+	// maxRecentKnoks caps /recent's count, keeping the embed well inside Discord's limits
+	maxRecentKnoks = 10
+
+	// defaultSearchResults is how many results /search shows without a limit
+	defaultSearchResults = 5
+
+	// maxSearchResults caps /search's limit
+	maxSearchResults = 25
+
+	// maxSearchTitle is how many characters of a knok's title /search shows
+	maxSearchTitle = 100
+
+	// Discord's limits on embed parts, in characters
+	maxEmbedTitle       = 256
+	maxEmbedFieldName   = 256
+	maxEmbedFieldValue  = 1024
+	maxEmbedDescription = 4096
+	maxEmbedFooter      = 2048
+
+	// maxStatsPlatforms is how many platforms /stats ranks
+	maxStatsPlatforms = 3
+
+	// maxResponseEmbeds is how many embeds Discord allows in one message
+	maxResponseEmbeds = 10
+)
+
+// minRecentKnoks and minSearchResults are the lowest counts /recent and /search accept;
+// the options take pointers
+var (
+	minRecentKnoks   = float64(1)
+	minSearchResults = float64(1)
+)
+
 // Command definitions
 var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "platforms",
+		Description: "List the platforms whose links the bot tracks",
+		Type:        discordgo.ChatApplicationCommand,
+	},
+	{
+		Name:        "recent",
+		Description: "Show the latest links shared in this server",
+		Type:        discordgo.ChatApplicationCommand,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "count",
+				Description: fmt.Sprintf("How many links to show (default %d, max %d)", defaultRecentKnoks, maxRecentKnoks),
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &minRecentKnoks,
+				MaxValue:    maxRecentKnoks,
+			},
+		},
+	},
+	{
+		Name:        "search",
+		Description: "Search links shared in this server by title",
+		Type:        discordgo.ChatApplicationCommand,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "query",
+				Description: "What to search for",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+			{
+				Name:        "limit",
+				Description: fmt.Sprintf("How many results to show (default %d, max %d)", defaultSearchResults, maxSearchResults),
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &minSearchResults,
+				MaxValue:    maxSearchResults,
+			},
+		},
+	},
 	{
 		Name:        "stats",
 		Description: "Show server music statistics",
 		Type:        discordgo.ChatApplicationCommand,
 	},
+	{
+		Name:        "status",
+		Description: "Show the metadata extraction status of a shared link",
+		Type:        discordgo.ChatApplicationCommand,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "url",
+				Description: "The link that was shared",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+		},
+	},
 }
 
 // registerCommands registers slash commands with Discord
@@ -36,21 +130,46 @@ func (s *BotService) onInteractionCreate(session *discordgo.Session, interaction
 	}
 
 	command := interaction.ApplicationCommandData()
+	// User is only set for DMs; guild interactions carry the user on Member
+	userID := ""
+	if interaction.Member != nil && interaction.Member.User != nil {
+		userID = interaction.Member.User.ID
+	} else if interaction.User != nil {
+		userID = interaction.User.ID
+	}
+
 	s.logger.Debug("Received slash command",
 		"command", command.Name,
-		"user_id", interaction.User.ID,
+		"user_id", userID,
 		"guild_id", interaction.GuildID,
 	)
 
+	response := s.commandResponse(interaction)
+
+	// Send response
+	if err := session.InteractionRespond(interaction.Interaction, response); err != nil {
+		s.logger.Error("Failed to respond to interaction", "error", err)
+	}
+}
+
+// commandResponse builds the response to a slash command, made ephemeral if the server
+// lists the command in its ephemeral_commands setting
+func (s *BotService) commandResponse(interaction *discordgo.InteractionCreate) *discordgo.InteractionResponse {
+	command := interaction.ApplicationCommandData()
+
 	var response *discordgo.InteractionResponse
 
 	switch command.Name {
+	case "platforms":
+		response = s.handlePlatformsCommand()
 	case "recent":
 		response = s.handleRecentCommand(interaction)
 	case "stats":
 		response = s.handleStatsCommand(interaction)
 	case "search":
 		response = s.handleSearchCommand(interaction)
+	case "status":
+		response = s.handleStatusCommand(interaction)
 	default:
 		response = &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -60,53 +179,189 @@ func (s *BotService) onInteractionCreate(session *discordgo.Session, interaction
 		}
 	}
 
-	// Send response
-	if err := session.InteractionRespond(interaction.Interaction, response); err != nil {
-		s.logger.Error("Failed to respond to interaction", "error", err)
+	if s.ephemeralCommand(interaction.GuildID, command.Name) {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+
+	return response
+}
+
+// ephemeralCommand reports whether a guild wants command's responses shown only to the
+// user who ran it. Defaults to false, posting responses to the channel
+func (s *BotService) ephemeralCommand(guildID, command string) bool {
+	if s.serverRepo == nil || guildID == "" {
+		return false
+	}
+
+	server, err := s.serverRepo.GetByID(context.Background(), guildID)
+	if err != nil || server == nil {
+		return false
 	}
+
+	return server.EphemeralCommand(command)
 }
 
-// handleRecentCommand handles the /recent command
+// handlePlatformsCommand handles the /platforms command, listing the enabled platforms
+// whose links are tracked, highest priority first
+func (s *BotService) handlePlatformsCommand() *discordgo.InteractionResponse {
+	names := s.urlDetector.GetSupportedPlatformNames()
+	if len(names) == 0 {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Supported platforms aren't available right now",
+			},
+		}
+	}
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = "• " + name
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "🎧 Supported Platforms",
+					Color:       0x9b59b6,
+					Description: truncateRunes(strings.Join(lines, "\n"), maxEmbedDescription),
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: fmt.Sprintf("%d platforms - links from other sites aren't tracked", len(names)),
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleRecentCommand handles the /recent command, listing the server's latest knoks
 func (s *BotService) handleRecentCommand(interaction *discordgo.InteractionCreate) *discordgo.InteractionResponse {
-	// Get count option (default to 5)
-	count := 5
-	if len(interaction.ApplicationCommandData().Options) > 0 {
-		if option := interaction.ApplicationCommandData().Options[0]; option.Name == "count" {
+	// Get count option (default to 5, at most 10)
+	count := defaultRecentKnoks
+	for _, option := range interaction.ApplicationCommandData().Options {
+		if option.Name == "count" {
 			if countVal, ok := option.Value.(float64); ok {
 				count = int(countVal)
 			}
 		}
 	}
+	count = max(1, min(count, maxRecentKnoks))
+
+	if s.knokRepo == nil {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Recent knoks aren't available right now",
+			},
+		}
+	}
+
+	knoks, err := s.knokRepo.GetRecentByServer(context.Background(), interaction.GuildID, domain.DefaultKnokSort, nil, nil, nil, "", count)
+	if err != nil {
+		s.logger.Error("Failed to get recent knoks",
+			"error", err,
+			"guild_id", interaction.GuildID,
+		)
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Couldn't load recent knoks, please try again later",
+			},
+		}
+	}
+
+	if len(knoks) == 0 {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🎵 No knoks yet - share a link to get started!",
+			},
+		}
+	}
 
-	// TODO: Implement recent knoks fetching from database
-	response := &discordgo.InteractionResponse{
+	if s.embedStyle(interaction.GuildID) == domain.EmbedStyleCards {
+		return knokCardsResponse("🎵 **Recent Music Knoks**", 0x00ff00, knoks)
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(knoks))
+	for _, knok := range knoks {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  truncateRunes(knokTitle(knok), maxEmbedFieldName),
+			Value: truncateRunes(fmt.Sprintf("%s\nPosted <t:%d:D>", knok.URL, knok.PostedAt.Unix()), maxEmbedFieldValue),
+		})
+	}
+
+	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{
 				{
-					Title: "🎵 Recent Music Knoks",
-					Color: 0x00ff00,
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:  "Status",
-							Value: "Coming soon... The bot will show the latest knoks shared in this server.",
-						},
-					},
-					Footer: &discordgo.MessageEmbedFooter{
-						Text: fmt.Sprintf("Requested %d knoks", count),
-					},
+					Title:  "🎵 Recent Music Knoks",
+					Color:  0x00ff00,
+					Fields: fields,
 				},
 			},
 		},
 	}
+}
 
-	return response
+// truncateRunes shortens s to at most n characters, ending with an ellipsis when cut
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
 }
 
-// handleStatsCommand handles the /stats command
+// handleStatsCommand handles the /stats command, summarizing the server's completed knoks
 func (s *BotService) handleStatsCommand(interaction *discordgo.InteractionCreate) *discordgo.InteractionResponse {
-	// TODO: Implement stats fetching from database
-	response := &discordgo.InteractionResponse{
+	if s.knokRepo == nil {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Server statistics aren't available right now",
+			},
+		}
+	}
+
+	stats, err := s.knokRepo.GetServerStats(context.Background(), interaction.GuildID)
+	if err != nil {
+		s.logger.Error("Failed to get server stats",
+			"error", err,
+			"guild_id", interaction.GuildID,
+		)
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Couldn't load server statistics, please try again later",
+			},
+		}
+	}
+
+	if stats.Total == 0 {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🎵 No knoks yet - share a link to get started!",
+			},
+		}
+	}
+
+	topPlatforms := stats.Platforms[:min(len(stats.Platforms), maxStatsPlatforms)]
+	lines := make([]string, 0, len(topPlatforms))
+	for i, platform := range topPlatforms {
+		lines = append(lines, fmt.Sprintf("%d. %s - %d", i+1, platform.Platform, platform.Count))
+	}
+
+	recentActivity := "Unknown"
+	if stats.LatestPostedAt != nil {
+		recentActivity = fmt.Sprintf("Last knok <t:%d:R>", stats.LatestPostedAt.Unix())
+	}
+
+	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{
@@ -116,36 +371,34 @@ func (s *BotService) handleStatsCommand(interaction *discordgo.InteractionCreate
 					Fields: []*discordgo.MessageEmbedField{
 						{
 							Name:  "Total Knoks",
-							Value: "Coming soon...",
+							Value: strconv.Itoa(stats.Total),
 						},
 						{
 							Name:  "Most Active Platform",
-							Value: "Coming soon...",
+							Value: strings.Join(lines, "\n"),
 						},
 						{
 							Name:  "Recent Activity",
-							Value: "Coming soon...",
+							Value: recentActivity,
 						},
 					},
 				},
 			},
 		},
 	}
-
-	return response
 }
 
-// handleSearchCommand handles the /search command
+// handleSearchCommand handles the /search command, listing the server's knoks matching a query
 func (s *BotService) handleSearchCommand(interaction *discordgo.InteractionCreate) *discordgo.InteractionResponse {
-	// Get search query
+	// Get search query and limit (default to 5, at most 25)
 	var query string
-	var limit int = 5
+	limit := defaultSearchResults
 
 	for _, option := range interaction.ApplicationCommandData().Options {
 		switch option.Name {
 		case "query":
 			if queryVal, ok := option.Value.(string); ok {
-				query = queryVal
+				query = strings.TrimSpace(queryVal)
 			}
 		case "limit":
 			if limitVal, ok := option.Value.(float64); ok {
@@ -153,6 +406,7 @@ func (s *BotService) handleSearchCommand(interaction *discordgo.InteractionCreat
 			}
 		}
 	}
+	limit = max(1, min(limit, maxSearchResults))
 
 	if query == "" {
 		return &discordgo.InteractionResponse{
@@ -163,28 +417,247 @@ func (s *BotService) handleSearchCommand(interaction *discordgo.InteractionCreat
 		}
 	}
 
-	// TODO: Implement search functionality
-	response := &discordgo.InteractionResponse{
+	if s.knokRepo == nil {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Search isn't available right now",
+			},
+		}
+	}
+
+	knoks, err := s.knokRepo.Search(context.Background(), &interaction.GuildID, query, nil, limit)
+	if err != nil {
+		s.logger.Error("Failed to search knoks",
+			"error", err,
+			"guild_id", interaction.GuildID,
+			"query", query,
+		)
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Couldn't search knoks, please try again later",
+			},
+		}
+	}
+
+	if len(knoks) == 0 {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("🔍 No knoks found for **%s**", truncateRunes(query, maxSearchTitle)),
+			},
+		}
+	}
+
+	if s.embedStyle(interaction.GuildID) == domain.EmbedStyleCards {
+		heading := fmt.Sprintf("🔍 **Search Results** for \"%s\"", truncateRunes(query, maxSearchTitle))
+		return knokCardsResponse(heading, 0xff9900, knoks)
+	}
+
+	// Embed field names can't hold links, so results go in the description as markdown links
+	lines := make([]string, 0, len(knoks))
+	for i, knok := range knoks {
+		lines = append(lines, fmt.Sprintf("%d. [%s](%s) - <t:%d:D>",
+			i+1, markdownLinkText.Replace(truncateRunes(knokTitle(knok), maxSearchTitle)), knok.URL, knok.PostedAt.Unix()))
+	}
+
+	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{
 				{
 					Title:       "🔍 Search Results",
 					Color:       0xff9900,
-					Description: fmt.Sprintf("Searching for: **%s**", query),
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:  "Status",
-							Value: "Coming soon... The bot will search through all knoks in this server.",
-						},
-					},
+					Description: truncateRunes(strings.Join(lines, "\n"), maxEmbedDescription),
 					Footer: &discordgo.MessageEmbedFooter{
-						Text: fmt.Sprintf("Limit: %d results", limit),
+						Text: truncateRunes(fmt.Sprintf("Results for \"%s\"", query), maxEmbedFooter),
 					},
 				},
 			},
 		},
 	}
+}
 
-	return response
+// markdownLinkText escapes brackets so a title can't end its markdown link early
+var markdownLinkText = strings.NewReplacer("[", "\\[", "]", "\\]")
+
+// knokTitle returns a knok's title, or its URL while it has none
+func knokTitle(knok *domain.Knok) string {
+	if knok.Title != nil && *knok.Title != "" {
+		return *knok.Title
+	}
+	return knok.URL
+}
+
+// embedStyle returns the embed_style setting for a guild, defaulting to compact
+func (s *BotService) embedStyle(guildID string) string {
+	if s.serverRepo == nil {
+		return domain.EmbedStyleCompact
+	}
+
+	server, err := s.serverRepo.GetByID(context.Background(), guildID)
+	if err != nil || server == nil {
+		return domain.EmbedStyleCompact
+	}
+
+	return server.EmbedStyle()
+}
+
+// knokCardsResponse lays knoks out in the cards embed style: one embed per knok, linking
+// its title and showing its thumbnail, under a heading. Discord allows 10 embeds per
+// message, so any further knoks are left out and the heading says so
+func knokCardsResponse(heading string, color int, knoks []*domain.Knok) *discordgo.InteractionResponse {
+	if len(knoks) > maxResponseEmbeds {
+		heading += fmt.Sprintf(" (first %d of %d)", maxResponseEmbeds, len(knoks))
+		knoks = knoks[:maxResponseEmbeds]
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(knoks))
+	for _, knok := range knoks {
+		embed := &discordgo.MessageEmbed{
+			Title:       truncateRunes(knokTitle(knok), maxEmbedTitle),
+			URL:         knok.URL,
+			Color:       color,
+			Description: fmt.Sprintf("Posted <t:%d:D>", knok.PostedAt.Unix()),
+		}
+		if knok.ThumbnailURL != nil && *knok.ThumbnailURL != "" {
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *knok.ThumbnailURL}
+		}
+		embeds = append(embeds, embed)
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: heading,
+			Embeds:  embeds,
+		},
+	}
+}
+
+// handleStatusCommand handles the /status command
+func (s *BotService) handleStatusCommand(interaction *discordgo.InteractionCreate) *discordgo.InteractionResponse {
+	var rawURL string
+	for _, option := range interaction.ApplicationCommandData().Options {
+		if option.Name == "url" {
+			if urlVal, ok := option.Value.(string); ok {
+				rawURL = urlVal
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please provide a URL",
+			},
+		}
+	}
+
+	normalizedURL, err := urldetector.NormalizeURL(rawURL)
+	if err != nil {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ That doesn't look like a valid URL",
+			},
+		}
+	}
+
+	knok := s.findKnokByURL(interaction.GuildID, normalizedURL)
+	if knok == nil {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🤷 That link hasn't been shared in this server yet",
+			},
+		}
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Status",
+			Value:  knok.ExtractionStatus,
+			Inline: true,
+		},
+	}
+
+	if method, ok := knok.Metadata["extraction_method"].(string); ok && method != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Method",
+			Value:  method,
+			Inline: true,
+		})
+	}
+
+	if knok.ExtractionStatus == domain.ExtractionStatusComplete && knok.Title != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Title",
+			Value: *knok.Title,
+		})
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "🔎 Link Status",
+					Color:       statusColor(knok.ExtractionStatus),
+					Description: knok.URL,
+					Fields:      fields,
+				},
+			},
+		},
+	}
+}
+
+// findKnokByURL looks up a knok in a server by its normalized URL, falling back to
+// the canonical form and then the platform item ID so share-link variants of the same
+// track still match
+func (s *BotService) findKnokByURL(guildID, normalizedURL string) *domain.Knok {
+	if s.knokRepo == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if knok, err := s.knokRepo.GetByURL(ctx, guildID, normalizedURL); err == nil && knok != nil {
+		return knok
+	}
+
+	canonicalURL, err := urldetector.CanonicalizeURL(normalizedURL)
+	if err != nil {
+		return nil
+	}
+	if knok, err := s.knokRepo.GetByCanonicalURL(ctx, guildID, canonicalURL); err == nil && knok != nil {
+		return knok
+	}
+
+	// Fall back to the platform item ID for variants that canonicalize differently
+	if s.urlDetector != nil {
+		for _, urlInfo := range s.urlDetector.DetectURLs(normalizedURL) {
+			if urlInfo.PlatformItemID == "" {
+				continue
+			}
+			if knok, err := s.knokRepo.GetByPlatformItemID(ctx, guildID, urlInfo.Platform, urlInfo.PlatformItemID); err == nil && knok != nil {
+				return knok
+			}
+		}
+	}
+
+	return nil
+}
+
+// statusColor returns the embed color for an extraction status
+func statusColor(status string) int {
+	switch status {
+	case domain.ExtractionStatusComplete:
+		return 0x00ff00
+	case domain.ExtractionStatusFailed:
+		return 0xff0000
+	default:
+		return 0xff9900
+	}
 }
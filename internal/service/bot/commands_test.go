@@ -0,0 +1,494 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// newTestCommand builds a slash command interaction in guild-1 with string options
+func newTestCommand(name string, options map[string]interface{}) *discordgo.InteractionCreate {
+	data := discordgo.ApplicationCommandInteractionData{Name: name}
+	for key, value := range options {
+		data.Options = append(data.Options, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  key,
+			Value: value,
+		})
+	}
+
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "guild-1",
+			Data:    data,
+		},
+	}
+}
+
+// embedField returns the value of the named field in the first embed of a response
+func embedField(response *discordgo.InteractionResponse, name string) string {
+	if response.Data == nil || len(response.Data.Embeds) == 0 {
+		return ""
+	}
+	for _, field := range response.Data.Embeds[0].Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+func TestCommandDefinitions(t *testing.T) {
+	defined := make(map[string]*discordgo.ApplicationCommand)
+	for _, command := range commands {
+		defined[command.Name] = command
+	}
+
+	// Every command onInteractionCreate dispatches must be registered with Discord
+	for _, name := range []string{"platforms", "recent", "stats", "search", "status"} {
+		if defined[name] == nil {
+			t.Errorf("/%s is handled but not registered", name)
+		}
+	}
+
+	tests := []struct {
+		command  string
+		option   string
+		required bool
+		min, max float64
+	}{
+		{"recent", "count", false, 1, 10},
+		{"search", "limit", false, 1, 25},
+	}
+	for _, tt := range tests {
+		command := defined[tt.command]
+		if command == nil {
+			continue
+		}
+		var option *discordgo.ApplicationCommandOption
+		for _, o := range command.Options {
+			if o.Name == tt.option {
+				option = o
+			}
+		}
+		if option == nil {
+			t.Errorf("/%s has no %s option", tt.command, tt.option)
+			continue
+		}
+		if option.Type != discordgo.ApplicationCommandOptionInteger || option.Required != tt.required {
+			t.Errorf("/%s %s = type %v, required %v, want an optional integer", tt.command, tt.option, option.Type, option.Required)
+		}
+		if option.MinValue == nil || *option.MinValue != tt.min || option.MaxValue != tt.max {
+			t.Errorf("/%s %s range = %v-%v, want %v-%v", tt.command, tt.option, option.MinValue, option.MaxValue, tt.min, tt.max)
+		}
+	}
+
+	if search := defined["search"]; search != nil {
+		if len(search.Options) == 0 || search.Options[0].Name != "query" || !search.Options[0].Required ||
+			search.Options[0].Type != discordgo.ApplicationCommandOptionString {
+			t.Errorf("/search options = %+v, want a required query string first", search.Options)
+		}
+	}
+}
+
+func TestHandleStatusCommand(t *testing.T) {
+	service, knokRepo, _ := newTestBotService(nil)
+
+	title := "Artist - Track"
+	knoks := []*domain.Knok{
+		{
+			ID:               uuid.New(),
+			ServerID:         "guild-1",
+			URL:              "https://soundcloud.com/artist/pending",
+			CanonicalURL:     "https://soundcloud.com/artist/pending",
+			ExtractionStatus: domain.ExtractionStatusPending,
+			PostedAt:         time.Now(),
+		},
+		{
+			ID:               uuid.New(),
+			ServerID:         "guild-1",
+			URL:              "https://soundcloud.com/artist/complete",
+			CanonicalURL:     "https://soundcloud.com/artist/complete",
+			Title:            &title,
+			Metadata:         map[string]interface{}{"extraction_method": "oembed"},
+			ExtractionStatus: domain.ExtractionStatusComplete,
+			PostedAt:         time.Now(),
+		},
+	}
+	for _, knok := range knoks {
+		knokRepo.Create(context.Background(), knok)
+	}
+
+	t.Run("Pending", func(t *testing.T) {
+		response := service.handleStatusCommand(newTestCommand("status", map[string]interface{}{
+			"url": "soundcloud.com/artist/pending",
+		}))
+		if got := embedField(response, "Status"); got != domain.ExtractionStatusPending {
+			t.Errorf("Status = %q, want %q", got, domain.ExtractionStatusPending)
+		}
+		if got := embedField(response, "Title"); got != "" {
+			t.Errorf("Title should be omitted while pending, got %q", got)
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		response := service.handleStatusCommand(newTestCommand("status", map[string]interface{}{
+			"url": "https://soundcloud.com/artist/complete?utm_source=share",
+		}))
+		if got := embedField(response, "Status"); got != domain.ExtractionStatusComplete {
+			t.Errorf("Status = %q, want %q", got, domain.ExtractionStatusComplete)
+		}
+		if got := embedField(response, "Method"); got != "oembed" {
+			t.Errorf("Method = %q, want %q", got, "oembed")
+		}
+		if got := embedField(response, "Title"); got != title {
+			t.Errorf("Title = %q, want %q", got, title)
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		response := service.handleStatusCommand(newTestCommand("status", map[string]interface{}{
+			"url": "https://soundcloud.com/artist/unknown",
+		}))
+		if len(response.Data.Embeds) != 0 || !strings.Contains(response.Data.Content, "hasn't been shared") {
+			t.Errorf("unexpected not-found response: %+v", response.Data)
+		}
+	})
+}
+
+func TestHandleRecentCommand(t *testing.T) {
+	service, knokRepo, _ := newTestBotService(nil)
+
+	t.Run("No knoks", func(t *testing.T) {
+		response := service.handleRecentCommand(newTestCommand("recent", nil))
+		if len(response.Data.Embeds) != 0 || !strings.Contains(response.Data.Content, "No knoks yet") {
+			t.Errorf("unexpected empty response: %+v", response.Data)
+		}
+	})
+
+	now := time.Now()
+	for i := 0; i < 12; i++ {
+		title := fmt.Sprintf("Track %d", i)
+		knok := &domain.Knok{
+			ID:               uuid.New(),
+			ServerID:         "guild-1",
+			URL:              fmt.Sprintf("https://soundcloud.com/artist/track-%d", i),
+			ExtractionStatus: domain.ExtractionStatusComplete,
+			PostedAt:         now.Add(-time.Duration(i) * time.Minute),
+		}
+		// Knoks still being extracted fall back to their URL
+		if i != 1 {
+			knok.Title = &title
+		}
+		knokRepo.Create(context.Background(), knok)
+	}
+	knokRepo.Create(context.Background(), &domain.Knok{
+		ID:       uuid.New(),
+		ServerID: "guild-2",
+		URL:      "https://soundcloud.com/other/track",
+		PostedAt: now.Add(time.Minute),
+	})
+
+	tests := []struct {
+		name      string
+		options   map[string]interface{}
+		wantCount int
+	}{
+		{"Default count", nil, 5},
+		{"Requested count", map[string]interface{}{"count": float64(3)}, 3},
+		{"Count capped at 10", map[string]interface{}{"count": float64(25)}, 10},
+		{"Count of at least 1", map[string]interface{}{"count": float64(0)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := service.handleRecentCommand(newTestCommand("recent", tt.options))
+			if len(response.Data.Embeds) != 1 {
+				t.Fatalf("response = %+v, want one embed", response.Data)
+			}
+			fields := response.Data.Embeds[0].Fields
+			if len(fields) != tt.wantCount {
+				t.Fatalf("%d fields, want %d", len(fields), tt.wantCount)
+			}
+			if fields[0].Name != "Track 0" || !strings.Contains(fields[0].Value, "https://soundcloud.com/artist/track-0") {
+				t.Errorf("first field = %+v, want the newest knok", fields[0])
+			}
+			if !strings.Contains(fields[0].Value, fmt.Sprintf("<t:%d:D>", now.Unix())) {
+				t.Errorf("first field = %q, want its posted date", fields[0].Value)
+			}
+			if len(fields) > 1 && fields[1].Name != "https://soundcloud.com/artist/track-1" {
+				t.Errorf("untitled knok field name = %q, want its URL", fields[1].Name)
+			}
+		})
+	}
+}
+
+func TestListingCommandEmbedStyles(t *testing.T) {
+	// The same twelve knoks, newest first: the newest has a thumbnail and the next has no title yet
+	now := time.Now()
+	thumbnail := "https://example.com/cover.jpg"
+	knoks := make([]*domain.Knok, 12)
+	for i := range knoks {
+		title := fmt.Sprintf("Ambient Mix %d", i)
+		knoks[i] = &domain.Knok{
+			ID:       uuid.New(),
+			ServerID: "guild-1",
+			URL:      fmt.Sprintf("https://soundcloud.com/artist/mix-%d", i),
+			Title:    &title,
+			PostedAt: now.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	knoks[0].ThumbnailURL = &thumbnail
+	knoks[1].Title = nil
+
+	recent := newTestCommand("recent", map[string]interface{}{"count": float64(10)})
+	search := newTestCommand("search", map[string]interface{}{"query": "ambient", "limit": float64(25)})
+
+	compactStyles := []struct {
+		name  string
+		style string
+	}{
+		{"Unset", ""},
+		{"Compact", domain.EmbedStyleCompact},
+		{"Invalid style falls back to compact", "grid"},
+	}
+	for _, tt := range compactStyles {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &domain.Server{ID: "guild-1", Settings: map[string]interface{}{}}
+			if tt.style != "" {
+				server.Settings["embed_style"] = tt.style
+			}
+			service, knokRepo, _ := newTestBotService(nil, server)
+			for _, knok := range knoks {
+				knokRepo.Create(context.Background(), knok)
+			}
+
+			response := service.handleRecentCommand(recent)
+			if len(response.Data.Embeds) != 1 || len(response.Data.Embeds[0].Fields) != 10 {
+				t.Fatalf("/recent = %+v, want one embed with 10 fields", response.Data)
+			}
+			if got := response.Data.Embeds[0].Fields[0].Name; got != "Ambient Mix 0" {
+				t.Errorf("/recent first field = %q, want Ambient Mix 0", got)
+			}
+
+			// The untitled knok doesn't match the search
+			response = service.handleSearchCommand(search)
+			if len(response.Data.Embeds) != 1 {
+				t.Fatalf("/search = %+v, want one embed", response.Data)
+			}
+			if lines := strings.Split(response.Data.Embeds[0].Description, "\n"); len(lines) != 11 {
+				t.Errorf("/search listed %d results, want 11", len(lines))
+			}
+		})
+	}
+
+	t.Run("Cards", func(t *testing.T) {
+		service, knokRepo, _ := newTestBotService(nil, &domain.Server{
+			ID:       "guild-1",
+			Settings: map[string]interface{}{"embed_style": domain.EmbedStyleCards},
+		})
+		for _, knok := range knoks {
+			knokRepo.Create(context.Background(), knok)
+		}
+
+		response := service.handleRecentCommand(recent)
+		if response.Data.Content != "🎵 **Recent Music Knoks**" {
+			t.Errorf("/recent heading = %q", response.Data.Content)
+		}
+		embeds := response.Data.Embeds
+		if len(embeds) != 10 {
+			t.Fatalf("/recent sent %d embeds, want one per knok", len(embeds))
+		}
+		first := embeds[0]
+		if first.Title != "Ambient Mix 0" || first.URL != knoks[0].URL || first.Color != 0x00ff00 {
+			t.Errorf("first card = %+v, want the newest knok linked", first)
+		}
+		if first.Thumbnail == nil || first.Thumbnail.URL != thumbnail {
+			t.Errorf("first card thumbnail = %+v, want %s", first.Thumbnail, thumbnail)
+		}
+		if want := fmt.Sprintf("<t:%d:D>", now.Unix()); !strings.Contains(first.Description, want) {
+			t.Errorf("first card description = %q, want its posted date", first.Description)
+		}
+		if embeds[1].Title != knoks[1].URL || embeds[1].Thumbnail != nil {
+			t.Errorf("untitled card = %+v, want its URL as title and no thumbnail", embeds[1])
+		}
+
+		// Discord allows ten embeds per message, so the eleventh result is left out
+		response = service.handleSearchCommand(search)
+		if want := "🔍 **Search Results** for \"ambient\" (first 10 of 11)"; response.Data.Content != want {
+			t.Errorf("/search heading = %q, want %q", response.Data.Content, want)
+		}
+		if len(response.Data.Embeds) != 10 || response.Data.Embeds[0].Title != "Ambient Mix 0" || response.Data.Embeds[0].Color != 0xff9900 {
+			t.Errorf("/search cards = %+v, want the ten newest matches", response.Data.Embeds)
+		}
+	})
+}
+
+func TestCommandResponseEphemeral(t *testing.T) {
+	service, _, _ := newTestBotService(nil,
+		&domain.Server{ID: "guild-1", Settings: map[string]interface{}{
+			// Settings read back from the database hold lists as []interface{}
+			"ephemeral_commands": []interface{}{"search", "status"},
+		}},
+		&domain.Server{ID: "guild-2", Settings: map[string]interface{}{}},
+	)
+
+	tests := []struct {
+		name          string
+		guildID       string
+		command       string
+		options       map[string]interface{}
+		wantEphemeral bool
+	}{
+		{"Listed command", "guild-1", "search", map[string]interface{}{"query": "ambient"}, true},
+		{"Another listed command", "guild-1", "status", map[string]interface{}{"url": "https://soundcloud.com/artist/track"}, true},
+		{"Unlisted command", "guild-1", "recent", nil, false},
+		{"Server without the setting", "guild-2", "search", map[string]interface{}{"query": "ambient"}, false},
+		{"Unknown server", "guild-3", "search", map[string]interface{}{"query": "ambient"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interaction := newTestCommand(tt.command, tt.options)
+			interaction.GuildID = tt.guildID
+
+			response := service.commandResponse(interaction)
+			if got := response.Data.Flags&discordgo.MessageFlagsEphemeral != 0; got != tt.wantEphemeral {
+				t.Errorf("ephemeral = %v, want %v (flags %d)", got, tt.wantEphemeral, response.Data.Flags)
+			}
+		})
+	}
+}
+
+func TestHandleStatsCommand(t *testing.T) {
+	service, knokRepo, _ := newTestBotService(nil)
+
+	response := service.handleStatsCommand(newTestCommand("stats", nil))
+	if len(response.Data.Embeds) != 0 || !strings.Contains(response.Data.Content, "No knoks yet") {
+		t.Errorf("unexpected empty response: %+v", response.Data)
+	}
+
+	now := time.Now()
+	add := func(serverID, platform, status string, postedAt time.Time) {
+		knokRepo.Create(context.Background(), &domain.Knok{
+			ID:               uuid.New(),
+			ServerID:         serverID,
+			URL:              "https://example.com/" + uuid.NewString(),
+			Platform:         platform,
+			ExtractionStatus: status,
+			PostedAt:         postedAt,
+		})
+	}
+	for i, platform := range []string{"youtube", "youtube", "youtube", "spotify", "spotify", "soundcloud", "bandcamp"} {
+		add("guild-1", platform, domain.ExtractionStatusComplete, now.Add(-time.Duration(i+1)*time.Hour))
+	}
+	// Pending knoks and other servers' knoks aren't counted
+	add("guild-1", "bandcamp", domain.ExtractionStatusPending, now)
+	add("guild-1", "bandcamp", domain.ExtractionStatusPending, now)
+	add("guild-2", "mixcloud", domain.ExtractionStatusComplete, now)
+
+	response = service.handleStatsCommand(newTestCommand("stats", nil))
+	if got := embedField(response, "Total Knoks"); got != "7" {
+		t.Errorf("Total Knoks = %q, want 7", got)
+	}
+	if got, want := embedField(response, "Most Active Platform"), "1. youtube - 3\n2. spotify - 2\n3. bandcamp - 1"; got != want {
+		t.Errorf("Most Active Platform = %q, want %q", got, want)
+	}
+	if got, want := embedField(response, "Recent Activity"), fmt.Sprintf("<t:%d:R>", now.Add(-time.Hour).Unix()); !strings.Contains(got, want) {
+		t.Errorf("Recent Activity = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestHandleSearchCommand(t *testing.T) {
+	service, knokRepo, _ := newTestBotService(nil)
+
+	now := time.Now()
+	add := func(serverID, title string, postedAt time.Time) {
+		knokRepo.Create(context.Background(), &domain.Knok{
+			ID:       uuid.New(),
+			ServerID: serverID,
+			URL:      "https://soundcloud.com/artist/" + uuid.NewString(),
+			Title:    &title,
+			PostedAt: postedAt,
+		})
+	}
+	for i := 0; i < 30; i++ {
+		add("guild-1", fmt.Sprintf("Ambient Mix %d", i), now.Add(-time.Duration(i)*time.Minute))
+	}
+	add("guild-1", "[Live] Ambient "+strings.Repeat("x", 150), now.Add(time.Minute))
+	add("guild-2", "Ambient elsewhere", now.Add(time.Hour))
+
+	t.Run("Missing query", func(t *testing.T) {
+		response := service.handleSearchCommand(newTestCommand("search", map[string]interface{}{"query": "  "}))
+		if !strings.Contains(response.Data.Content, "Please provide a search query") {
+			t.Errorf("response = %+v, want a missing query message", response.Data)
+		}
+	})
+
+	t.Run("No results", func(t *testing.T) {
+		response := service.handleSearchCommand(newTestCommand("search", map[string]interface{}{"query": "techno"}))
+		if len(response.Data.Embeds) != 0 || !strings.Contains(response.Data.Content, "No knoks found for **techno**") {
+			t.Errorf("response = %+v, want a no results message", response.Data)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		options   map[string]interface{}
+		wantLines int
+	}{
+		{"Default limit", map[string]interface{}{"query": "ambient"}, 5},
+		{"Requested limit", map[string]interface{}{"query": "ambient", "limit": float64(2)}, 2},
+		{"Limit capped at 25", map[string]interface{}{"query": "ambient", "limit": float64(50)}, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := service.handleSearchCommand(newTestCommand("search", tt.options))
+			if len(response.Data.Embeds) != 1 {
+				t.Fatalf("response = %+v, want one embed", response.Data)
+			}
+			lines := strings.Split(response.Data.Embeds[0].Description, "\n")
+			if len(lines) != tt.wantLines {
+				t.Fatalf("%d results, want %d", len(lines), tt.wantLines)
+			}
+
+			// The newest result is this server's long title, escaped and cut to 100 characters
+			wantTitle := "\\[Live\\] Ambient " + strings.Repeat("x", 100-len("[Live] Ambient ")-1) + "…"
+			if !strings.HasPrefix(lines[0], "1. ["+wantTitle+"](https://soundcloud.com/artist/") {
+				t.Errorf("first result = %q, want a link titled %q", lines[0], wantTitle)
+			}
+			if len(lines) > 1 && !strings.HasPrefix(lines[1], "2. [Ambient Mix 0](") {
+				t.Errorf("second result = %q, want the next newest knok", lines[1])
+			}
+		})
+	}
+}
+
+func TestHandlePlatformsCommand(t *testing.T) {
+	service, _, _ := newTestBotService(nil)
+
+	response := service.handlePlatformsCommand()
+	if len(response.Data.Embeds) != 1 {
+		t.Fatalf("response = %+v, want one embed", response.Data)
+	}
+	embed := response.Data.Embeds[0]
+
+	platforms, _ := (&fakePlatformLoader{}).GetAllByPriority()
+	lines := strings.Split(embed.Description, "\n")
+	if len(lines) != len(platforms) {
+		t.Fatalf("%d platforms listed, want %d", len(lines), len(platforms))
+	}
+	for i, platform := range platforms {
+		if lines[i] != "• "+platform.Name {
+			t.Errorf("line %d = %q, want %q in priority order", i, lines[i], platform.Name)
+		}
+	}
+	if want := fmt.Sprintf("%d platforms", len(platforms)); !strings.HasPrefix(embed.Footer.Text, want) {
+		t.Errorf("footer = %q, want it to start with %q", embed.Footer.Text, want)
+	}
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+// closeDisallowedIntents is the gateway close code for a privileged intent the bot hasn't
+// been granted in the Discord Developer Portal
+const closeDisallowedIntents = 4014
+
+// gatewayIntents returns the intents the bot identifies with. Message content is a
+// privileged intent, so it's only requested when configured
+func gatewayIntents(messageContent bool) discordgo.Intent {
+	intents := discordgo.IntentsAllWithoutPrivileged
+	if messageContent {
+		intents |= discordgo.IntentMessageContent
+	}
+	return intents
+}
+
+// isDisallowedIntentsError reports whether the gateway refused the connection because a
+// requested privileged intent isn't enabled for the bot
+func isDisallowedIntentsError(err error) bool {
+	var closeErr *websocket.CloseError
+	return errors.As(err, &closeErr) && closeErr.Code == closeDisallowedIntents
+}
+
+// hasNoContent reports whether a message arrived with nothing in it. Without the Message
+// Content Intent Discord strips content, embeds, attachments and stickers alike, so a
+// message with none of them is what a missing intent looks like
+func hasNoContent(message *discordgo.Message) bool {
+	return message.Content == "" && len(message.Embeds) == 0 &&
+		len(message.Attachments) == 0 && len(message.StickerItems) == 0
+}
+
+// observeMessageContent tracks guild messages from users arriving without content. After
+// DiscordEmptyContentWarnAfter in a row the intent is assumed missing: an error is logged
+// once and HealthCheck fails until a message with content arrives
+func (s *BotService) observeMessageContent(message *discordgo.Message) {
+	threshold := s.config.DiscordEmptyContentWarnAfter
+	if threshold <= 0 || message.GuildID == "" || message.Author == nil || message.Author.Bot {
+		return
+	}
+
+	s.emptyContentMu.Lock()
+	defer s.emptyContentMu.Unlock()
+
+	if !hasNoContent(message) {
+		s.consecutiveEmptyContent = 0
+		if s.messageContentMissing.Swap(false) {
+			s.logger.Info("Message content is arriving again")
+		}
+		return
+	}
+
+	s.consecutiveEmptyContent++
+	if s.consecutiveEmptyContent >= threshold && !s.messageContentMissing.Swap(true) {
+		s.logger.Error("MESSAGE CONTENT INTENT MISSING: every recent message arrived empty, so no links can be detected. "+
+			"Enable the Message Content Intent for the bot in the Discord Developer Portal (Bot > Privileged Gateway Intents) "+
+			"and make sure DISCORD_MESSAGE_CONTENT_INTENT is true",
+			"consecutive_empty_messages", s.consecutiveEmptyContent,
+		)
+	}
+}
+
+// HealthCheck reports whether the bot is connected and receiving message content
+func (s *BotService) HealthCheck() error {
+	if s.ctx.Err() != nil {
+		return fmt.Errorf("bot context cancelled: %w", s.ctx.Err())
+	}
+	if !s.ready.Load() {
+		return errors.New("bot has not received a Ready event from Discord")
+	}
+	if s.messageContentMissing.Load() {
+		return errors.New("messages are arriving without content; the Message Content Intent is likely not enabled")
+	}
+	return nil
+}
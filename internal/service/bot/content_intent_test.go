@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"knock-fm/internal/config"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+func TestObserveMessageContentWarnsWhenContentIsMissing(t *testing.T) {
+	service, _, _ := newTestBotService(&config.Config{DiscordEmptyContentWarnAfter: 3})
+	var logs bytes.Buffer
+	service.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	service.ready.Store(true)
+
+	user := &discordgo.User{ID: "user-1"}
+	warnings := func() int { return strings.Count(logs.String(), "MESSAGE CONTENT INTENT MISSING") }
+
+	for i := 0; i < 2; i++ {
+		service.observeMessageContent(newTestMessage(fmt.Sprint(i), user, "").Message)
+	}
+	if warnings() != 0 {
+		t.Fatal("warned before the threshold was reached")
+	}
+	if err := service.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck() before the threshold = %v, want nil", err)
+	}
+
+	// Messages from bots and DMs don't count either way
+	service.observeMessageContent(newTestMessage("bot", &discordgo.User{ID: "bot-1", Bot: true}, "has content").Message)
+	dm := newTestMessage("dm", user, "has content").Message
+	dm.GuildID = ""
+	service.observeMessageContent(dm)
+
+	for i := 2; i < 6; i++ {
+		service.observeMessageContent(newTestMessage(fmt.Sprint(i), user, "").Message)
+	}
+	if warnings() != 1 {
+		t.Errorf("logged %d warnings, want exactly 1", warnings())
+	}
+	if err := service.HealthCheck(); err == nil {
+		t.Error("HealthCheck() = nil while content is missing, want an error")
+	}
+
+	// Content arriving again clears the warning state
+	service.observeMessageContent(newTestMessage("6", user, "https://example.com").Message)
+	if err := service.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck() after content arrived = %v, want nil", err)
+	}
+}
+
+func TestObserveMessageContentIgnoresAttachmentOnlyMessages(t *testing.T) {
+	service, _, _ := newTestBotService(&config.Config{DiscordEmptyContentWarnAfter: 1})
+	service.ready.Store(true)
+
+	message := newTestMessage("1", &discordgo.User{ID: "user-1"}, "").Message
+	message.Attachments = []*discordgo.MessageAttachment{{ID: "a", Filename: "song.mp3"}}
+	service.observeMessageContent(message)
+
+	if err := service.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck() after an attachment-only message = %v, want nil", err)
+	}
+}
+
+func TestIsDisallowedIntentsError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Disallowed intents", &websocket.CloseError{Code: 4014, Text: "Disallowed intent(s)."}, true},
+		{"Wrapped", fmt.Errorf("open: %w", &websocket.CloseError{Code: 4014}), true},
+		{"Authentication failed", &websocket.CloseError{Code: 4004}, false},
+		{"Other error", fmt.Errorf("dial tcp: connection refused"), false},
+		{"No error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDisallowedIntentsError(tt.err); got != tt.want {
+				t.Errorf("isDisallowedIntentsError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatewayIntents(t *testing.T) {
+	if gatewayIntents(true)&discordgo.IntentMessageContent == 0 {
+		t.Error("gatewayIntents(true) should request message content")
+	}
+	if gatewayIntents(false)&discordgo.IntentMessageContent != 0 {
+		t.Error("gatewayIntents(false) should not request message content")
+	}
+}
@@ -3,12 +3,16 @@ package bot
 import (
 	"context"
 	"fmt"
+	"knock-fm/internal/config"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/tracing"
 	"knock-fm/internal/pkg/urldetector"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 
@@ -30,12 +34,102 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 		"timestamp", message.Timestamp.UnixNano(),
 	)
 
-	// Ignore bot messages
-	if message.Author.Bot {
-		s.logger.Info("HANDLER_EXIT: Ignoring bot message", "handler_id", handlerID, "author_id", message.Author.ID)
+	// Don't process messages until the first Ready event has been handled
+	if !s.ready.Load() {
+		s.logger.Info("HANDLER_EXIT: Bot not ready yet", "handler_id", handlerID)
+		return
+	}
+
+	s.observeMessageContent(message.Message)
+
+	knoksCreated := s.processMessage(message, handlerID)
+	if knoksCreated == 0 {
 		return
 	}
 
+	s.logger.Info("HANDLER_EXIT: Processing completed successfully",
+		"handler_id", handlerID,
+		"knoks_created", knoksCreated,
+	)
+
+	s.sendFeedback(session, message, handlerID)
+}
+
+// reactionAdder is the part of the Discord session used to give feedback on tracked messages
+type reactionAdder interface {
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error
+}
+
+// sendFeedback acknowledges a tracked message according to the server's notification_mode.
+// Only reaction mode gives immediate feedback; reply and thread modes are handled by the
+// worker once metadata extraction completes, and silent mode gives none.
+func (s *BotService) sendFeedback(discord reactionAdder, message *discordgo.MessageCreate, handlerID string) {
+	mode := s.notificationMode(message.GuildID)
+	if mode != domain.NotificationModeReaction {
+		s.logger.Info("Skipping reaction for notification mode",
+			"handler_id", handlerID,
+			"notification_mode", mode,
+		)
+		return
+	}
+
+	// Add emoji reaction to give user feedback
+	s.logger.Info("Attempting to add reaction emoji",
+		"handler_id", handlerID,
+		"channel_id", message.ChannelID,
+		"message_id", message.ID,
+	)
+	if err := discord.MessageReactionAdd(message.ChannelID, message.ID, "🎵"); err != nil {
+		s.logger.Error("Failed to add emoji reaction - check bot permissions",
+			"error", err,
+			"message_id", message.ID,
+			"channel_id", message.ChannelID,
+			"guild_id", message.GuildID,
+		)
+	} else {
+		s.logger.Info("Successfully added reaction emoji",
+			"handler_id", handlerID,
+			"message_id", message.ID,
+		)
+	}
+}
+
+// notificationMode returns the notification_mode setting for a guild, defaulting to reaction
+func (s *BotService) notificationMode(guildID string) string {
+	if s.serverRepo == nil {
+		return domain.NotificationModeReaction
+	}
+
+	server, err := s.serverRepo.GetByID(context.Background(), guildID)
+	if err != nil || server == nil {
+		return domain.NotificationModeReaction
+	}
+
+	return server.NotificationMode()
+}
+
+// processMessage runs the guild/channel/author checks for a message, detects URLs and
+// creates knoks for them. Returns the number of URLs that were processed successfully.
+func (s *BotService) processMessage(message *discordgo.MessageCreate, handlerID string) int {
+	// Ignore bot messages unless the server has opted in to tracking them
+	if message.Author.Bot && !s.isBotAuthorAllowed(message) {
+		s.logger.Info("HANDLER_EXIT: Ignoring bot message", "handler_id", handlerID, "author_id", message.Author.ID)
+		return 0
+	}
+
+	// Ignore guilds that belong to another shard (Discord routes these elsewhere,
+	// this guards against misconfigured shard counts across processes)
+	if s.config.DiscordShardCount > 1 && message.GuildID != "" &&
+		config.ShardForGuild(message.GuildID, s.config.DiscordShardCount) != s.config.DiscordShardID {
+		s.logger.Info("HANDLER_EXIT: Guild belongs to another shard",
+			"handler_id", handlerID,
+			"guild_id", message.GuildID,
+			"shard_id", s.config.DiscordShardID,
+			"shard_count", s.config.DiscordShardCount,
+		)
+		return 0
+	}
+
 	// Check global guild (server) restrictions from environment
 	if len(s.config.DiscordAllowedGuilds) > 0 {
 		allowed := false
@@ -51,7 +145,7 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 				"guild_id", message.GuildID,
 				"allowed_guilds", s.config.DiscordAllowedGuilds,
 			)
-			return
+			return 0
 		}
 		s.logger.Info("Guild check passed", "handler_id", handlerID, "guild_id", message.GuildID)
 	}
@@ -71,7 +165,7 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 				"channel_id", message.ChannelID,
 				"allowed_channels", s.config.DiscordAllowedChannels,
 			)
-			return
+			return 0
 		}
 		s.logger.Info("Channel check passed", "handler_id", handlerID, "channel_id", message.ChannelID)
 	}
@@ -95,7 +189,7 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 						"channel_id", message.ChannelID,
 						"allowed_channels", allowedChannels,
 					)
-					return
+					return 0
 				}
 				s.logger.Info("Database channel check passed",
 					"handler_id", handlerID,
@@ -105,17 +199,29 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 		}
 	}
 
-	// Check if message contains any supported URLs
-	s.logger.Info("🔍 RAW MESSAGE CONTENT from Discord",
-		"handler_id", handlerID,
-		"message_content", message.Content,
-		"content_length", len(message.Content))
+	// Check if message contains any supported URLs. The content itself is only logged when
+	// the retention policy keeps all of it
+	contentAttrs := []any{"handler_id", handlerID, "content_length", len(message.Content)}
+	if policy := s.config.MessageContentRetention; policy == "" || policy == domain.MessageContentRetentionFull {
+		contentAttrs = append(contentAttrs, "message_content", message.Content)
+	}
+	s.logger.Info("🔍 RAW MESSAGE CONTENT from Discord", contentAttrs...)
 
 	urls := s.extractURLs(message.Content)
 	if len(urls) == 0 {
 		s.logger.Debug("HANDLER_EXIT: No URLs found",
 			"handler_id", handlerID)
-		return
+		return 0
+	}
+
+	// Skip links buried in conversation if the server only tracks intentional shares
+	if !s.isDominantLink(message) {
+		s.logger.Info("HANDLER_EXIT: Link is not the dominant content of the message",
+			"handler_id", handlerID,
+			"message_id", message.ID,
+			"guild_id", message.GuildID,
+		)
+		return 0
 	}
 
 	s.logger.Debug("EXTRACTED_URLS: Found URLs",
@@ -131,6 +237,21 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 		"urls", urls,
 	)
 
+	// Each knok's lifecycle trace starts here and follows its job through the queue
+	ctx, span := tracing.Tracer().Start(context.Background(), "bot.ingest_message", trace.WithAttributes(
+		attribute.String("discord.message_id", message.ID),
+		attribute.String("discord.guild_id", message.GuildID),
+		attribute.Int("url_count", len(urls)),
+	))
+	defer span.End()
+
+	// With batching enabled, collect the extraction jobs for a multi-link message and
+	// queue them together so the worker can share one browser and HTTP client
+	var batch *[]map[string]interface{}
+	if s.config.BatchMetadataExtraction && len(urls) > 1 {
+		batch = &[]map[string]interface{}{}
+	}
+
 	// Process each detected URL
 	knoksCreated := 0
 	for i, urlInfo := range urls {
@@ -141,7 +262,7 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 			"platform", urlInfo.Platform,
 		)
 
-		if err := s.processDetectedURL(message, urlInfo); err != nil {
+		if err := s.processDetectedURL(ctx, message, urlInfo, batch); err != nil {
 			s.logger.Error("Failed to process URL",
 				"error", err,
 				"url", urlInfo.URL,
@@ -152,44 +273,40 @@ func (s *BotService) onMessageCreate(session *discordgo.Session, message *discor
 		}
 	}
 
+	if batch != nil && len(*batch) > 0 {
+		if err := s.queueExtractionBatch(ctx, message, *batch); err != nil {
+			s.logger.Error("Failed to process URL batch",
+				"error", err,
+				"message_id", message.ID,
+				"batch_size", len(*batch),
+			)
+			knoksCreated -= len(*batch)
+			for _, item := range *batch {
+				if url, ok := item["url"].(string); ok {
+					s.releaseMessageURL(ctx, message.ID, url)
+				}
+			}
+		}
+	}
+
 	if knoksCreated > 0 {
 		s.logger.Info("Successfully processed music URLs",
 			"message_id", message.ID,
 			"guild_id", message.GuildID,
 			"knoks_created", knoksCreated,
 		)
-
-		s.logger.Info("HANDLER_EXIT: Processing completed successfully",
-			"handler_id", handlerID,
-			"knoks_created", knoksCreated,
-		)
-
-		// Add emoji reaction to give user feedback
-		s.logger.Info("Attempting to add reaction emoji",
-			"handler_id", handlerID,
-			"channel_id", message.ChannelID,
-			"message_id", message.ID,
-		)
-		if err := session.MessageReactionAdd(message.ChannelID, message.ID, "🎵"); err != nil {
-			s.logger.Error("Failed to add emoji reaction - check bot permissions",
-				"error", err,
-				"message_id", message.ID,
-				"channel_id", message.ChannelID,
-				"guild_id", message.GuildID,
-			)
-		} else {
-			s.logger.Info("Successfully added reaction emoji",
-				"handler_id", handlerID,
-				"message_id", message.ID,
-			)
-		}
 	}
+
+	return knoksCreated
 }
 
-// processDetectedURL creates knok records and queues metadata extraction jobs
-func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInfo urldetector.URLInfo) error {
-	ctx := context.Background()
+// messageClaimTTL is how long a message's URL is remembered as handled, long enough to
+// catch duplicate handler invocations and Discord re-delivering the message
+const messageClaimTTL = 5 * time.Minute
 
+// processDetectedURL creates knok records and queues metadata extraction jobs.
+// If batch is non-nil the job payload is appended to it instead of being queued.
+func (s *BotService) processDetectedURL(ctx context.Context, message *discordgo.MessageCreate, urlInfo urldetector.URLInfo, batch *[]map[string]interface{}) (retErr error) {
 	// DEBUG: Track processDetectedURL invocations
 	processID := fmt.Sprintf("PROCESS_%d_%s", time.Now().UnixNano(), urlInfo.URL[len(urlInfo.URL)-8:])
 	s.logger.Info("🔍 PROCESS_ENTRY: processDetectedURL called",
@@ -200,6 +317,32 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 		"goroutine_id", fmt.Sprintf("%p", &ctx), // Unique per goroutine
 	)
 
+	// Skip a message URL that's already being handled before any database lookups. If
+	// Redis is unavailable, the database dedup below still applies
+	claimed, err := s.queueRepo.ClaimMessageURL(ctx, message.ID, urlInfo.URL, messageClaimTTL)
+	if err != nil {
+		s.logger.Warn("Failed to claim message URL, continuing without the fast-path dedup",
+			"error", err,
+			"message_id", message.ID,
+			"url", urlInfo.URL,
+		)
+	} else if !claimed {
+		s.logger.Info("Skipping message URL that is already being handled",
+			"process_id", processID,
+			"message_id", message.ID,
+			"url", urlInfo.URL,
+		)
+		return nil
+	}
+	if claimed {
+		// A URL that failed wasn't handled, so let a redelivery of the message retry it
+		defer func() {
+			if retErr != nil {
+				s.releaseMessageURL(ctx, message.ID, urlInfo.URL)
+			}
+		}()
+	}
+
 	// Check if platform is unknown and handle according to server settings
 	if urlInfo.Platform == domain.PlatformUnknown {
 		// Get unknown platform mode (server override or global default)
@@ -244,11 +387,13 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 	var knokID uuid.UUID
 	var existingKnok *domain.Knok
 
-	// Check for existing knok by Discord message ID
+	// Check for existing knok by Discord message ID. A message can hold several links,
+	// so only reuse the knok if it is for this URL
 	if s.knokRepo != nil {
-		existingKnok, err := s.knokRepo.GetByDiscordMessage(ctx, message.ID)
-		if err == nil && existingKnok != nil {
+		found, err := s.knokRepo.GetByDiscordMessage(ctx, message.ID)
+		if err == nil && found != nil && found.URL == urlInfo.URL {
 			// Use existing knok ID
+			existingKnok = found
 			knokID = existingKnok.ID
 			s.logger.Debug("Found existing knok by Discord message",
 				"existing_knok_id", existingKnok.ID,
@@ -259,9 +404,10 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 
 	// Check for existing knok with same canonical URL in this server
 	if existingKnok == nil && s.knokRepo != nil {
-		existingKnok, err := s.knokRepo.GetByCanonicalURL(ctx, message.GuildID, urlInfo.CanonicalURL)
-		if err == nil && existingKnok != nil {
+		found, err := s.knokRepo.GetByCanonicalURL(ctx, message.GuildID, urlInfo.CanonicalURL)
+		if err == nil && found != nil {
 			// Use existing knok ID
+			existingKnok = found
 			knokID = existingKnok.ID
 			s.logger.Debug("Found existing knok by canonical URL",
 				"knok_id", knokID,
@@ -271,6 +417,21 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 		}
 	}
 
+	// Fall back to the platform item ID so other URL variants of the same item are deduped
+	if knokID == uuid.Nil && urlInfo.PlatformItemID != "" && s.knokRepo != nil {
+		found, err := s.knokRepo.GetByPlatformItemID(ctx, message.GuildID, urlInfo.Platform, urlInfo.PlatformItemID)
+		if err == nil && found != nil {
+			// Use existing knok ID
+			existingKnok = found
+			knokID = existingKnok.ID
+			s.logger.Debug("Found existing knok by platform item ID",
+				"knok_id", knokID,
+				"platform_item_id", urlInfo.PlatformItemID,
+				"extraction_status", existingKnok.ExtractionStatus,
+			)
+		}
+	}
+
 	// Generate new knok ID only if we don't have an existing one
 	if knokID == uuid.Nil {
 		knokID = uuid.New()
@@ -288,12 +449,18 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 		"discord_channel_id": message.ChannelID,
 		"discord_guild_id":   message.GuildID,
 		"discord_user_id":    message.Author.ID,
-		"message_content":    message.Content,
+	}
+	if content := domain.QueuedMessageContent(s.config.MessageContentRetention, message.Content); content != nil {
+		jobPayload["message_content"] = *content
 	}
 
 	// Ensure server exists in database before creating knok
+	autoExtraction := true
 	if s.serverRepo != nil {
 		server, err := s.serverRepo.GetByID(ctx, message.GuildID)
+		if err == nil && server != nil {
+			autoExtraction = server.AutoExtractionEnabled()
+		}
 		if err != nil {
 			s.logger.Warn("Server not found in database, creating basic record",
 				"guild_id", message.GuildID,
@@ -323,11 +490,15 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 			Platform:         urlInfo.Platform,
 			DiscordMessageID: message.ID,
 			DiscordChannelID: message.ChannelID,
-			MessageContent:   &message.Content,
+			MessageContent:   domain.RetainedMessageContent(s.config.MessageContentRetention, message.Content),
+			DiscordUserID:    &message.Author.ID,
 			ExtractionStatus: domain.ExtractionStatusPending,
 			PostedAt:         now,
 			CreatedAt:        now,
 		}
+		if urlInfo.PlatformItemID != "" {
+			knok.PlatformItemID = &urlInfo.PlatformItemID
+		}
 
 		// Store knok in database
 		if err := s.knokRepo.Create(ctx, knok); err != nil {
@@ -396,8 +567,18 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 				"knok_id", knokID,
 				"url", urlInfo.URL,
 			)
-		case domain.ExtractionStatusPending, domain.ExtractionStatusFailed:
-			// Should re-process pending or failed extractions
+		case domain.ExtractionStatusPending, domain.ExtractionStatusFailed, domain.ExtractionStatusLowQuality:
+			// Should re-process pending, failed or low quality extractions, unless the knok
+			// keeps failing and is cooling down
+			if until, cooling := s.config.ExtractionRetryBudget.CooldownUntil(existingKnok, time.Now()); cooling {
+				shouldQueueJob = false
+				s.logger.Info("Knok extraction keeps failing, skipping job queue until cooldown ends",
+					"knok_id", knokID,
+					"url", urlInfo.URL,
+					"cooldown_until", until,
+				)
+				break
+			}
 			shouldQueueJob = true
 			s.logger.Info("Knok needs metadata extraction, queuing job",
 				"knok_id", knokID,
@@ -415,6 +596,27 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 		return nil
 	}
 
+	// Servers can track knoks without extracting them; the knok stays pending until an
+	// extraction is requested explicitly
+	if !autoExtraction {
+		s.logger.Info("Auto extraction disabled for server, leaving knok pending",
+			"knok_id", knokID,
+			"url", urlInfo.URL,
+			"server_id", message.GuildID,
+		)
+		return nil
+	}
+
+	// Leave batched jobs for the caller to queue once every URL has been processed
+	if batch != nil {
+		*batch = append(*batch, jobPayload)
+		s.logger.Debug("Added metadata extraction job to batch",
+			"knok_id", knokID,
+			"url", urlInfo.URL,
+		)
+		return nil
+	}
+
 	// Queue the metadata extraction job
 	if err := s.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadata, jobPayload); err != nil {
 		s.logger.Error("Failed to queue metadata extraction job",
@@ -447,6 +649,115 @@ func (s *BotService) processDetectedURL(message *discordgo.MessageCreate, urlInf
 	return nil
 }
 
+// releaseMessageURL drops the claim on a message URL that couldn't be handled
+func (s *BotService) releaseMessageURL(ctx context.Context, messageID, url string) {
+	if err := s.queueRepo.ReleaseMessageURL(context.WithoutCancel(ctx), messageID, url); err != nil {
+		s.logger.Warn("Failed to release message URL claim",
+			"error", err,
+			"message_id", messageID,
+			"url", url,
+		)
+	}
+}
+
+// queueExtractionBatch queues a single batched metadata extraction job for the knoks
+// detected in one message. If queueing fails, every knok in the batch is marked failed.
+func (s *BotService) queueExtractionBatch(ctx context.Context, message *discordgo.MessageCreate, items []map[string]interface{}) error {
+	jobPayload := map[string]interface{}{
+		"discord_message_id": message.ID,
+		"discord_channel_id": message.ChannelID,
+		"discord_guild_id":   message.GuildID,
+		"items":              items,
+	}
+
+	if err := s.queueRepo.Enqueue(ctx, domain.JobTypeExtractMetadataBatch, jobPayload); err != nil {
+		if s.knokRepo != nil {
+			for _, item := range items {
+				if knokID, err := uuid.Parse(fmt.Sprint(item["knok_id"])); err == nil {
+					s.knokRepo.UpdateExtractionStatus(ctx, knokID, domain.ExtractionStatusFailed)
+				}
+			}
+		}
+
+		return fmt.Errorf("failed to queue batched metadata extraction job: %w", err)
+	}
+
+	s.logger.Info("Batched metadata extraction job queued successfully",
+		"message_id", message.ID,
+		"batch_size", len(items),
+	)
+
+	return nil
+}
+
+// isBotAuthorAllowed reports whether a message from a bot or webhook should be tracked.
+// Servers opt in with the allow_bot_authors setting, optionally narrowed by allowed_bot_ids.
+// The bot's own messages are never tracked.
+func (s *BotService) isBotAuthorAllowed(message *discordgo.MessageCreate) bool {
+	if s.session != nil && s.session.State != nil && s.session.State.User != nil &&
+		message.Author.ID == s.session.State.User.ID {
+		return false
+	}
+
+	if s.serverRepo == nil {
+		return false
+	}
+
+	server, err := s.serverRepo.GetByID(context.Background(), message.GuildID)
+	if err != nil || server == nil || !server.BoolSetting("allow_bot_authors") {
+		return false
+	}
+
+	allowedIDs := server.StringSliceSetting("allowed_bot_ids")
+	if len(allowedIDs) == 0 {
+		return true
+	}
+
+	for _, id := range allowedIDs {
+		if id == message.Author.ID || (message.WebhookID != "" && id == message.WebhookID) {
+			return true
+		}
+	}
+
+	s.logger.Debug("Bot author not in allowed_bot_ids",
+		"author_id", message.Author.ID,
+		"webhook_id", message.WebhookID,
+		"guild_id", message.GuildID,
+	)
+	return false
+}
+
+// defaultMaxContextWords is the number of non-URL words allowed alongside a link when a
+// server enables require_dominant_link without setting max_context_words
+const defaultMaxContextWords = 5
+
+// isDominantLink reports whether the links in a message are its main content.
+// Always true unless the server has enabled the require_dominant_link setting.
+func (s *BotService) isDominantLink(message *discordgo.MessageCreate) bool {
+	if s.serverRepo == nil {
+		return true
+	}
+
+	server, err := s.serverRepo.GetByID(context.Background(), message.GuildID)
+	if err != nil || server == nil || !server.BoolSetting("require_dominant_link") {
+		return true
+	}
+
+	maxWords := defaultMaxContextWords
+	if configured, ok := server.IntSetting("max_context_words"); ok && configured >= 0 {
+		maxWords = configured
+	}
+
+	contextWords := urldetector.CountContextWords(message.Content)
+	s.logger.Debug("Checked link dominance",
+		"message_id", message.ID,
+		"context_words", contextWords,
+		"max_context_words", maxWords,
+	)
+
+	return contextWords <= maxWords
+}
+
 // extractURLs finds all supported music URLs in a message using centralized detector
 func (s *BotService) extractURLs(content string) []urldetector.URLInfo {
 	return s.urlDetector.DetectURLs(content)
@@ -0,0 +1,936 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"knock-fm/internal/config"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// createTestLogger creates a logger for testing
+func createTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError, // Only show errors during tests
+	}))
+}
+
+// fakePlatformLoader serves the default platform config, highest priority first
+type fakePlatformLoader struct{}
+
+func (l *fakePlatformLoader) GetAllByPriority() ([]*domain.Platform, error) {
+	config := domain.GetDefaultPlatformConfig()
+	platforms := make([]*domain.Platform, 0, len(config.Platforms))
+	for _, platform := range config.Platforms {
+		p := platform
+		p.Enabled = true
+		platforms = append(platforms, &p)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		if platforms[i].Priority != platforms[j].Priority {
+			return platforms[i].Priority > platforms[j].Priority
+		}
+		return platforms[i].ID < platforms[j].ID
+	})
+	return platforms, nil
+}
+
+func (l *fakePlatformLoader) IsLoaded() bool { return true }
+
+// fakeKnokRepo is an in-memory domain.KnokRepository
+type fakeKnokRepo struct {
+	mu    sync.Mutex
+	knoks map[uuid.UUID]*domain.Knok
+}
+
+func newFakeKnokRepo() *fakeKnokRepo {
+	return &fakeKnokRepo{knoks: make(map[uuid.UUID]*domain.Knok)}
+}
+
+func (r *fakeKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if knok, ok := r.knoks[id]; ok {
+		return knok, nil
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetByDiscordMessage(ctx context.Context, messageID string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.DiscordMessageID == messageID {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+// Search matches titles containing the query, ignoring case, newest first
+func (r *fakeKnokRepo) Search(ctx context.Context, serverID *string, query string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var knoks []*domain.Knok
+	for _, knok := range r.knoks {
+		if serverID != nil && knok.ServerID != *serverID {
+			continue
+		}
+		if knok.Title != nil && strings.Contains(strings.ToLower(*knok.Title), strings.ToLower(query)) {
+			knoks = append(knoks, knok)
+		}
+	}
+	sort.Slice(knoks, func(i, j int) bool { return knoks[i].PostedAt.After(knoks[j].PostedAt) })
+	if len(knoks) > limit {
+		knoks = knoks[:limit]
+	}
+	return knoks, nil
+}
+
+func (r *fakeKnokRepo) GetRandom(ctx context.Context) (*domain.Knok, error) {
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetByStatus(ctx context.Context, status string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetByExtractionMethod(ctx context.Context, method string, cursor *time.Time, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetExtractionReport(ctx context.Context) (*domain.ExtractionReport, error) {
+	return &domain.ExtractionReport{}, nil
+}
+
+func (r *fakeKnokRepo) GetActivityHistogram(ctx context.Context, serverID *string, from, to time.Time, bucket string) ([]*domain.ActivityBucket, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetAllByPlatform(ctx context.Context, platform string, cursor *domain.KnokCursor, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetByPlatformAndStatus(ctx context.Context, platform string, statuses []string, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) UpdatePlatform(ctx context.Context, id uuid.UUID, platform string) error {
+	return nil
+}
+
+func (r *fakeKnokRepo) GetCountsByServer(ctx context.Context, serverID string) (*domain.KnokCounts, error) {
+	return &domain.KnokCounts{}, nil
+}
+
+func (r *fakeKnokRepo) GetServerStats(ctx context.Context, serverID string) (*domain.ServerStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := &domain.ServerStats{Platforms: make([]*domain.PlatformCount, 0)}
+	counts := make(map[string]int)
+	for _, knok := range r.knoks {
+		if knok.ServerID != serverID || knok.ExtractionStatus != domain.ExtractionStatusComplete {
+			continue
+		}
+		stats.Total++
+		counts[knok.Platform]++
+		if stats.LatestPostedAt == nil || knok.PostedAt.After(*stats.LatestPostedAt) {
+			postedAt := knok.PostedAt
+			stats.LatestPostedAt = &postedAt
+		}
+	}
+	for platform, count := range counts {
+		stats.Platforms = append(stats.Platforms, &domain.PlatformCount{Platform: platform, Count: count})
+	}
+	sort.Slice(stats.Platforms, func(i, j int) bool {
+		if stats.Platforms[i].Count != stats.Platforms[j].Count {
+			return stats.Platforms[i].Count > stats.Platforms[j].Count
+		}
+		return stats.Platforms[i].Platform < stats.Platforms[j].Platform
+	})
+	return stats, nil
+}
+
+func (r *fakeKnokRepo) GetTopPosters(ctx context.Context, serverID string, limit int) ([]*domain.PosterCount, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetForDate(ctx context.Context, date time.Time) (*domain.Knok, error) {
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) Create(ctx context.Context, knok *domain.Knok) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.knoks[knok.ID] = knok
+	return nil
+}
+
+func (r *fakeKnokRepo) Update(ctx context.Context, knok *domain.Knok) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.knoks[knok.ID] = knok
+	return nil
+}
+
+func (r *fakeKnokRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.knoks, id)
+	return nil
+}
+
+func (r *fakeKnokRepo) SoftDeleteByUser(ctx context.Context, userID string, serverID *string) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeKnokRepo) GetByURL(ctx context.Context, serverID, url string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.ServerID == serverID && knok.URL == url {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetByCanonicalURL(ctx context.Context, serverID, canonicalURL string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.ServerID == serverID && knok.CanonicalURL == canonicalURL {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetByPlatformItemID(ctx context.Context, serverID, platform, itemID string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.ServerID == serverID && knok.Platform == platform &&
+			knok.PlatformItemID != nil && *knok.PlatformItemID == itemID {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *fakeKnokRepo) GetRecent(ctx context.Context, order domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetRecentByPlatform(ctx context.Context, platform string, order domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) GetRecentByServer(ctx context.Context, serverID string, order domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var knoks []*domain.Knok
+	for _, knok := range r.knoks {
+		if knok.ServerID == serverID && (cursor == nil || knok.PostedAt.Before(cursor.Time)) {
+			knoks = append(knoks, knok)
+		}
+	}
+	sort.Slice(knoks, func(i, j int) bool { return knoks[i].PostedAt.After(knoks[j].PostedAt) })
+	if len(knoks) > limit {
+		knoks = knoks[:limit]
+	}
+	return knoks, nil
+}
+
+func (r *fakeKnokRepo) GetByPlatform(ctx context.Context, serverID, platform string, offset, limit int) ([]*domain.Knok, int, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeKnokRepo) GetByPlatformCursor(ctx context.Context, serverID, platform string, order domain.KnokSort, cursor *domain.KnokCursor, from, to *time.Time, status string, limit int) ([]*domain.Knok, error) {
+	return nil, nil
+}
+
+func (r *fakeKnokRepo) UpdateExtractionStatus(ctx context.Context, id uuid.UUID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if knok, ok := r.knoks[id]; ok {
+		knok.ExtractionStatus = status
+	}
+	return nil
+}
+
+func (r *fakeKnokRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.knoks)
+}
+
+// fakeServerRepo is an in-memory domain.ServerRepository
+type fakeServerRepo struct {
+	mu      sync.Mutex
+	servers map[string]*domain.Server
+}
+
+func newFakeServerRepo(servers ...*domain.Server) *fakeServerRepo {
+	repo := &fakeServerRepo{servers: make(map[string]*domain.Server)}
+	for _, server := range servers {
+		repo.servers[server.ID] = server
+	}
+	return repo
+}
+
+func (r *fakeServerRepo) GetByID(ctx context.Context, id string) (*domain.Server, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if server, ok := r.servers[id]; ok {
+		return server, nil
+	}
+	return nil, domain.ErrServerNotFound
+}
+
+func (r *fakeServerRepo) Create(ctx context.Context, server *domain.Server) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.servers[server.ID] = server
+	return nil
+}
+
+func (r *fakeServerRepo) Update(ctx context.Context, server *domain.Server) error {
+	return r.Create(ctx, server)
+}
+
+func (r *fakeServerRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.servers, id)
+	return nil
+}
+
+func (r *fakeServerRepo) List(ctx context.Context, offset, limit int) ([]*domain.Server, int, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeServerRepo) GetByChannelID(ctx context.Context, channelID string) (*domain.Server, error) {
+	return nil, domain.ErrServerNotFound
+}
+
+func (r *fakeServerRepo) UpdateSettings(ctx context.Context, id string, settings map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if server, ok := r.servers[id]; ok {
+		server.Settings = settings
+	}
+	return nil
+}
+
+// fakeQueueRepo records enqueued jobs, failing them with enqueueErr if set
+type fakeQueueRepo struct {
+	mu         sync.Mutex
+	jobs       []map[string]interface{}
+	jobTypes   []string
+	claimed    map[string]bool
+	enqueueErr error
+}
+
+func (r *fakeQueueRepo) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enqueueErr != nil {
+		return r.enqueueErr
+	}
+	if p, ok := payload.(map[string]interface{}); ok {
+		r.jobs = append(r.jobs, p)
+		r.jobTypes = append(r.jobTypes, jobType)
+	}
+	return nil
+}
+
+func (r *fakeQueueRepo) EnqueuePriority(ctx context.Context, jobType string, payload interface{}) error {
+	return r.Enqueue(ctx, jobType, payload)
+}
+
+func (r *fakeQueueRepo) Dequeue(ctx context.Context, jobType string) (*domain.QueueJob, error) {
+	return nil, nil
+}
+
+func (r *fakeQueueRepo) Complete(ctx context.Context, jobID string) error { return nil }
+
+func (r *fakeQueueRepo) Fail(ctx context.Context, jobID string, errorMsg string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeQueueRepo) GetPendingCount(ctx context.Context, jobType string) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeQueueRepo) GetProcessingJobIDs(ctx context.Context, jobType string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeQueueRepo) ReclaimProcessing(ctx context.Context, jobType string) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeQueueRepo) RecomputeStats(ctx context.Context, jobType string) (map[string]int64, error) {
+	return map[string]int64{}, nil
+}
+
+func (r *fakeQueueRepo) SetPaused(ctx context.Context, paused bool) error { return nil }
+
+func (r *fakeQueueRepo) IsPaused(ctx context.Context) (bool, error) { return false, nil }
+
+func (r *fakeQueueRepo) RequestOEmbedReload(ctx context.Context) error { return nil }
+
+func (r *fakeQueueRepo) GetOEmbedReloadRequest(ctx context.Context) (int64, error) { return 0, nil }
+
+func (r *fakeQueueRepo) AcquireKnokLock(ctx context.Context, knokID uuid.UUID, ttl time.Duration) (string, bool, error) {
+	return "token", true, nil
+}
+
+func (r *fakeQueueRepo) ReleaseKnokLock(ctx context.Context, knokID uuid.UUID, token string) error {
+	return nil
+}
+
+func (r *fakeQueueRepo) ClaimMessageURL(ctx context.Context, messageID, url string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.claimed == nil {
+		r.claimed = make(map[string]bool)
+	}
+	key := messageID + ":" + url
+	if r.claimed[key] {
+		return false, nil
+	}
+	r.claimed[key] = true
+	return true, nil
+}
+
+func (r *fakeQueueRepo) ReleaseMessageURL(ctx context.Context, messageID, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.claimed, messageID+":"+url)
+	return nil
+}
+
+// newTestBotService builds a BotService wired to in-memory repositories (no Discord session)
+func newTestBotService(cfg *config.Config, servers ...*domain.Server) (*BotService, *fakeKnokRepo, *fakeQueueRepo) {
+	if cfg == nil {
+		cfg = &config.Config{DefaultUnknownPlatformMode: "permissive"}
+	}
+	logger := createTestLogger()
+	knokRepo := newFakeKnokRepo()
+	queueRepo := &fakeQueueRepo{}
+
+	return &BotService{
+		config:      cfg,
+		logger:      logger,
+		queueRepo:   queueRepo,
+		knokRepo:    knokRepo,
+		serverRepo:  newFakeServerRepo(servers...),
+		urlDetector: urldetector.New(&fakePlatformLoader{}, nil, logger),
+		ctx:         context.Background(),
+		cancel:      func() {},
+	}, knokRepo, queueRepo
+}
+
+// newTestMessage builds a MessageCreate event for the given author and content
+func newTestMessage(id string, author *discordgo.User, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        id,
+			ChannelID: "channel-1",
+			GuildID:   "guild-1",
+			Author:    author,
+			Content:   content,
+			Timestamp: time.Now(),
+		},
+	}
+}
+
+func TestProcessMessageBotAuthors(t *testing.T) {
+	const trackURL = "https://soundcloud.com/artist/track"
+
+	tests := []struct {
+		name      string
+		settings  map[string]interface{}
+		author    *discordgo.User
+		webhookID string
+		wantKnoks int
+	}{
+		{
+			name:      "Human author is tracked",
+			settings:  map[string]interface{}{},
+			author:    &discordgo.User{ID: "user-1"},
+			wantKnoks: 1,
+		},
+		{
+			name:      "Bot author ignored by default",
+			settings:  map[string]interface{}{},
+			author:    &discordgo.User{ID: "bot-1", Bot: true},
+			wantKnoks: 0,
+		},
+		{
+			name:      "Bot author tracked when allow_bot_authors is set",
+			settings:  map[string]interface{}{"allow_bot_authors": true},
+			author:    &discordgo.User{ID: "bot-1", Bot: true},
+			wantKnoks: 1,
+		},
+		{
+			name: "Allowlisted bot is tracked",
+			settings: map[string]interface{}{
+				"allow_bot_authors": true,
+				"allowed_bot_ids":   []interface{}{"bot-1"},
+			},
+			author:    &discordgo.User{ID: "bot-1", Bot: true},
+			wantKnoks: 1,
+		},
+		{
+			name: "Allowlisted webhook is tracked",
+			settings: map[string]interface{}{
+				"allow_bot_authors": true,
+				"allowed_bot_ids":   []interface{}{"webhook-1"},
+			},
+			author:    &discordgo.User{ID: "webhook-1", Bot: true},
+			webhookID: "webhook-1",
+			wantKnoks: 1,
+		},
+		{
+			name: "Bot outside allowlist is ignored",
+			settings: map[string]interface{}{
+				"allow_bot_authors": true,
+				"allowed_bot_ids":   []interface{}{"bot-2"},
+			},
+			author:    &discordgo.User{ID: "bot-1", Bot: true},
+			wantKnoks: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &domain.Server{ID: "guild-1", Name: "Test", Settings: tt.settings}
+			service, knokRepo, _ := newTestBotService(nil, server)
+
+			message := newTestMessage("msg-1", tt.author, "check this out "+trackURL)
+			message.WebhookID = tt.webhookID
+
+			got := service.processMessage(message, "test")
+			if got != tt.wantKnoks {
+				t.Errorf("processMessage() = %d, want %d", got, tt.wantKnoks)
+			}
+			if knokRepo.count() != tt.wantKnoks {
+				t.Errorf("stored knoks = %d, want %d", knokRepo.count(), tt.wantKnoks)
+			}
+		})
+	}
+}
+
+func TestProcessMessageDominantLink(t *testing.T) {
+	const bareLink = "https://soundcloud.com/artist/track"
+	const buriedLink = "so yesterday we were talking about that gig and someone sent https://soundcloud.com/artist/track which reminded me of the whole thing"
+
+	tests := []struct {
+		name      string
+		settings  map[string]interface{}
+		content   string
+		wantKnoks int
+	}{
+		{
+			name:      "Buried link tracked when rule disabled",
+			settings:  map[string]interface{}{},
+			content:   buriedLink,
+			wantKnoks: 1,
+		},
+		{
+			name:      "Bare link tracked when rule enabled",
+			settings:  map[string]interface{}{"require_dominant_link": true},
+			content:   bareLink,
+			wantKnoks: 1,
+		},
+		{
+			name:      "Buried link skipped when rule enabled",
+			settings:  map[string]interface{}{"require_dominant_link": true},
+			content:   buriedLink,
+			wantKnoks: 0,
+		},
+		{
+			name:      "Short caption within default threshold",
+			settings:  map[string]interface{}{"require_dominant_link": true},
+			content:   "this one slaps " + bareLink,
+			wantKnoks: 1,
+		},
+		{
+			name: "Short caption over custom threshold",
+			settings: map[string]interface{}{
+				"require_dominant_link": true,
+				"max_context_words":     float64(0),
+			},
+			content:   "this one slaps " + bareLink,
+			wantKnoks: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &domain.Server{ID: "guild-1", Name: "Test", Settings: tt.settings}
+			service, _, _ := newTestBotService(nil, server)
+
+			message := newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, tt.content)
+			if got := service.processMessage(message, "test"); got != tt.wantKnoks {
+				t.Errorf("processMessage() = %d, want %d", got, tt.wantKnoks)
+			}
+		})
+	}
+}
+
+// fakeReactionAdder records reactions instead of calling Discord
+type fakeReactionAdder struct {
+	reactions []string
+}
+
+func (f *fakeReactionAdder) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	f.reactions = append(f.reactions, emojiID)
+	return nil
+}
+
+func TestSendFeedbackNotificationMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		settings      map[string]interface{}
+		wantReactions int
+	}{
+		{
+			name:          "Default mode reacts",
+			settings:      map[string]interface{}{},
+			wantReactions: 1,
+		},
+		{
+			name:          "Reaction mode reacts",
+			settings:      map[string]interface{}{"notification_mode": "reaction"},
+			wantReactions: 1,
+		},
+		{
+			name:          "Silent mode stays quiet",
+			settings:      map[string]interface{}{"notification_mode": "silent"},
+			wantReactions: 0,
+		},
+		{
+			name:          "Reply mode waits for completion",
+			settings:      map[string]interface{}{"notification_mode": "reply"},
+			wantReactions: 0,
+		},
+		{
+			name:          "Invalid mode falls back to reaction",
+			settings:      map[string]interface{}{"notification_mode": "loud"},
+			wantReactions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newTestBotService(nil, &domain.Server{ID: "guild-1", Settings: tt.settings})
+			discord := &fakeReactionAdder{}
+
+			service.sendFeedback(discord, newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, "https://soundcloud.com/artist/track"), "test")
+
+			if len(discord.reactions) != tt.wantReactions {
+				t.Errorf("got %d reactions, want %d", len(discord.reactions), tt.wantReactions)
+			}
+		})
+	}
+}
+
+func TestProcessMessageBatchExtraction(t *testing.T) {
+	content := "https://soundcloud.com/artist/one https://soundcloud.com/artist/two https://soundcloud.com/artist/three"
+
+	t.Run("Batching disabled queues one job per URL", func(t *testing.T) {
+		service, knokRepo, queueRepo := newTestBotService(nil)
+
+		created := service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, content), "test")
+
+		if created != 3 || knokRepo.count() != 3 {
+			t.Fatalf("created = %d, knoks = %d, want 3", created, knokRepo.count())
+		}
+		if len(queueRepo.jobs) != 3 {
+			t.Fatalf("got %d jobs, want 3", len(queueRepo.jobs))
+		}
+		for _, jobType := range queueRepo.jobTypes {
+			if jobType != domain.JobTypeExtractMetadata {
+				t.Errorf("job type = %q, want %q", jobType, domain.JobTypeExtractMetadata)
+			}
+		}
+	})
+
+	t.Run("Batching enabled queues one job per message", func(t *testing.T) {
+		cfg := &config.Config{DefaultUnknownPlatformMode: "permissive", BatchMetadataExtraction: true}
+		service, knokRepo, queueRepo := newTestBotService(cfg)
+
+		created := service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, content), "test")
+
+		if created != 3 || knokRepo.count() != 3 {
+			t.Fatalf("created = %d, knoks = %d, want 3", created, knokRepo.count())
+		}
+		if len(queueRepo.jobs) != 1 || queueRepo.jobTypes[0] != domain.JobTypeExtractMetadataBatch {
+			t.Fatalf("got job types %v, want a single %q", queueRepo.jobTypes, domain.JobTypeExtractMetadataBatch)
+		}
+		items, ok := queueRepo.jobs[0]["items"].([]map[string]interface{})
+		if !ok || len(items) != 3 {
+			t.Fatalf("batch items = %v, want 3 items", queueRepo.jobs[0]["items"])
+		}
+	})
+
+	t.Run("Single URL is not batched", func(t *testing.T) {
+		cfg := &config.Config{DefaultUnknownPlatformMode: "permissive", BatchMetadataExtraction: true}
+		service, _, queueRepo := newTestBotService(cfg)
+
+		service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, "https://soundcloud.com/artist/one"), "test")
+
+		if len(queueRepo.jobs) != 1 || queueRepo.jobTypes[0] != domain.JobTypeExtractMetadata {
+			t.Fatalf("got job types %v, want a single %q", queueRepo.jobTypes, domain.JobTypeExtractMetadata)
+		}
+	})
+}
+
+func TestProcessMessageDuplicateDelivery(t *testing.T) {
+	service, knokRepo, queueRepo := newTestBotService(nil)
+	message := newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, "https://soundcloud.com/artist/one https://soundcloud.com/artist/two")
+
+	if created := service.processMessage(message, "first"); created != 2 {
+		t.Fatalf("first delivery created %d knoks, want 2", created)
+	}
+
+	// The knoks are still pending, so without the claim a second delivery would queue them again
+	service.processMessage(message, "second")
+
+	if knokRepo.count() != 2 {
+		t.Errorf("knoks = %d, want 2", knokRepo.count())
+	}
+	if len(queueRepo.jobs) != 2 {
+		t.Errorf("jobs = %d, want 2 with the second delivery skipped", len(queueRepo.jobs))
+	}
+
+	// Another message with the same links is still handled
+	service.processMessage(newTestMessage("msg-2", &discordgo.User{ID: "user-2"}, "https://soundcloud.com/artist/one"), "third")
+	if len(queueRepo.jobs) != 3 {
+		t.Errorf("jobs = %d, want 3 after a different message", len(queueRepo.jobs))
+	}
+}
+
+func TestProcessMessageContentRetention(t *testing.T) {
+	const content = "secret plans for tonight https://soundcloud.com/artist/track"
+
+	tests := []struct {
+		policy      string
+		wantPayload string // empty = no message_content in the job payload
+		wantLogged  bool
+	}{
+		{policy: domain.MessageContentRetentionFull, wantPayload: content, wantLogged: true},
+		{policy: domain.MessageContentRetentionURLs, wantPayload: "https://soundcloud.com/artist/track"},
+		{policy: domain.MessageContentRetentionNone},
+		{policy: domain.MessageContentRetentionRedact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			service, _, queueRepo := newTestBotService(&config.Config{
+				DefaultUnknownPlatformMode: "permissive",
+				MessageContentRetention:    tt.policy,
+			})
+			var logs bytes.Buffer
+			service.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, content), "test")
+
+			if len(queueRepo.jobs) != 1 {
+				t.Fatalf("jobs = %d, want 1", len(queueRepo.jobs))
+			}
+			got, ok := queueRepo.jobs[0]["message_content"]
+			if tt.wantPayload == "" {
+				if ok {
+					t.Errorf("job payload message_content = %q, want none", got)
+				}
+			} else if got != tt.wantPayload {
+				t.Errorf("job payload message_content = %q, want %q", got, tt.wantPayload)
+			}
+
+			if logged := strings.Contains(logs.String(), "secret plans"); logged != tt.wantLogged {
+				t.Errorf("message content logged = %v, want %v", logged, tt.wantLogged)
+			}
+		})
+	}
+}
+
+func TestProcessMessageRedeliveryRetriesFailedURLs(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch bool
+	}{
+		{name: "Single jobs"},
+		{name: "Batched job", batch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, queueRepo := newTestBotService(&config.Config{
+				DefaultUnknownPlatformMode: "permissive",
+				BatchMetadataExtraction:    tt.batch,
+			})
+			message := newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, "https://soundcloud.com/artist/one https://soundcloud.com/artist/two")
+
+			queueRepo.enqueueErr = errors.New("redis unavailable")
+			if created := service.processMessage(message, "first"); created != 0 {
+				t.Fatalf("failed delivery created %d knoks, want 0", created)
+			}
+
+			// The failed URLs' claims were released, so the redelivery queues them
+			queueRepo.enqueueErr = nil
+			if created := service.processMessage(message, "second"); created != 2 {
+				t.Errorf("redelivery created %d knoks, want 2", created)
+			}
+			if len(queueRepo.jobs) == 0 {
+				t.Error("redelivery queued no jobs")
+			}
+		})
+	}
+}
+
+func TestProcessMessageDedupsYouTubeVariantsByItemID(t *testing.T) {
+	service, knokRepo, queueRepo := newTestBotService(nil)
+
+	// A knok stored before canonicalization existed keeps the short link as its canonical URL,
+	// so only the video ID ties it to a full youtube.com link
+	itemID := "dQw4w9WgXcQ"
+	existing := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         "guild-1",
+		URL:              "https://youtu.be/dQw4w9WgXcQ",
+		CanonicalURL:     "https://youtu.be/dQw4w9WgXcQ",
+		Platform:         domain.PlatformYouTube,
+		PlatformItemID:   &itemID,
+		ExtractionStatus: domain.ExtractionStatusPending,
+	}
+	knokRepo.Create(context.Background(), existing)
+
+	// The existing knok is still pending, so the repost queues extraction for it
+	service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=30"), "test")
+
+	if knokRepo.count() != 1 {
+		t.Fatalf("knoks = %d, want 1", knokRepo.count())
+	}
+	if len(queueRepo.jobs) != 1 || queueRepo.jobs[0]["knok_id"] != existing.ID.String() {
+		t.Errorf("jobs = %v, want one job for existing knok %s", queueRepo.jobs, existing.ID)
+	}
+}
+
+func TestProcessMessageAutoExtraction(t *testing.T) {
+	content := "https://soundcloud.com/artist/one https://soundcloud.com/artist/two"
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		batching bool
+		wantJobs int
+	}{
+		{name: "Unset extracts automatically", settings: nil, wantJobs: 2},
+		{name: "Enabled extracts automatically", settings: map[string]interface{}{"auto_extraction": true}, wantJobs: 2},
+		{name: "Disabled queues no jobs", settings: map[string]interface{}{"auto_extraction": false}, wantJobs: 0},
+		{name: "Disabled queues no batch", settings: map[string]interface{}{"auto_extraction": false}, batching: true, wantJobs: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{DefaultUnknownPlatformMode: "permissive", BatchMetadataExtraction: tt.batching}
+			server := &domain.Server{ID: "guild-1", Settings: tt.settings}
+			service, knokRepo, queueRepo := newTestBotService(cfg, server)
+
+			created := service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, content), "test")
+
+			if created != 2 || knokRepo.count() != 2 {
+				t.Fatalf("created = %d, knoks = %d, want 2 tracked knoks", created, knokRepo.count())
+			}
+			if len(queueRepo.jobs) != tt.wantJobs {
+				t.Errorf("got %d jobs, want %d", len(queueRepo.jobs), tt.wantJobs)
+			}
+			for _, knok := range knokRepo.knoks {
+				if knok.ExtractionStatus != domain.ExtractionStatusPending {
+					t.Errorf("knok %s status = %q, want %q", knok.URL, knok.ExtractionStatus, domain.ExtractionStatusPending)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessMessageSkipsKnoksInExtractionCooldown(t *testing.T) {
+	budget := domain.ExtractionRetryBudget{MaxFailures: 3, Window: 24 * time.Hour, Cooldown: 24 * time.Hour}
+
+	tests := []struct {
+		name        string
+		failures    int
+		lastFailure time.Time
+		wantJobs    int
+	}{
+		{name: "Repeatedly failing knok is cooling down", failures: 3, lastFailure: time.Now().Add(-time.Hour), wantJobs: 0},
+		{name: "Failures below the budget", failures: 2, lastFailure: time.Now().Add(-time.Hour), wantJobs: 1},
+		{name: "Cooldown has passed", failures: 3, lastFailure: time.Now().Add(-25 * time.Hour), wantJobs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, knokRepo, queueRepo := newTestBotService(&config.Config{
+				DefaultUnknownPlatformMode: "permissive",
+				ExtractionRetryBudget:      budget,
+			})
+
+			existing := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              "https://soundcloud.com/artist/broken",
+				CanonicalURL:     "https://soundcloud.com/artist/broken",
+				Platform:         domain.PlatformSoundCloud,
+				ExtractionStatus: domain.ExtractionStatusFailed,
+			}
+			for i := 0; i < tt.failures; i++ {
+				budget.RecordFailure(existing, tt.lastFailure)
+			}
+			knokRepo.Create(context.Background(), existing)
+
+			// Reposting the link must not queue extraction while the knok is cooling down
+			for i := 0; i < 2; i++ {
+				service.processMessage(newTestMessage(fmt.Sprintf("msg-%d", i), &discordgo.User{ID: "user-1"}, existing.URL), "test")
+			}
+
+			if len(queueRepo.jobs) != tt.wantJobs*2 {
+				t.Errorf("jobs = %d, want %d", len(queueRepo.jobs), tt.wantJobs*2)
+			}
+		})
+	}
+}
+
+func TestProcessMessageStripsInvisibleChars(t *testing.T) {
+	service, knokRepo, _ := newTestBotService(nil)
+
+	// Zero-width spaces and joiners copied along with a link split it unless stripped
+	content := "check this \u200Bhttps://sound\u200Bcloud.com/artist/tr\u200Dack\uFEFF"
+	created := service.processMessage(newTestMessage("msg-1", &discordgo.User{ID: "user-1"}, content), "test")
+
+	if created != 1 || knokRepo.count() != 1 {
+		t.Fatalf("created = %d, knoks = %d, want 1", created, knokRepo.count())
+	}
+	for _, knok := range knokRepo.knoks {
+		if knok.URL != "https://soundcloud.com/artist/track" || knok.Platform != domain.PlatformSoundCloud {
+			t.Errorf("knok = %s on %s, want the SoundCloud track without invisible characters", knok.URL, knok.Platform)
+		}
+	}
+}
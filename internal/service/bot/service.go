@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/retry"
 	"knock-fm/internal/pkg/urldetector"
 	"knock-fm/internal/pkg/urlresolver"
 	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
 	"github.com/bwmarrin/discordgo"
@@ -34,6 +37,20 @@ type BotService struct {
 	// State
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// ready is set on the first Ready event; messages are ignored until then
+	ready atomic.Bool
+
+	// consecutiveEmptyContent counts guild messages in a row that arrived without content;
+	// messageContentMissing is set once it passes the warning threshold
+	emptyContentMu          sync.Mutex
+	consecutiveEmptyContent int
+	messageContentMissing   atomic.Bool
+
+	// Slash commands are registered once per process, not on every gateway reconnect
+	commandsMu         sync.Mutex
+	commandsRegistered bool
+	registerCommandsFn func() error
 }
 
 // New creates a new bot service
@@ -58,6 +75,10 @@ func New(
 		cancel:      cancel,
 	}
 
+	botService.urlDetector.SetPlatformCacheSize(config.PlatformCacheSize)
+	botService.urlDetector.SetLogRejectedURLs(config.LogRejectedURLs)
+	botService.urlDetector.SetStripEmojiBeforeURLs(config.StripEmojiBeforeURLs)
+
 	logger.Debug("BOT_SERVICE_CREATED: New bot service instance created",
 		"bot_service_ptr", fmt.Sprintf("%p", botService),
 	)
@@ -69,7 +90,19 @@ func New(
 		return nil, err
 	}
 
+	// Gateway sharding: Discord only sends this session events for guilds on our shard.
+	// Knok dedup is scoped per guild, so it is unaffected by sharding.
+	session.ShardID = config.DiscordShardID
+	session.ShardCount = config.DiscordShardCount
+
+	// Links are read from message content, which needs the privileged intent
+	session.Identify.Intents = gatewayIntents(config.DiscordMessageContentIntent)
+	if !config.DiscordMessageContentIntent {
+		logger.Warn("Message Content Intent not requested: the bot will only see links in messages that mention it")
+	}
+
 	botService.session = session
+	botService.registerCommandsFn = botService.registerCommands
 
 	// Register handlers
 	botService.registerHandlers()
@@ -80,8 +113,22 @@ func New(
 func (s *BotService) Start() error {
 	s.logger.Info("Starting Discord bot...")
 
-	// Open connection to Discord
-	if err := s.session.Open(); err != nil {
+	// Open connection to Discord, retrying in case it is briefly unreachable at startup
+	backoff := retry.Backoff{Attempts: s.config.DiscordConnectAttempts}
+	err := retry.Do(s.ctx, backoff, s.logger, "open Discord connection", func() error {
+		err := s.session.Open()
+		if isDisallowedIntentsError(err) {
+			// Retrying can't help until the intent is enabled in the Developer Portal
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if isDisallowedIntentsError(err) {
+		s.logger.Error("Discord refused the Message Content Intent. Enable it for the bot in the Discord Developer Portal "+
+			"(Bot > Privileged Gateway Intents), or set DISCORD_MESSAGE_CONTENT_INTENT=false to run without it", "error", err)
+		return fmt.Errorf("message content intent not enabled for the bot: %w", err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to open Discord connection: %w", err)
 	}
 
@@ -118,6 +165,8 @@ func (s *BotService) registerHandlers() {
 	)
 
 	s.session.AddHandler(s.onReady)
+	s.session.AddHandler(s.onDisconnect)
+	s.session.AddHandler(s.onResumed)
 	s.session.AddHandler(s.onMessageCreate)
 	s.session.AddHandler(s.onInteractionCreate)
 
@@ -130,6 +179,8 @@ func (s *BotService) onReady(session *discordgo.Session, ready *discordgo.Ready)
 		"username", ready.User.Username,
 		"discriminator", ready.User.Discriminator,
 		"guilds", len(ready.Guilds),
+		"shard_id", session.ShardID,
+		"shard_count", session.ShardCount,
 	)
 
 	s.logger.Debug("ON_READY: Ready event fired",
@@ -138,11 +189,12 @@ func (s *BotService) onReady(session *discordgo.Session, ready *discordgo.Ready)
 		"user_id", ready.User.ID,
 	)
 
-	// Register commands now that bot is connected
-	if err := s.registerCommands(); err != nil {
-		s.logger.Error("Failed to register slash commands", "error", err)
-	} else {
-		s.logger.Info("Slash commands registered successfully from service.go")
+	// Ready fires again after every full gateway reconnect, so only register commands once
+	s.ensureCommandsRegistered()
+
+	// Open the gate for message processing
+	if !s.ready.Swap(true) {
+		s.logger.Info("Bot is accepting messages")
 	}
 
 	// Set bot status
@@ -151,3 +203,38 @@ func (s *BotService) onReady(session *discordgo.Session, ready *discordgo.Ready)
 		s.logger.Error("Failed to set bot status", "error", err)
 	}
 }
+
+// ensureCommandsRegistered registers slash commands the first time it is called.
+// A failed registration is retried on the next Ready event.
+func (s *BotService) ensureCommandsRegistered() {
+	s.commandsMu.Lock()
+	defer s.commandsMu.Unlock()
+
+	if s.commandsRegistered {
+		s.logger.Debug("Slash commands already registered, skipping")
+		return
+	}
+
+	if err := s.registerCommandsFn(); err != nil {
+		s.logger.Error("Failed to register slash commands", "error", err)
+		return
+	}
+
+	s.commandsRegistered = true
+	s.logger.Info("Slash commands registered successfully from service.go")
+}
+
+// onDisconnect is called when the gateway connection drops.
+// discordgo reconnects automatically with backoff; this just records it.
+func (s *BotService) onDisconnect(session *discordgo.Session, event *discordgo.Disconnect) {
+	s.logger.Warn("Discord gateway disconnected, waiting for reconnect",
+		"shard_id", session.ShardID,
+	)
+}
+
+// onResumed is called when a dropped gateway session is resumed without a new Ready
+func (s *BotService) onResumed(session *discordgo.Session, event *discordgo.Resumed) {
+	s.logger.Info("Discord gateway session resumed",
+		"shard_id", session.ShardID,
+	)
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestOnReadyRegistersCommandsOnce(t *testing.T) {
+	service, _, _ := newTestBotService(nil)
+
+	calls := 0
+	service.registerCommandsFn = func() error {
+		calls++
+		return nil
+	}
+
+	session := &discordgo.Session{}
+	ready := &discordgo.Ready{User: &discordgo.User{ID: "bot", Username: "knok"}}
+
+	if service.ready.Load() {
+		t.Fatal("service should not be ready before the first Ready event")
+	}
+
+	service.onReady(session, ready)
+	service.onReady(session, ready)
+
+	if calls != 1 {
+		t.Errorf("registerCommands called %d times, want 1", calls)
+	}
+	if !service.ready.Load() {
+		t.Error("service should be ready after the Ready event")
+	}
+}
+
+func TestOnReadyRetriesFailedRegistration(t *testing.T) {
+	service, _, _ := newTestBotService(nil)
+
+	calls := 0
+	service.registerCommandsFn = func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("discord unavailable")
+		}
+		return nil
+	}
+
+	session := &discordgo.Session{}
+	ready := &discordgo.Ready{User: &discordgo.User{ID: "bot", Username: "knok"}}
+
+	service.onReady(session, ready)
+	service.onReady(session, ready)
+	service.onReady(session, ready)
+
+	if calls != 2 {
+		t.Errorf("registerCommands called %d times, want 2", calls)
+	}
+}
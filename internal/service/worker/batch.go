@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// batchItem is a single URL in a batched metadata extraction job
+type batchItem struct {
+	KnokID   uuid.UUID
+	URL      string
+	Platform string
+	UserID   string
+}
+
+// ProcessMetadataExtractionBatch extracts metadata for every URL posted in one message,
+// sharing a single HTTP client and browser between them. Each knok keeps its own
+// extraction status; the job only fails if no knok in the batch could be updated. Knoks
+// that failed in a batch that otherwise succeeded are recorded as failed right away, since
+// the job won't be retried; when every knok failed, the queue retries the whole batch.
+func (p *JobProcessor) ProcessMetadataExtractionBatch(ctx context.Context, payload map[string]interface{}, logger *slog.Logger) error {
+	items, err := parseBatchItems(payload)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Processing batched metadata extraction job",
+		"message_id", payload["discord_message_id"],
+		"batch_size", len(items),
+	)
+
+	resources := newExtractionResources(p.logger)
+	defer resources.Close()
+
+	var failed []batchItem
+	var failures []error
+	for i, item := range items {
+		// Stop early if the job timed out; the remaining knoks stay pending for a retry
+		if ctx.Err() != nil {
+			return fmt.Errorf("batch interrupted after %d of %d items: %w", i, len(items), ctx.Err())
+		}
+
+		itemLogger := logger.With("knok_id", item.KnokID, "batch_index", i)
+		itemLogger.Info("Processing batch item",
+			"url", item.URL,
+			"platform", item.Platform,
+		)
+
+		if err := p.extractAndSaveMetadata(ctx, resources, item.KnokID, item.URL, item.UserID, itemLogger); err != nil {
+			itemLogger.Error("Batch item failed", "error", err, "url", item.URL)
+			failed = append(failed, item)
+			failures = append(failures, err)
+		}
+	}
+
+	logger.Info("Batched metadata extraction completed",
+		"batch_size", len(items),
+		"failed", len(failed),
+	)
+
+	if len(failed) == len(items) {
+		return fmt.Errorf("all %d items in batch failed", len(failed))
+	}
+
+	for i, item := range failed {
+		p.RecordExtractionFailure(ctx, item.KnokID, failures[i].Error(), logger.With("knok_id", item.KnokID))
+	}
+
+	return nil
+}
+
+// RecordBatchFailure records reason as the extraction failure of every knok in a batch
+// payload, once the batch job has run out of retries
+func (p *JobProcessor) RecordBatchFailure(ctx context.Context, payload map[string]interface{}, reason string, logger *slog.Logger) {
+	items, err := parseBatchItems(payload)
+	if err != nil {
+		logger.Warn("Failed to parse batch to record extraction failure", "error", err)
+		return
+	}
+
+	for _, item := range items {
+		p.RecordExtractionFailure(ctx, item.KnokID, reason, logger.With("knok_id", item.KnokID))
+	}
+}
+
+// parseBatchItems reads the items of a batch payload. Items arrive as []interface{}
+// after a round trip through the queue, or as []map[string]interface{} when enqueued in-process.
+func parseBatchItems(payload map[string]interface{}) ([]batchItem, error) {
+	var rawItems []map[string]interface{}
+	switch values := payload["items"].(type) {
+	case []map[string]interface{}:
+		rawItems = values
+	case []interface{}:
+		for _, value := range values {
+			rawItem, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid item in batch payload")
+			}
+			rawItems = append(rawItems, rawItem)
+		}
+	default:
+		return nil, fmt.Errorf("missing or invalid items in payload")
+	}
+
+	if len(rawItems) == 0 {
+		return nil, fmt.Errorf("batch payload has no items")
+	}
+
+	items := make([]batchItem, 0, len(rawItems))
+	for _, rawItem := range rawItems {
+		knokIDStr, ok := rawItem["knok_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("missing or invalid knok_id in batch item")
+		}
+
+		knokID, err := uuid.Parse(knokIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid knok_id format: %w", err)
+		}
+
+		url, ok := rawItem["url"].(string)
+		if !ok {
+			return nil, fmt.Errorf("missing or invalid url in batch item")
+		}
+
+		platform, _ := rawItem["platform"].(string)
+		userID, _ := rawItem["discord_user_id"].(string)
+
+		items = append(items, batchItem{KnokID: knokID, URL: url, Platform: platform, UserID: userID})
+	}
+
+	return items, nil
+}
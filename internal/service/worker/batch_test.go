@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestProcessMetadataExtractionBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head>
+			<title>Track %[1]s</title>
+			<meta property="og:title" content="Track %[1]s">
+			<meta property="og:description" content="A mix">
+		</head></html>`, r.URL.Path[1:])
+	}))
+	defer server.Close()
+
+	knokRepo := &stubKnokRepo{knoks: make(map[uuid.UUID]*domain.Knok)}
+	var items []interface{}
+	for _, name := range []string{"one", "two", "three"} {
+		knok := &domain.Knok{
+			ID:               uuid.New(),
+			ServerID:         "guild-1",
+			URL:              server.URL + "/" + name,
+			ExtractionStatus: domain.ExtractionStatusPending,
+		}
+		knokRepo.knoks[knok.ID] = knok
+		items = append(items, map[string]interface{}{
+			"knok_id":         knok.ID.String(),
+			"url":             knok.URL,
+			"platform":        domain.PlatformUnknown,
+			"discord_user_id": "user-1",
+		})
+	}
+
+	// No oEmbed extractor, so extraction goes straight to the HTTP tier
+	processor := &JobProcessor{
+		logger:   createTestLogger(),
+		knokRepo: knokRepo,
+	}
+
+	payload := map[string]interface{}{
+		"discord_message_id": "msg-1",
+		"items":              items,
+	}
+	if err := processor.ProcessMetadataExtractionBatch(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessMetadataExtractionBatch() error = %v", err)
+	}
+
+	for _, knok := range knokRepo.knoks {
+		if knok.ExtractionStatus != domain.ExtractionStatusComplete {
+			t.Errorf("knok %s status = %q, want %q", knok.URL, knok.ExtractionStatus, domain.ExtractionStatusComplete)
+		}
+		if method := knok.Metadata["extraction_method"]; method != "http_static" {
+			t.Errorf("knok %s extraction_method = %v, want http_static", knok.URL, method)
+		}
+		if knok.Title == nil || *knok.Title == "" {
+			t.Errorf("knok %s has no title", knok.URL)
+		}
+		if knok.DiscordUserID == nil || *knok.DiscordUserID != "user-1" {
+			t.Errorf("knok %s discord_user_id = %v, want the item's poster", knok.URL, knok.DiscordUserID)
+		}
+	}
+}
+
+func TestProcessMetadataExtractionRecordsPoster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="Late Night Mix"></head></html>`)
+	}))
+	defer server.Close()
+
+	poster, payloadPoster := "user-1", "user-2"
+	tests := []struct {
+		name     string
+		stored   *string
+		payload  interface{}
+		wantUser *string
+	}{
+		{"Stored without a poster", nil, payloadPoster, &payloadPoster},
+		{"Stored poster is kept", &poster, payloadPoster, &poster},
+		{"No poster in the payload", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + "/track",
+				DiscordUserID:    tt.stored,
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+			}
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if tt.payload != nil {
+				payload["discord_user_id"] = tt.payload
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			got := knok.DiscordUserID
+			if (got == nil) != (tt.wantUser == nil) || (got != nil && *got != *tt.wantUser) {
+				t.Errorf("discord_user_id = %v, want %v", got, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRecordBatchFailure(t *testing.T) {
+	pending := &domain.Knok{ID: uuid.New(), ExtractionStatus: domain.ExtractionStatusPending, Metadata: map[string]interface{}{}}
+	complete := &domain.Knok{ID: uuid.New(), ExtractionStatus: domain.ExtractionStatusComplete, Metadata: map[string]interface{}{}}
+	knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{pending.ID: pending, complete.ID: complete}}
+	processor := &JobProcessor{logger: createTestLogger(), knokRepo: knokRepo}
+
+	payload := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"knok_id": pending.ID.String(), "url": "https://example.com/a"},
+		map[string]interface{}{"knok_id": complete.ID.String(), "url": "https://example.com/b"},
+	}}
+	processor.RecordBatchFailure(context.Background(), payload, "batch interrupted", createTestLogger())
+
+	if pending.ExtractionStatus != domain.ExtractionStatusFailed || pending.Metadata["extraction_error"] != "batch interrupted" {
+		t.Errorf("pending knok = %q with metadata %v, want failed with the reason", pending.ExtractionStatus, pending.Metadata)
+	}
+
+	// A knok that finished before the batch gave up is left as it is
+	if complete.ExtractionStatus != domain.ExtractionStatusComplete || complete.Metadata["extraction_error"] != nil {
+		t.Errorf("complete knok = %q with metadata %v, want it unchanged", complete.ExtractionStatus, complete.Metadata)
+	}
+}
+
+func TestParseBatchItems(t *testing.T) {
+	knokID := uuid.New().String()
+
+	tests := []struct {
+		name      string
+		payload   map[string]interface{}
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name: "Items from the queue",
+			payload: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"knok_id": knokID, "url": "https://example.com/a", "discord_user_id": "user-1"},
+				map[string]interface{}{"knok_id": knokID, "url": "https://example.com/b"},
+			}},
+			wantCount: 2,
+		},
+		{
+			name: "Items enqueued in-process",
+			payload: map[string]interface{}{"items": []map[string]interface{}{
+				{"knok_id": knokID, "url": "https://example.com/a", "discord_user_id": "user-1"},
+			}},
+			wantCount: 1,
+		},
+		{
+			name:    "Missing items",
+			payload: map[string]interface{}{},
+			wantErr: true,
+		},
+		{
+			name:    "Empty items",
+			payload: map[string]interface{}{"items": []interface{}{}},
+			wantErr: true,
+		},
+		{
+			name: "Invalid knok_id",
+			payload: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"knok_id": "not-a-uuid", "url": "https://example.com/a"},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseBatchItems(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBatchItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != tt.wantCount {
+				t.Errorf("got %d items, want %d", len(items), tt.wantCount)
+			}
+			if len(items) > 0 && items[0].UserID != "user-1" {
+				t.Errorf("first item UserID = %q, want user-1", items[0].UserID)
+			}
+		})
+	}
+}
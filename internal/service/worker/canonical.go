@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// resolveCanonicalLink resolves a page's <link rel="canonical"> href against the page URL.
+// Links to an unrelated site are ignored, so a page can't claim another site's knok; a
+// mobile or www variant of the same site is fine.
+func resolveCanonicalLink(pageURL, href string) (string, bool) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", false
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return "", false
+	}
+	if !sameSite(base.Hostname(), resolved.Hostname()) {
+		return "", false
+	}
+	return resolved.String(), true
+}
+
+// sameSite reports whether two hosts are the same site, ignoring www. and subdomains
+// such as m. for mobile variants
+func sameSite(a, b string) bool {
+	a = strings.TrimPrefix(strings.ToLower(a), "www.")
+	b = strings.TrimPrefix(strings.ToLower(b), "www.")
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+// mergeCanonicalDuplicate checks whether the page's canonical URL belongs to another knok
+// in the same server. If so, the knok being extracted is a duplicate variant: it's deleted,
+// and its metadata is given to the existing knok if that one never got any. Reports
+// whether the knok was merged away.
+func (p *JobProcessor) mergeCanonicalDuplicate(ctx context.Context, knok *domain.Knok, canonicalLink string, logger *slog.Logger) bool {
+	canonicalized, err := urldetector.CanonicalizeURL(canonicalLink)
+	if err != nil || canonicalized == knok.CanonicalURL {
+		return false
+	}
+
+	existing, err := p.knokRepo.GetByCanonicalURL(ctx, knok.ServerID, canonicalized)
+	if err != nil {
+		if !errors.Is(err, domain.ErrKnokNotFound) {
+			logger.Warn("Failed to look up knok by page canonical URL", "error", err, "canonical_url", canonicalized)
+		}
+		return false
+	}
+	if existing.ID == knok.ID {
+		return false
+	}
+
+	if existing.ExtractionStatus != domain.ExtractionStatusComplete {
+		existing.Title = knok.Title
+		existing.Metadata = knok.Metadata
+		existing.ThumbnailURL = knok.ThumbnailURL
+		existing.ExtractionStatus = knok.ExtractionStatus
+		if err := p.knokRepo.Update(ctx, existing); err != nil {
+			logger.Warn("Failed to copy metadata to existing knok", "error", err, "existing_knok_id", existing.ID)
+			return false
+		}
+	}
+
+	if err := p.knokRepo.Delete(ctx, knok.ID); err != nil {
+		logger.Warn("Failed to delete duplicate knok", "error", err, "knok_id", knok.ID)
+		return false
+	}
+
+	logger.Info("Merged duplicate knok into existing knok by page canonical URL",
+		"knok_id", knok.ID,
+		"existing_knok_id", existing.ID,
+		"url", knok.URL,
+		"canonical_url", canonicalized)
+	return true
+}
@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveCanonicalLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageURL string
+		href    string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "Absolute link",
+			pageURL: "https://example.com/shows/1?view=mobile",
+			href:    "https://example.com/shows/1",
+			want:    "https://example.com/shows/1",
+			wantOK:  true,
+		},
+		{
+			name:    "Relative link",
+			pageURL: "https://example.com/m/shows/1",
+			href:    "/shows/1",
+			want:    "https://example.com/shows/1",
+			wantOK:  true,
+		},
+		{
+			name:    "Mobile page pointing at the desktop site",
+			pageURL: "https://m.example.com/shows/1",
+			href:    "https://www.example.com/shows/1",
+			want:    "https://www.example.com/shows/1",
+			wantOK:  true,
+		},
+		{
+			name:    "Unrelated site",
+			pageURL: "https://example.com/shows/1",
+			href:    "https://other.org/shows/1",
+			wantOK:  false,
+		},
+		{
+			name:    "Non-HTTP scheme",
+			pageURL: "https://example.com/shows/1",
+			href:    "javascript:alert(1)",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveCanonicalLink(tt.pageURL, tt.href)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("resolveCanonicalLink(%q, %q) = %q, %v, want %q, %v", tt.pageURL, tt.href, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProcessMetadataExtractionCanonicalLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head>
+			<title>Late Night Mix</title>
+			<meta property="og:title" content="Late Night Mix">
+			<meta property="og:description" content="A mix">
+			<link rel="canonical" href="/shows/late-night-mix">
+		</head></html>`)
+	}))
+	defer server.Close()
+
+	// The test server listens on a random port, which canonicalization rejects by default
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	canonicalize := func(rawURL string) string {
+		canonical, err := urldetector.CanonicalizeURL(rawURL)
+		if err != nil {
+			t.Fatalf("CanonicalizeURL(%q) error = %v", rawURL, err)
+		}
+		return canonical
+	}
+	pageURL := server.URL + "/m/shows/late-night-mix"
+	canonicalURL := server.URL + "/shows/late-night-mix"
+
+	tests := []struct {
+		name           string
+		existingStatus string
+		existingServer string
+		wantMerged     bool
+	}{
+		{
+			name:           "Canonical URL matches an existing knok",
+			existingStatus: domain.ExtractionStatusComplete,
+			existingServer: "guild-1",
+			wantMerged:     true,
+		},
+		{
+			name:           "Existing knok without metadata gets the extracted metadata",
+			existingStatus: domain.ExtractionStatusFailed,
+			existingServer: "guild-1",
+			wantMerged:     true,
+		},
+		{
+			name:           "Knok in another server is left alone",
+			existingStatus: domain.ExtractionStatusComplete,
+			existingServer: "guild-2",
+			wantMerged:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existingTitle := "Existing Title"
+			existing := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         tt.existingServer,
+				URL:              canonicalURL,
+				CanonicalURL:     canonicalize(canonicalURL),
+				Title:            &existingTitle,
+				ExtractionStatus: tt.existingStatus,
+			}
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              pageURL,
+				CanonicalURL:     canonicalize(pageURL),
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{existing.ID: existing, knok.ID: knok}}
+			processor := &JobProcessor{logger: createTestLogger(), knokRepo: knokRepo}
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			_, stillExists := knokRepo.knoks[knok.ID]
+			if stillExists == tt.wantMerged {
+				t.Fatalf("duplicate knok still exists = %v, want merged = %v", stillExists, tt.wantMerged)
+			}
+			if _, ok := knokRepo.knoks[existing.ID]; !ok {
+				t.Fatal("existing knok was deleted")
+			}
+
+			if !tt.wantMerged {
+				if got := knok.Metadata["canonical_url"]; got != canonicalURL {
+					t.Errorf("metadata canonical_url = %v, want %s", got, canonicalURL)
+				}
+				return
+			}
+
+			wantTitle := existingTitle
+			if tt.existingStatus != domain.ExtractionStatusComplete {
+				wantTitle = "Late Night Mix"
+			}
+			if existing.Title == nil || *existing.Title != wantTitle {
+				t.Errorf("existing knok title = %v, want %q", existing.Title, wantTitle)
+			}
+			if existing.ExtractionStatus != domain.ExtractionStatusComplete {
+				t.Errorf("existing knok status = %q, want %q", existing.ExtractionStatus, domain.ExtractionStatusComplete)
+			}
+		})
+	}
+}
+
+func TestProcessNotificationMergedKnok(t *testing.T) {
+	notifier := &fakeNotifier{}
+	processor := &JobProcessor{
+		logger:   createTestLogger(),
+		knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{}},
+		notifier: notifier,
+	}
+
+	// A knok merged away as a duplicate is gone by the time its notification runs
+	payload := map[string]interface{}{"knok_id": uuid.New().String()}
+	if err := processor.ProcessNotification(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessNotification() error = %v, want the deleted knok skipped", err)
+	}
+	if len(notifier.replies)+len(notifier.messages)+len(notifier.threads) != 0 {
+		t.Error("expected no Discord messages for a deleted knok")
+	}
+}
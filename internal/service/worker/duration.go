@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// iso8601DurationRegex matches ISO 8601 durations such as "PT4M13S" or "P1DT2H", with
+// optionally fractional components
+var iso8601DurationRegex = regexp.MustCompile(
+	`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`,
+)
+
+// parseDurationSeconds converts a duration from page or oEmbed metadata to whole seconds.
+// Values come as plain seconds ("213", "213.4") or ISO 8601 durations ("PT3M33S").
+// Returns false for anything else, including zero or negative durations
+func parseDurationSeconds(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return roundDuration(seconds)
+	}
+
+	// "P" and "P1DT" match the pattern but aren't valid durations
+	value = strings.ToUpper(value)
+	match := iso8601DurationRegex.FindStringSubmatch(value)
+	if match == nil || strings.HasSuffix(value, "T") {
+		return 0, false
+	}
+
+	var seconds float64
+	for i, unit := range []float64{86400, 3600, 60, 1} {
+		if match[i+1] == "" {
+			continue
+		}
+		component, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds += component * unit
+	}
+	return roundDuration(seconds)
+}
+
+// roundDuration rounds seconds to a whole number, rejecting durations that aren't positive
+func roundDuration(seconds float64) (int, bool) {
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds > math.MaxInt32 {
+		return 0, false
+	}
+	rounded := int(math.Round(seconds))
+	if rounded <= 0 {
+		return 0, false
+	}
+	return rounded, true
+}
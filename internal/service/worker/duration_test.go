@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseDurationSeconds(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int
+		wantOK bool
+	}{
+		{"213", 213, true},
+		{" 213 ", 213, true},
+		{"213.6", 214, true},
+		{"PT3M33S", 213, true},
+		{"PT1H2M3S", 3723, true},
+		{"PT4M13.5S", 254, true},
+		{"P1DT1S", 86401, true},
+		{"pt45s", 45, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"-5", 0, false},
+		{"P", 0, false},
+		{"PT", 0, false},
+		{"P1DT", 0, false},
+		{"3:33", 0, false},
+		{"NaN", 0, false},
+		{"a few minutes", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseDurationSeconds(tt.value)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("parseDurationSeconds(%q) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestProcessMetadataExtractionDuration(t *testing.T) {
+	pages := map[string]string{
+		"/track": `<html><head>
+			<meta property="og:title" content="Late Night Mix">
+			<meta property="og:image" content="https://example.com/cover.jpg">
+			<meta property="music:duration" content="213">
+		</head></html>`,
+		"/video": `<html><head>
+			<meta property="og:title" content="Live Set">
+			<meta property="og:image" content="https://example.com/live.jpg">
+			<meta itemprop="duration" content="PT1H2M3S">
+		</head></html>`,
+		"/no-duration": `<html><head>
+			<meta property="og:title" content="Liner Notes">
+			<meta property="og:image" content="https://example.com/notes.jpg">
+		</head></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"/track", 213},
+		{"/video", 3723},
+		{"/no-duration", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + tt.path,
+				CanonicalURL:     server.URL + tt.path,
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: knokRepo,
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					return nil, fmt.Errorf("rod disabled in tests")
+				},
+			}
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			if got := knok.Metadata["duration_seconds"]; got != tt.want {
+				t.Errorf("duration_seconds = %v, want %v", got, tt.want)
+			}
+			// The image lands in its own column as well as the metadata
+			if knok.ThumbnailURL == nil || *knok.ThumbnailURL != knok.Metadata["image"] {
+				t.Errorf("ThumbnailURL = %v, want metadata image %v", knok.ThumbnailURL, knok.Metadata["image"])
+			}
+		})
+	}
+}
@@ -38,6 +38,7 @@ type oEmbedResponse struct {
 	Width           interface{} `json:"width"`            // Resource width (int or string e.g. "100%")
 	Height          interface{} `json:"height"`           // Resource height (int or string)
 	Description     string      `json:"description"`      // Description (not in spec, but some providers include it)
+	Duration        interface{} `json:"duration"`         // Length in seconds (not in spec, e.g. Vimeo; number or string)
 }
 
 // NewOEmbedExtractor creates a new oEmbed metadata extractor
@@ -204,6 +205,13 @@ func (e *OEmbedExtractor) oembedToMetadata(oembed *oEmbedResponse, originalURL s
 		metadata["site_name"] = oembed.AuthorName
 	}
 
+	// Duration - providers send seconds as a number or a string
+	if oembed.Duration != nil {
+		if duration := strings.TrimSpace(fmt.Sprint(oembed.Duration)); duration != "" {
+			metadata["duration"] = duration
+		}
+	}
+
 	// Additional metadata for debugging/logging
 	if oembed.Type != "" {
 		metadata["oembed_type"] = oembed.Type
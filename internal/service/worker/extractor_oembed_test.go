@@ -138,3 +138,26 @@ func stringContains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestOEmbedToMetadataDuration(t *testing.T) {
+	extractor := NewOEmbedExtractor(nil, createTestLogger())
+
+	tests := []struct {
+		name     string
+		duration interface{}
+		want     string
+	}{
+		{"Number", float64(213), "213"},
+		{"String", "PT3M33S", "PT3M33S"},
+		{"Missing", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := extractor.oembedToMetadata(&oEmbedResponse{Title: "Late Night Mix", Duration: tt.duration}, "https://vimeo.com/1")
+			if got := metadata["duration"]; got != tt.want {
+				t.Errorf("duration = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
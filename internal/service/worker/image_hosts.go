@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetAllowedImageHosts sets the hosts extracted images may come from; images on any other
+// host are dropped. An empty list keeps every image
+func (p *JobProcessor) SetAllowedImageHosts(hosts []string) {
+	p.imageHosts = nil
+	for _, host := range hosts {
+		host = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+		if host != "" {
+			p.imageHosts = append(p.imageHosts, host)
+		}
+	}
+}
+
+// isAllowedImage reports whether rawURL is an http(s) URL on an allowed image host or a
+// subdomain of one. Every image is allowed when no hosts are configured
+func (p *JobProcessor) isAllowedImage(rawURL string) bool {
+	if len(p.imageHosts) == 0 {
+		return true
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	for _, allowed := range p.imageHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropDisallowedImage clears an extracted image that isn't on an allowed host, so it is
+// never stored or served
+func (p *JobProcessor) dropDisallowedImage(metadata map[string]string) {
+	if image := metadata["image"]; image != "" && !p.isAllowedImage(image) {
+		p.logger.Info("Dropping image from a host that isn't allowed", "image", image)
+		metadata["image"] = ""
+	}
+}
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIsAllowedImage(t *testing.T) {
+	processor := &JobProcessor{}
+	if !processor.isAllowedImage("https://anywhere.example.com/cover.jpg") {
+		t.Error("isAllowedImage() with no hosts configured should allow every image")
+	}
+
+	processor.SetAllowedImageHosts([]string{"sndcdn.com", " I.SCDN.co "})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://i1.sndcdn.com/artworks-000-t500x500.jpg", want: true},
+		{url: "https://sndcdn.com/cover.jpg", want: true},
+		{url: "http://i.scdn.co/image/ab67616d", want: true},
+		{url: "https://I.SCDN.CO/image/ab67616d", want: true},
+		{url: "https://evil.example.com/i.scdn.co/cover.jpg", want: false},
+		{url: "https://sndcdn.com.example.com/cover.jpg", want: false},
+		{url: "https://notsndcdn.com/cover.jpg", want: false},
+		{url: "javascript://i.scdn.co/%0aalert(1)", want: false},
+		{url: "/relative/cover.jpg", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := processor.isAllowedImage(tt.url); got != tt.want {
+				t.Errorf("isAllowedImage(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessMetadataExtractionDropsDisallowedImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		image     string
+		wantImage string
+	}{
+		{"Allowed host", "https://i1.sndcdn.com/artworks-t500x500.jpg", "https://i1.sndcdn.com/artworks-t500x500.jpg"},
+		{"Disallowed host", "https://tracker.example.com/pixel.jpg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `<html><head>
+					<meta property="og:title" content="Late Night Mix">
+					<meta property="og:description" content="Two hours of ambient">
+					<meta property="og:image" content="%s">
+				</head></html>`, tt.image)
+			}))
+			defer server.Close()
+
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + "/track",
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+			}
+			processor.SetAllowedImageHosts([]string{"sndcdn.com"})
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			if knok.Metadata["image"] != tt.wantImage {
+				t.Errorf("metadata image = %v, want %q", knok.Metadata["image"], tt.wantImage)
+			}
+			if tt.wantImage == "" && knok.ThumbnailURL != nil {
+				t.Errorf("ThumbnailURL = %q, want nil", *knok.ThumbnailURL)
+			}
+			if tt.wantImage != "" && (knok.ThumbnailURL == nil || *knok.ThumbnailURL != tt.wantImage) {
+				t.Errorf("ThumbnailURL = %v, want %q", knok.ThumbnailURL, tt.wantImage)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// knokLockTTL bounds how long a knok stays locked if its worker dies without releasing
+// it. It's longer than any job timeout so a slow extraction keeps its lock
+const knokLockTTL = 10 * time.Minute
+
+// knokLocker is the part of the queue repository that keeps one extraction per knok
+type knokLocker interface {
+	AcquireKnokLock(ctx context.Context, knokID uuid.UUID, ttl time.Duration) (string, bool, error)
+	ReleaseKnokLock(ctx context.Context, knokID uuid.UUID, token string) error
+}
+
+// lockKnok claims a knok for extraction so two jobs for the same knok don't race on its
+// row. It returns false if another worker is already extracting the knok, otherwise a
+// func releasing the lock. Extraction goes ahead unlocked if Redis can't be reached
+func (p *JobProcessor) lockKnok(ctx context.Context, knokID uuid.UUID, logger *slog.Logger) (func(), bool) {
+	if p.knokLock == nil {
+		return func() {}, true
+	}
+
+	token, acquired, err := p.knokLock.AcquireKnokLock(ctx, knokID, knokLockTTL)
+	if err != nil {
+		logger.Warn("Failed to lock knok, extracting without a lock", "error", err)
+		return func() {}, true
+	}
+	if !acquired {
+		return nil, false
+	}
+
+	return func() {
+		// Release even if the job timed out, so the next job doesn't wait out the TTL
+		if err := p.knokLock.ReleaseKnokLock(context.WithoutCancel(ctx), knokID, token); err != nil {
+			logger.Warn("Failed to release knok lock", "error", err)
+		}
+	}, true
+}
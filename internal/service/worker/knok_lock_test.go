@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeKnokLocker keeps knok locks in memory
+type fakeKnokLocker struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]string
+}
+
+func (l *fakeKnokLocker) AcquireKnokLock(ctx context.Context, knokID uuid.UUID, ttl time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, held := l.locks[knokID]; held {
+		return "", false, nil
+	}
+	token := uuid.New().String()
+	l.locks[knokID] = token
+	return token, true, nil
+}
+
+func (l *fakeKnokLocker) ReleaseKnokLock(ctx context.Context, knokID uuid.UUID, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks[knokID] == token {
+		delete(l.locks, knokID)
+	}
+	return nil
+}
+
+func TestProcessMetadataExtractionKnokLock(t *testing.T) {
+	// The first page fetch blocks until released, holding its job mid-extraction
+	var fetches atomic.Int32
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	var block sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		block.Do(func() {
+			close(fetching)
+			<-release
+		})
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="Late Night Mix"></head></html>`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	knok := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         "guild-1",
+		URL:              server.URL + "/track",
+		CanonicalURL:     server.URL + "/track",
+		ExtractionStatus: domain.ExtractionStatusPending,
+	}
+	locker := &fakeKnokLocker{locks: make(map[uuid.UUID]string)}
+	processor := &JobProcessor{
+		logger:   createTestLogger(),
+		knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+		knokLock: locker,
+		rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+			return nil, fmt.Errorf("rod disabled in tests")
+		},
+	}
+	payload := map[string]interface{}{
+		"knok_id":  knok.ID.String(),
+		"url":      knok.URL,
+		"platform": domain.PlatformUnknown,
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		first <- processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger())
+	}()
+	<-fetching
+
+	// A second job while the first holds the lock completes without extracting
+	before := fetches.Load()
+	if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("concurrent ProcessMetadataExtraction() error = %v, want a no-op", err)
+	}
+	if got := fetches.Load(); got != before {
+		t.Errorf("concurrent job fetched the page %d times, want it skipped", got-before)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+	}
+	if len(locker.locks) != 0 {
+		t.Errorf("locks held after extraction = %v, want the lock released", locker.locks)
+	}
+
+	// Once released, the next job extracts again
+	before = fetches.Load()
+	if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessMetadataExtraction() after release error = %v", err)
+	}
+	if fetches.Load() == before {
+		t.Error("job after the lock was released didn't extract")
+	}
+	if knok.Title == nil || *knok.Title != "Late Night Mix" {
+		t.Errorf("title = %v, want Late Night Mix", knok.Title)
+	}
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// oembedProvidersFetchTimeout bounds downloading a providers file from a URL
+	oembedProvidersFetchTimeout = 30 * time.Second
+
+	// maxOEmbedProvidersSize caps how much of a providers file is read; the oembed.com
+	// list is a few hundred KB
+	maxOEmbedProvidersSize = 10 << 20
+)
+
+// SetOEmbedProvidersSource loads oEmbed providers from a file path or http(s) URL instead of
+// the copy embedded at build time, so providers can be added without a rebuild. If the
+// override can't be loaded the embedded providers stay in use. ReloadOEmbedProviders reads
+// the source again
+func (p *JobProcessor) SetOEmbedProvidersSource(ctx context.Context, source string) {
+	p.oembedProvidersSource = source
+	if source == "" {
+		return
+	}
+
+	if _, err := p.ReloadOEmbedProviders(ctx); err != nil {
+		p.logger.Warn("Failed to load oEmbed providers override, using embedded providers",
+			"source", source,
+			"error", err,
+		)
+	}
+}
+
+// ReloadOEmbedProviders reloads oEmbed providers from the configured source, or the embedded
+// file when there is none, and returns how many were loaded. If the source can't be loaded
+// the current providers are kept
+func (p *JobProcessor) ReloadOEmbedProviders(ctx context.Context) (int, error) {
+	if p.oembedExtractor == nil {
+		return 0, errors.New("oEmbed extraction is disabled")
+	}
+
+	registry, report, err := loadOEmbedProviders(ctx, p.oembedProvidersSource)
+	if err != nil {
+		return 0, err
+	}
+	p.oembedExtractor.registry.replace(registry)
+
+	source := p.oembedProvidersSource
+	if source == "" {
+		source = "embedded"
+	}
+	p.logger.Info("oEmbed providers loaded", "source", source, "provider_count", report.ProvidersLoaded)
+	logOEmbedRegistryReport(p.logger, report)
+
+	return report.ProvidersLoaded, nil
+}
+
+// loadOEmbedProviders builds a registry from the providers file at source, or the embedded
+// one when source is empty. A file without any usable provider is an error, so a bad
+// override can't silently disable oEmbed extraction
+func loadOEmbedProviders(ctx context.Context, source string) (*OEmbedRegistry, *OEmbedRegistryReport, error) {
+	if source == "" {
+		return loadOEmbedRegistry(oembedProvidersJSON)
+	}
+
+	data, err := readOEmbedProviders(ctx, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registry, report, err := loadOEmbedRegistry(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if report.ProvidersLoaded == 0 {
+		return nil, report, fmt.Errorf("%s: no usable oEmbed providers", source)
+	}
+	return registry, report, nil
+}
+
+// readOEmbedProviders reads a providers file from a local path or an http(s) URL
+func readOEmbedProviders(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read oEmbed providers file: %w", err)
+		}
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oembedProvidersFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oEmbed providers request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oEmbed providers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch oEmbed providers: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOEmbedProvidersSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oEmbed providers response: %w", err)
+	}
+	return data, nil
+}
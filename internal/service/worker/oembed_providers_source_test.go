@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// overrideProvidersJSON replaces YouTube's endpoint and adds a provider the embedded file
+// doesn't have
+const overrideProvidersJSON = `[
+	{"provider_name": "YouTube", "endpoints": [{"url": "https://override.example/youtube/oembed", "schemes": ["https://*.youtube.com/watch*"]}]},
+	{"provider_name": "Mixtapes", "endpoints": [{"url": "https://mixtapes.example/oembed", "schemes": ["https://mixtapes.example/*"]}]}
+]`
+
+func TestOEmbedProvidersOverrideTakesPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oembed_providers.json")
+	if err := os.WriteFile(path, []byte(overrideProvidersJSON), 0644); err != nil {
+		t.Fatalf("failed to write providers file: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(overrideProvidersJSON))
+	}))
+	defer server.Close()
+
+	for name, source := range map[string]string{"File": path, "URL": server.URL} {
+		t.Run(name, func(t *testing.T) {
+			processor := NewJobProcessor(createTestLogger(), nil, nil)
+			processor.SetOEmbedProvidersSource(context.Background(), source)
+			registry := processor.oembedExtractor.registry
+
+			if count := registry.GetProviderCount(); count != 2 {
+				t.Errorf("provider count = %d, want the override's 2", count)
+			}
+			if provider := registry.Match("https://mixtapes.example/tape/1"); provider == nil || provider.Name != "Mixtapes" {
+				t.Errorf("Match() = %v, want the Mixtapes provider from the override", provider)
+			}
+			if provider := registry.Match("https://www.youtube.com/watch?v=abc"); provider == nil || provider.Endpoint != "https://override.example/youtube/oembed" {
+				t.Errorf("Match() = %v, want YouTube with the override's endpoint", provider)
+			}
+			if provider := registry.Match("https://open.spotify.com/track/abc"); provider != nil {
+				t.Errorf("Match() = %s, want no match for a provider only in the embedded file", provider.Name)
+			}
+		})
+	}
+}
+
+func TestOEmbedProvidersOverrideFallsBack(t *testing.T) {
+	embedded, err := NewOEmbedRegistry()
+	if err != nil {
+		t.Fatalf("NewOEmbedRegistry() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.json")
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(malformed, []byte(`{"not": "a list"`), 0644)
+	os.WriteFile(empty, []byte(`[]`), 0644)
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"Missing file", filepath.Join(dir, "missing.json")},
+		{"Malformed file", malformed},
+		{"No usable providers", empty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewJobProcessor(createTestLogger(), nil, nil)
+			processor.SetOEmbedProvidersSource(context.Background(), tt.source)
+
+			if count := processor.oembedExtractor.registry.GetProviderCount(); count != embedded.GetProviderCount() {
+				t.Errorf("provider count = %d, want the embedded %d", count, embedded.GetProviderCount())
+			}
+			if _, err := processor.ReloadOEmbedProviders(context.Background()); err == nil {
+				t.Error("ReloadOEmbedProviders() should fail for a bad source")
+			}
+			if count := processor.oembedExtractor.registry.GetProviderCount(); count != embedded.GetProviderCount() {
+				t.Errorf("provider count after failed reload = %d, want the embedded %d", count, embedded.GetProviderCount())
+			}
+		})
+	}
+}
+
+func TestReloadOEmbedProvidersPicksUpChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oembed_providers.json")
+	if err := os.WriteFile(path, []byte(overrideProvidersJSON), 0644); err != nil {
+		t.Fatalf("failed to write providers file: %v", err)
+	}
+
+	processor := NewJobProcessor(createTestLogger(), nil, nil)
+	processor.SetOEmbedProvidersSource(context.Background(), path)
+
+	updated := `[{"provider_name": "Radio", "endpoints": [{"url": "https://radio.example/oembed", "schemes": ["https://radio.example/*"]}]}]`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatalf("failed to update providers file: %v", err)
+	}
+
+	count, err := processor.ReloadOEmbedProviders(context.Background())
+	if err != nil {
+		t.Fatalf("ReloadOEmbedProviders() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("ReloadOEmbedProviders() = %d, want 1", count)
+	}
+	if processor.oembedExtractor.registry.Match("https://radio.example/show/1") == nil {
+		t.Error("provider added to the file should match after reload")
+	}
+	if processor.oembedExtractor.registry.Match("https://mixtapes.example/tape/1") != nil {
+		t.Error("provider removed from the file should not match after reload")
+	}
+}
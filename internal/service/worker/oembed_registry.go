@@ -1,11 +1,14 @@
 package worker
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 //go:embed oembed_providers.json
@@ -18,8 +21,10 @@ type OEmbedProvider struct {
 	Schemes  []*regexp.Regexp // Compiled regex patterns for URL matching
 }
 
-// OEmbedRegistry manages oEmbed providers and matches URLs to providers
+// OEmbedRegistry manages oEmbed providers and matches URLs to providers. Its providers can
+// be replaced while it's in use, when the providers file is reloaded
 type OEmbedRegistry struct {
+	mu        sync.RWMutex
 	providers []*OEmbedProvider
 }
 
@@ -33,26 +38,72 @@ type rawProvider struct {
 	} `json:"endpoints"`
 }
 
+// requiredOEmbedProviders are providers whose absence means the providers file is broken,
+// as most shared links go through them
+var requiredOEmbedProviders = []string{"YouTube", "Spotify", "SoundCloud"}
+
+// OEmbedSkip is a provider or scheme from the providers file that wasn't loaded
+type OEmbedSkip struct {
+	Provider string
+	Scheme   string // empty when the whole provider was skipped
+	Reason   string
+}
+
+// OEmbedRegistryReport describes how much of the providers file was loaded
+type OEmbedRegistryReport struct {
+	ProvidersLoaded  int
+	ProvidersSkipped int
+	SchemesLoaded    int
+	SchemesSkipped   int
+	Skipped          []OEmbedSkip
+
+	// MissingRequired lists required providers that weren't loaded
+	MissingRequired []string
+}
+
 // NewOEmbedRegistry creates and initializes a new oEmbed registry
 func NewOEmbedRegistry() (*OEmbedRegistry, error) {
+	registry, _, err := loadOEmbedRegistry(oembedProvidersJSON)
+	return registry, err
+}
+
+// ValidateOEmbedProviders loads the providers file from source (see
+// JobProcessor.SetOEmbedProvidersSource), or the embedded one when source is empty, and
+// reports what was loaded, what was skipped and why
+func ValidateOEmbedProviders(ctx context.Context, source string) (*OEmbedRegistryReport, error) {
+	_, report, err := loadOEmbedProviders(ctx, source)
+	return report, err
+}
+
+// loadOEmbedRegistry builds a registry from a providers file in the oembed.com format.
+// Providers without a usable endpoint or scheme and schemes that aren't http(s) URL
+// patterns are skipped and recorded in the report
+func loadOEmbedRegistry(data []byte) (*OEmbedRegistry, *OEmbedRegistryReport, error) {
 	var rawProviders []rawProvider
-	if err := json.Unmarshal(oembedProvidersJSON, &rawProviders); err != nil {
-		return nil, fmt.Errorf("failed to parse oEmbed providers: %w", err)
+	if err := json.Unmarshal(data, &rawProviders); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse oEmbed providers: %w", err)
 	}
 
 	registry := &OEmbedRegistry{
 		providers: make([]*OEmbedProvider, 0, len(rawProviders)),
 	}
+	report := &OEmbedRegistryReport{}
+	skipProvider := func(name, reason string) {
+		report.ProvidersSkipped++
+		report.Skipped = append(report.Skipped, OEmbedSkip{Provider: name, Reason: reason})
+	}
 
 	// Parse and compile patterns for each provider
 	for _, raw := range rawProviders {
 		if len(raw.Endpoints) == 0 {
+			skipProvider(raw.ProviderName, "no endpoints")
 			continue
 		}
 
 		// Use first endpoint (most providers have only one)
 		endpoint := raw.Endpoints[0]
 		if endpoint.URL == "" {
+			skipProvider(raw.ProviderName, "endpoint has no URL")
 			continue
 		}
 
@@ -72,27 +123,69 @@ func NewOEmbedRegistry() (*OEmbedRegistry, error) {
 
 		// Compile URL patterns into regexes
 		for _, scheme := range schemes {
-			pattern := schemeToRegex(scheme)
-			regex, err := regexp.Compile(pattern)
+			if !strings.HasPrefix(scheme, "http://") && !strings.HasPrefix(scheme, "https://") {
+				report.SchemesSkipped++
+				report.Skipped = append(report.Skipped, OEmbedSkip{Provider: raw.ProviderName, Scheme: scheme, Reason: "not an http(s) URL pattern"})
+				continue
+			}
+			regex, err := regexp.Compile(schemeToRegex(scheme))
 			if err != nil {
-				// Skip invalid patterns
+				report.SchemesSkipped++
+				report.Skipped = append(report.Skipped, OEmbedSkip{Provider: raw.ProviderName, Scheme: scheme, Reason: err.Error()})
 				continue
 			}
 			provider.Schemes = append(provider.Schemes, regex)
 		}
 
 		// Only add provider if it has at least one valid scheme
-		if len(provider.Schemes) > 0 {
-			registry.providers = append(registry.providers, provider)
+		if len(provider.Schemes) == 0 {
+			skipProvider(raw.ProviderName, "no valid schemes")
+			continue
+		}
+		registry.providers = append(registry.providers, provider)
+		report.ProvidersLoaded++
+		report.SchemesLoaded += len(provider.Schemes)
+	}
+
+	for _, name := range requiredOEmbedProviders {
+		if registry.GetProvider(name) == nil {
+			report.MissingRequired = append(report.MissingRequired, name)
 		}
 	}
 
-	return registry, nil
+	return registry, report, nil
+}
+
+// logOEmbedRegistryReport logs what was skipped while loading the providers file at debug
+// level, and warns whenever a required provider is missing
+func logOEmbedRegistryReport(logger *slog.Logger, report *OEmbedRegistryReport) {
+	logger.Debug("oEmbed providers file validated",
+		"providers_loaded", report.ProvidersLoaded,
+		"providers_skipped", report.ProvidersSkipped,
+		"schemes_loaded", report.SchemesLoaded,
+		"schemes_skipped", report.SchemesSkipped,
+	)
+	for _, skip := range report.Skipped {
+		logger.Debug("oEmbed provider entry skipped", "provider", skip.Provider, "scheme", skip.Scheme, "reason", skip.Reason)
+	}
+	if len(report.MissingRequired) > 0 {
+		logger.Warn("oEmbed providers file is missing required providers", "missing", report.MissingRequired)
+	}
+}
+
+// replace swaps in the providers of another registry
+func (r *OEmbedRegistry) replace(other *OEmbedRegistry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers = other.providers
 }
 
 // Match finds an oEmbed provider for the given URL
 // Returns nil if no provider matches
 func (r *OEmbedRegistry) Match(url string) *OEmbedProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, provider := range r.providers {
 		for _, pattern := range provider.Schemes {
 			if pattern.MatchString(url) {
@@ -126,12 +219,17 @@ func schemeToRegex(scheme string) string {
 
 // GetProviderCount returns the total number of registered providers
 func (r *OEmbedRegistry) GetProviderCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return len(r.providers)
 }
 
 // GetProvider returns a provider by name (case-sensitive)
 // Useful for testing or debugging
 func (r *OEmbedRegistry) GetProvider(name string) *OEmbedProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, provider := range r.providers {
 		if provider.Name == name {
 			return provider
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"context"
 	"testing"
 )
 
@@ -17,6 +18,66 @@ func TestOEmbedRegistry(t *testing.T) {
 	t.Logf("Loaded %d oEmbed providers", registry.GetProviderCount())
 }
 
+func TestValidateOEmbedProviders(t *testing.T) {
+	report, err := ValidateOEmbedProviders(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ValidateOEmbedProviders() error = %v", err)
+	}
+
+	if len(report.MissingRequired) > 0 {
+		t.Errorf("required providers missing: %v", report.MissingRequired)
+	}
+
+	registry, err := NewOEmbedRegistry()
+	if err != nil {
+		t.Fatalf("NewOEmbedRegistry() error = %v", err)
+	}
+	for _, name := range []string{"YouTube", "Spotify", "SoundCloud"} {
+		if provider := registry.GetProvider(name); provider == nil || len(provider.Schemes) == 0 {
+			t.Errorf("provider %s was not loaded with any schemes", name)
+		}
+	}
+	if report.ProvidersLoaded == 0 || report.SchemesLoaded == 0 {
+		t.Errorf("loaded %d providers with %d schemes, want some", report.ProvidersLoaded, report.SchemesLoaded)
+	}
+	if got := len(report.Skipped); got != report.ProvidersSkipped+report.SchemesSkipped {
+		t.Errorf("report lists %d skips, want %d", got, report.ProvidersSkipped+report.SchemesSkipped)
+	}
+}
+
+func TestLoadOEmbedRegistryReportsSkips(t *testing.T) {
+	data := []byte(`[
+		{"provider_name": "Good", "endpoints": [{"url": "https://good.example/oembed", "schemes": ["https://good.example/*", "good:*"]}]},
+		{"provider_name": "NoEndpoints", "endpoints": []},
+		{"provider_name": "NoURL", "endpoints": [{"schemes": ["https://nourl.example/*"]}]},
+		{"provider_name": "NoSchemes", "endpoints": [{"url": "https://noschemes.example/oembed"}]}
+	]`)
+
+	registry, report, err := loadOEmbedRegistry(data)
+	if err != nil {
+		t.Fatalf("loadOEmbedRegistry() error = %v", err)
+	}
+
+	if registry.GetProvider("Good") == nil {
+		t.Error("provider Good should be loaded")
+	}
+	if report.ProvidersLoaded != 1 || report.ProvidersSkipped != 3 {
+		t.Errorf("providers loaded/skipped = %d/%d, want 1/3", report.ProvidersLoaded, report.ProvidersSkipped)
+	}
+	if report.SchemesLoaded != 1 || report.SchemesSkipped != 1 {
+		t.Errorf("schemes loaded/skipped = %d/%d, want 1/1", report.SchemesLoaded, report.SchemesSkipped)
+	}
+	if len(report.MissingRequired) != len(requiredOEmbedProviders) {
+		t.Errorf("missing required = %v, want all of %v", report.MissingRequired, requiredOEmbedProviders)
+	}
+}
+
+func TestLoadOEmbedRegistryMalformedFile(t *testing.T) {
+	if _, _, err := loadOEmbedRegistry([]byte(`{"not": "a list"`)); err == nil {
+		t.Error("loadOEmbedRegistry() with malformed JSON should fail")
+	}
+}
+
 func TestOEmbedRegistryMatching(t *testing.T) {
 	registry, err := NewOEmbedRegistry()
 	if err != nil {
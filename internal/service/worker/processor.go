@@ -1,10 +1,13 @@
 package worker
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/netguard"
 	"knock-fm/internal/pkg/urldetector"
 	"log/slog"
 	"net/http"
@@ -12,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
@@ -25,6 +29,131 @@ type JobProcessor struct {
 	knokRepo         domain.KnokRepository
 	serverRepo       domain.ServerRepository
 	oembedExtractor  *OEmbedExtractor
+
+	// oembedProvidersSource is a file path or URL overriding the embedded oEmbed providers;
+	// empty uses the embedded file
+	oembedProvidersSource string
+
+	// notifier sends completion notifications to Discord; nil disables them
+	notifier discordNotifier
+
+	// now returns the current time, for checking quiet hours; nil uses time.Now
+	now func() time.Time
+
+	// rodDomains lists JS-only sites whose URLs skip the oEmbed and HTTP tiers and go
+	// straight to Rod. A domain also matches its subdomains
+	rodDomains []string
+
+	// imageHosts, when set, are the only hosts extracted images are kept from. A host
+	// also matches its subdomains
+	imageHosts []string
+
+	// markLowQuality gives extractions with only a URL-equal title the low_quality status
+	markLowQuality bool
+
+	// redactMessageContent clears a knok's message content once its extraction finishes
+	redactMessageContent bool
+
+	// retryBudget counts extraction failures in knok metadata
+	retryBudget domain.ExtractionRetryBudget
+
+	// markRestricted gives knoks whose page is private, age-restricted or behind a login
+	// the restricted status instead of extracting a fallback title from it
+	markRestricted bool
+
+	// knokLock stops two workers extracting the same knok at once; nil disables locking
+	knokLock knokLocker
+
+	// publicHostsOnly refuses to fetch pages on non-public addresses, for extractions
+	// requested by API users rather than from Discord
+	publicHostsOnly bool
+
+	// rodExtract runs the Rod tier; nil uses extractMetadataWithRodSimple
+	rodExtract func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error)
+}
+
+// discordNotifier is the part of the Discord session used for completion notifications
+type discordNotifier interface {
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	MessageThreadStart(channelID, messageID string, name string, archiveDuration int, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+}
+
+// extractionResources holds the HTTP client and headless browser used for metadata extraction.
+// A batch shares one set across all of its URLs; the browser is only launched when a URL
+// falls through to the Rod tier.
+type extractionResources struct {
+	logger     *slog.Logger
+	httpClient *http.Client
+
+	// publicOnly restricts HTTP requests to public addresses and disables the browser
+	publicOnly bool
+
+	launcher *launcher.Launcher
+	browser  *rod.Browser
+}
+
+// newExtractionResources creates extraction resources with a fresh HTTP client
+func newExtractionResources(logger *slog.Logger) *extractionResources {
+	return &extractionResources{
+		logger: logger,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			// Follow redirects automatically
+			CheckRedirect: nil,
+		},
+	}
+}
+
+// Browser returns the shared headless browser, launching system Chromium on first use.
+// The browser is killed when ctx is done or Close is called.
+func (r *extractionResources) Browser(ctx context.Context) (*rod.Browser, error) {
+	if r.browser != nil {
+		return r.browser, nil
+	}
+
+	// Launch headless browser using system Chromium
+	l := launcher.New().
+		Bin("/usr/bin/chromium-browser"). // Use system Chromium in Alpine
+		Headless(true).
+		Set("no-sandbox").
+		Set("disable-web-security").
+		Set("disable-features", "VizDisplayCompositor").
+		Set("disable-extensions").
+		Set("disable-plugins")
+
+	r.logger.Info("Using Chromium browser", "path", "/usr/bin/chromium-browser")
+
+	controlURL, err := l.Context(ctx).Launch()
+	if err != nil {
+		l.Cleanup()
+		return nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+	r.logger.Info("Browser launched successfully", "control_url", controlURL)
+
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		l.Cleanup()
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+
+	r.launcher = l
+	r.browser = browser
+	return browser, nil
+}
+
+// Close shuts down the browser if one was launched
+func (r *extractionResources) Close() {
+	if r.browser != nil {
+		if err := r.browser.Close(); err != nil {
+			r.logger.Warn("Failed to close browser", "error", err)
+		}
+		r.browser = nil
+	}
+	if r.launcher != nil {
+		r.launcher.Cleanup()
+		r.launcher = nil
+	}
 }
 
 const (
@@ -38,7 +167,7 @@ func NewJobProcessor(
 	serverRepo domain.ServerRepository,
 ) *JobProcessor {
 	// Initialize oEmbed registry and extractor
-	oembedRegistry, err := NewOEmbedRegistry()
+	oembedRegistry, report, err := loadOEmbedRegistry(oembedProvidersJSON)
 	if err != nil {
 		logger.Error("Failed to initialize oEmbed registry", "error", err)
 		// Continue without oEmbed support
@@ -50,6 +179,7 @@ func NewJobProcessor(
 	}
 
 	logger.Info("oEmbed registry initialized", "provider_count", oembedRegistry.GetProviderCount())
+	logOEmbedRegistryReport(logger, report)
 
 	oembedExtractor := NewOEmbedExtractor(oembedRegistry, logger)
 
@@ -90,6 +220,26 @@ func (p *JobProcessor) ProcessMetadataExtraction(ctx context.Context, payload ma
 		"platform", platform,
 	)
 
+	// Older jobs and seeded knoks may not carry the poster
+	userID, _ := payload["discord_user_id"].(string)
+
+	resources := newExtractionResources(p.logger)
+	defer resources.Close()
+
+	return p.extractAndSaveMetadata(ctx, resources, knokID, url, userID, logger)
+}
+
+// extractAndSaveMetadata extracts metadata for a single knok and stores the result.
+// userID is the poster from the job payload, recorded if the knok was stored without one
+func (p *JobProcessor) extractAndSaveMetadata(ctx context.Context, resources *extractionResources, knokID uuid.UUID, url, userID string, logger *slog.Logger) error {
+	// Another job for this knok is running, e.g. a re-post and a refresh, so this one is a no-op
+	release, locked := p.lockKnok(ctx, knokID, logger)
+	if !locked {
+		logger.Info("Knok is already being extracted, skipping", "knok_id", knokID)
+		return nil
+	}
+	defer release()
+
 	// Update knok status to processing (if knok repo is available)
 	if p.knokRepo != nil {
 		if err := p.knokRepo.UpdateExtractionStatus(ctx, knokID, domain.ExtractionStatusProcessing); err != nil {
@@ -98,7 +248,12 @@ func (p *JobProcessor) ProcessMetadataExtraction(ctx context.Context, payload ma
 	}
 
 	// Extract metadata using three-tier strategy
-	extractedMetadata, extractionMethod, err := p.extractMetadataWithFallbacks(ctx, url)
+	timings := newExtractionTimings()
+	extractedMetadata, extractionMethod, err := p.extractMetadataWithFallbacks(ctx, resources, url, timings)
+	var restricted *RestrictedContentError
+	if errors.As(err, &restricted) {
+		return p.saveRestricted(ctx, knokID, restricted, logger)
+	}
 	if err != nil {
 		logger.Error("Failed to extract metadata with fallbacks", "error", err, "url", url)
 		// Create minimal fallback metadata
@@ -107,6 +262,7 @@ func (p *JobProcessor) ProcessMetadataExtraction(ctx context.Context, payload ma
 		}
 		extractionMethod = "error_fallback"
 	}
+	p.dropDisallowedImage(extractedMetadata)
 
 	// Create metadata with extracted data and extraction method info
 	metadata := map[string]interface{}{
@@ -137,18 +293,57 @@ func (p *JobProcessor) ProcessMetadataExtraction(ctx context.Context, payload ma
 			knok.Title = &title
 		}
 
+		if knok.DiscordUserID == nil && userID != "" {
+			knok.DiscordUserID = &userID
+		}
+
 		// Update metadata field
 		knok.Metadata = map[string]interface{}{
-			"extraction_method": extractionMethod,
-			"extraction_time":   time.Now().Unix(),
-			"image":             metadata["image"],
-			"site_name":         metadata["site_name"],
-			"title":             metadata["title"],
-			"description":       metadata["description"],
+			"extraction_method":  extractionMethod,
+			"extraction_time":    time.Now().Unix(),
+			"extraction_timings": timings.milliseconds(),
+			"image":              metadata["image"],
+			"site_name":          metadata["site_name"],
+			"title":              metadata["title"],
+			"description":        metadata["description"],
+		}
+
+		// The image gets its own column too; metadata.image stays for older readers
+		knok.ThumbnailURL = nil
+		if image := extractedMetadata["image"]; image != "" {
+			knok.ThumbnailURL = &image
+		}
+
+		// Track length, when the page or oEmbed provider gives one
+		if seconds, ok := parseDurationSeconds(extractedMetadata["duration"]); ok {
+			knok.Metadata["duration_seconds"] = seconds
 		}
 
 		// Update extraction status
 		knok.ExtractionStatus = domain.ExtractionStatusComplete
+		if p.markLowQuality && isLowQualityMetadata(extractedMetadata, url) {
+			logger.Info("Extraction only found a URL title, marking knok low quality",
+				"knok_id", knokID,
+				"extraction_method", extractionMethod,
+			)
+			knok.ExtractionStatus = domain.ExtractionStatusLowQuality
+		}
+
+		// Under the redact_after_extraction policy the message is only kept until now
+		if p.redactMessageContent {
+			knok.MessageContent = nil
+		}
+
+		// A page's <link rel="canonical"> can reveal that this URL is a variant of a page
+		// that already has a knok; if so this knok is merged into that one
+		if href := extractedMetadata["canonical_url"]; href != "" {
+			if canonicalLink, ok := resolveCanonicalLink(url, href); ok {
+				knok.Metadata["canonical_url"] = canonicalLink
+				if p.mergeCanonicalDuplicate(ctx, knok, canonicalLink, logger) {
+					return nil
+				}
+			}
+		}
 
 		// OG URL writeback: if og:url differs from canonical URL, re-canonicalize
 		if ogURL, ok := extractedMetadata["url"]; ok && ogURL != "" {
@@ -181,6 +376,54 @@ func (p *JobProcessor) ProcessMetadataExtraction(ctx context.Context, payload ma
 	return nil
 }
 
+// ExtractMetadata runs the four-tier extraction for a URL without touching any knok.
+// Returns the metadata and the extraction method that produced it.
+func (p *JobProcessor) ExtractMetadata(ctx context.Context, url string) (map[string]string, string, error) {
+	resources := newExtractionResources(p.logger)
+	if p.publicHostsOnly {
+		resources = newPublicExtractionResources(p.logger)
+	}
+	defer resources.Close()
+
+	metadata, method, err := p.extractMetadataWithFallbacks(ctx, resources, url, nil)
+	if err == nil {
+		p.dropDisallowedImage(metadata)
+	}
+	return metadata, method, err
+}
+
+// SetExtractionRetryBudget sets the retry budget extraction failures are counted against
+func (p *JobProcessor) SetExtractionRetryBudget(budget domain.ExtractionRetryBudget) {
+	p.retryBudget = budget
+}
+
+// RecordExtractionFailure marks a knok as failed and stores the reason in its metadata
+// as extraction_error, so failures can be triaged from the admin API. The failure is
+// counted against the knok's retry budget. A knok that finished extracting in the meantime,
+// e.g. an earlier item of an interrupted batch, is left as it is
+func (p *JobProcessor) RecordExtractionFailure(ctx context.Context, knokID uuid.UUID, reason string, logger *slog.Logger) {
+	if p.knokRepo == nil {
+		return
+	}
+
+	knok, err := p.knokRepo.GetByID(ctx, knokID)
+	if err != nil {
+		logger.Warn("Failed to get knok to record extraction failure", "error", err, "knok_id", knokID)
+		return
+	}
+	if knok.ExtractionStatus == domain.ExtractionStatusComplete {
+		return
+	}
+
+	p.retryBudget.RecordFailure(knok, p.currentTime())
+	knok.Metadata["extraction_error"] = reason
+	knok.ExtractionStatus = domain.ExtractionStatusFailed
+
+	if err := p.knokRepo.Update(ctx, knok); err != nil {
+		logger.Warn("Failed to record extraction failure", "error", err, "knok_id", knokID)
+	}
+}
+
 // ProcessKnok processes knok processing jobs
 func (p *JobProcessor) ProcessKnok(ctx context.Context, payload map[string]interface{}, logger *slog.Logger) error {
 	// This would handle additional knok processing beyond metadata extraction
@@ -189,22 +432,125 @@ func (p *JobProcessor) ProcessKnok(ctx context.Context, payload map[string]inter
 	return nil
 }
 
-// ProcessNotification processes notification jobs
+// ProcessNotification posts the result of a completed extraction back to Discord,
+// according to the server's notification_mode setting
 func (p *JobProcessor) ProcessNotification(ctx context.Context, payload map[string]interface{}, logger *slog.Logger) error {
-	// This would handle sending notifications about completed jobs
-	// For now, just log that we received the job
-	logger.Info("Processing notification job", "payload", payload)
+	knokIDStr, ok := payload["knok_id"].(string)
+	if !ok {
+		return fmt.Errorf("missing or invalid knok_id in payload")
+	}
+
+	knokID, err := uuid.Parse(knokIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid knok_id format: %w", err)
+	}
+
+	if p.notifier == nil || p.knokRepo == nil {
+		logger.Info("Skipping notification (no Discord session or knok repo available)", "knok_id", knokID)
+		return nil
+	}
+
+	knok, err := p.knokRepo.GetByID(ctx, knokID)
+	if err != nil {
+		if errors.Is(err, domain.ErrKnokNotFound) {
+			// Deleted since extraction, e.g. merged into an existing knok as a duplicate
+			logger.Info("Skipping notification for deleted knok", "knok_id", knokID)
+			return nil
+		}
+		return fmt.Errorf("failed to get knok for notification: %w", err)
+	}
+
+	mode := domain.NotificationModeReaction
+	if p.serverRepo != nil {
+		server, err := p.serverRepo.GetByID(ctx, knok.ServerID)
+		if err != nil {
+			logger.Warn("Failed to get server for notification, using default mode", "error", err, "server_id", knok.ServerID)
+		} else {
+			mode = server.NotificationMode()
+
+			if server.InQuietHours(p.currentTime()) {
+				logger.Info("Skipping completion notification during quiet hours",
+					"knok_id", knokID,
+					"server_id", knok.ServerID,
+				)
+				return nil
+			}
+		}
+	}
+
+	content := notificationContent(knok)
+
+	switch mode {
+	case domain.NotificationModeReply:
+		reference := &discordgo.MessageReference{
+			MessageID: knok.DiscordMessageID,
+			ChannelID: knok.DiscordChannelID,
+			GuildID:   knok.ServerID,
+		}
+		if _, err := p.notifier.ChannelMessageSendReply(knok.DiscordChannelID, content, reference); err != nil {
+			return fmt.Errorf("failed to send reply notification: %w", err)
+		}
+	case domain.NotificationModeThread:
+		thread, err := p.notifier.MessageThreadStart(knok.DiscordChannelID, knok.DiscordMessageID, threadName(knok), threadArchiveMinutes)
+		if err != nil {
+			return fmt.Errorf("failed to start notification thread: %w", err)
+		}
+		if _, err := p.notifier.ChannelMessageSend(thread.ID, content); err != nil {
+			return fmt.Errorf("failed to send thread notification: %w", err)
+		}
+	default:
+		// Silent and reaction modes don't post anything on completion
+		logger.Debug("No completion notification for mode", "knok_id", knokID, "notification_mode", mode)
+		return nil
+	}
+
+	logger.Info("Sent completion notification",
+		"knok_id", knokID,
+		"notification_mode", mode,
+	)
 	return nil
 }
 
-// extractOgMetadata fetches the HTML page and extracts the opengraph metadata tag values
-func (p *JobProcessor) extractOgMetadata(ctx context.Context, url string) (map[string]string, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		// Follow redirects automatically
-		CheckRedirect: nil,
+// currentTime returns the processor's notion of now
+func (p *JobProcessor) currentTime() time.Time {
+	if p.now != nil {
+		return p.now()
 	}
+	return time.Now()
+}
 
+const (
+	// threadArchiveMinutes is how long notification threads stay open without activity
+	threadArchiveMinutes = 1440
+
+	// maxThreadNameLength is Discord's limit on channel and thread names
+	maxThreadNameLength = 100
+)
+
+// notificationContent formats the completion message for a knok
+func notificationContent(knok *domain.Knok) string {
+	if knok.ExtractionStatus == domain.ExtractionStatusFailed || knok.Title == nil || *knok.Title == "" {
+		return "🎵 Saved, but couldn't fetch details for this link"
+	}
+	return fmt.Sprintf("🎵 Saved: **%s**", *knok.Title)
+}
+
+// threadName returns the name for a knok's notification thread, truncated to Discord's limit
+func threadName(knok *domain.Knok) string {
+	name := "🎵 Knok"
+	if knok.Title != nil && *knok.Title != "" {
+		name = *knok.Title
+	}
+
+	runes := []rune(name)
+	if len(runes) > maxThreadNameLength {
+		name = string(runes[:maxThreadNameLength])
+	}
+	return name
+}
+
+// extractOgMetadata fetches the HTML page and extracts the opengraph metadata tag values
+func (p *JobProcessor) extractOgMetadata(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -228,22 +574,32 @@ func (p *JobProcessor) extractOgMetadata(ctx context.Context, url string) (map[s
 	req.Header.Set("Cache-Control", "max-age=0")
 
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := resources.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Limit response body size to prevent memory issues
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Private, age-restricted and login-walled pages have nothing worth extracting
+	if p.markRestricted {
+		if reason, ok := detectRestrictedPage(resp.StatusCode, body); ok {
+			return nil, &RestrictedContentError{Reason: reason, StatusCode: resp.StatusCode}
+		}
+	}
+
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
 	}
 
-	// Limit response body size to prevent memory issues
-	limitedReader := io.LimitReader(resp.Body, 1024*1024) // 1MB limit
-
 	// Parse HTML once and extract all Open Graph metadata
-	return p.extractOgMetadataFromHTML(limitedReader)
+	return p.extractOgMetadataFromHTML(bytes.NewReader(body))
 }
 
 // extractOgMetadataFromHTML parses HTML and extracts all Open Graph metadata tags
@@ -268,6 +624,25 @@ func (p *JobProcessor) extractOgMetadataFromHTML(r io.Reader) (map[string]string
 
 // findOgMetaInNode recursively searches for Open Graph and Twitter Card meta tags and collects their content
 func (p *JobProcessor) findOgMetaInNode(n *html.Node, ogData map[string]string) {
+	// Canonical link: <link rel="canonical" href="...">. Only the first one counts
+	if n.Type == html.ElementNode && n.Data == "link" {
+		var isCanonical bool
+		var href string
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "rel":
+				for _, rel := range strings.Fields(strings.ToLower(attr.Val)) {
+					isCanonical = isCanonical || rel == "canonical"
+				}
+			case "href":
+				href = attr.Val
+			}
+		}
+		if _, exists := ogData["canonical_url"]; isCanonical && href != "" && !exists {
+			ogData["canonical_url"] = href
+		}
+	}
+
 	if n.Type == html.ElementNode && n.Data == "meta" {
 		var property, content string
 
@@ -275,6 +650,12 @@ func (p *JobProcessor) findOgMetaInNode(n *html.Node, ogData map[string]string)
 		for _, attr := range n.Attr {
 			if attr.Key == "content" {
 				content = attr.Val
+			} else if attr.Key == "property" && (attr.Val == "music:duration" || attr.Val == "video:duration") {
+				// Track or video length: <meta property="music:duration" content="213">
+				property = "duration"
+			} else if attr.Key == "itemprop" && attr.Val == "duration" {
+				// Schema.org length, usually ISO 8601: <meta itemprop="duration" content="PT3M33S">
+				property = "duration"
 			} else if attr.Key == "property" && strings.HasPrefix(attr.Val, "og:") {
 				// OpenGraph tags: <meta property="og:title" content="...">
 				property = strings.TrimPrefix(attr.Val, "og:")
@@ -313,14 +694,7 @@ func (p *JobProcessor) findOgMetaInNode(n *html.Node, ogData map[string]string)
 }
 
 // extractTitleFromURL fetches the HTML page and extracts the title
-func (p *JobProcessor) extractTitleFromURL(ctx context.Context, url string) (string, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		// Follow redirects automatically
-		CheckRedirect: nil,
-	}
-
+func (p *JobProcessor) extractTitleFromURL(ctx context.Context, resources *extractionResources, url string) (string, error) {
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -341,7 +715,7 @@ func (p *JobProcessor) extractTitleFromURL(ctx context.Context, url string) (str
 	req.Header.Set("Cache-Control", "max-age=0")
 
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := resources.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
@@ -531,44 +905,14 @@ func (p *JobProcessor) extractMetadataWithRod(ctx context.Context, url string) (
 }
 
 // extractMetadataWithRodSimple uses the simplest possible Rod approach with proper error handling
-func (p *JobProcessor) extractMetadataWithRodSimple(ctx context.Context, url string) (map[string]string, error) {
+func (p *JobProcessor) extractMetadataWithRodSimple(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
 	p.logger.Info("Starting simple Rod metadata extraction", "url", url)
 
-	// Create timeout context
-	rodCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-
-	// Launch headless browser using system Chromium
-	l := launcher.New().
-		Bin("/usr/bin/chromium-browser"). // Use system Chromium in Alpine
-		Headless(true).
-		Set("no-sandbox").
-		Set("disable-web-security").
-		Set("disable-features", "VizDisplayCompositor").
-		Set("disable-extensions").
-		Set("disable-plugins")
-
-	p.logger.Info("Using Chromium browser", "path", "/usr/bin/chromium-browser")
-
-	defer l.Cleanup()
-
-	// Launch browser
-	controlURL, err := l.Context(rodCtx).Launch()
+	// Launches the browser on first use; later URLs in a batch reuse it
+	browser, err := resources.Browser(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to launch browser: %w", err)
-	}
-	p.logger.Info("Browser launched successfully", "control_url", controlURL)
-
-	// Connect to browser
-	browser := rod.New().ControlURL(controlURL)
-	if err := browser.Connect(); err != nil {
-		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if err := browser.Close(); err != nil {
-			p.logger.Warn("Failed to close browser", "error", err)
-		}
-	}()
 
 	p.logger.Info("Rod browser connected", "url", url)
 
@@ -632,14 +976,46 @@ func (p *JobProcessor) extractMetadataWithRodSimple(ctx context.Context, url str
 	return metadata, nil
 }
 
-// extractMetadataWithFallbacks implements the four-tier metadata extraction strategy
-func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, url string) (map[string]string, string, error) {
+// extractMetadataWithFallbacks implements the four-tier metadata extraction strategy. How
+// long each tier took is logged and recorded in timings, which may be nil
+func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, resources *extractionResources, url string, timings *extractionTimings) (map[string]string, string, error) {
 	p.logger.Info("Starting four-tier metadata extraction", "url", url)
 
+	if timings == nil {
+		timings = newExtractionTimings()
+	}
+	ctx, done := timings.startTotal(ctx, url)
+	defer func() {
+		done()
+		p.logger.Info("Metadata extraction timings", "url", url, timings.logAttr())
+	}()
+
+	// Known JS-only sites never yield metadata from oEmbed or static HTML, so try Rod
+	// first and only fall back to the HTTP tiers if it fails
+	var rodMetadata map[string]string
+	var rodErr error
+	rodTried := false
+	if p.isRodDomain(url) {
+		p.logger.Info("Rod domain: skipping oEmbed and HTTP tiers", "url", url)
+		rodCtx, rodDone := timings.start(ctx, timingRod)
+		rodMetadata, rodErr = p.runRodExtraction(rodCtx, resources, url)
+		rodDone()
+		rodTried = true
+		if rodErr == nil && rodMetadata["title"] != "" && (rodMetadata["description"] != "" || rodMetadata["image"] != "") {
+			p.logger.Info("Rod extraction successful for Rod domain",
+				"url", url,
+				"title", rodMetadata["title"])
+			return rodMetadata, "rod_browser", nil
+		}
+		p.logger.Warn("Rod extraction incomplete for Rod domain, falling back to HTTP tiers", "error", rodErr, "url", url)
+	}
+
 	// Tier 0: oEmbed API (fastest, most reliable for supported providers)
-	if p.oembedExtractor != nil {
+	if p.oembedExtractor != nil && !rodTried {
 		p.logger.Info("Tier 0: Attempting oEmbed metadata extraction", "url", url)
-		oembedMetadata, err := p.oembedExtractor.TryExtract(ctx, url)
+		oembedCtx, oembedDone := timings.start(ctx, timingOEmbed)
+		oembedMetadata, err := p.oembedExtractor.TryExtract(oembedCtx, url)
+		oembedDone()
 		if err != nil {
 			// oEmbed failed, but continue to fallback tiers
 			p.logger.Warn("oEmbed extraction failed", "error", err, "url", url)
@@ -663,7 +1039,18 @@ func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, url str
 
 	// Tier 1: HTTP + Static HTML Parsing
 	p.logger.Info("Tier 1: Attempting HTTP-based metadata extraction", "url", url)
-	httpMetadata, err := p.extractOgMetadata(ctx, url)
+	httpCtx, httpDone := timings.start(ctx, timingHTTP)
+	httpMetadata, err := p.extractOgMetadata(httpCtx, resources, url)
+	httpDone()
+	var restricted *RestrictedContentError
+	if errors.As(err, &restricted) {
+		p.logger.Info("Page is restricted, skipping remaining tiers", "url", url, "reason", restricted.Reason)
+		return nil, "", err
+	}
+	if errors.Is(err, netguard.ErrNonPublicAddress) {
+		p.logger.Warn("Refused to fetch non-public address, skipping remaining tiers", "url", url, "error", err)
+		return nil, "", err
+	}
 	if err != nil {
 		p.logger.Warn("HTTP metadata extraction failed", "error", err, "url", url)
 		httpMetadata = make(map[string]string)
@@ -679,7 +1066,9 @@ func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, url str
 		"total_fields", len(httpMetadata))
 
 	// Get basic title as fallback
-	title, titleErr := p.extractTitleFromURL(ctx, url)
+	titleCtx, titleDone := timings.start(ctx, timingTitle)
+	title, titleErr := p.extractTitleFromURL(titleCtx, resources, url)
+	titleDone()
 	if titleErr != nil {
 		p.logger.Warn("Title extraction failed", "error", titleErr, "url", url)
 		title = "Unknown Title"
@@ -708,8 +1097,12 @@ func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, url str
 	}
 
 	// Tier 2: Rod Headless Browser (for JavaScript-rendered content)
-	p.logger.Info("Tier 2: Attempting Rod-based metadata extraction", "url", url)
-	rodMetadata, rodErr := p.extractMetadataWithRodSimple(ctx, url)
+	if !rodTried {
+		p.logger.Info("Tier 2: Attempting Rod-based metadata extraction", "url", url)
+		rodCtx, rodDone := timings.start(ctx, timingRod)
+		rodMetadata, rodErr = p.runRodExtraction(rodCtx, resources, url)
+		rodDone()
+	}
 
 	if rodErr != nil {
 		p.logger.Warn("Rod metadata extraction skipped/failed", "error", rodErr, "url", url)
@@ -772,6 +1165,12 @@ func (p *JobProcessor) extractMetadataWithFallbacks(ctx context.Context, url str
 	if httpMetadata["site_name"] != "" {
 		fallbackMetadata["site_name"] = httpMetadata["site_name"]
 	}
+	if httpMetadata["canonical_url"] != "" {
+		fallbackMetadata["canonical_url"] = httpMetadata["canonical_url"]
+	}
+	if httpMetadata["duration"] != "" {
+		fallbackMetadata["duration"] = httpMetadata["duration"]
+	}
 
 	return fallbackMetadata, "title_fallback", nil
 }
@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// stubKnokRepo stores knoks by ID; other methods are unimplemented
+type stubKnokRepo struct {
+	domain.KnokRepository
+	mu    sync.Mutex
+	knoks map[uuid.UUID]*domain.Knok
+}
+
+func (r *stubKnokRepo) Update(ctx context.Context, knok *domain.Knok) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.knoks[knok.ID] = knok
+	return nil
+}
+
+func (r *stubKnokRepo) UpdateExtractionStatus(ctx context.Context, id uuid.UUID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	knok, ok := r.knoks[id]
+	if !ok {
+		return domain.ErrKnokNotFound
+	}
+	knok.ExtractionStatus = status
+	return nil
+}
+
+func (r *stubKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	knok, ok := r.knoks[id]
+	if !ok {
+		return nil, domain.ErrKnokNotFound
+	}
+	return knok, nil
+}
+
+func (r *stubKnokRepo) GetByCanonicalURL(ctx context.Context, serverID, canonicalURL string) (*domain.Knok, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, knok := range r.knoks {
+		if knok.ServerID == serverID && knok.CanonicalURL == canonicalURL {
+			return knok, nil
+		}
+	}
+	return nil, domain.ErrKnokNotFound
+}
+
+func (r *stubKnokRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.knoks[id]; !ok {
+		return domain.ErrKnokNotFound
+	}
+	delete(r.knoks, id)
+	return nil
+}
+
+// stubServerRepo serves a single server; other methods are unimplemented
+type stubServerRepo struct {
+	domain.ServerRepository
+	server *domain.Server
+}
+
+func (r *stubServerRepo) GetByID(ctx context.Context, id string) (*domain.Server, error) {
+	return r.server, nil
+}
+
+// fakeNotifier records Discord messages instead of sending them
+type fakeNotifier struct {
+	messages []string
+	replies  []string
+	threads  []string
+}
+
+func (f *fakeNotifier) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.messages = append(f.messages, content)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+func (f *fakeNotifier) ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.replies = append(f.replies, content)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+func (f *fakeNotifier) MessageThreadStart(channelID, messageID string, name string, archiveDuration int, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.threads = append(f.threads, name)
+	return &discordgo.Channel{ID: "thread-1"}, nil
+}
+
+func TestProcessNotification(t *testing.T) {
+	title := "Artist - Track"
+	knok := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         "guild-1",
+		DiscordMessageID: "msg-1",
+		DiscordChannelID: "channel-1",
+		Title:            &title,
+		ExtractionStatus: domain.ExtractionStatusComplete,
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		wantReplies  int
+		wantThreads  int
+		wantMessages int
+	}{
+		{name: "Silent", mode: domain.NotificationModeSilent},
+		{name: "Reaction", mode: domain.NotificationModeReaction},
+		{name: "Reply", mode: domain.NotificationModeReply, wantReplies: 1},
+		{name: "Thread", mode: domain.NotificationModeThread, wantThreads: 1, wantMessages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &fakeNotifier{}
+			processor := &JobProcessor{
+				logger:     createTestLogger(),
+				knokRepo:   &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+				serverRepo: &stubServerRepo{server: &domain.Server{ID: "guild-1", Settings: map[string]interface{}{"notification_mode": tt.mode}}},
+				notifier:   notifier,
+			}
+
+			err := processor.ProcessNotification(context.Background(), map[string]interface{}{"knok_id": knok.ID.String()}, createTestLogger())
+			if err != nil {
+				t.Fatalf("ProcessNotification() error = %v", err)
+			}
+
+			if len(notifier.replies) != tt.wantReplies {
+				t.Errorf("got %d replies, want %d", len(notifier.replies), tt.wantReplies)
+			}
+			if len(notifier.threads) != tt.wantThreads {
+				t.Errorf("got %d threads, want %d", len(notifier.threads), tt.wantThreads)
+			}
+			if len(notifier.messages) != tt.wantMessages {
+				t.Errorf("got %d messages, want %d", len(notifier.messages), tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestProcessNotificationQuietHours(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head>
+			<meta property="og:title" content="Late Night Mix">
+			<meta property="og:description" content="A mix">
+		</head></html>`)
+	}))
+	defer server.Close()
+
+	knok := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         "guild-1",
+		URL:              server.URL + "/mix",
+		DiscordMessageID: "msg-1",
+		DiscordChannelID: "channel-1",
+		ExtractionStatus: domain.ExtractionStatusPending,
+	}
+
+	notifier := &fakeNotifier{}
+	processor := &JobProcessor{
+		logger:   createTestLogger(),
+		knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+		serverRepo: &stubServerRepo{server: &domain.Server{ID: "guild-1", Settings: map[string]interface{}{
+			"notification_mode": domain.NotificationModeReply,
+			"quiet_hours_start": "22:00",
+			"quiet_hours_end":   "07:00",
+		}}},
+		notifier: notifier,
+		now: func() time.Time {
+			return time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+		},
+	}
+
+	payload := map[string]interface{}{
+		"knok_id":  knok.ID.String(),
+		"url":      knok.URL,
+		"platform": domain.PlatformUnknown,
+	}
+	if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+	}
+	if err := processor.ProcessNotification(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessNotification() error = %v", err)
+	}
+
+	if knok.ExtractionStatus != domain.ExtractionStatusComplete {
+		t.Errorf("knok status = %q, want %q", knok.ExtractionStatus, domain.ExtractionStatusComplete)
+	}
+	if len(notifier.replies)+len(notifier.messages)+len(notifier.threads) != 0 {
+		t.Errorf("expected no Discord messages during quiet hours, got replies=%v messages=%v threads=%v",
+			notifier.replies, notifier.messages, notifier.threads)
+	}
+}
@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"errors"
+	"knock-fm/internal/pkg/netguard"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// errBrowserNotPublicOnly is returned by the Rod tier for public-only extractions: the
+// browser makes its own connections, which can't be limited to public addresses
+var errBrowserNotPublicOnly = errors.New("headless browser is disabled for public-only extraction")
+
+// SetPublicHostsOnly sets whether ExtractMetadata refuses to connect to loopback, private,
+// link-local and unspecified addresses. The address is checked when each connection is
+// dialed, so redirects and DNS rebinding can't reach internal services. The Rod tier is
+// skipped while this is set
+func (p *JobProcessor) SetPublicHostsOnly(publicOnly bool) {
+	p.publicHostsOnly = publicOnly
+}
+
+// newPublicExtractionResources creates extraction resources whose HTTP client only
+// connects to public addresses
+func newPublicExtractionResources(logger *slog.Logger) *extractionResources {
+	return &extractionResources{
+		logger: logger,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: netguard.NewTransport(),
+		},
+		publicOnly: true,
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"knock-fm/internal/pkg/netguard"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExtractMetadataPublicHostsOnly(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Internal Dashboard</title></head></html>`))
+	}))
+	defer server.Close()
+
+	var rodCalls atomic.Int64
+	processor := &JobProcessor{
+		logger: createTestLogger(),
+		rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+			rodCalls.Add(1)
+			return map[string]string{"title": "Internal Dashboard", "image": "https://example.com/cover.jpg"}, nil
+		},
+	}
+	processor.SetRodDomains([]string{"127.0.0.1"})
+	processor.SetPublicHostsOnly(true)
+
+	_, _, err := processor.ExtractMetadata(context.Background(), server.URL+"/admin")
+	if !errors.Is(err, netguard.ErrNonPublicAddress) {
+		t.Fatalf("ExtractMetadata() error = %v, want ErrNonPublicAddress", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("server received %d requests, want 0", got)
+	}
+	if got := rodCalls.Load(); got != 0 {
+		t.Errorf("Rod tier ran %d times, want 0", got)
+	}
+}
@@ -0,0 +1,34 @@
+package worker
+
+import "strings"
+
+// SetMarkLowQuality sets whether extractions with only a URL-equal title are given the
+// low_quality status instead of complete
+func (p *JobProcessor) SetMarkLowQuality(mark bool) {
+	p.markLowQuality = mark
+}
+
+// isLowQualityMetadata reports whether extracted metadata has nothing better than the URL
+// itself: no image, a title that is empty or the URL, and a description that is empty or
+// the URL (the fallback tiers fill a missing description with the URL)
+func isLowQualityMetadata(metadata map[string]string, pageURL string) bool {
+	if metadata["image"] != "" {
+		return false
+	}
+	return isBareURLText(metadata["title"], pageURL) && isBareURLText(metadata["description"], pageURL)
+}
+
+// isBareURLText reports whether text is empty or the URL, ignoring case, the scheme,
+// a leading "www." and a trailing slash
+func isBareURLText(text, pageURL string) bool {
+	text = strings.TrimSpace(text)
+	return text == "" || normalizeURLText(text) == normalizeURLText(pageURL)
+}
+
+func normalizeURLText(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "https://")
+	s = strings.TrimPrefix(s, "http://")
+	s = strings.TrimPrefix(s, "www.")
+	return strings.TrimSuffix(s, "/")
+}
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIsLowQualityMetadata(t *testing.T) {
+	pageURL := "https://www.example.com/shows/late-night-mix/"
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     bool
+	}{
+		{
+			name:     "Bare title equal to the URL",
+			metadata: map[string]string{"title": pageURL, "description": pageURL},
+			want:     true,
+		},
+		{
+			name:     "Title is the URL without scheme or www",
+			metadata: map[string]string{"title": "example.com/shows/late-night-mix"},
+			want:     true,
+		},
+		{
+			name:     "No title",
+			metadata: map[string]string{},
+			want:     true,
+		},
+		{
+			name:     "Real title",
+			metadata: map[string]string{"title": "Late Night Mix", "description": pageURL},
+			want:     false,
+		},
+		{
+			name:     "URL title with a description",
+			metadata: map[string]string{"title": pageURL, "description": "Two hours of ambient"},
+			want:     false,
+		},
+		{
+			name:     "URL title with an image",
+			metadata: map[string]string{"title": pageURL, "image": "https://example.com/cover.jpg"},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLowQualityMetadata(tt.metadata, pageURL); got != tt.want {
+				t.Errorf("isLowQualityMetadata(%v) = %v, want %v", tt.metadata, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessMetadataExtractionLowQuality(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Some sites title their pages with the bare URL and nothing else
+		fmt.Fprintf(w, `<html><head><title>%s%s</title></head></html>`, server.URL, r.URL.Path)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	tests := []struct {
+		name       string
+		mark       bool
+		wantStatus string
+	}{
+		{name: "Marked low quality", mark: true, wantStatus: domain.ExtractionStatusLowQuality},
+		{name: "Marking disabled", mark: false, wantStatus: domain.ExtractionStatusComplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + "/mix",
+				CanonicalURL:     server.URL + "/mix",
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: knokRepo,
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					return nil, fmt.Errorf("rod disabled in tests")
+				},
+			}
+			processor.SetMarkLowQuality(tt.mark)
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			if knok.ExtractionStatus != tt.wantStatus {
+				t.Errorf("status = %q, want %q", knok.ExtractionStatus, tt.wantStatus)
+			}
+		})
+	}
+}
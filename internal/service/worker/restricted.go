@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons stored as restricted_reason on knoks whose page can't be read without signing in
+const (
+	restrictedReasonAgeRestricted = "age_restricted"
+	restrictedReasonPrivate       = "private"
+	restrictedReasonLoginRequired = "login_required"
+)
+
+// restrictedPageMarkers maps notices shown on private and age-gated pages (lowercased) to
+// the restriction they indicate. They're specific enough not to turn up on ordinary pages
+var restrictedPageMarkers = []struct {
+	marker string
+	reason string
+}{
+	{"sign in to confirm your age", restrictedReasonAgeRestricted},
+	{"this video is private", restrictedReasonPrivate},
+	{"this track is private", restrictedReasonPrivate},
+	{"this playlist is private", restrictedReasonPrivate},
+}
+
+// RestrictedContentError is returned by extraction when the page is private, age-restricted
+// or behind a login wall, so retrying or storing the page's title would be pointless
+type RestrictedContentError struct {
+	Reason     string
+	StatusCode int
+}
+
+func (e *RestrictedContentError) Error() string {
+	return fmt.Sprintf("restricted content (%s): HTTP %d", e.Reason, e.StatusCode)
+}
+
+// SetMarkRestricted sets whether private, age-restricted and login-walled pages give the
+// knok the restricted status instead of a fallback title
+func (p *JobProcessor) SetMarkRestricted(mark bool) {
+	p.markRestricted = mark
+}
+
+// detectRestrictedPage reports why a fetched page can't be read without signing in, if it
+// can't. 401 and 403 responses are always restricted; other pages only when they show a
+// private or age-gate notice
+func detectRestrictedPage(statusCode int, body []byte) (string, bool) {
+	lower := strings.ToLower(string(body))
+	for _, m := range restrictedPageMarkers {
+		if strings.Contains(lower, m.marker) {
+			return m.reason, true
+		}
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return restrictedReasonLoginRequired, true
+	case http.StatusForbidden:
+		return restrictedReasonPrivate, true
+	}
+	return "", false
+}
+
+// saveRestricted gives a knok whose page turned out to be restricted the restricted status,
+// recording why in its metadata. The job succeeds so the extraction isn't retried
+func (p *JobProcessor) saveRestricted(ctx context.Context, knokID uuid.UUID, restricted *RestrictedContentError, logger *slog.Logger) error {
+	logger.Info("Page is restricted, marking knok restricted",
+		"knok_id", knokID,
+		"reason", restricted.Reason,
+		"status_code", restricted.StatusCode,
+	)
+
+	if p.knokRepo == nil {
+		return nil
+	}
+
+	knok, err := p.knokRepo.GetByID(ctx, knokID)
+	if err != nil {
+		return fmt.Errorf("failed to get knok for update: %w", err)
+	}
+
+	if knok.Metadata == nil {
+		knok.Metadata = make(map[string]interface{})
+	}
+	knok.Metadata["restricted_reason"] = restricted.Reason
+	knok.Metadata["restricted_status_code"] = restricted.StatusCode
+	knok.Metadata["extraction_time"] = time.Now().Unix()
+	knok.ExtractionStatus = domain.ExtractionStatusRestricted
+
+	if p.redactMessageContent {
+		knok.MessageContent = nil
+	}
+
+	if err := p.knokRepo.Update(ctx, knok); err != nil {
+		return fmt.Errorf("failed to update knok: %w", err)
+	}
+	return nil
+}
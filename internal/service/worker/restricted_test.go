@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const ageGatePage = `<html><head><title>YouTube</title></head><body>Sign in to confirm your age</body></html>`
+
+func TestDetectRestrictedPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantReason string
+		wantOK     bool
+	}{
+		{name: "Age gate on a 403", statusCode: http.StatusForbidden, body: ageGatePage, wantReason: restrictedReasonAgeRestricted, wantOK: true},
+		{name: "Age gate on a 200", statusCode: http.StatusOK, body: ageGatePage, wantReason: restrictedReasonAgeRestricted, wantOK: true},
+		{name: "Private video", statusCode: http.StatusOK, body: "<p>This video is private.</p>", wantReason: restrictedReasonPrivate, wantOK: true},
+		{name: "Bare 401", statusCode: http.StatusUnauthorized, body: "", wantReason: restrictedReasonLoginRequired, wantOK: true},
+		{name: "Bare 403", statusCode: http.StatusForbidden, body: "Forbidden", wantReason: restrictedReasonPrivate, wantOK: true},
+		{name: "Ordinary page", statusCode: http.StatusOK, body: "<title>Late Night Mix</title>", wantOK: false},
+		{name: "Not found", statusCode: http.StatusNotFound, body: "Not Found", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := detectRestrictedPage(tt.statusCode, []byte(tt.body))
+			if ok != tt.wantOK || reason != tt.wantReason {
+				t.Errorf("detectRestrictedPage() = (%q, %v), want (%q, %v)", reason, ok, tt.wantReason, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProcessMetadataExtractionRestricted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, ageGatePage)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	tests := []struct {
+		name       string
+		mark       bool
+		wantStatus string
+		wantReason interface{}
+	}{
+		{name: "Marked restricted", mark: true, wantStatus: domain.ExtractionStatusRestricted, wantReason: restrictedReasonAgeRestricted},
+		{name: "Marking disabled", mark: false, wantStatus: domain.ExtractionStatusComplete, wantReason: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + "/watch",
+				CanonicalURL:     server.URL + "/watch",
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: knokRepo,
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					return nil, fmt.Errorf("rod disabled in tests")
+				},
+			}
+			processor.SetMarkRestricted(tt.mark)
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			if knok.ExtractionStatus != tt.wantStatus {
+				t.Errorf("status = %q, want %q", knok.ExtractionStatus, tt.wantStatus)
+			}
+			if got := knok.Metadata["restricted_reason"]; got != tt.wantReason {
+				t.Errorf("restricted_reason = %v, want %v", got, tt.wantReason)
+			}
+			if tt.mark && knok.Title != nil {
+				t.Errorf("title = %q, want none for a restricted page", *knok.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package worker
+
+import "knock-fm/internal/domain"
+
+// SetMessageContentRetention applies the message content retention policy to extraction:
+// under redact_after_extraction a knok's message content is cleared once extraction finishes
+func (p *JobProcessor) SetMessageContentRetention(policy string) {
+	p.redactMessageContent = policy == domain.MessageContentRetentionRedact
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestProcessMetadataExtractionRedactsMessageContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Late Night Mix</title></head></html>`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	tests := []struct {
+		name        string
+		policy      string
+		wantContent bool
+	}{
+		{name: "Redacted after extraction", policy: domain.MessageContentRetentionRedact, wantContent: false},
+		{name: "Kept under full retention", policy: domain.MessageContentRetentionFull, wantContent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "my friend's set last night " + server.URL + "/mix"
+			knok := &domain.Knok{
+				ID:               uuid.New(),
+				ServerID:         "guild-1",
+				URL:              server.URL + "/mix",
+				CanonicalURL:     server.URL + "/mix",
+				MessageContent:   &content,
+				ExtractionStatus: domain.ExtractionStatusPending,
+			}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}}
+			processor := &JobProcessor{
+				logger:   createTestLogger(),
+				knokRepo: knokRepo,
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					return nil, fmt.Errorf("rod disabled in tests")
+				},
+			}
+			processor.SetMessageContentRetention(tt.policy)
+
+			payload := map[string]interface{}{
+				"knok_id":  knok.ID.String(),
+				"url":      knok.URL,
+				"platform": domain.PlatformUnknown,
+			}
+			if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+				t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+			}
+
+			if knok.ExtractionStatus != domain.ExtractionStatusComplete {
+				t.Fatalf("status = %q, want %q", knok.ExtractionStatus, domain.ExtractionStatusComplete)
+			}
+			if gotContent := knok.MessageContent != nil; gotContent != tt.wantContent {
+				t.Errorf("message content kept = %v, want %v", gotContent, tt.wantContent)
+			}
+		})
+	}
+}
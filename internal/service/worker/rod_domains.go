@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// SetRodDomains sets the JS-only domains whose URLs are extracted with Rod first
+func (p *JobProcessor) SetRodDomains(domains []string) {
+	p.rodDomains = nil
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		if domain != "" {
+			p.rodDomains = append(p.rodDomains, domain)
+		}
+	}
+}
+
+// isRodDomain reports whether rawURL's host is a configured Rod domain or a subdomain of one
+func (p *JobProcessor) isRodDomain(rawURL string) bool {
+	if len(p.rodDomains) == 0 {
+		return false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	for _, domain := range p.rodDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// runRodExtraction runs the Rod tier through rodExtract if one is set
+func (p *JobProcessor) runRodExtraction(ctx context.Context, resources *extractionResources, rawURL string) (map[string]string, error) {
+	if resources.publicOnly {
+		return nil, errBrowserNotPublicOnly
+	}
+	if p.rodExtract != nil {
+		return p.rodExtract(ctx, resources, rawURL)
+	}
+	return p.extractMetadataWithRodSimple(ctx, resources, rawURL)
+}
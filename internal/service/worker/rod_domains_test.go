@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIsRodDomain(t *testing.T) {
+	processor := &JobProcessor{}
+	processor.SetRodDomains([]string{"dublab.com", " WWW.NTS.live "})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://dublab.com/archive/some-show", want: true},
+		{url: "https://www.dublab.com/archive/some-show", want: true},
+		{url: "https://DUBLAB.com/archive", want: true},
+		{url: "https://nts.live/shows/some-show", want: true},
+		{url: "https://notdublab.com/archive", want: false},
+		{url: "https://dublab.com.example.org/archive", want: false},
+		{url: "https://soundcloud.com/artist/track", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := processor.isRodDomain(tt.url); got != tt.want {
+				t.Errorf("isRodDomain(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractMetadataRodDomain(t *testing.T) {
+	var httpRequests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequests.Add(1)
+		// A JS-only site serves an empty shell without metadata
+		fmt.Fprint(w, `<html><head></head><body><div id="app"></div></body></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		rodDomains       []string
+		rodErr           error
+		wantMethod       string
+		wantHTTPRequests bool
+		wantRodCalls     int64
+	}{
+		{
+			name:             "Rod domain bypasses the HTTP tier",
+			rodDomains:       []string{"127.0.0.1"},
+			wantMethod:       "rod_browser",
+			wantHTTPRequests: false,
+			wantRodCalls:     1,
+		},
+		{
+			name:             "Rod domain falls back to HTTP when Rod fails",
+			rodDomains:       []string{"127.0.0.1"},
+			rodErr:           errors.New("failed to launch browser"),
+			wantMethod:       "title_fallback",
+			wantHTTPRequests: true,
+			wantRodCalls:     1,
+		},
+		{
+			name:             "Other domains try HTTP before Rod",
+			rodDomains:       []string{"dublab.com"},
+			wantMethod:       "rod_browser",
+			wantHTTPRequests: true,
+			wantRodCalls:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequests.Store(0)
+			var rodCalls atomic.Int64
+			processor := &JobProcessor{
+				logger: createTestLogger(),
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					rodCalls.Add(1)
+					if tt.rodErr != nil {
+						return nil, tt.rodErr
+					}
+					return map[string]string{"title": "Rendered Show", "image": "https://example.com/cover.jpg"}, nil
+				},
+			}
+			processor.SetRodDomains(tt.rodDomains)
+
+			_, method, err := processor.ExtractMetadata(context.Background(), server.URL+"/show")
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", method, tt.wantMethod)
+			}
+			if got := httpRequests.Load() > 0; got != tt.wantHTTPRequests {
+				t.Errorf("HTTP tier requested = %v, want %v", got, tt.wantHTTPRequests)
+			}
+			if got := rodCalls.Load(); got != tt.wantRodCalls {
+				t.Errorf("Rod tier ran %d times, want %d", got, tt.wantRodCalls)
+			}
+		})
+	}
+}
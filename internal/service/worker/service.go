@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"knock-fm/internal/config"
 	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/tracing"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -13,6 +14,10 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WorkerService processes background jobs
@@ -35,6 +40,12 @@ type WorkerService struct {
 
 	// WorkerStats tracks worker performance metrics
 	stats *WorkerStats
+
+	// paused is the pause state seen on the last poll, so changes are logged once
+	paused bool
+
+	// oembedReloadSeen is the last oEmbed providers reload request acted on, in unix nanoseconds
+	oembedReloadSeen int64
 }
 
 // WorkerStats tracks worker performance metrics
@@ -80,6 +91,17 @@ func New(
 
 	// Create job processor
 	processor := NewJobProcessor(logger, knokRepo, serverRepo)
+	processor.SetRodDomains(config.RodDomains)
+	processor.SetAllowedImageHosts(config.AllowedImageHosts)
+	processor.SetMarkLowQuality(config.MarkLowQualityMetadata)
+	processor.SetMarkRestricted(config.MarkRestrictedContent)
+	processor.SetExtractionRetryBudget(config.ExtractionRetryBudget)
+	processor.SetMessageContentRetention(config.MessageContentRetention)
+	processor.SetOEmbedProvidersSource(ctx, config.OEmbedProvidersSource)
+	processor.knokLock = queueRepo
+	if discordSession != nil {
+		processor.notifier = discordSession
+	}
 	workerService.processor = processor
 
 	return workerService, nil
@@ -89,6 +111,13 @@ func New(
 func (w *WorkerService) Start() error {
 	w.logger.Info("Starting worker service...")
 
+	// Providers were just loaded, so only reload requests made from now on apply
+	if requested, err := w.queueRepo.GetOEmbedReloadRequest(w.ctx); err != nil {
+		w.logger.Warn("Failed to get oEmbed providers reload request", "error", err)
+	} else {
+		w.oembedReloadSeen = requested
+	}
+
 	// Start job processing goroutines
 	go w.processJobs()
 
@@ -131,6 +160,7 @@ func (w *WorkerService) processJobs() {
 			return
 		case <-ticker.C:
 			w.writeHeartbeat()
+			w.reloadOEmbedProvidersIfRequested()
 			w.processPendingJobs()
 		}
 	}
@@ -145,11 +175,46 @@ func (w *WorkerService) writeHeartbeat() {
 	}
 }
 
+// reloadOEmbedProvidersIfRequested reloads the oEmbed providers when an admin has requested
+// it since the last reload
+func (w *WorkerService) reloadOEmbedProvidersIfRequested() {
+	requested, err := w.queueRepo.GetOEmbedReloadRequest(w.ctx)
+	if err != nil {
+		w.logger.Error("Failed to get oEmbed providers reload request", "error", err)
+		return
+	}
+	if requested == w.oembedReloadSeen {
+		return
+	}
+	w.oembedReloadSeen = requested
+
+	if _, err := w.processor.ReloadOEmbedProviders(w.ctx); err != nil {
+		w.logger.Error("Failed to reload oEmbed providers, keeping current providers", "error", err)
+	}
+}
+
 // processPendingJobs processes all pending jobs of a specific type
 func (w *WorkerService) processPendingJobs() {
+	// An admin can pause processing globally; jobs keep accumulating in the queue
+	paused, err := w.queueRepo.IsPaused(w.ctx)
+	if err != nil {
+		w.logger.Error("Failed to check whether job processing is paused", "error", err)
+		return
+	}
+	if paused != w.paused {
+		w.logger.Info("Job processing pause state changed", "paused", paused)
+		w.paused = paused
+	}
+	if paused {
+		return
+	}
+
 	// Process metadata extraction jobs
 	w.processJobType(domain.JobTypeExtractMetadata)
 
+	// Process batched metadata extraction jobs
+	w.processJobType(domain.JobTypeExtractMetadataBatch)
+
 	// Process knok processing jobs
 	w.processJobType(domain.JobTypeProcessKnok)
 
@@ -210,15 +275,47 @@ func (w *WorkerService) processJobType(jobType string) {
 }
 
 const (
-	// jobTimeout is the maximum time a single job is allowed to run before being abandoned.
+	// jobTimeout is the default maximum time a single job is allowed to run before being abandoned.
 	// This prevents stuck Chromium/Rod processes from deadlocking the worker.
+	// Override per job type with JOB_TIMEOUTS.
 	jobTimeout = 90 * time.Second
 
 	// heartbeatFile is written on every poll cycle with the current unix timestamp.
 	// Docker healthcheck reads this to detect a stuck worker.
 	heartbeatFile = "/tmp/worker-heartbeat"
+
+	// maxBatchTimeout is the default cap on the timeout of a batched job, which otherwise scales with its size
+	maxBatchTimeout = 5 * time.Minute
 )
 
+// configuredTimeout returns the JOB_TIMEOUTS override for a job type, or fallback if none is set
+func (w *WorkerService) configuredTimeout(jobType string, fallback time.Duration) time.Duration {
+	if w.config != nil {
+		if timeout, ok := w.config.JobTimeouts[jobType]; ok {
+			return timeout
+		}
+	}
+	return fallback
+}
+
+// jobTimeoutFor returns the timeout for a job. Batched jobs get the extract_metadata timeout
+// per item, capped at the extract_metadata_batch timeout.
+func (w *WorkerService) jobTimeoutFor(job *domain.QueueJob) time.Duration {
+	if job.Type != domain.JobTypeExtractMetadataBatch {
+		return w.configuredTimeout(job.Type, jobTimeout)
+	}
+
+	perItem := w.configuredTimeout(domain.JobTypeExtractMetadata, jobTimeout)
+	maxTimeout := w.configuredTimeout(domain.JobTypeExtractMetadataBatch, maxBatchTimeout)
+
+	items, err := parseBatchItems(job.Payload)
+	if err != nil || len(items) == 0 {
+		return min(perItem, maxTimeout)
+	}
+
+	return min(perItem*time.Duration(len(items)), maxTimeout)
+}
+
 // processJob processes a single job in an isolated goroutine with a hard timeout.
 // If the job doesn't complete within jobTimeout, the worker moves on.
 func (w *WorkerService) processJob(job *domain.QueueJob) {
@@ -235,24 +332,23 @@ func (w *WorkerService) processJob(job *domain.QueueJob) {
 		jobLogger.Error("Failed to mark job as processing", "error", err)
 	}
 
+	// Continue the trace the job was queued in, so a knok's lifecycle is a single trace
+	traceCtx, span := tracing.Tracer().Start(tracing.Extract(w.ctx, job.TraceContext), "worker.process_job",
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+		),
+	)
+	defer span.End()
+
 	// Run the job in a goroutine with a timeout so a stuck process can't block the worker loop
-	jobCtx, jobCancel := context.WithTimeout(w.ctx, jobTimeout)
+	timeout := w.jobTimeoutFor(job)
+	jobCtx, jobCancel := context.WithTimeout(traceCtx, timeout)
 	defer jobCancel()
 
 	resultCh := make(chan error, 1)
 	go func() {
-		var processingErr error
-		switch job.Type {
-		case domain.JobTypeExtractMetadata:
-			processingErr = w.processor.ProcessMetadataExtraction(jobCtx, job.Payload, jobLogger)
-		case domain.JobTypeProcessKnok:
-			processingErr = w.processor.ProcessKnok(jobCtx, job.Payload, jobLogger)
-		case domain.JobTypeNotifyComplete:
-			processingErr = w.processor.ProcessNotification(jobCtx, job.Payload, jobLogger)
-		default:
-			processingErr = fmt.Errorf("unknown job type: %s", job.Type)
-		}
-		resultCh <- processingErr
+		resultCh <- w.dispatchJob(jobCtx, job, jobLogger)
 	}()
 
 	// Wait for job completion or timeout
@@ -261,9 +357,9 @@ func (w *WorkerService) processJob(job *domain.QueueJob) {
 	case processingErr = <-resultCh:
 		// Job completed (success or failure)
 	case <-jobCtx.Done():
-		processingErr = fmt.Errorf("job timed out after %s", jobTimeout)
+		processingErr = fmt.Errorf("job timed out after %s", timeout)
 		jobLogger.Error("Job timed out — abandoned to unblock worker",
-			"timeout", jobTimeout,
+			"timeout", timeout,
 			"job_id", job.ID,
 		)
 	}
@@ -271,12 +367,29 @@ func (w *WorkerService) processJob(job *domain.QueueJob) {
 	// Update job status based on result
 	if processingErr != nil {
 		jobLogger.Error("Job processing failed", "error", processingErr)
+		span.RecordError(processingErr)
+		span.SetStatus(codes.Error, processingErr.Error())
 
 		// Mark job as failed
-		if err := w.queueRepo.Fail(w.ctx, job.ID, processingErr.Error()); err != nil {
+		deadLettered, err := w.queueRepo.Fail(w.ctx, job.ID, processingErr.Error())
+		if err != nil {
 			jobLogger.Error("Failed to mark job as failed", "error", err)
 		}
 
+		// Keep the failure reason on the knok so it can be triaged. Only a job that won't be
+		// retried fails its knoks; while a retry is pending they stay as they are, and the
+		// queue's own retries aren't charged to the knoks' retry budget
+		if deadLettered {
+			switch job.Type {
+			case domain.JobTypeExtractMetadata:
+				if knokID, err := uuid.Parse(fmt.Sprint(job.Payload["knok_id"])); err == nil {
+					w.processor.RecordExtractionFailure(w.ctx, knokID, processingErr.Error(), jobLogger)
+				}
+			case domain.JobTypeExtractMetadataBatch:
+				w.processor.RecordBatchFailure(w.ctx, job.Payload, processingErr.Error(), jobLogger)
+			}
+		}
+
 		// Update stats
 		w.stats.JobsFailed++
 	} else {
@@ -287,6 +400,19 @@ func (w *WorkerService) processJob(job *domain.QueueJob) {
 			jobLogger.Error("Failed to mark job as completed", "error", err)
 		}
 
+		// Queue a completion notification for servers that want a reply or thread
+		if w.discordSession != nil {
+			switch job.Type {
+			case domain.JobTypeExtractMetadata:
+				w.enqueueNotification(job.Payload, jobLogger)
+			case domain.JobTypeExtractMetadataBatch:
+				items, _ := parseBatchItems(job.Payload)
+				for _, item := range items {
+					w.enqueueNotification(map[string]interface{}{"knok_id": item.KnokID.String()}, jobLogger)
+				}
+			}
+		}
+
 		// Update stats
 		w.stats.JobsSucceeded++
 	}
@@ -311,6 +437,36 @@ func (w *WorkerService) processJob(job *domain.QueueJob) {
 	)
 }
 
+// dispatchJob runs a job with the processor method for its type
+func (w *WorkerService) dispatchJob(ctx context.Context, job *domain.QueueJob, logger *slog.Logger) error {
+	switch job.Type {
+	case domain.JobTypeExtractMetadata:
+		return w.processor.ProcessMetadataExtraction(ctx, job.Payload, logger)
+	case domain.JobTypeExtractMetadataBatch:
+		return w.processor.ProcessMetadataExtractionBatch(ctx, job.Payload, logger)
+	case domain.JobTypeProcessKnok:
+		return w.processor.ProcessKnok(ctx, job.Payload, logger)
+	case domain.JobTypeNotifyComplete:
+		return w.processor.ProcessNotification(ctx, job.Payload, logger)
+	default:
+		return fmt.Errorf("unknown job type: %s", job.Type)
+	}
+}
+
+// enqueueNotification queues a notify_complete job for the knok in an extraction payload
+func (w *WorkerService) enqueueNotification(payload map[string]interface{}, logger *slog.Logger) {
+	knokID, ok := payload["knok_id"].(string)
+	if !ok {
+		return
+	}
+
+	if err := w.queueRepo.Enqueue(w.ctx, domain.JobTypeNotifyComplete, map[string]interface{}{
+		"knok_id": knokID,
+	}); err != nil {
+		logger.Error("Failed to enqueue completion notification", "error", err, "knok_id", knokID)
+	}
+}
+
 // GetStats returns current worker statistics
 func (w *WorkerService) GetStats() *WorkerStats {
 	return w.stats
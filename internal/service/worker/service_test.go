@@ -0,0 +1,266 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/config"
+	"knock-fm/internal/domain"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// blockingKnokRepo blocks GetByID until release is closed, ignoring the context like a
+// pathological extraction would
+type blockingKnokRepo struct {
+	domain.KnokRepository
+	release chan struct{}
+}
+
+func (r *blockingKnokRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Knok, error) {
+	<-r.release
+	return &domain.Knok{ID: id}, nil
+}
+
+// recordingQueueRepo records failed jobs; other methods are unimplemented
+type recordingQueueRepo struct {
+	domain.QueueRepository
+	mu         sync.Mutex
+	failed     map[string]string
+	deadLetter bool
+}
+
+func (q *recordingQueueRepo) Complete(ctx context.Context, jobID string) error { return nil }
+
+func (q *recordingQueueRepo) Fail(ctx context.Context, jobID string, errorMsg string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.failed[jobID] = errorMsg
+	return q.deadLetter, nil
+}
+
+func TestProcessJobTimeout(t *testing.T) {
+	logger := createTestLogger()
+	knokRepo := &blockingKnokRepo{release: make(chan struct{})}
+	defer close(knokRepo.release)
+	queueRepo := &recordingQueueRepo{failed: make(map[string]string)}
+
+	processor := NewJobProcessor(logger, knokRepo, nil)
+	processor.notifier = &fakeNotifier{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &WorkerService{
+		config: &config.Config{JobTimeouts: map[string]time.Duration{
+			domain.JobTypeNotifyComplete: 50 * time.Millisecond,
+		}},
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		queueRepo: queueRepo,
+		processor: processor,
+		stats:     &WorkerStats{},
+	}
+
+	job := &domain.QueueJob{
+		ID:      "job-1",
+		Type:    domain.JobTypeNotifyComplete,
+		Payload: map[string]interface{}{"knok_id": uuid.New().String()},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.processJob(job)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("processJob didn't return after the job timeout")
+	}
+
+	queueRepo.mu.Lock()
+	reason, failed := queueRepo.failed[job.ID]
+	queueRepo.mu.Unlock()
+	if !failed {
+		t.Fatal("timed out job was not failed")
+	}
+	if !strings.Contains(reason, "timed out") {
+		t.Errorf("failure reason = %q, want a timeout reason", reason)
+	}
+	if w.stats.JobsFailed != 1 {
+		t.Errorf("JobsFailed = %d, want 1", w.stats.JobsFailed)
+	}
+}
+
+func TestProcessJobRecordsFailureWhenDeadLettered(t *testing.T) {
+	for _, deadLetter := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dead letter %v", deadLetter), func(t *testing.T) {
+			logger := createTestLogger()
+			knok := &domain.Knok{ID: uuid.New(), ExtractionStatus: domain.ExtractionStatusPending, Metadata: map[string]interface{}{}}
+			knokRepo := &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}}
+			queueRepo := &recordingQueueRepo{failed: make(map[string]string), deadLetter: deadLetter}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w := &WorkerService{
+				config:    &config.Config{},
+				logger:    logger,
+				ctx:       ctx,
+				cancel:    cancel,
+				queueRepo: queueRepo,
+				processor: NewJobProcessor(logger, knokRepo, nil),
+				stats:     &WorkerStats{},
+			}
+
+			// A payload without a platform fails before any extraction
+			w.processJob(&domain.QueueJob{
+				ID:      "job-1",
+				Type:    domain.JobTypeExtractMetadata,
+				Payload: map[string]interface{}{"knok_id": knok.ID.String(), "url": "https://example.com"},
+			})
+
+			if _, failed := queueRepo.failed["job-1"]; !failed {
+				t.Fatal("job was not failed")
+			}
+			wantStatus := domain.ExtractionStatusPending
+			if deadLetter {
+				wantStatus = domain.ExtractionStatusFailed
+			}
+			if knok.ExtractionStatus != wantStatus {
+				t.Errorf("knok status = %q, want %q", knok.ExtractionStatus, wantStatus)
+			}
+			if _, recorded := knok.Metadata["extraction_error"]; recorded != deadLetter {
+				t.Errorf("extraction_error recorded = %v, want %v", recorded, deadLetter)
+			}
+		})
+	}
+}
+
+func TestJobTimeoutFor(t *testing.T) {
+	batchJob := func(n int) *domain.QueueJob {
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = map[string]interface{}{"knok_id": uuid.New().String(), "url": "https://example.com"}
+		}
+		return &domain.QueueJob{Type: domain.JobTypeExtractMetadataBatch, Payload: map[string]interface{}{"items": items}}
+	}
+
+	tests := []struct {
+		name     string
+		timeouts map[string]time.Duration
+		job      *domain.QueueJob
+		want     time.Duration
+	}{
+		{
+			name: "Default",
+			job:  &domain.QueueJob{Type: domain.JobTypeExtractMetadata},
+			want: jobTimeout,
+		},
+		{
+			name:     "Configured job type",
+			timeouts: map[string]time.Duration{domain.JobTypeNotifyComplete: 15 * time.Second},
+			job:      &domain.QueueJob{Type: domain.JobTypeNotifyComplete},
+			want:     15 * time.Second,
+		},
+		{
+			name:     "Other job types keep the default",
+			timeouts: map[string]time.Duration{domain.JobTypeNotifyComplete: 15 * time.Second},
+			job:      &domain.QueueJob{Type: domain.JobTypeExtractMetadata},
+			want:     jobTimeout,
+		},
+		{
+			name: "Batch scales per item",
+			job:  batchJob(2),
+			want: 2 * jobTimeout,
+		},
+		{
+			name:     "Batch uses the configured per-item timeout",
+			timeouts: map[string]time.Duration{domain.JobTypeExtractMetadata: 10 * time.Second},
+			job:      batchJob(3),
+			want:     30 * time.Second,
+		},
+		{
+			name:     "Batch is capped by the configured batch timeout",
+			timeouts: map[string]time.Duration{domain.JobTypeExtractMetadataBatch: time.Minute},
+			job:      batchJob(10),
+			want:     time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &WorkerService{config: &config.Config{JobTimeouts: tt.timeouts}}
+			if got := w.jobTimeoutFor(tt.job); got != tt.want {
+				t.Errorf("jobTimeoutFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// pausableQueueRepo serves a fixed number of pending jobs and counts dequeues; other methods
+// are unimplemented
+type pausableQueueRepo struct {
+	domain.QueueRepository
+	paused   bool
+	pending  int
+	dequeued int
+}
+
+func (q *pausableQueueRepo) SetPaused(ctx context.Context, paused bool) error {
+	q.paused = paused
+	return nil
+}
+
+func (q *pausableQueueRepo) IsPaused(ctx context.Context) (bool, error) { return q.paused, nil }
+
+func (q *pausableQueueRepo) GetPendingCount(ctx context.Context, jobType string) (int, error) {
+	if jobType != domain.JobTypeProcessKnok {
+		return 0, nil
+	}
+	return q.pending, nil
+}
+
+func (q *pausableQueueRepo) Dequeue(ctx context.Context, jobType string) (*domain.QueueJob, error) {
+	if q.pending == 0 {
+		return nil, nil
+	}
+	q.pending--
+	q.dequeued++
+	return &domain.QueueJob{ID: "job", Type: domain.JobTypeProcessKnok, Payload: map[string]interface{}{}}, nil
+}
+
+func (q *pausableQueueRepo) Complete(ctx context.Context, jobID string) error { return nil }
+
+func (q *pausableQueueRepo) Fail(ctx context.Context, jobID string, errorMsg string) (bool, error) {
+	return false, nil
+}
+
+func TestProcessPendingJobsPaused(t *testing.T) {
+	queueRepo := &pausableQueueRepo{pending: 2}
+	w := &WorkerService{
+		config:    &config.Config{},
+		logger:    createTestLogger(),
+		ctx:       context.Background(),
+		queueRepo: queueRepo,
+		processor: NewJobProcessor(createTestLogger(), nil, nil),
+		stats:     &WorkerStats{},
+	}
+	ctx := context.Background()
+
+	queueRepo.SetPaused(ctx, true)
+	w.processPendingJobs()
+	if queueRepo.dequeued != 0 {
+		t.Fatalf("dequeued %d jobs while paused, want 0", queueRepo.dequeued)
+	}
+
+	queueRepo.SetPaused(ctx, false)
+	w.processPendingJobs()
+	if queueRepo.dequeued != 2 {
+		t.Errorf("dequeued %d jobs after resume, want 2", queueRepo.dequeued)
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"knock-fm/internal/domain"
+	"sort"
+)
+
+// metadataExtractor runs the extraction tiers for a URL without touching any knok
+type metadataExtractor interface {
+	ExtractMetadata(ctx context.Context, url string) (map[string]string, string, error)
+}
+
+// RunJob processes one job in the foreground, for debugging a job that misbehaves. The job
+// runs with its usual timeout and saves its result like a queued run would, but the queue
+// isn't touched: its status isn't changed, no failure is counted against the knok's retry
+// budget and no completion notification is queued.
+func (w *WorkerService) RunJob(ctx context.Context, job *domain.QueueJob) error {
+	jobCtx, cancel := context.WithTimeout(ctx, w.jobTimeoutFor(job))
+	defer cancel()
+
+	jobLogger := w.logger.With("job_id", job.ID, "job_type", job.Type)
+	jobLogger.Info("Running single job", "payload", job.Payload)
+	return w.dispatchJob(jobCtx, job, jobLogger)
+}
+
+// ExtractURL runs the extraction pipeline for url once and writes the method that
+// produced the metadata and each field found to out. Each tier's outcome is logged by
+// the extractor as it runs
+func ExtractURL(ctx context.Context, extractor metadataExtractor, url string, out io.Writer) error {
+	metadata, method, err := extractor.ExtractMetadata(ctx, url)
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+
+	fmt.Fprintf(out, "url: %s\n", url)
+	fmt.Fprintf(out, "extraction_method: %s\n", method)
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s: %s\n", key, metadata[key])
+	}
+	return nil
+}
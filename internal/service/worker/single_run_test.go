@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"knock-fm/internal/config"
+	"knock-fm/internal/domain"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// stubExtractor returns a fixed extraction result
+type stubExtractor struct {
+	metadata map[string]string
+	method   string
+	err      error
+	urls     []string
+}
+
+func (e *stubExtractor) ExtractMetadata(ctx context.Context, url string) (map[string]string, string, error) {
+	e.urls = append(e.urls, url)
+	return e.metadata, e.method, e.err
+}
+
+func TestExtractURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor *stubExtractor
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "Prints method and fields in order",
+			extractor: &stubExtractor{
+				metadata: map[string]string{"title": "Song", "image": "https://img.example.com/a.jpg"},
+				method:   "http_static",
+			},
+			want: "url: https://example.com/song\n" +
+				"extraction_method: http_static\n" +
+				"image: https://img.example.com/a.jpg\n" +
+				"title: Song\n",
+		},
+		{
+			name:      "Extraction error",
+			extractor: &stubExtractor{err: errors.New("connection refused")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := ExtractURL(context.Background(), tt.extractor, "https://example.com/song", &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out.String() != tt.want {
+				t.Errorf("ExtractURL() wrote %q, want %q", out.String(), tt.want)
+			}
+			if len(tt.extractor.urls) != 1 {
+				t.Errorf("extractor called %d times, want 1", len(tt.extractor.urls))
+			}
+		})
+	}
+}
+
+func TestRunJobLeavesQueueAlone(t *testing.T) {
+	queueRepo := &recordingQueueRepo{failed: make(map[string]string)}
+	w := &WorkerService{
+		config:    &config.Config{},
+		logger:    createTestLogger(),
+		queueRepo: queueRepo,
+		processor: NewJobProcessor(createTestLogger(), nil, nil),
+		stats:     &WorkerStats{},
+	}
+
+	job := &domain.QueueJob{
+		ID:      "job-1",
+		Type:    domain.JobTypeExtractMetadata,
+		Payload: map[string]interface{}{"knok_id": uuid.New().String()},
+	}
+	if err := w.RunJob(context.Background(), job); err == nil {
+		t.Fatal("RunJob() with a payload missing url should fail")
+	}
+	if len(queueRepo.failed) != 0 {
+		t.Errorf("RunJob() failed %d queued jobs, want 0", len(queueRepo.failed))
+	}
+	if w.stats.JobsProcessed != 0 {
+		t.Errorf("RunJob() counted %d processed jobs, want 0", w.stats.JobsProcessed)
+	}
+}
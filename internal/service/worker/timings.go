@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"knock-fm/internal/pkg/tracing"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extraction tiers timed in a knok's extraction_timings metadata
+const (
+	timingOEmbed = "oembed"
+	timingHTTP   = "http_static"
+	timingTitle  = "title"
+	timingRod    = "rod_browser"
+	timingTotal  = "total"
+)
+
+// extractionTimings records how long each extraction tier took for one URL. Tiers that
+// didn't run have no entry
+type extractionTimings struct {
+	durations map[string]time.Duration
+}
+
+func newExtractionTimings() *extractionTimings {
+	return &extractionTimings{durations: make(map[string]time.Duration)}
+}
+
+// start begins timing a tier in its own trace span. The returned function ends the span
+// and records the duration; the tier should run with the returned context
+func (t *extractionTimings) start(ctx context.Context, tier string) (context.Context, func()) {
+	ctx, span := tracing.Tracer().Start(ctx, "extract."+tier)
+	start := time.Now()
+	return ctx, func() {
+		t.record(tier, start)
+		span.End()
+	}
+}
+
+// startTotal is start for the whole extraction, whose span the tier spans are children of
+func (t *extractionTimings) startTotal(ctx context.Context, url string) (context.Context, func()) {
+	ctx, span := tracing.Tracer().Start(ctx, "extract_metadata", trace.WithAttributes(attribute.String("url.full", url)))
+	start := time.Now()
+	return ctx, func() {
+		t.record(timingTotal, start)
+		span.End()
+	}
+}
+
+// record adds the time since start to a tier
+func (t *extractionTimings) record(tier string, start time.Time) {
+	t.durations[tier] += time.Since(start)
+}
+
+// milliseconds returns the durations in milliseconds, as stored in knok metadata
+func (t *extractionTimings) milliseconds() map[string]int64 {
+	ms := make(map[string]int64, len(t.durations))
+	for tier, d := range t.durations {
+		ms[tier] = d.Milliseconds()
+	}
+	return ms
+}
+
+// logAttr groups the durations in milliseconds under timings_ms, in a stable order
+func (t *extractionTimings) logAttr() slog.Attr {
+	tiers := make([]string, 0, len(t.durations))
+	for tier := range t.durations {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
+	attrs := make([]any, 0, len(tiers))
+	for _, tier := range tiers {
+		attrs = append(attrs, slog.Int64(tier, t.durations[tier].Milliseconds()))
+	}
+	return slog.Group("timings_ms", attrs...)
+}
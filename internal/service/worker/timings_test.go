@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"knock-fm/internal/domain"
+	"knock-fm/internal/pkg/urldetector"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestExtractMetadataTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/og" {
+			fmt.Fprint(w, `<html><head><title>Mix</title><meta property="og:title" content="Mix"><meta property="og:image" content="https://example.com/cover.jpg"></head></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>Bare page</title></head></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		rodDomains []string
+		wantTiers  []string
+	}{
+		{
+			name:      "Complete HTTP metadata stops before Rod",
+			path:      "/og",
+			wantTiers: []string{timingHTTP, timingOEmbed, timingTitle, timingTotal},
+		},
+		{
+			name:      "Falls through to Rod",
+			path:      "/bare",
+			wantTiers: []string{timingHTTP, timingOEmbed, timingRod, timingTitle, timingTotal},
+		},
+		{
+			name:       "Rod domain runs only Rod",
+			path:       "/bare",
+			rodDomains: []string{"127.0.0.1"},
+			wantTiers:  []string{timingRod, timingTotal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &JobProcessor{
+				logger:          createTestLogger(),
+				oembedExtractor: NewOEmbedExtractor(&OEmbedRegistry{}, createTestLogger()),
+				rodExtract: func(ctx context.Context, resources *extractionResources, url string) (map[string]string, error) {
+					return map[string]string{"title": "Rendered", "image": "https://example.com/cover.jpg"}, nil
+				},
+			}
+			processor.SetRodDomains(tt.rodDomains)
+
+			resources := newExtractionResources(createTestLogger())
+			defer resources.Close()
+
+			timings := newExtractionTimings()
+			if _, _, err := processor.extractMetadataWithFallbacks(context.Background(), resources, server.URL+tt.path, timings); err != nil {
+				t.Fatalf("extractMetadataWithFallbacks() error = %v", err)
+			}
+
+			tiers := make([]string, 0, len(timings.durations))
+			for tier := range timings.durations {
+				tiers = append(tiers, tier)
+			}
+			sort.Strings(tiers)
+			if strings.Join(tiers, ",") != strings.Join(tt.wantTiers, ",") {
+				t.Errorf("timed tiers = %v, want %v", tiers, tt.wantTiers)
+			}
+		})
+	}
+}
+
+func TestProcessMetadataExtractionStoresTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Mix</title><meta property="og:title" content="Mix"><meta property="og:description" content="A mix"></head></html>`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	urldetector.SetAllowedPorts([]string{serverURL.Port()})
+	defer urldetector.SetAllowedPorts(nil)
+
+	knok := &domain.Knok{
+		ID:               uuid.New(),
+		ServerID:         "guild-1",
+		URL:              server.URL + "/mix",
+		CanonicalURL:     server.URL + "/mix",
+		ExtractionStatus: domain.ExtractionStatusPending,
+	}
+	processor := &JobProcessor{
+		logger:   createTestLogger(),
+		knokRepo: &stubKnokRepo{knoks: map[uuid.UUID]*domain.Knok{knok.ID: knok}},
+	}
+
+	payload := map[string]interface{}{
+		"knok_id":  knok.ID.String(),
+		"url":      knok.URL,
+		"platform": domain.PlatformUnknown,
+	}
+	if err := processor.ProcessMetadataExtraction(context.Background(), payload, createTestLogger()); err != nil {
+		t.Fatalf("ProcessMetadataExtraction() error = %v", err)
+	}
+
+	timings, ok := knok.Metadata["extraction_timings"].(map[string]int64)
+	if !ok {
+		t.Fatalf("extraction_timings = %#v, want per-tier milliseconds", knok.Metadata["extraction_timings"])
+	}
+	for _, tier := range []string{timingHTTP, timingTitle, timingTotal} {
+		if _, ok := timings[tier]; !ok {
+			t.Errorf("extraction_timings has no %s entry: %v", tier, timings)
+		}
+	}
+	if _, ok := timings[timingRod]; ok {
+		t.Errorf("extraction_timings has a %s entry for a tier that didn't run: %v", timingRod, timings)
+	}
+}
@@ -119,6 +119,13 @@ class ApiClient {
       has_more: false,
     };
   }
+
+  async getDailyKnok(date?: string): Promise<KnokDto> {
+    const endpoint = `/api/v1/knoks/daily${
+      date ? `?date=${encodeURIComponent(date)}` : ""
+    }`;
+    return this.request<KnokDto>(endpoint);
+  }
   async healthCheck(): Promise<{ status: string }> {
     return this.request<{ status: string }>("/health");
   }
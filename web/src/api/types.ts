@@ -6,6 +6,9 @@ export interface KnokDto {
   title: string;
   url: string;
   posted_at: string;
+  extraction_status: string;
+  thumbnail_url?: string;
+  duration_seconds?: number; // track length, when the source gives one
   metadata: KnokMetaData;
 }
 
@@ -14,6 +17,7 @@ export interface KnokMetaData {
   image?: string;
   site_name?: string;
   description?: string;
+  duration_seconds?: number;
 }
 
 export interface KnoksResponse {